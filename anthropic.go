@@ -0,0 +1,150 @@
+package flexjson
+
+import (
+	"io"
+	"maps"
+	"slices"
+)
+
+// ToolUseBlock is a tool_use content block of an Anthropic Messages API
+// response, as accumulated by a ToolInputAccumulator
+type ToolUseBlock struct {
+	ID       string         // ID of the tool use
+	Name     string         // Name of the tool
+	Input    map[string]any // Arguments parsed so far
+	Complete bool           // Whether the block has been stopped
+}
+
+// ToolInputAccumulator builds the arguments of the tool_use blocks of a
+// streamed Anthropic Messages API response as they arrive. The arguments of a
+// block arrive as the partial_json fragments of its input_json_delta events;
+// the accumulator feeds them to a StreamingParser per block, so each block's
+// arguments can be read while they are still streaming.
+type ToolInputAccumulator struct {
+	opts   []Option
+	blocks map[int]*toolUseState
+}
+
+// toolUseState is the state of one tool_use block
+type toolUseState struct {
+	ToolUseBlock
+	parser *StreamingParser
+}
+
+// NewToolInputAccumulator creates an accumulator. opts configure the parser of
+// each block's arguments.
+func NewToolInputAccumulator(opts ...Option) *ToolInputAccumulator {
+	return &ToolInputAccumulator{
+		opts:   opts,
+		blocks: make(map[int]*toolUseState),
+	}
+}
+
+// HandleEvent processes a decoded streaming event. content_block_start events
+// for tool_use blocks record the tool, content_block_delta events with an
+// input_json_delta add to the block's arguments, and content_block_stop
+// events mark the block complete. A message_start event clears the blocks of
+// the previous message. Other events are ignored.
+func (a *ToolInputAccumulator) HandleEvent(event map[string]any) error {
+	index, _ := eventIndex(event["index"])
+	switch event["type"] {
+	case "message_start":
+		clear(a.blocks)
+
+	case "content_block_start":
+		block, _ := event["content_block"].(map[string]any)
+		if kind, _ := block["type"].(string); kind != "tool_use" && kind != "server_tool_use" {
+			return nil
+		}
+		state := a.block(index)
+		state.ID, _ = block["id"].(string)
+		state.Name, _ = block["name"].(string)
+
+	case "content_block_delta":
+		delta, _ := event["delta"].(map[string]any)
+		if delta["type"] != "input_json_delta" {
+			return nil
+		}
+		partial, _ := delta["partial_json"].(string)
+		return a.block(index).parser.ProcessString(partial)
+
+	case "content_block_stop":
+		if state, ok := a.blocks[index]; ok {
+			state.Complete = true
+		}
+	}
+	return nil
+}
+
+// ProcessEvent processes the JSON data of a streaming event
+func (a *ToolInputAccumulator) ProcessEvent(data string) error {
+	event, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	return a.HandleEvent(event)
+}
+
+// ProcessSSE reads a Server-Sent Events stream of a Messages API response from
+// r and processes each of its events, as StreamingParser.ProcessSSE does
+func (a *ToolInputAccumulator) ProcessSSE(r io.Reader) error {
+	var handleErr error
+	sp := NewStreamingParser(nil, WithDocuments(func(event map[string]any) {
+		if handleErr == nil {
+			handleErr = a.HandleEvent(event)
+		}
+	}))
+
+	if err := sp.ProcessSSE(r); err != nil {
+		return err
+	}
+	return handleErr
+}
+
+// Inputs returns the arguments parsed so far for each tool_use block, keyed
+// by the index of the block in the message
+func (a *ToolInputAccumulator) Inputs() map[int]map[string]any {
+	inputs := make(map[int]map[string]any, len(a.blocks))
+	for index, state := range a.blocks {
+		inputs[index] = state.parser.GetCurrentOutput()
+	}
+	return inputs
+}
+
+// Block returns the tool_use block at index, and whether there is one
+func (a *ToolInputAccumulator) Block(index int) (ToolUseBlock, bool) {
+	state, ok := a.blocks[index]
+	if !ok {
+		return ToolUseBlock{}, false
+	}
+	block := state.ToolUseBlock
+	block.Input = state.parser.GetCurrentOutput()
+	return block, true
+}
+
+// Indexes returns the indexes of the tool_use blocks seen so far, in order
+func (a *ToolInputAccumulator) Indexes() []int {
+	return slices.Sorted(maps.Keys(a.blocks))
+}
+
+// block returns the state of the block at index, creating it if a delta
+// arrives before its start event
+func (a *ToolInputAccumulator) block(index int) *toolUseState {
+	state, ok := a.blocks[index]
+	if !ok {
+		state = &toolUseState{parser: NewStreamingParser(nil, a.opts...)}
+		a.blocks[index] = state
+	}
+	return state
+}
+
+// eventIndex converts the index of an event to an int
+func eventIndex(v any) (int, bool) {
+	switch n := v.(type) {
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,90 @@
+package flexjson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// anthropicStream is a Messages API stream with a text block and a tool_use
+// block whose arguments are cut off
+const anthropicStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","role":"assistant","content":[]}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"location\": \"San Fra"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"ncisco\", \"unit\": \"cel"}}
+
+`
+
+func TestToolInputAccumulator(t *testing.T) {
+	acc := NewToolInputAccumulator()
+	if err := acc.ProcessSSE(strings.NewReader(anthropicStream)); err != nil {
+		t.Fatalf("ProcessSSE() error = %v", err)
+	}
+
+	if indexes := acc.Indexes(); !reflect.DeepEqual(indexes, []int{1}) {
+		t.Errorf("Indexes() = %v, want [1]", indexes)
+	}
+	expected := map[int]map[string]any{1: {"location": "San Francisco"}}
+	if inputs := acc.Inputs(); !reflect.DeepEqual(inputs, expected) {
+		t.Errorf("Inputs() = %#v, want %#v", inputs, expected)
+	}
+
+	// The rest of the arguments and the end of the block
+	for _, data := range []string{
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"sius\"}"}}`,
+		`{"type":"content_block_stop","index":1}`,
+	} {
+		if err := acc.ProcessEvent(data); err != nil {
+			t.Fatalf("ProcessEvent() error = %v", err)
+		}
+	}
+
+	block, ok := acc.Block(1)
+	if !ok {
+		t.Fatal("Block(1) not found")
+	}
+	want := ToolUseBlock{
+		ID:       "toolu_1",
+		Name:     "get_weather",
+		Input:    map[string]any{"location": "San Francisco", "unit": "celsius"},
+		Complete: true,
+	}
+	if !reflect.DeepEqual(block, want) {
+		t.Errorf("Block(1) = %#v, want %#v", block, want)
+	}
+
+	// A new message starts over
+	if err := acc.ProcessEvent(`{"type":"message_start","message":{}}`); err != nil {
+		t.Fatalf("ProcessEvent() error = %v", err)
+	}
+	if _, ok := acc.Block(1); ok {
+		t.Error("Block(1) found after message_start")
+	}
+}
+
+func TestToolInputAccumulatorError(t *testing.T) {
+	acc := NewToolInputAccumulator()
+	err := acc.ProcessEvent(`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"[1]"}}`)
+	if err == nil {
+		t.Error("ProcessEvent() succeeded, want an error for arguments that aren't an object")
+	}
+}
@@ -0,0 +1,29 @@
+package flexjson
+
+// WithBestEffort makes Parse, ParseOrdered, and ParseSpans return the object
+// parsed so far alongside any error, with an error that says why it may be
+// incomplete, rather than a complete object or nothing:
+//
+//   - Input cut off before the root object is closed returns ErrPartial.
+//   - Input that isn't valid returns the ParseError for the first problem,
+//     which matches ErrInvalid with errors.Is. The object holds the values
+//     before it.
+//   - Complete, valid input returns a nil error.
+//
+// Without it, cut-off input returns a nil error and invalid input a nil
+// object.
+func WithBestEffort() Option {
+	return func(d *decoder) {
+		d.bestEffort = true
+	}
+}
+
+// bestEffortResult returns the root value parsed so far if it is wanted
+// after an error, or nil
+func (d *decoder) bestEffortResult() any {
+	if !d.bestEffort {
+		return nil
+	}
+	d.syncArrays()
+	return d.result
+}
@@ -0,0 +1,83 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithBestEffort(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]any
+		partial bool
+		invalid bool
+	}{
+		{"complete", `{"a": 1}`, map[string]any{"a": int64(1)}, false, false},
+		{"truncated", `{"a": 1, "b": [tr`, map[string]any{"a": int64(1), "b": []interface{}{}}, true, false},
+		{"truncated string", `{"a": "hel`, map[string]any{"a": "hel"}, true, false},
+		{"empty", ``, nil, true, false},
+		{"invalid", `{"a": 1, "b": [2, x], "c": 3}`, map[string]any{"a": int64(1), "b": []interface{}{int64(2)}}, false, true},
+		{"not an object", `[1]`, nil, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input, WithBestEffort())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+			if errors.Is(err, ErrPartial) != tt.partial {
+				t.Errorf("Parse() error = %v, want ErrPartial %v", err, tt.partial)
+			}
+			if errors.Is(err, ErrInvalid) != tt.invalid {
+				t.Errorf("Parse() error = %v, want ErrInvalid %v", err, tt.invalid)
+			}
+
+			ordered, err := ParseOrdered(tt.input, WithBestEffort())
+			if (ordered == nil) != (tt.want == nil) || errors.Is(err, ErrPartial) != tt.partial || errors.Is(err, ErrInvalid) != tt.invalid {
+				t.Errorf("ParseOrdered() = %v, %v", ordered, err)
+			}
+
+			obj, _, err := ParseSpans(tt.input, WithBestEffort())
+			if !reflect.DeepEqual(obj, tt.want) || errors.Is(err, ErrPartial) != tt.partial || errors.Is(err, ErrInvalid) != tt.invalid {
+				t.Errorf("ParseSpans() = %v, %v", obj, err)
+			}
+		})
+	}
+}
+
+func TestWithoutBestEffort(t *testing.T) {
+	if got, err := Parse(`{"a": 1, "b": x}`); got != nil || !errors.Is(err, ErrInvalid) {
+		t.Errorf("Parse(invalid) = %v, %v, want nil and ErrInvalid", got, err)
+	}
+	if got, err := Parse(`{"a": 1`); got == nil || err != nil {
+		t.Errorf("Parse(truncated) = %v, %v, want the object and no error", got, err)
+	}
+}
+
+func TestParseErrorIs(t *testing.T) {
+	tests := []struct {
+		code    ErrorCode
+		partial bool
+		invalid bool
+	}{
+		{CodeUnexpectedEOF, true, false},
+		{CodeUnexpectedToken, false, true},
+		{CodeInvalidNumber, false, true},
+		{CodeNotAnObject, false, true},
+		{CodeValueTooLong, false, false},
+		{CodeOutputTooLarge, false, false},
+		{CodeTooManyValues, false, false},
+		{CodeShapeMismatch, false, true},
+		{CodeUnsupportedEncoding, false, true},
+	}
+
+	for _, tt := range tests {
+		err := error(&ParseError{Code: tt.code})
+		if errors.Is(err, ErrPartial) != tt.partial || errors.Is(err, ErrInvalid) != tt.invalid {
+			t.Errorf("%s: Is(ErrPartial) = %v, Is(ErrInvalid) = %v", tt.code, errors.Is(err, ErrPartial), errors.Is(err, ErrInvalid))
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package flexjson
+
+// changeSet collects the paths stored since it was last drained, in the order
+// they were first stored
+type changeSet struct {
+	paths []string
+	seen  map[string]bool
+}
+
+// add records a change to the value at path
+func (c *changeSet) add(path string) {
+	if !c.seen[path] {
+		c.seen[path] = true
+		c.paths = append(c.paths, path)
+	}
+}
+
+// drain returns the paths recorded and starts again
+func (c *changeSet) drain() []string {
+	paths := c.paths
+	c.paths = nil
+	clear(c.seen)
+	return paths
+}
+
+// ChangedPaths returns the paths, in the format described in path.go, of the
+// values created or replaced in the output since the last call, in the order
+// they were first stored, so a UI can re-render only what changed. A path is
+// listed when a value is stored at it, so adding to an object or array lists
+// the new member or element but not the container itself. A string appears
+// once it is complete. The list is reset on each call; the first call lists
+// every value in the output.
+//
+// Changes are only tracked once ChangedPaths has been called, so parsers that
+// don't use it pay nothing for it.
+func (sp *StreamingParser) ChangedPaths() []string {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if sp.changes == nil {
+		sp.changes = &changeSet{seen: make(map[string]bool)}
+		for _, n := range descendants(nil, jsonPathNode{value: *sp.output})[1:] {
+			sp.changes.add(n.path)
+		}
+	}
+	return sp.changes.drain()
+}
+
+// changed records a change to the value at path for ChangedPaths and for a
+// TypedStreamingParser
+func (d *decoder) changed(path string) {
+	if d.changes != nil {
+		d.changes.add(path)
+	}
+	if d.updated != nil {
+		d.updated.add(path)
+	}
+}
+
+// changeTree holds the paths of a changeSet as a tree of their segments, so
+// the changes inside a value can be found as it is walked
+type changeTree struct {
+	stored  bool                   // Whether a value was stored at the path itself
+	keys    map[string]*changeTree // Changes inside members, by key
+	indexes map[int]*changeTree    // Changes inside elements, by index
+}
+
+// newChangeTree builds the tree of paths
+func newChangeTree(paths []string) *changeTree {
+	root := &changeTree{}
+	for _, path := range paths {
+		segments, ok := parsePath(path)
+		if !ok {
+			root.stored = true
+			continue
+		}
+		c := root
+		for _, seg := range segments {
+			c = c.child(seg)
+		}
+		c.stored = true
+	}
+	return root
+}
+
+// child returns the changes inside the value at seg, adding them if needed
+func (c *changeTree) child(seg pathSegment) *changeTree {
+	if seg.index >= 0 {
+		if c.indexes == nil {
+			c.indexes = make(map[int]*changeTree)
+		}
+		if c.indexes[seg.index] == nil {
+			c.indexes[seg.index] = &changeTree{}
+		}
+		return c.indexes[seg.index]
+	}
+	if c.keys == nil {
+		c.keys = make(map[string]*changeTree)
+	}
+	if c.keys[seg.key] == nil {
+		c.keys[seg.key] = &changeTree{}
+	}
+	return c.keys[seg.key]
+}
+
+// key returns the changes inside the member under k, and whether it has
+// changed. A nil tree stands for a value that is new, so all of it has.
+func (c *changeTree) key(k string) (*changeTree, bool) {
+	if c == nil {
+		return nil, true
+	}
+	child, ok := c.keys[k]
+	return child, ok
+}
+
+// index returns the changes inside the element at i, and whether it has
+// changed, as key does
+func (c *changeTree) index(i int) (*changeTree, bool) {
+	if c == nil {
+		return nil, true
+	}
+	child, ok := c.indexes[i]
+	return child, ok
+}
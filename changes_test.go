@@ -0,0 +1,29 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangedPaths(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	steps := []struct {
+		chunk    string
+		expected []string
+	}{
+		{`{"b": 1, "a": {"x": [true, `, []string{"a", "a.x", "a.x[0]", "b"}},
+		{``, nil},
+		{`"str`, nil},
+		{`ing"], "y": {}}, "b": 2,`, []string{"a.x[1]", "a.y", "b"}},
+		{` "c": [{"d": null}]}`, []string{"c", "c[0]", "c[0].d"}},
+	}
+
+	for _, step := range steps {
+		if err := sp.ProcessString(step.chunk); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", step.chunk, err)
+		}
+		if got := sp.ChangedPaths(); !reflect.DeepEqual(got, step.expected) {
+			t.Errorf("ChangedPaths() after %q = %q, want %q", step.chunk, got, step.expected)
+		}
+	}
+}
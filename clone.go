@@ -0,0 +1,98 @@
+package flexjson
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a copy of the parser with its own copy of the output, so
+// candidate continuations of a stream, such as completions of a truncated
+// response, can be fed to the copy without affecting the parser. The copy
+// has the same options and settings, but no watchers, event handler, patch
+// or document callbacks, instrumentation, logger, debug trace, or raw
+// buffer, so speculative input doesn't reach them. The handler given with
+// WithSchema is kept, so the copy's violations are reported as the parser's
+// would be. The output of a parser with a MapSink isn't copied; its clone
+// keeps track of the structure without building any output.
+func (sp *StreamingParser) Clone() *StreamingParser {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	// Copy the output as the decoder sees it, without the partial string
+	sp.unstorePartial()
+	if sp.partials {
+		defer sp.storePartial()
+	}
+
+	c := &StreamingParser{
+		decoder:     sp.decoder,
+		partial:     sp.partial,
+		partialText: sp.partialText,
+		lastChar:    sp.lastChar,
+		trace:       sp.trace,
+		traceOn:     sp.traceOn,
+		traceChars:  sp.traceChars,
+		traceLines:  sp.traceLines,
+		noise:       sp.noise,
+		inComment:   sp.inComment,
+		partialRune: slices.Clone(sp.partialRune),
+		offset:      sp.offset,
+		recent:      sp.recent,
+		hardened:    sp.hardened,
+	}
+	c.logf = c.log
+	c.watchers = nil
+	c.merged = nil
+	c.updated = nil
+	c.dispatch = nil
+	c.handler = nil
+	c.patches = nil
+	c.documents = nil
+	c.inst = Instrumentation{}
+	c.logger = nil
+	c.spans = maps.Clone(sp.spans)
+	c.issues = slices.Clip(sp.issues)
+	if sp.changes != nil {
+		c.changes = &changeSet{paths: slices.Clone(sp.changes.paths), seen: maps.Clone(sp.changes.seen)}
+	}
+
+	output := snapshotValue(*sp.output).(map[string]any)
+	c.output = &output
+	if sp.sink != nil {
+		c.sink = nil
+		c.skipOutput = true
+	}
+
+	c.stack = make([]frame, 0, len(sp.stack))
+	for _, f := range sp.stack {
+		kind, n := f.kind, f.n
+		if sp.sink != nil {
+			// The sink's objects aren't copied, so the clone skips them
+			switch kind {
+			case containerArray:
+				kind, n = containerSkippedArray, len(f.arr)
+			case containerSkippedArray:
+			default:
+				kind = containerSkippedObject
+			}
+		}
+		reopened, _ := c.reopen(kind, n)
+		reopened.key, reopened.path, reopened.count = f.key, f.path, f.count
+		reopened.schema, reopened.seen = f.schema, maps.Clone(f.seen)
+		c.push(reopened)
+	}
+	if len(c.stack) > 0 {
+		c.result = c.stack[0].value()
+	} else if c.done() {
+		c.result = *c.output
+	}
+
+	c.lexer = NewIncrementalLexer()
+	c.configureLexer(c.lexer)
+	c.lexer.restore(sp.lexer.save())
+
+	if c.partials {
+		c.storePartial()
+	}
+	return c
+}
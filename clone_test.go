@@ -0,0 +1,134 @@
+package flexjson
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestStreamingParserClone(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		opts   []Option
+		// Continuations fed to clones, each with the output it completes
+		candidates map[string]map[string]any
+		want       map[string]any // Output of the original once its input is complete
+		rest       string
+	}{
+		{
+			name:   "open string",
+			prefix: `{"a": [1, {"b": "he`,
+			candidates: map[string]map[string]any{
+				`llo"}]}`: {"a": []interface{}{int64(1), map[string]any{"b": "hello"}}},
+				`y"}]}`:   {"a": []interface{}{int64(1), map[string]any{"b": "hey"}}},
+			},
+			rest: `lp"}], "c": true}`,
+			want: map[string]any{"a": []interface{}{int64(1), map[string]any{"b": "help"}}, "c": true},
+		},
+		{
+			name:   "partial strings",
+			prefix: `{"a": ["x", "y`,
+			opts:   []Option{WithPartialStrings()},
+			candidates: map[string]map[string]any{
+				`z"]}`: {"a": []interface{}{"x", "yz"}},
+				`"]}`:  {"a": []interface{}{"x", "y"}},
+			},
+			rest: `"]}`,
+			want: map[string]any{"a": []interface{}{"x", "y"}},
+		},
+		{
+			name:   "number",
+			prefix: `{"n": 12`,
+			candidates: map[string]map[string]any{
+				`3}`:  {"n": int64(123)},
+				`.5}`: {"n": 12.5},
+			},
+			rest: `}`,
+			want: map[string]any{"n": int64(12)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := map[string]any{}
+			sp := NewStreamingParser(&out, tt.opts...)
+			if err := sp.ProcessString(tt.prefix); err != nil {
+				t.Fatalf("ProcessString() error = %v", err)
+			}
+			before := sp.Snapshot()
+
+			for candidate, want := range tt.candidates {
+				clone := sp.Clone()
+				if err := clone.ProcessString(candidate); err != nil {
+					t.Fatalf("clone.ProcessString(%q) error = %v", candidate, err)
+				}
+				if !clone.IsComplete() {
+					t.Errorf("clone is not complete after %q", candidate)
+				}
+				if got := clone.Snapshot(); !reflect.DeepEqual(got, want) {
+					t.Errorf("clone output after %q = %v, want %v", candidate, got, want)
+				}
+				if !reflect.DeepEqual(out, before) {
+					t.Fatalf("original output changed to %v after clone was fed %q, want %v", out, candidate, before)
+				}
+			}
+
+			if err := sp.ProcessString(tt.rest); err != nil {
+				t.Fatalf("ProcessString() error = %v", err)
+			}
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("original output = %v, want %v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamingParserCloneDropsCallbacks(t *testing.T) {
+	out := map[string]any{}
+	var ops []PatchOp
+	var calls int
+	var log bytes.Buffer
+	inst := Instrumentation{
+		OnChunk: func(int) { calls++ },
+		OnToken: func(Token) { calls++ },
+		OnError: func(error) { calls++ },
+	}
+	sp := NewStreamingParser(&out,
+		WithPatches(func(op PatchOp) { ops = append(ops, op) }),
+		WithInstrumentation(inst),
+		WithLogger(slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: LevelTrace}))))
+	sp.ProcessString(`{"a": 1,`)
+	n, before, logged := len(ops), calls, log.Len()
+
+	clone := sp.Clone()
+	clone.ProcessString(` "b": 2}`)
+	if len(ops) != n {
+		t.Errorf("clone sent %d patches, want none", len(ops)-n)
+	}
+	if calls != before {
+		t.Errorf("clone made %d instrumentation calls, want none", calls-before)
+	}
+	if log.Len() != logged {
+		t.Errorf("clone logged %q, want nothing", log.String()[logged:])
+	}
+}
+
+func TestStreamingParserCloneSink(t *testing.T) {
+	sink := NewOrderedMap()
+	sp := NewStreamingParser(nil)
+	sp.SetSink(sink)
+	sp.ProcessString(`{"a": [1, {"b": 2`)
+
+	clone := sp.Clone()
+	if err := clone.ProcessString(`}], "c": 3}`); err != nil {
+		t.Fatalf("clone.ProcessString() error = %v", err)
+	}
+	if !clone.IsComplete() {
+		t.Error("clone is not complete")
+	}
+	if sink.Len() != 1 {
+		t.Errorf("sink has %d keys after the clone was fed input, want 1", sink.Len())
+	}
+}
@@ -0,0 +1,239 @@
+// Command flexjson parses, repairs, and streams JSON that may be cut off,
+// such as a payload truncated in a log or a model's reply captured
+// mid-stream.
+//
+// Usage:
+//
+//	flexjson parse [-compact] [-json5] [file]
+//	flexjson repair [file]
+//	flexjson stream [-patches] [-chunk n] [-json5] [file]
+//
+// parse prints the object parsed from the input as indented JSON, tolerating
+// input that stops early. repair prints the input completed into valid JSON
+// text, keeping it byte for byte. stream reads the input as it arrives and
+// prints a snapshot of the output after each chunk, or with -patches the
+// JSON Patch operations that build it, one JSON value per line.
+//
+// The input is read from file, or from standard input when file is omitted
+// or "-". Errors are printed to standard error and exit with status 1;
+// incomplete input is reported there too but isn't an error.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jpoz/flexjson"
+)
+
+const usage = `usage:
+	flexjson parse [-compact] [-json5] [file]
+	flexjson repair [file]
+	flexjson stream [-patches] [-chunk n] [-json5] [file]
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with args, returning the exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var cmd func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+	switch args[0] {
+	case "parse":
+		cmd = parse
+	case "repair":
+		cmd = repair
+	case "stream":
+		cmd = stream
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "flexjson: unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+
+	if err := cmd(args[1:], stdin, stdout, stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(stderr, "flexjson %s: %v\n", args[0], err)
+			return 1
+		}
+		return 2
+	}
+	return 0
+}
+
+// errUsage is returned for invalid flags or arguments, which the flag
+// package has already reported
+var errUsage = errors.New("usage")
+
+// newFlagSet returns the flag set for a command
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("flexjson "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseFlags parses args into fs and returns the input named by the one
+// argument left, or stdin. The caller must close the input.
+func parseFlags(fs *flag.FlagSet, args []string, stdin io.Reader) (io.ReadCloser, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errUsage
+	}
+
+	switch fs.NArg() {
+	case 0:
+		return io.NopCloser(stdin), nil
+	case 1:
+		if fs.Arg(0) == "-" {
+			return io.NopCloser(stdin), nil
+		}
+		return os.Open(fs.Arg(0))
+	}
+	fmt.Fprintf(fs.Output(), "%s: too many arguments\n", fs.Name())
+	return nil, errUsage
+}
+
+// syntax returns the options for the -json5 flag
+func syntax(json5 bool) []flexjson.Option {
+	if json5 {
+		return []flexjson.Option{flexjson.WithSyntax(flexjson.SyntaxJSON5)}
+	}
+	return nil
+}
+
+// parse prints the object parsed from the input
+func parse(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("parse", stderr)
+	compact := fs.Bool("compact", false, "print the object on one line")
+	json5 := fs.Bool("json5", false, "accept JSON5")
+	in, err := parseFlags(fs, args, stdin)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	input, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	opts := append(syntax(*json5), flexjson.WithBestEffort())
+	obj, err := flexjson.Parse(string(input), opts...)
+	if obj != nil {
+		indent := "  "
+		if *compact {
+			indent = ""
+		}
+		if werr := writeJSON(stdout, obj, indent); werr != nil {
+			return werr
+		}
+	}
+	if errors.Is(err, flexjson.ErrPartial) {
+		fmt.Fprintln(stderr, "flexjson parse: input is incomplete")
+		return nil
+	}
+	return err
+}
+
+// repair prints the input completed into valid JSON text
+func repair(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("repair", stderr)
+	in, err := parseFlags(fs, args, stdin)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	input, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	completed, err := flexjson.CompleteJSON(string(input))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, completed)
+	return err
+}
+
+// stream prints the output of a StreamingParser after each chunk of input,
+// or the patches that build it
+func stream(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("stream", stderr)
+	patches := fs.Bool("patches", false, "print JSON Patch operations instead of snapshots")
+	chunk := fs.Int("chunk", 4096, "read at most `n` bytes at a time")
+	json5 := fs.Bool("json5", false, "accept JSON5")
+	in, err := parseFlags(fs, args, stdin)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if *chunk < 1 {
+		fmt.Fprintln(stderr, "flexjson stream: -chunk must be positive")
+		return errUsage
+	}
+
+	var werr error
+	opts := syntax(*json5)
+	if *patches {
+		opts = append(opts, flexjson.WithPatches(func(op flexjson.PatchOp) {
+			if werr == nil {
+				werr = writeJSON(stdout, op, "")
+			}
+		}))
+	}
+	sp := flexjson.NewStreamingParser(nil, opts...)
+
+	buf := make([]byte, *chunk)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			perr := sp.ProcessBytes(buf[:n])
+			if !*patches && werr == nil {
+				werr = writeJSON(stdout, sp.Snapshot(), "")
+			}
+			if perr != nil {
+				return perr
+			}
+			if werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !sp.IsComplete() {
+		fmt.Fprintln(stderr, "flexjson stream: input is incomplete")
+	}
+	return nil
+}
+
+// writeJSON writes v to w as JSON followed by a newline, indented with
+// indent unless it's empty
+func writeJSON(w io.Writer, v any, indent string) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	return enc.Encode(v)
+}
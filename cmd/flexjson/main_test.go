@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		input  string
+		status int
+		stdout string
+		stderr string
+	}{
+		{
+			name:   "parse",
+			args:   []string{"parse"},
+			input:  `{"a": [1, {"b": "hel`,
+			stdout: "{\n  \"a\": [\n    1,\n    {\n      \"b\": \"hel\"\n    }\n  ]\n}\n",
+			stderr: "flexjson parse: input is incomplete\n",
+		},
+		{
+			name:   "parse compact",
+			args:   []string{"parse", "-compact"},
+			input:  `{"a": "<b>", "c": true}`,
+			stdout: `{"a":"<b>","c":true}` + "\n",
+		},
+		{
+			name:   "parse json5",
+			args:   []string{"parse", "-compact", "-json5", "-"},
+			input:  `{a: 'b', // note` + "\n",
+			stdout: `{"a":"b"}` + "\n",
+			stderr: "flexjson parse: input is incomplete\n",
+		},
+		{
+			name:   "parse invalid",
+			args:   []string{"parse", "-compact"},
+			input:  `{"a": 1, "b" 2}`,
+			status: 1,
+			stdout: `{"a":1}` + "\n",
+			stderr: "flexjson parse: unexpected '2' at line 1, column 14: expected ':' after key\n",
+		},
+		{
+			name:   "repair",
+			args:   []string{"repair"},
+			input:  `{"a": [1, {"b": "hel`,
+			stdout: `{"a": [1, {"b": "hel"}]}` + "\n",
+		},
+		{
+			name:   "repair invalid",
+			args:   []string{"repair"},
+			input:  `{"a" 1}`,
+			status: 1,
+			stderr: "flexjson repair: unexpected '1' at line 1, column 6: expected ':' after key\n",
+		},
+		{
+			name:   "stream",
+			args:   []string{"stream", "-chunk", "8"},
+			input:  `{"a": [1, {"b": "hel"}]}`,
+			stdout: "{\"a\":[]}\n{\"a\":[1,{}]}\n{\"a\":[1,{\"b\":\"hel\"}]}\n",
+		},
+		{
+			name:   "stream patches",
+			args:   []string{"stream", "-patches"},
+			input:  `{"a": [1]`,
+			stdout: "{\"op\":\"add\",\"path\":\"\",\"value\":{}}\n{\"op\":\"add\",\"path\":\"/a\",\"value\":[]}\n{\"op\":\"add\",\"path\":\"/a/0\",\"value\":1}\n",
+			stderr: "flexjson stream: input is incomplete\n",
+		},
+		{
+			name:   "no command",
+			status: 2,
+			stderr: usage,
+		},
+		{
+			name:   "unknown command",
+			args:   []string{"format"},
+			status: 2,
+			stderr: "flexjson: unknown command \"format\"\n" + usage,
+		},
+		{
+			name:   "too many arguments",
+			args:   []string{"repair", "a", "b"},
+			status: 2,
+			stderr: "flexjson repair: too many arguments\n",
+		},
+		{
+			name:   "missing file",
+			args:   []string{"parse", "testdata/missing.json"},
+			status: 1,
+			stderr: "flexjson parse: open testdata/missing.json: no such file or directory\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := run(tt.args, strings.NewReader(tt.input), &stdout, &stderr)
+			if status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+			if stderr.String() != tt.stderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.stderr)
+			}
+		})
+	}
+}
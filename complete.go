@@ -0,0 +1,463 @@
+package flexjson
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// CompleteJSON repairs a JSON document cut off by the end of partial into
+// valid JSON text, for passing on to systems that require well-formed input.
+// An open string is closed, and open arrays and objects are closed in order.
+// What can't be completed without inventing content is dropped: a trailing
+// comma, a key without a value, a partial escape at the end of a string, and
+// a number or literal cut off before it is valid. Text after a complete
+// document is dropped too, and the rest of the input is kept byte for byte,
+// so a complete document is returned unchanged apart from surrounding
+// whitespace.
+//
+// The root value may be of any type. Input that isn't the start of a JSON
+// document returns a *ParseError, as does input with no value. That
+// includes a string holding a control character that isn't escaped or an
+// escape JSON doesn't have, and a number that no more digits would make
+// valid, which the parsers accept.
+func CompleteJSON(partial string) (completed string, err error) {
+	defer recoverInternal(&err, nil)
+
+	d := &decoder{skipOutput: true}
+	cut, quote := -1, false
+	var number *Token // A number that isn't valid JSON, unless the input ends after it
+	for tok := range NewLexer(partial).Tokens() {
+		if number != nil && tok.Type != TokenEOF {
+			return "", locateError(tokenError(*number, CodeInvalidNumber, "valid number"), partial)
+		}
+		if tok.Type == TokenEOF || d.done() {
+			// Tokens after the root value are dropped
+			break
+		}
+		if err := d.token(tok); err != nil {
+			return "", locateError(err, partial)
+		}
+		if tok.Type == TokenNumber && !isJSONNumber(tok.Value) {
+			number = &tok
+			continue
+		}
+		if tok.Type == TokenString {
+			if err := checkString(tok, partial[tok.Start:tok.End]); err != nil {
+				return "", locateError(err, partial)
+			}
+		}
+
+		switch d.state {
+		case stateDelimiter, stateValueOrClose, stateKeyOrClose, stateDone:
+			// Closing the open containers here makes a valid document
+			cut, quote = tok.End, false
+			if tok.Type == TokenString && !closedString(partial[tok.Start:tok.End]) {
+				cut, quote = tok.Start+validStringPrefix(partial[tok.Start:tok.End]), true
+			}
+		}
+	}
+
+	if number != nil && !isJSONNumber(number.Value+"0") {
+		// Only digits could follow, and they wouldn't help
+		return "", locateError(tokenError(*number, CodeInvalidNumber, "valid number"), partial)
+	}
+	if cut < 0 {
+		// No value has started; report the end of the input
+		if err := d.token(Token{Type: TokenEOF, Start: len(partial)}); err != nil {
+			return "", locateError(err, partial)
+		}
+		return "", ErrUnexpectedEOF
+	}
+
+	var b strings.Builder
+	b.WriteString(partial[:cut])
+	if quote {
+		b.WriteByte('"')
+	}
+	b.WriteString(d.closers())
+	return b.String(), nil
+}
+
+// TrimToBudget returns the longest prefix of the JSON document doc that
+// can be made valid JSON within n bytes, for fitting a payload into an API
+// with a size limit. Members and elements are kept whole, in order, and are
+// dropped from the end until what is left, with the brackets that close its
+// open arrays and objects, fits. A document that fits is returned with only
+// the text after its root value dropped, and doc may itself be cut off, as
+// for CompleteJSON.
+//
+// TrimToBudget returns nil if doc isn't the start of a JSON document, as
+// when a string in it holds a control character that isn't escaped or an
+// escape JSON doesn't have, or if not even an empty root container, or the
+// root value, fits in n bytes. The result doesn't share memory with doc.
+func TrimToBudget(doc []byte, n int) []byte {
+	d := &decoder{skipOutput: true}
+	input := bytesString(doc)
+	cut, closers := -1, ""
+	var number *Token // A number that isn't valid JSON, unless the input ends after it
+	for tok := range NewLexer(input).Tokens() {
+		if tok.Type == TokenEOF || d.done() || tok.End > n {
+			// Nothing after this fits, or the root value is done
+			break
+		}
+		if number != nil {
+			return nil
+		}
+		if err := d.token(tok); err != nil {
+			return nil
+		}
+		if tok.Type == TokenNumber && !isJSONNumber(tok.Value) {
+			number = &tok
+			continue
+		}
+		if tok.Type == TokenString {
+			raw := input[tok.Start:tok.End]
+			if checkString(tok, raw) != nil {
+				return nil
+			}
+			if !closedString(raw) {
+				continue
+			}
+		}
+
+		switch d.state {
+		case stateDelimiter, stateValueOrClose, stateKeyOrClose, stateDone:
+			// Closing the open containers here makes a valid document
+			if tok.End+len(d.stack) <= n {
+				cut, closers = tok.End, d.closers()
+			}
+		}
+	}
+
+	if cut < 0 {
+		return nil
+	}
+	trimmed := make([]byte, 0, cut+len(closers))
+	trimmed = append(trimmed, doc[:cut]...)
+	return append(trimmed, closers...)
+}
+
+// closers returns the brackets that close the open containers, innermost first
+func (d *decoder) closers() string {
+	b := make([]byte, 0, len(d.stack))
+	for i := len(d.stack) - 1; i >= 0; i-- {
+		if d.stack[i].isArray() {
+			b = append(b, ']')
+		} else {
+			b = append(b, '}')
+		}
+	}
+	return string(b)
+}
+
+// closedString reports whether the string token text s ends with its
+// closing quote
+func closedString(s string) bool {
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return false
+	}
+	// The quote is escaped if an odd number of backslashes precede it
+	backslashes := 0
+	for i := len(s) - 2; i > 0 && s[i] == '\\'; i-- {
+		backslashes++
+	}
+	return backslashes%2 == 0
+}
+
+// validStringPrefix returns the length of the longest prefix of the text of
+// an unterminated string that ends neither inside an escape nor inside a
+// UTF-8 sequence
+func validStringPrefix(s string) int {
+	end := 0
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] != '\\':
+			i++
+		case i+1 < len(s) && s[i+1] == 'u':
+			i += 6
+		default:
+			i += 2
+		}
+		if i <= len(s) {
+			end = i
+		}
+	}
+	return end - incompleteRuneSuffix(s[:end])
+}
+
+// checkString returns a *ParseError for the first text in the string token
+// tok, whose text is raw, that JSON doesn't allow in a string: a control
+// character that isn't escaped, or an escape JSON doesn't have. An escape
+// cut off at the end of an unterminated string is left to be completed.
+func checkString(tok Token, raw string) error {
+	_, err := checkStringText(raw[1:], tok.Start+1)
+	return err
+}
+
+// checkStringText checks text, part of a string token from offset start up
+// to at most its closing quote, as checkString does. text mustn't start
+// inside an escape. It returns the length of text checked, which stops short
+// of an escape cut off at its end, as that may be completed by more input.
+func checkStringText(text string, start int) (int, error) {
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '"':
+			return len(text), nil
+		case c < 0x20:
+			quoted := strconv.Quote(string(c))
+			return i, &ParseError{Code: CodeUnexpectedCharacter, Offset: start + i, Got: quoted[1 : len(quoted)-1], Expected: "escaped control character"}
+		case c == '\\':
+			if escapePrefix(text[i+1:]) {
+				return i, nil
+			}
+			n, ok := escapeLength(text[i+1:])
+			if !ok {
+				return i, &ParseError{Code: CodeUnexpectedCharacter, Offset: start + i, Got: text[i : i+2], Expected: "valid escape"}
+			}
+			i += n
+		}
+	}
+	return len(text), nil
+}
+
+// escapePrefix reports whether s, the text after a backslash, is cut off
+// before the end of what could still be a valid escape
+func escapePrefix(s string) bool {
+	if s == "" {
+		return true
+	}
+	if s[0] != 'u' || len(s) >= 5 {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if _, ok := hexValue(s[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// locateError fills in the position of a ParseError in input
+func locateError(err error, input string) error {
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		perr.locate(input)
+	}
+	return err
+}
+
+// CompletionSuffix returns the text to append to partial to complete the JSON
+// document it starts, such as `"}]}`, so the original bytes can be kept
+// exactly. The suffix finishes what is cut off: it closes an open string and
+// the open arrays and objects, and completes a literal, number, or escape at
+// the end of the input. Where more is needed, it adds the least it can: null
+// for a missing value, and an empty key for a trailing comma in an object.
+// A complete document has an empty suffix.
+//
+// Input that isn't the start of a JSON document returns a *ParseError, as do
+// input with no value, text after the root value, a number that can't be
+// completed by appending to it, and a string holding a control character
+// that isn't escaped or an escape JSON doesn't have.
+func CompletionSuffix(partial string) (suffix string, err error) {
+	defer recoverInternal(&err, nil)
+
+	c := newCompleter()
+	c.lexer.feed(partial)
+	if err = c.drain(); err == nil {
+		suffix, err = c.suffix()
+	}
+	if err != nil {
+		return "", locateError(err, partial)
+	}
+	return suffix, nil
+}
+
+// completer tracks a document as its tokens arrive, to work out the suffix
+// that completes it
+type completer struct {
+	lexer *Lexer
+	d     decoder
+	last  Token // Last token decoded
+
+	checked int // Offset the pending string has been checked up to
+}
+
+// newCompleter creates a completer for a document fed in chunks
+func newCompleter() *completer {
+	c := &completer{lexer: NewIncrementalLexer(), d: decoder{skipOutput: true, truncate: true}}
+	// Strings are checked as they arrive, so their text needn't be kept
+	c.lexer.SetLimits(1, 0)
+	return c
+}
+
+// drain decodes the tokens the lexer has completed
+func (c *completer) drain() error {
+	for {
+		tok, ok := c.lexer.NextToken()
+		if !ok || tok.Type == TokenEOF {
+			return c.checkPending()
+		}
+		if c.d.done() {
+			return tokenError(tok, CodeUnexpectedToken, "end of input")
+		}
+		if c.last.Type == TokenNumber && !isJSONNumber(c.last.Value) {
+			return tokenError(c.last, CodeInvalidNumber, "valid number")
+		}
+		if err := c.d.token(tok); err != nil {
+			return err
+		}
+		if tok.Type == TokenString {
+			from := max(tok.Start+1, c.checked)
+			if _, err := checkStringText(c.lexer.text(from, tok.End), from); err != nil {
+				return err
+			}
+			c.checked, c.lexer.holding = 0, false
+		}
+		c.last = tok
+	}
+}
+
+// checkPending checks the text of the string being scanned that has arrived
+// since the last call, holding back only an escape cut off at its end
+func (c *completer) checkPending() error {
+	if !c.lexer.inString() {
+		return nil
+	}
+	from := max(c.lexer.pos+1, c.checked)
+	n, err := checkStringText(c.lexer.text(from, c.lexer.end()), from)
+	if err != nil {
+		return err
+	}
+	c.checked = from + n
+	c.lexer.holding, c.lexer.hold = true, c.checked
+	return nil
+}
+
+// suffix marks the end of the input and returns the text that completes the
+// document
+func (c *completer) suffix() (string, error) {
+	pending, tail := c.lexer.pos, c.lexer.stringTail()
+	c.lexer.Close()
+	if err := c.drain(); err != nil {
+		return "", err
+	}
+
+	d, end := &c.d, c.lexer.end()
+	var b strings.Builder
+	rest := strings.TrimLeftFunc(c.lexer.text(max(pending, c.last.End), end), func(r rune) bool {
+		return r < utf8.RuneSelf && isSpace(byte(r))
+	})
+	switch {
+	case rest != "":
+		// A literal cut off by the end of the input, which the lexer dropped
+		if d.done() {
+			return "", tokenError(Token{Type: TokenError, Value: rest, Start: end - len(rest)}, CodeUnexpectedToken, "end of input")
+		}
+		literal, tokenType := completeLiteral(rest)
+		b.WriteString(literal[len(rest):])
+		if err := d.token(Token{Type: tokenType, Value: literal}); err != nil {
+			return "", err
+		}
+
+	case tail != nil:
+		b.WriteString(completeString(*tail))
+
+	case c.last.Type == TokenNumber && !isJSONNumber(c.last.Value):
+		if c.last.End < end || !isJSONNumber(c.last.Value+"0") {
+			return "", tokenError(c.last, CodeInvalidNumber, "valid number")
+		}
+		b.WriteByte('0')
+		d.pendingErr = nil
+		if d.expectingValue() {
+			if err := d.token(Token{Type: TokenNumber, Value: c.last.Value + "0"}); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	switch {
+	case len(d.stack) == 0 && !d.done():
+		// No value has started; report the end of the input
+		return "", d.token(Token{Type: TokenEOF, Start: end})
+	case d.state == stateKey:
+		b.WriteString(`"":null`)
+	case d.state == stateColon:
+		b.WriteString(`:null`)
+	case d.state == stateValue:
+		b.WriteString(`null`)
+	}
+	b.WriteString(d.closers())
+	return b.String(), nil
+}
+
+// stringTail returns the unfinished escape or UTF-8 sequence at the end of
+// the string being scanned, or nil outside strings
+func (l *Lexer) stringTail() *string {
+	if l.scanned == 0 {
+		return nil
+	}
+
+	var tail string
+	switch {
+	case l.escape == 1:
+		tail = `\`
+	case l.escape > 1:
+		// Only the number of \uXXXX digits read matters
+		tail = `\u` + strings.Repeat("0", l.escape-2)
+	default:
+		tail = l.text(max(l.base, l.pos+1, l.end()-utf8.UTFMax), l.end())
+		tail = tail[len(tail)-incompleteRuneSuffix(tail):]
+	}
+	return &tail
+}
+
+// completeLiteral returns the literal that prefix starts, and its token type
+func completeLiteral(prefix string) (string, TokenType) {
+	for _, literal := range []string{"true", "false", "null"} {
+		if strings.HasPrefix(literal, prefix) {
+			tokenType, _ := literalType(literal)
+			return literal, tokenType
+		}
+	}
+	return prefix, TokenError
+}
+
+// completeString returns the text that closes an unterminated string ending
+// in tail, an unfinished escape or UTF-8 sequence, or nothing
+func completeString(tail string) string {
+	switch {
+	case tail == "":
+		return `"`
+	case tail[0] == '\\' && len(tail) == 1:
+		// Escape the backslash itself
+		return `\"`
+	case tail[0] == '\\':
+		// Pad the \uXXXX digits with zeros
+		return strings.Repeat("0", 6-len(tail)) + `"`
+	}
+
+	// Finish the UTF-8 sequence with the smallest valid continuation bytes
+	seq := []byte(tail)
+	for !utf8.FullRune(seq) {
+		c := byte(0x80)
+		for c < 0xbf && !validRunePrefix(append(seq, c)) {
+			c++
+		}
+		seq = append(seq, c)
+	}
+	return string(seq[len(tail):]) + `"`
+}
+
+// validRunePrefix reports whether seq is a valid UTF-8 sequence or the start
+// of one
+func validRunePrefix(seq []byte) bool {
+	// Complete the sequence with the largest continuation bytes to check it
+	seq = append([]byte(nil), seq...)
+	for !utf8.FullRune(seq) {
+		seq = append(seq, 0xbf)
+	}
+	r, _ := utf8.DecodeRune(seq)
+	return r != utf8.RuneError
+}
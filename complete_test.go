@@ -0,0 +1,214 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCompleteJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"a": 1, "b": [true, {"c": "x"}]}`, `{"a": 1, "b": [true, {"c": "x"}]}`},
+		{`  {"a": 1}  trailing`, `  {"a": 1}`},
+		{`{"a": "hel`, `{"a": "hel"}`},
+		{`{"a": [1, 2`, `{"a": [1, 2]}`},
+		{`{"a": [1, 2,`, `{"a": [1, 2]}`},
+		{`{"a": 1,`, `{"a": 1}`},
+		{`{"a": 1, "b"`, `{"a": 1}`},
+		{`{"a": 1, "b":`, `{"a": 1}`},
+		{`{"a": 1, "b": tr`, `{"a": 1}`},
+		{`{"a": 1, "b": 1.`, `{"a": 1}`},
+		{`{"a": 1, "b": -`, `{"a": 1}`},
+		{`{"a": 1, "b": 1e`, `{"a": 1}`},
+		{`{"a": 1, "b": 1e+5`, `{"a": 1, "b": 1e+5}`},
+		{`[-0.5, 0`, `[-0.5, 0]`},
+		{`{"a": 1, "bc`, `{"a": 1}`},
+		{`{"a": {"b": [`, `{"a": {"b": []}}`},
+		{`{"a": "x\`, `{"a": "x"}`},
+		{`{"a": "x\u12`, `{"a": "x"}`},
+		{`{"a": "xሴ`, `{"a": "xሴ"}`},
+		{`{"a": "x\"`, `{"a": "x\""}`},
+		{`{"a": "x\\`, `{"a": "x\\"}`},
+		{"{\"a\": \"caf\xc3", `{"a": "caf"}`},
+		{`[1, [2, `, `[1, [2]]`},
+		{`"unterminated`, `"unterminated"`},
+		{`42`, `42`},
+		{`{`, `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := CompleteJSON(tt.input)
+			if err != nil {
+				t.Fatalf("CompleteJSON() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("CompleteJSON() = %q, want %q", result, tt.expected)
+			}
+			if !json.Valid([]byte(result)) {
+				t.Errorf("CompleteJSON() = %q is not valid JSON", result)
+			}
+		})
+	}
+}
+
+func TestCompleteJSONErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  error
+	}{
+		{"", ErrUnexpectedEOF},
+		{"  tr", ErrUnexpectedEOF},
+		{`{"a": ]`, ErrUnexpectedToken},
+		{`{"a" 1}`, ErrUnexpectedToken},
+		{`{"a": 01, "b": 2}`, ErrInvalidNumber},
+		{`[1., 2]`, ErrInvalidNumber},
+		{`{"a": 01`, ErrInvalidNumber},
+		{"{\"a\": \"x\ny", ErrUnexpectedToken},
+		{"[\"\t\"]", ErrUnexpectedToken},
+		{`{"a": "\q`, ErrUnexpectedToken},
+		{`{"a": "\u12x"}`, ErrUnexpectedToken},
+	}
+
+	for _, tt := range tests {
+		out, err := CompleteJSON(tt.input)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("CompleteJSON(%q) error = %v, want %v", tt.input, err, tt.want)
+		}
+		var perr *ParseError
+		if err != nil && !errors.As(err, &perr) {
+			t.Errorf("CompleteJSON(%q) error = %T, want *ParseError", tt.input, err)
+		}
+		if err == nil && !json.Valid([]byte(out)) {
+			t.Errorf("CompleteJSON(%q) = %q, which is not valid JSON", tt.input, out)
+		}
+	}
+}
+
+func TestCompletionSuffix(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"a": 1}`, ``},
+		{`{"a": 1}  `, ``},
+		{`{"a": [1, {"b": "x`, `"}]}`},
+		{`{"a": [1, 2`, `]}`},
+		{`{"a": [1, 2,`, `null]}`},
+		{`{"a": 1,`, `"":null}`},
+		{`{"a": 1, "b"`, `:null}`},
+		{`{"a": 1, "b": `, `null}`},
+		{`{"a": 1, "bc`, `":null}`},
+		{`{"a": tr`, `ue}`},
+		{`{"a": [fa`, `lse]}`},
+		{`{"a": n`, `ull}`},
+		{`{"a": 1.`, `0}`},
+		{`{"a": -`, `0}`},
+		{`{"a": 1e+`, `0}`},
+		{`{"a": "x\`, `\"}`},
+		{`{"a": "x\u12`, `00"}`},
+		{"{\"a\": \"caf\xc3", "\x80\"}"},
+		{"[\"\xe0", "\xa0\x80\"]"},
+		{`{`, `}`},
+		{`[`, `]`},
+		{`"abc`, `"`},
+		{`nul`, `l`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := CompletionSuffix(tt.input)
+			if err != nil {
+				t.Fatalf("CompletionSuffix() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("CompletionSuffix() = %q, want %q", result, tt.expected)
+			}
+			if !json.Valid([]byte(tt.input + result)) {
+				t.Errorf("Completed text %q is not valid JSON", tt.input+result)
+			}
+		})
+	}
+}
+
+func TestCompletionSuffixErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  error
+	}{
+		{"", ErrUnexpectedEOF},
+		{"  ", ErrUnexpectedEOF},
+		{`{"a": ]`, ErrUnexpectedToken},
+		{`{"a": 1} {`, ErrUnexpectedToken},
+		{`{"a": 1} tr`, ErrUnexpectedToken},
+		{`{tr`, ErrUnexpectedToken},
+		{`{"a": 01`, ErrInvalidNumber},
+		{`{"a": 1. `, ErrInvalidNumber},
+		{`[1., 2`, ErrInvalidNumber},
+		{"{\"a\": \"x\ny", ErrUnexpectedToken},
+		{"[\"\t\"]", ErrUnexpectedToken},
+		{`{"a": "\q`, ErrUnexpectedToken},
+		{`{"a": "\u12x"}`, ErrUnexpectedToken},
+	}
+
+	for _, tt := range tests {
+		_, err := CompletionSuffix(tt.input)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("CompletionSuffix(%q) error = %v, want %v", tt.input, err, tt.want)
+		}
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("CompletionSuffix(%q) error = %T, want *ParseError", tt.input, err)
+		}
+	}
+}
+
+func TestTrimToBudget(t *testing.T) {
+	tests := []struct {
+		doc  string
+		n    int
+		want string
+	}{
+		{`{"a": 1, "b": [1, 2, 3]}`, 100, `{"a": 1, "b": [1, 2, 3]}`},
+		{`{"a": 1, "b": [1, 2, 3]}`, 24, `{"a": 1, "b": [1, 2, 3]}`},
+		{`{"a": 1, "b": [1, 2, 3]}`, 23, `{"a": 1, "b": [1, 2]}`},
+		{`{"a": 1, "b": [1, 2, 3]}`, 18, `{"a": 1, "b": [1]}`},
+		{`{"a": 1, "b": [1, 2, 3]}`, 17, `{"a": 1, "b": []}`},
+		{`{"a": 1, "b": [1, 2, 3]}`, 16, `{"a": 1}`},
+		{`{"a": 1, "b": [1, 2, 3]}`, 15, `{"a": 1}`},
+		{`{"a": 1, "b": [1, 2, 3]}`, 8, `{"a": 1}`},
+		{`{"a": 1, "b": [1, 2, 3]}`, 7, `{}`},
+		{`{"a": 1, "b": [1, 2, 3]}`, 1, ``},
+		{`{"a": "a long string"}`, 20, `{}`},
+		{`[[[1]]]`, 6, `[[[]]]`},
+		{`{"a": [1, 2` + "\n", 100, `{"a": [1, 2]}`},
+		{`{"a": "cut of`, 100, `{}`},
+		{`{"a": 1} trailing`, 100, `{"a": 1}`},
+		{`"text"`, 6, `"text"`},
+		{`"text"`, 5, ``},
+		{`{"a": 1, "b" 2}`, 100, ``},
+		{`{"a": 01}`, 100, ``},
+		{"{\"a\":\"x\x01\"}", 100, ``},
+		{`{"a":"\q"}`, 100, ``},
+		{``, 100, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.doc, tt.n), func(t *testing.T) {
+			got := TrimToBudget([]byte(tt.doc), tt.n)
+			if string(got) != tt.want {
+				t.Errorf("TrimToBudget() = %q, want %q", got, tt.want)
+			}
+			if tt.want == "" && got != nil {
+				t.Errorf("TrimToBudget() = %q, want nil", got)
+			}
+			if len(got) > tt.n || (got != nil && !json.Valid(got)) {
+				t.Errorf("TrimToBudget() = %q is too long or invalid", got)
+			}
+		})
+	}
+}
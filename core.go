@@ -0,0 +1,661 @@
+package flexjson
+
+import "log/slog"
+
+// decoderState is what the decoder expects from the next token
+type decoderState uint8
+
+const (
+	stateValue        decoderState = iota // A value
+	stateValueOrClose                     // A value or ']', just after '['
+	stateKey                              // A key, after ','
+	stateKeyOrClose                       // A key or '}', just after '{'
+	stateColon                            // The ':' after a key
+	stateDelimiter                        // ',' or the closing bracket, after a value
+	stateDone                             // The root value is complete
+)
+
+// decoder builds values from a stream of tokens. It is the core shared by
+// Parser, which feeds it the tokens of a complete input, and StreamingParser,
+// which feeds it tokens as they arrive. Values are stored as soon as their
+// token is decoded, so the output always reflects the input seen so far.
+//
+// The root object and nested objects are the output map (or MapSink) and
+// map[string]any, and arrays are []interface{}. Each open object or array is
+// a frame on the stack. An open array's slice is kept in its frame so it can
+// grow, and is stored in its parent again when it is closed, and whenever the
+// output is looked at before then (see syncArrays), rather than each time it
+// grows.
+type decoder struct {
+	stack      []frame      // Open objects and arrays, innermost last
+	state      decoderState // What the next token must be
+	result     interface{}  // The root value
+	pendingErr error        // Invalid number, reported unless the input ends next
+
+	output      *map[string]any   // Map that receives the root object's members (nil for a new map)
+	objectsOnly bool              // Whether the root value must be an object
+	numbers     numberMode        // Type that numbers are stored as
+	merge       bool              // Whether objects are merged into the output instead of replacing it
+	syntax      Syntax            // Extensions to JSON that are accepted
+	maxString   int               // Longest string kept, in bytes (0 for no limit)
+	maxNumber   int               // Longest number kept, in bytes (0 for no limit)
+	truncate    bool              // Whether values over a limit are truncated instead of an error
+	marker      string            // Appended to truncated values
+	maxOutput   int               // Approximate memory budget for the output, in bytes (0 for no limit)
+	discard     bool              // Whether values over the budget are discarded instead of an error
+	outputSize  int               // Approximate memory held by the output so far
+	discarding  bool              // Whether the budget has been exceeded and values are being discarded
+	maxKeys     int               // Most members an object may have (0 for no limit)
+	maxElements int               // Most elements an array may have (0 for no limit)
+	projecting  bool              // Whether only the paths in project are stored
+	project     [][]pathSegment   // Paths stored when projecting
+	skip        [][]pathSegment   // Paths that are never stored
+	raw         [][]pathSegment   // Paths stored as RawValue
+	rawLeaves   bool              // Whether every string, number, and literal is stored as RawValue
+	source      *Lexer            // Lexer whose input raw values are taken from (nil unless storing them)
+	rawDepth    int               // Stack depth of the object or array being kept raw (0 for none)
+	rawStart    int               // Offset of the object or array being kept raw
+	spans       map[string][2]int // Source offsets of each value, by path (nil unless recorded)
+	tokEnd      int               // Offset just past the last token (kept only for raw values and spans)
+	changes     *changeSet        // Paths stored since ChangedPaths was last called (nil until it is)
+	updated     *changeSet        // Paths stored since a TypedStreamingParser last updated its value (nil for other parsers)
+	patches     func(op PatchOp)  // Receives JSON Patch operations as the output grows (nil when unset)
+	partialOp   bool              // Whether the pending string has been sent as a partial patch
+	partials    bool              // Whether strings are stored in the output as they stream
+	recovery    bool              // Whether syntax errors are recorded and skipped instead of returned
+	resyncing   bool              // Whether tokens are being skipped after an error
+	resyncDepth int               // Depth of the objects and arrays opened while resyncing
+	issues      []*ParseError     // Errors recovered from
+	bestEffort  bool              // Whether the value parsed so far is returned with errors
+
+	watchers   []watcher                                       // Subscriptions registered with Watch
+	merged     []int                                           // Watchers of containers changed inside during the Process call, with async dispatch
+	dispatch   *dispatcher                                     // Async event delivery (nil for synchronous dispatch)
+	handler    EventHandler                                    // Receives structural events (nil when unset)
+	skipOutput bool                                            // Whether to skip building the output map
+	sink       MapSink                                         // Receives the root object's members instead of output (nil when unset)
+	hook       DecodeHookFunc                                  // Converts scalar values before they are stored (nil when unset)
+	schema     *Schema                                         // Schema the document is checked against (nil when unset)
+	violation  func(v SchemaViolation)                         // Receives schema violations
+	guard      bool                                            // Whether schema violations are errors, with WithShape
+	guardTok   Token                                           // Token being decoded, kept for errors when guarding
+	documents  func(doc map[string]any)                        // Receives each root object of a multi-document stream (nil when unset)
+	stats      Stats                                           // Statistics returned by StreamingParser.Stats
+	inst       Instrumentation                                 // Callbacks for observability code
+	logger     *slog.Logger                                    // Receives the debug trace (nil for the default output)
+	logf       func(level slog.Level, msg string, args ...any) // Debug logger (nil when disabled)
+}
+
+// containerKind is the kind of an open object or array
+type containerKind uint8
+
+const (
+	containerRoot          containerKind = iota // The output map
+	containerObject                             // A nested object
+	containerArray                              // An array
+	containerSkippedObject                      // An object whose output is discarded
+	containerSkippedArray                       // An array whose output is discarded
+	containerSink                               // An object stored in a MapSink
+)
+
+// frame is an open object or array on the decoder's stack
+type frame struct {
+	kind  containerKind
+	obj   map[string]any // Members of the output map or a nested object
+	sink  MapSink        // Members of an object stored in a sink
+	arr   []interface{}  // Elements of an array
+	stale bool           // Whether arr has grown since it was stored in its parent
+	n     int            // Number of elements of a skipped array
+	key   string         // Current key of an object
+	path  string         // Path of the container
+	count int            // Number of members or elements, counted when limited
+
+	schema *Schema         // Schema of the container (nil when unconstrained)
+	seen   map[string]bool // Keys read, when the schema requires members
+
+	copied    []any          // Copies of complete elements, taken for async watchers
+	copiedObj map[string]any // Copies of complete members, taken for async watchers
+}
+
+// value returns the container as it is stored in its parent: the map, sink,
+// or slice, or nil for one whose output is discarded
+func (f *frame) value() any {
+	switch f.kind {
+	case containerRoot, containerObject:
+		return f.obj
+	case containerSink:
+		return f.sink
+	case containerArray:
+		return f.arr
+	}
+	return nil
+}
+
+// isArray reports whether the container is an array
+func (f *frame) isArray() bool {
+	return f.kind == containerArray || f.kind == containerSkippedArray
+}
+
+// skipped reports whether the container's output is discarded
+func (f *frame) skipped() bool {
+	return f.kind == containerSkippedObject || f.kind == containerSkippedArray
+}
+
+// top returns the container on top of the stack
+func (d *decoder) top() *frame {
+	return &d.stack[len(d.stack)-1]
+}
+
+// token decodes the next token. Tokens after the root value is complete are
+// ignored, unless a document handler is set, when they start the next root
+// value.
+func (d *decoder) token(tok Token) error {
+	d.countToken(tok)
+	if d.inst.OnToken != nil {
+		d.inst.OnToken(tok)
+	}
+
+	if d.guard {
+		d.guardTok = tok
+	}
+
+	var err error
+	if d.recovery {
+		err = d.recoverToken(tok)
+	} else {
+		err = d.decodeToken(tok)
+	}
+	if err != nil {
+		d.instrumentError(err)
+	}
+	return err
+}
+
+// decodeToken decodes the next token, returning the first error
+func (d *decoder) decodeToken(tok Token) error {
+	if err := d.pendingErr; err != nil {
+		d.pendingErr = nil
+		if tok.Type != TokenEOF {
+			return err
+		}
+		// An invalid number cut off by the end of the input is dropped
+	}
+
+	if d.rawDepth > 0 || d.spans != nil {
+		d.tokEnd = tok.End
+	}
+
+	switch d.state {
+	case stateDone:
+		if d.documents == nil || tok.Type == TokenEOF {
+			return nil
+		}
+		d.nextDocument()
+		return d.value(tok)
+
+	case stateKey, stateKeyOrClose:
+		switch {
+		case tok.Type == TokenString || tok.Type == TokenIdentifier:
+			if err := d.count(tok); err != nil {
+				return err
+			}
+			d.debugf("\tStoring as key\n")
+			key := tok.Value
+			if tok.Truncated {
+				var err error
+				if key, err = d.truncated(tok); err != nil {
+					return err
+				}
+			}
+			d.top().key = key
+			if d.schema != nil {
+				if err := d.checkKey(key); err != nil {
+					return err
+				}
+			}
+			d.emitKey(key)
+			d.state = stateColon
+			return nil
+		case tok.Type == TokenRightBrace && (d.state == stateKeyOrClose || d.trailingCommas()):
+			return d.close(false)
+		case tok.Type == TokenEOF:
+			return d.end(tok)
+		}
+		return d.unexpected(tok, CodeExpectedKey, "string key")
+
+	case stateColon:
+		switch tok.Type {
+		case TokenColon:
+			d.state = stateValue
+			return nil
+		case TokenEOF:
+			return d.end(tok)
+		}
+		return d.unexpected(tok, CodeExpectedColon, "':' after key")
+
+	case stateDelimiter:
+		array := d.inArray()
+		switch {
+		case tok.Type == TokenComma && array:
+			d.state = stateValue
+			return nil
+		case tok.Type == TokenComma:
+			d.state = stateKey
+			return nil
+		case tok.Type == TokenRightBracket && array:
+			return d.close(true)
+		case tok.Type == TokenRightBrace && !array:
+			return d.close(false)
+		case tok.Type == TokenEOF:
+			return d.end(tok)
+		}
+		if array {
+			return d.unexpected(tok, CodeExpectedArrayDelimiter, "',' or ']' after array value")
+		}
+		return d.unexpected(tok, CodeExpectedObjectDelimiter, "',' or '}' after object value")
+	}
+
+	return d.value(tok)
+}
+
+// value decodes a token where a value is expected
+func (d *decoder) value(tok Token) error {
+	if len(d.stack) == 0 && d.objectsOnly && tok.Type != TokenLeftBrace && tok.Type != TokenEOF {
+		return d.unexpected(tok, CodeNotAnObject, "object")
+	}
+	if err := d.charge(tok); err != nil {
+		return err
+	}
+	if len(d.stack) > 0 && d.inArray() {
+		if err := d.count(tok); err != nil {
+			return err
+		}
+	}
+	if d.spans != nil {
+		d.spanStart(tok)
+	}
+	if raw, err := d.rawValue(tok); raw {
+		return err
+	}
+
+	switch tok.Type {
+	case TokenLeftBrace:
+		return d.open(false)
+	case TokenLeftBracket:
+		return d.open(true)
+	case TokenRightBracket:
+		if d.state == stateValueOrClose || (d.trailingCommas() && len(d.stack) > 0 && d.inArray()) {
+			return d.close(true)
+		}
+	case TokenString, TokenNumber:
+		if tok.Truncated {
+			value, err := d.truncated(tok)
+			if err != nil {
+				return err
+			}
+			return d.scalar(value)
+		}
+		if tok.Type == TokenString {
+			d.debugf("\tAdding string value\n")
+			return d.scalar(tok.Value)
+		}
+		n, ok := d.number(tok.Value)
+		if !ok {
+			err := tokenError(tok, CodeInvalidNumber, "valid number")
+			if len(d.stack) == 0 {
+				return err
+			}
+			d.pendingErr = err
+			return nil
+		}
+		if d.logf != nil {
+			// Checked here so n isn't boxed for nothing
+			d.debugf("\tAdding number value: %v\n", n)
+		}
+		return d.scalar(n)
+	case TokenTrue:
+		return d.scalar(true)
+	case TokenFalse:
+		return d.scalar(false)
+	case TokenNull:
+		return d.scalar(nil)
+	case TokenEOF:
+		return d.end(tok)
+	case TokenColon:
+		return d.unexpected(tok, CodeUnexpectedColon, "value")
+	}
+	return d.unexpected(tok, CodeUnexpectedToken, "value")
+}
+
+// unexpected builds a ParseError for tok. Characters that don't start a token
+// are always reported as CodeUnexpectedCharacter.
+func (d *decoder) unexpected(tok Token, code ErrorCode, expected string) error {
+	if tok.Type == TokenError {
+		if mark, encoding := byteOrderMark(tok.Value); tok.Start == 0 && mark == tok.Value {
+			return encodingError(tok, encoding)
+		}
+		code = CodeUnexpectedCharacter
+	}
+	return tokenError(tok, code, expected)
+}
+
+// scalar adds a string, number, or literal to the current container, after
+// running the decode hook on it
+func (d *decoder) scalar(value interface{}) error {
+	if d.schema != nil {
+		if err := d.checkScalar(value); err != nil {
+			return err
+		}
+	}
+	if d.hook != nil {
+		v, err := d.applyHook(d.valuePath(), value)
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+	d.addValue(value)
+	d.afterValue()
+	return nil
+}
+
+// afterValue moves on once a value is complete
+func (d *decoder) afterValue() {
+	if len(d.stack) == 0 {
+		d.state = stateDone
+		d.documentEnd()
+	} else {
+		d.state = stateDelimiter
+	}
+}
+
+// open starts a new object or array and pushes it onto the stack
+func (d *decoder) open(array bool) error {
+	path := d.valuePath()
+	var f frame
+	switch {
+	case array:
+		d.debugf("Start of array\n")
+		f = d.newArray(path)
+	case len(d.stack) == 0:
+		d.debugf("Start of object\n")
+		d.debugf("\tRoot object\n")
+		f = d.newRoot()
+	default:
+		d.debugf("Start of object\n")
+		d.debugf("\tCreating new object\n")
+		f = d.newObject(path)
+	}
+	f.path = path
+	if d.schema != nil {
+		if err := d.checkContainer(&f, array); err != nil {
+			return err
+		}
+	}
+
+	// Add it to its parent, then push it onto the stack
+	d.emitStart(path, array)
+	d.addContainer(&f)
+	d.push(f)
+
+	if array {
+		d.state = stateValueOrClose
+	} else {
+		d.state = stateKeyOrClose
+	}
+	return nil
+}
+
+// close finishes the object or array on top of the stack
+func (d *decoder) close(array bool) error {
+	if array {
+		d.debugf("End of array\n")
+	} else {
+		d.debugf("End of object\n")
+	}
+
+	if top := len(d.stack) - 1; d.stack[top].stale {
+		d.storeArray(top)
+	}
+	if d.schema != nil && !array {
+		if err := d.checkRequired(); err != nil {
+			return err
+		}
+	}
+	d.notifyClose()
+	d.emitEnd(array)
+	if d.spans != nil {
+		d.spanEnd()
+	}
+	d.pop()
+	if d.rawDepth > len(d.stack) {
+		return d.endRaw()
+	}
+	d.afterValue()
+	return nil
+}
+
+// end handles the end of the input. Open containers are kept as they are, and
+// a key without a value is stored with a nil value.
+func (d *decoder) end(tok Token) error {
+	if len(d.stack) == 0 {
+		return tokenError(tok, CodeUnexpectedEOF, "value")
+	}
+
+	if d.state == stateColon || (d.state == stateValue && !d.inArray()) {
+		d.addValue(nil)
+	}
+	d.syncArrays()
+	d.state = stateDone
+	return nil
+}
+
+// done reports whether the root value is complete
+func (d *decoder) done() bool {
+	return d.state == stateDone
+}
+
+// inArray reports whether the container on top of the stack is an array
+func (d *decoder) inArray() bool {
+	return d.top().isArray()
+}
+
+// isOpen reports whether the object or array at path is still open
+func (d *decoder) isOpen(path string) bool {
+	for i := range d.stack {
+		if d.stack[i].path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// expectingKey reports whether the next token is an object key
+func (d *decoder) expectingKey() bool {
+	return d.state == stateKey || d.state == stateKeyOrClose
+}
+
+// expectingValue reports whether the next token is a value
+func (d *decoder) expectingValue() bool {
+	return d.state == stateValue || d.state == stateValueOrClose
+}
+
+// storeArray stores the array at stack index i in its parent again, after
+// it has grown
+func (d *decoder) storeArray(i int) {
+	arr := d.stack[i].arr
+	d.stack[i].stale = false
+	if i == 0 {
+		d.result = arr
+		return
+	}
+	switch parent := &d.stack[i-1]; parent.kind {
+	case containerRoot, containerObject:
+		parent.obj[parent.key] = arr
+	case containerSink:
+		parent.sink.Set(parent.key, arr)
+	case containerArray:
+		// The parent's element shares the parent's backing array, so the
+		// parent itself doesn't need storing again
+		parent.arr[len(parent.arr)-1] = arr
+	}
+}
+
+// syncArrays stores the open arrays that have grown in their parents again,
+// so the output holds every value decoded so far. It is called before the
+// output is looked at while arrays are still open: at the end of the input,
+// before watchers are passed a container, and when a StreamingParser returns
+// from processing a chunk.
+func (d *decoder) syncArrays() {
+	for i := range d.stack {
+		if d.stack[i].stale {
+			d.storeArray(i)
+		}
+	}
+}
+
+// newRoot returns the frame for the root object
+func (d *decoder) newRoot() frame {
+	switch {
+	case d.skipOutput || d.discarding:
+		return frame{kind: containerSkippedObject}
+	case d.sink != nil:
+		return frame{kind: containerSink, sink: d.sink}
+	case d.output != nil:
+		return frame{kind: containerRoot, obj: *d.output}
+	}
+	return frame{kind: containerRoot, obj: make(map[string]any)}
+}
+
+// push pushes a new container onto the stack
+func (d *decoder) push(f frame) {
+	d.stack = append(d.stack, f)
+	d.stats.MaxDepth = max(d.stats.MaxDepth, len(d.stack))
+}
+
+// pop pops the current container from the stack
+func (d *decoder) pop() {
+	d.stack[len(d.stack)-1] = frame{} // Don't keep the container alive
+	d.stack = d.stack[:len(d.stack)-1]
+}
+
+// valuePath returns the path of the next value added to the current container
+func (d *decoder) valuePath() string {
+	if len(d.stack) == 0 {
+		return ""
+	}
+	switch f := d.top(); f.kind {
+	case containerArray:
+		return appendIndexPath(f.path, len(f.arr))
+	case containerSkippedArray:
+		return appendIndexPath(f.path, f.n)
+	default:
+		return appendKeyPath(f.path, f.key)
+	}
+}
+
+// addValue adds a string, number, or literal to the current container, or
+// makes it the root value
+func (d *decoder) addValue(value interface{}) {
+	if len(d.watchers) > 0 || d.handler != nil {
+		path := d.valuePath()
+		defer d.valueAdded(path, value)
+	}
+	d.stats.Values++
+	d.store(value, nil)
+}
+
+// addContainer adds the new object or array f to the current container, or
+// makes it the root value
+func (d *decoder) addContainer(f *frame) {
+	if len(d.watchers) > 0 {
+		path := d.valuePath()
+		defer d.notify(path, f.value(), false)
+	}
+	d.stats.Values++
+	d.store(f.value(), f)
+}
+
+// store stores value in the current container, or makes it the root value.
+// container is the frame of a new object or array (nil for other values).
+func (d *decoder) store(value any, container *frame) {
+	if len(d.stack) == 0 {
+		d.result = value
+		if d.patches != nil {
+			d.patch("add", "", patchedValue(value, container))
+		}
+		return
+	}
+
+	top := len(d.stack) - 1
+	current := &d.stack[top]
+
+	if current.kind == containerSkippedArray {
+		current.n++
+		return
+	}
+	if d.skipOutput || d.discarding {
+		return
+	}
+	if d.filtering() && d.discardValue(d.valuePath()) {
+		// A placeholder keeps the indexes of the array's other elements
+		if current.kind == containerArray {
+			current.arr = append(current.arr, nil)
+			current.stale = true
+		}
+		return
+	}
+
+	if d.changes != nil || d.updated != nil {
+		d.changed(d.valuePath())
+	}
+	if d.patches != nil {
+		d.patchValue(current, patchedValue(value, container))
+	}
+	switch current.kind {
+	case containerRoot, containerObject:
+		current.obj[current.key] = value
+		delete(current.copiedObj, current.key)
+	case containerSink:
+		current.sink.Set(current.key, value)
+	case containerArray:
+		current.arr = append(current.arr, value)
+		current.stale = true
+	}
+}
+
+// reset clears the decoding state, keeping the configuration
+func (d *decoder) reset() {
+	d.syncArrays()
+	clear(d.stack)
+	d.stack = d.stack[:0]
+	d.state = stateValue
+	d.result = nil
+	d.pendingErr = nil
+	d.rawDepth = 0
+	d.merged = d.merged[:0]
+	d.partialOp = false
+	d.resyncing = false
+	clear(d.spans)
+	if d.source != nil {
+		d.source.holding = false
+	}
+	if !d.merge {
+		d.outputSize = 0
+		d.discarding = false
+	}
+}
+
+// debugf writes a debug trace message about a step taken
+func (d *decoder) debugf(msg string, args ...any) {
+	if d.logf != nil {
+		d.logf(slog.LevelDebug, msg, args...)
+	}
+}
+
+// warnf writes a debug trace message about a problem the decoder carries on from
+func (d *decoder) warnf(msg string, args ...any) {
+	if d.logf != nil {
+		d.logf(slog.LevelWarn, msg, args...)
+	}
+}
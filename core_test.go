@@ -0,0 +1,187 @@
+package flexjson
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParserAndStreamingParserAgree(t *testing.T) {
+	inputs := []string{
+		`{"a":[1,-2.5e3,{"b":[true,false,null]}],"c":"x\tyé😀"}`,
+		`{"nested":{"empty":{},"list":[]},"n":0}`,
+		`{"partial":[1,{"k":"v"`,
+		`{"a":1,}`,
+		`{"a":[1,]}`,
+		`{"a" 1}`,
+		`{"a":1 "b":2}`,
+		`{"a":[1 2]}`,
+		`{"a":nope}`,
+		`{"a":1e999999,"b":2}`,
+		`{1:2}`,
+		`[1,2]`,
+		`"text"`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			parsed, parseErr := Parse(input)
+
+			sp := NewStreamingParser(nil)
+			streamErr := sp.ProcessString(input)
+
+			var perr, serr *ParseError
+			if errors.As(parseErr, &perr) != errors.As(streamErr, &serr) {
+				t.Fatalf("Parse() error = %v, ProcessString() error = %v", parseErr, streamErr)
+			}
+			if perr != nil {
+				if perr.Code != serr.Code || perr.Offset != serr.Offset || perr.Got != serr.Got {
+					t.Errorf("Parse() error = %#v, ProcessString() error = %#v", *perr, *serr)
+				}
+				return
+			}
+
+			if streamed := sp.GetCurrentOutput(); !reflect.DeepEqual(streamed, parsed) {
+				t.Errorf("ProcessString() output = %#v, Parse() = %#v", streamed, parsed)
+			}
+		})
+	}
+}
+
+func TestParserPlainArrays(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{input: `[1,[2,[3]]]`, expected: []interface{}{int64(1), []interface{}{int64(2), []interface{}{int64(3)}}}},
+		{input: `[1,[2,[3`, expected: []interface{}{int64(1), []interface{}{int64(2), []interface{}{int64(3)}}}},
+		{input: `{"a":[{"b":[]}]}`, expected: map[string]any{"a": []interface{}{map[string]any{"b": []interface{}{}}}}},
+		{input: `"x"`, expected: "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			value, err := NewParser(NewLexer(tt.input).Tokenize()).Parse()
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(value, tt.expected) {
+				t.Errorf("Parse() = %#v, want %#v", value, tt.expected)
+			}
+		})
+	}
+}
+
+func TestArraysStoredAsTheyGrow(t *testing.T) {
+	out := map[string]any{}
+	sp := NewStreamingParser(&out)
+	var watched []any
+	sp.Watch("", func(v any, done bool) {
+		a, _ := v.(map[string]any)["a"].([]interface{})
+		watched = append(watched, len(a))
+	})
+
+	steps := []struct {
+		chunk    string
+		expected map[string]any
+	}{
+		{`{"a": [1, [2,`, map[string]any{"a": []interface{}{int64(1), []interface{}{int64(2)}}}},
+		{` 3], 4,`, map[string]any{"a": []interface{}{int64(1), []interface{}{int64(2), int64(3)}, int64(4)}}},
+		{` 5]}`, map[string]any{"a": []interface{}{int64(1), []interface{}{int64(2), int64(3)}, int64(4), int64(5)}}},
+	}
+	for _, step := range steps {
+		if err := sp.ProcessString(step.chunk); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", step.chunk, err)
+		}
+		if !reflect.DeepEqual(out, step.expected) {
+			t.Errorf("after %q output = %#v, want %#v", step.chunk, out, step.expected)
+		}
+	}
+
+	// Each change inside the root is seen by its watcher with the array's
+	// elements so far
+	if want := []any{0, 0, 1, 2, 2, 2, 2, 3, 4, 4, 4}; !reflect.DeepEqual(watched, want) {
+		t.Errorf("watched lengths = %v, want %v", watched, want)
+	}
+}
+
+// benchmarkDocument returns a document of n records mixing nested objects and
+// arrays, like the responses the parser is used on
+func benchmarkDocument(n int) string {
+	var b strings.Builder
+	b.WriteString(`{"items": [`)
+	for i := range n {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, `{"id": %d, "name": "item %d", "tags": ["a", "b", "c"], "meta": {"score": %d.5, "ok": true, "ref": null}}`, i, i, i)
+	}
+	b.WriteString(`], "total": `)
+	fmt.Fprintf(&b, "%d}", n)
+	return b.String()
+}
+
+func BenchmarkParse(b *testing.B) {
+	input := benchmarkDocument(1000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Parse(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStreamingParser(b *testing.B) {
+	input := benchmarkDocument(1000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for b.Loop() {
+		sp := NewStreamingParser(nil)
+		for chunk := range slices.Chunk([]byte(input), 64) {
+			if err := sp.ProcessBytes(chunk); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkNestedArrays(b *testing.B) {
+	input := `{"a": ` + strings.Repeat("[1, ", 1000) + "1" + strings.Repeat("]", 1000) + "}"
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Parse(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLongArray(b *testing.B) {
+	input := `{"a": [` + strings.Repeat(`1, "x", `, 50000) + "null]}"
+
+	b.Run("Parse", func(b *testing.B) {
+		b.SetBytes(int64(len(input)))
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := Parse(input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("StreamingParser", func(b *testing.B) {
+		b.SetBytes(int64(len(input)))
+		b.ReportAllocs()
+		for b.Loop() {
+			sp := NewStreamingParser(nil)
+			for chunk := range slices.Chunk([]byte(input), 4096) {
+				if err := sp.ProcessBytes(chunk); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package flexjson
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// CSVConverter streams CSV input and converts each row into a JSON object
+// keyed by the header row, using the same value types as the JSON parsers
+type CSVConverter struct {
+	reader     *csv.Reader
+	headers    []string
+	inferTypes bool
+}
+
+// NewCSVConverter creates a CSVConverter reading from r. The first record is
+// used as the header row.
+func NewCSVConverter(r io.Reader) *CSVConverter {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Tolerate ragged rows
+	reader.ReuseRecord = true
+
+	return &CSVConverter{
+		reader:     reader,
+		inferTypes: true,
+	}
+}
+
+// SetInferTypes controls whether cells that are valid JSON numbers, booleans,
+// or null are converted to int64, float64, bool, or nil. Enabled by default;
+// when disabled every cell is a string.
+func (c *CSVConverter) SetInferTypes(value bool) {
+	c.inferTypes = value
+}
+
+// SetComma sets the field delimiter (',' by default)
+func (c *CSVConverter) SetComma(comma rune) {
+	c.reader.Comma = comma
+}
+
+// Headers returns the header row, reading it if no row has been read yet
+func (c *CSVConverter) Headers() ([]string, error) {
+	if c.headers == nil {
+		record, err := c.reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		c.headers = make([]string, len(record))
+		copy(c.headers, record)
+	}
+	return c.headers, nil
+}
+
+// Next returns the next row as an object. Missing cells are set to nil and
+// cells without a header are keyed by their 1-based column number.
+// It returns io.EOF when there are no more rows.
+func (c *CSVConverter) Next() (map[string]any, error) {
+	headers, err := c.Headers()
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := c.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]any, len(headers))
+	for i, header := range headers {
+		if header == "" {
+			header = strconv.Itoa(i + 1)
+		}
+		if i < len(record) {
+			row[header] = c.cellValue(record[i])
+		} else {
+			row[header] = nil
+		}
+	}
+	for i := len(headers); i < len(record); i++ {
+		row[strconv.Itoa(i+1)] = c.cellValue(record[i])
+	}
+
+	return row, nil
+}
+
+// Each calls fn for every remaining row, stopping at the first error
+func (c *CSVConverter) Each(fn func(row map[string]any) error) error {
+	for {
+		row, err := c.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+// cellValue converts a cell to the matching JSON value
+func (c *CSVConverter) cellValue(cell string) any {
+	if !c.inferTypes {
+		return cell
+	}
+
+	switch cell {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+
+	// Only convert text that is exactly a JSON number, so values like "007"
+	// or "+1" keep their original form
+	if isJSONNumber(cell) {
+		if n, ok := parseNumber(cell); ok {
+			return n
+		}
+	}
+	return cell
+}
+
+// isJSONNumber reports whether s matches the JSON number grammar exactly
+func isJSONNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+
+	// Integer part: a single zero or a non-zero digit followed by digits
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && s[i] >= '1' && s[i] <= '9':
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+	default:
+		return false
+	}
+
+	// Fractional part
+	if i < len(s) && s[i] == '.' {
+		i++
+		if i >= len(s) || !isDigit(s[i]) {
+			return false
+		}
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+	}
+
+	// Exponent part
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if i >= len(s) || !isDigit(s[i]) {
+			return false
+		}
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+	}
+
+	return i == len(s)
+}
@@ -0,0 +1,83 @@
+package flexjson
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCSVConverter(t *testing.T) {
+	input := "name,age,score,active,zip,note\n" +
+		"John,30,9.5,true,007,\n" +
+		"Jane,-2,1e3,false,12345,\"hello, world\"\n" +
+		"Short,1\n" +
+		"Long,2,3,null,4,5,extra\n"
+
+	c := NewCSVConverter(strings.NewReader(input))
+
+	var rows []map[string]any
+	err := c.Each(func(row map[string]any) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Each() error = %v", err)
+	}
+
+	expected := []map[string]any{
+		{"name": "John", "age": int64(30), "score": 9.5, "active": true, "zip": "007", "note": ""},
+		{"name": "Jane", "age": int64(-2), "score": float64(1000), "active": false, "zip": int64(12345), "note": "hello, world"},
+		{"name": "Short", "age": int64(1), "score": nil, "active": nil, "zip": nil, "note": nil},
+		{"name": "Long", "age": int64(2), "score": int64(3), "active": nil, "zip": int64(4), "note": int64(5), "7": "extra"},
+	}
+
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Unexpected rows.\nGot  %v\nwant %v", rows, expected)
+	}
+
+	headers, err := c.Headers()
+	if err != nil {
+		t.Fatalf("Headers() error = %v", err)
+	}
+	if !reflect.DeepEqual(headers, []string{"name", "age", "score", "active", "zip", "note"}) {
+		t.Errorf("Unexpected headers: %v", headers)
+	}
+}
+
+func TestCSVConverterWithoutTypeInference(t *testing.T) {
+	c := NewCSVConverter(strings.NewReader("id;flag\n1;true\n"))
+	c.SetComma(';')
+	c.SetInferTypes(false)
+
+	row, err := c.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+
+	expected := map[string]any{"id": "1", "flag": "true"}
+	if !reflect.DeepEqual(row, expected) {
+		t.Errorf("Next() = %v, want %v", row, expected)
+	}
+
+	if _, err := c.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next() error = %v, want io.EOF", err)
+	}
+}
+
+func TestIsJSONNumber(t *testing.T) {
+	valid := []string{"0", "-0", "12", "1.5", "-1.5e10", "2E-3", "0.0"}
+	invalid := []string{"", "-", "01", "+1", "1.", ".5", "1e", "1e+", "0x10", "1,000", "NaN"}
+
+	for _, s := range valid {
+		if !isJSONNumber(s) {
+			t.Errorf("isJSONNumber(%q) = false, want true", s)
+		}
+	}
+	for _, s := range invalid {
+		if isJSONNumber(s) {
+			t.Errorf("isJSONNumber(%q) = true, want false", s)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package flexjson
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TraceFormat selects how debug tracing describes each character
+type TraceFormat uint8
+
+const (
+	// TraceVerbose prints the parser state for each character followed by a
+	// description of every step taken. This is the format used by SetDebug.
+	TraceVerbose TraceFormat = iota
+	// TraceCompact prints a single line per character:
+	//
+	//	<offset> <char> <flags> <buffer>
+	//
+	// where flags are K (expecting key), C (expecting colon), S (in string),
+	// and E (escaping), or '-' when none apply
+	TraceCompact
+)
+
+// LevelTrace is the level, below slog.LevelDebug, at which a StreamingParser
+// logs its state before each character to a logger given with WithLogger
+const LevelTrace = slog.LevelDebug - 4
+
+// WithLogger sends the debug trace to logger as structured records instead
+// of printing it to stdout, so it can go through a service's logging
+// pipeline. The steps the parser takes are logged at slog.LevelDebug, and
+// problems it carries on from, such as errors skipped with WithRecovery, at
+// slog.LevelWarn. A StreamingParser also logs its state before each character
+// at LevelTrace, and is traced from the start, as after SetDebug(true);
+// DebugOptions still sample and limit the trace, but their Output and Format
+// are ignored. Which records are kept is up to the logger's handler.
+func WithLogger(logger *slog.Logger) Option {
+	return func(d *decoder) {
+		d.logger = logger
+		d.logf = func(level slog.Level, msg string, args ...any) {
+			logRecord(logger, level, msg, args)
+		}
+	}
+}
+
+// logRecord logs a trace message formatted for the verbose trace to logger
+func logRecord(logger *slog.Logger, level slog.Level, msg string, args []any, attrs ...slog.Attr) {
+	ctx := context.Background()
+	if logger.Enabled(ctx, level) {
+		logger.LogAttrs(ctx, level, strings.TrimSpace(fmt.Sprintf(msg, args...)), attrs...)
+	}
+}
+
+// DebugOptions configures debug tracing. The zero value traces every character
+// in the verbose format to stdout, like SetDebug(true).
+type DebugOptions struct {
+	Output      io.Writer   // Where trace lines are written (default os.Stdout)
+	Format      TraceFormat // Trace format
+	SampleEvery int         // Trace only every Nth character (0 or 1 traces all)
+	MaxLines    int         // Stop tracing after this many lines (0 for no limit)
+	MaxBuffer   int         // Truncate the token buffer shown to this many bytes (0 for no limit)
+}
+
+// SetDebugOptions enables debug tracing with opts. Sampling, line limits, and
+// the compact format keep the trace of a large stream to a usable size.
+func (sp *StreamingParser) SetDebugOptions(opts DebugOptions) {
+	sp.debug = true
+	sp.trace = opts
+	sp.traceChars = 0
+	sp.traceLines = 0
+}
+
+// traceChar decides whether the character c is traced and writes its state line
+func (sp *StreamingParser) traceChar(c string) {
+	if !sp.debug {
+		return
+	}
+
+	sp.traceChars++
+	sp.traceOn = sp.trace.SampleEvery <= 1 || (sp.traceChars-1)%sp.trace.SampleEvery == 0
+	if !sp.traceOn {
+		return
+	}
+
+	l := sp.lexer
+	buffer := l.pendingText()
+	if sp.trace.MaxBuffer > 0 && len(buffer) > sp.trace.MaxBuffer {
+		buffer = buffer[:sp.trace.MaxBuffer] + "..."
+	}
+
+	if sp.logger != nil {
+		if sp.logger.Enabled(context.Background(), LevelTrace) && sp.countLine() {
+			logRecord(sp.logger, LevelTrace, "character", nil,
+				slog.Int("offset", sp.offset),
+				slog.String("char", c),
+				slog.Bool("expecting_key", sp.expectingKey()),
+				slog.Bool("expecting_colon", sp.state == stateColon),
+				slog.Bool("in_string", l.inString()),
+				slog.Bool("escaping", l.escaping()),
+				slog.String("buffer", buffer))
+		}
+		return
+	}
+
+	if sp.trace.Format == TraceCompact {
+		flags := ""
+		for _, f := range []struct {
+			set  bool
+			flag string
+		}{{sp.expectingKey(), "K"}, {sp.state == stateColon, "C"}, {l.inString(), "S"}, {l.escaping(), "E"}} {
+			if f.set {
+				flags += f.flag
+			}
+		}
+		if flags == "" {
+			flags = "-"
+		}
+		sp.writeTrace("%d %s %s %s\n", sp.offset, strconv.Quote(c), flags, strconv.Quote(buffer))
+		return
+	}
+
+	sp.writeTrace("- %s\texpecting key: %v, expecting colon: %v, isEscaping: %v, inString: %v, buffer: %s\n", c,
+		sp.expectingKey(), sp.state == stateColon, l.escaping(), l.inString(), buffer)
+}
+
+// log writes a trace message about a step taken at level for the character
+// being traced
+func (sp *StreamingParser) log(level slog.Level, msg string, args ...any) {
+	switch {
+	case !sp.debug || !sp.traceOn:
+	case sp.logger != nil:
+		if sp.logger.Enabled(context.Background(), level) && sp.countLine() {
+			logRecord(sp.logger, level, msg, args, slog.Int("offset", sp.offset))
+		}
+	case sp.trace.Format == TraceVerbose:
+		sp.writeTrace(msg, args...)
+	}
+}
+
+// writeTrace writes a trace line, enforcing the line limit
+func (sp *StreamingParser) writeTrace(msg string, args ...interface{}) {
+	if !sp.countLine() {
+		return
+	}
+	fmt.Fprintf(sp.traceOutput(), msg, args...)
+}
+
+// countLine counts a trace line against the line limit, reporting whether it
+// is written. The line that reaches the limit is replaced by a note that the
+// trace was truncated.
+func (sp *StreamingParser) countLine() bool {
+	if sp.trace.MaxLines > 0 && sp.traceLines >= sp.trace.MaxLines {
+		return false
+	}
+
+	sp.traceLines++
+	if sp.trace.MaxLines == 0 || sp.traceLines < sp.trace.MaxLines {
+		return true
+	}
+	if sp.logger != nil {
+		logRecord(sp.logger, slog.LevelDebug, "trace truncated", nil, slog.Int("lines", sp.trace.MaxLines))
+		return false
+	}
+	fmt.Fprintf(sp.traceOutput(), "... trace truncated after %d lines\n", sp.trace.MaxLines)
+	return false
+}
+
+// traceOutput returns the writer trace lines are written to
+func (sp *StreamingParser) traceOutput() io.Writer {
+	if sp.trace.Output == nil {
+		return os.Stdout
+	}
+	return sp.trace.Output
+}
@@ -0,0 +1,121 @@
+package flexjson
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDebugOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     DebugOptions
+		input    string
+		expected string
+	}{
+		{
+			name:  "Compact",
+			opts:  DebugOptions{Format: TraceCompact},
+			input: `{"a":1}`,
+			expected: `0 "{" - ""
+1 "\"" K ""
+2 "a" KS ""
+3 "\"" KS "a"
+4 ":" C ""
+5 "1" - ""
+6 "}" - "1"
+`,
+		},
+		{
+			name:  "Sampled",
+			opts:  DebugOptions{Format: TraceCompact, SampleEvery: 3},
+			input: `{"a":1}`,
+			expected: `0 "{" - ""
+3 "\"" KS "a"
+6 "}" - "1"
+`,
+		},
+		{
+			name:  "Truncated buffer",
+			opts:  DebugOptions{Format: TraceCompact, SampleEvery: 100, MaxBuffer: 2},
+			input: `{"a":"` + strings.Repeat("x", 100) + `"`,
+			expected: `0 "{" - ""
+100 "x" S "xx..."
+`,
+		},
+		{
+			name:  "Line limit",
+			opts:  DebugOptions{MaxLines: 4},
+			input: `{"a":1}`,
+			expected: "- {\texpecting key: false, expecting colon: false, isEscaping: false, inString: false, buffer: \n" +
+				"Start of object\n" +
+				"\tRoot object\n" +
+				"... trace truncated after 4 lines\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			tt.opts.Output = &out
+
+			sp := NewStreamingParser(nil)
+			sp.SetDebugOptions(tt.opts)
+			if err := sp.ProcessString(tt.input); err != nil {
+				t.Fatalf("ProcessString() error = %v", err)
+			}
+
+			if out.String() != tt.expected {
+				t.Errorf("Trace =\n%s\nwant\n%s", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var out bytes.Buffer
+	handler := slog.NewTextHandler(&out, &slog.HandlerOptions{
+		Level: LevelTrace,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+
+	sp := NewStreamingParser(nil, WithLogger(slog.New(handler)), WithRecovery())
+	if err := sp.ProcessString(`{"a":x}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	expected := []string{
+		`level=DEBUG-4 msg=character offset=0 char={ expecting_key=false expecting_colon=false in_string=false escaping=false buffer=""`,
+		`level=DEBUG msg="Start of object" offset=0`,
+		`level=DEBUG msg="Root object" offset=0`,
+		`level=DEBUG-4 msg=character offset=1 char="\"" expecting_key=true expecting_colon=false in_string=false escaping=false buffer=""`,
+		`level=DEBUG-4 msg=character offset=2 char=a expecting_key=true expecting_colon=false in_string=true escaping=false buffer=""`,
+		`level=DEBUG-4 msg=character offset=3 char="\"" expecting_key=true expecting_colon=false in_string=true escaping=false buffer=a`,
+		`level=DEBUG msg="Storing as key" offset=3`,
+		`level=DEBUG-4 msg=character offset=4 char=: expecting_key=false expecting_colon=true in_string=false escaping=false buffer=""`,
+		`level=DEBUG-4 msg=character offset=5 char=x expecting_key=false expecting_colon=false in_string=false escaping=false buffer=""`,
+		`level=WARN msg="Recovering from unexpected 'x' at line 1, column 6: expected value" offset=5`,
+		`level=DEBUG-4 msg=character offset=6 char=} expecting_key=false expecting_colon=false in_string=false escaping=false buffer=""`,
+		`level=DEBUG msg="End of object" offset=6`,
+	}
+	if got := strings.Split(strings.TrimSpace(out.String()), "\n"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Log =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	// Parse logs its steps, and the handler's level filters them
+	out.Reset()
+	handler = slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelWarn})
+	if _, err := Parse(`{"a":x, "b":1}`, WithLogger(slog.New(handler)), WithRecovery()); err == nil {
+		t.Fatal("Parse() error = nil, want the recovered error")
+	}
+	if got := strings.Count(out.String(), "\n"); got != 1 || !strings.Contains(out.String(), "level=WARN") {
+		t.Errorf("Log at slog.LevelWarn =\n%s\nwant one warning", out.String())
+	}
+}
@@ -0,0 +1,206 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Decoder reads JSON values from an input stream. It has the Token, More, and
+// Decode methods of encoding/json.Decoder and can stand in for it, but input
+// cut off part way through a value is treated like the partial documents Parse
+// accepts rather than as an error:
+//
+//   - Token returns the delimiters that would have closed the open objects and
+//     arrays, then io.EOF. A key cut off before its value is followed by a nil
+//     value.
+//   - Decode decodes as much of the value as was read, then returns io.EOF
+//     on the next call.
+type Decoder struct {
+	r      io.Reader
+	buf    []byte
+	lexer  *Lexer
+	core   decoder // Tracks the structure of the tokens read so far
+	tok    Token   // Next token, when peeked is set
+	peeked bool
+	err    error // Read error, returned once the input read so far is used up
+}
+
+// NewDecoder returns a new decoder that reads from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r:     r,
+		buf:   make([]byte, 4096),
+		lexer: NewIncrementalLexer(),
+		core:  decoder{skipOutput: true},
+	}
+}
+
+// Token returns the next JSON token in the input stream: a json.Delim for
+// the four JSON delimiters [ ] { }, a bool, a float64 for numbers, a string,
+// or nil for null. Commas and colons are consumed without being returned. At
+// the end of the input, Token returns nil, io.EOF.
+func (dec *Decoder) Token() (json.Token, error) {
+	for {
+		tok, err := dec.peek()
+		if err != nil {
+			return nil, err
+		}
+		if tok.Type == TokenEOF {
+			return dec.finish()
+		}
+
+		if dec.core.done() {
+			// The next top-level value in the stream
+			dec.core.reset()
+		}
+		dec.peeked = false
+		if err := dec.core.token(tok); err != nil {
+			return nil, err
+		}
+
+		switch tok.Type {
+		case TokenComma, TokenColon:
+			continue
+		case TokenLeftBrace, TokenRightBrace, TokenLeftBracket, TokenRightBracket:
+			return json.Delim(tok.Value[0]), nil
+		case TokenString, TokenIdentifier:
+			return tok.Value, nil
+		case TokenNumber:
+			f, err := strconv.ParseFloat(tok.Value, 64)
+			if err != nil {
+				return nil, tokenError(tok, CodeInvalidNumber, "valid number")
+			}
+			return f, nil
+		case TokenTrue:
+			return true, nil
+		case TokenFalse:
+			return false, nil
+		}
+		return nil, nil
+	}
+}
+
+// More reports whether there is another element in the current array or
+// object being parsed, or another value in the stream
+func (dec *Decoder) More() bool {
+	tok, err := dec.peek()
+	if err != nil {
+		return false
+	}
+	switch tok.Type {
+	case TokenEOF, TokenRightBrace, TokenRightBracket:
+		return false
+	}
+	return true
+}
+
+// Decode reads the next JSON value from the input and stores it in the value
+// pointed to by v, following the rules of json.Unmarshal. A value cut off by
+// the end of the input is decoded as far as it goes, as with Unmarshal.
+func (dec *Decoder) Decode(v any) error {
+	// Skip the separator before the value
+	tok, err := dec.peek()
+	if err != nil {
+		return err
+	}
+	if tok.Type == TokenComma || tok.Type == TokenColon {
+		dec.peeked = false
+		if err := dec.core.token(tok); err != nil {
+			return err
+		}
+		if tok, err = dec.peek(); err != nil {
+			return err
+		}
+	}
+
+	if tok.Type == TokenEOF {
+		return io.EOF
+	}
+	if dec.core.done() {
+		dec.core.reset()
+	}
+
+	depth := len(dec.core.stack)
+	value := &decoder{}
+	for !value.done() {
+		tok, err := dec.peek()
+		if err != nil {
+			return err
+		}
+		if tok.Type == TokenEOF {
+			// The value was cut off, so finish it where it ends
+			if err := value.token(tok); err != nil {
+				return err
+			}
+			dec.core.unwind(depth)
+			break
+		}
+
+		dec.peeked = false
+		if err := dec.core.token(tok); err != nil {
+			return err
+		}
+		if err := value.token(tok); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(value.result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// peek returns the next token without consuming it, reading more input as needed
+func (dec *Decoder) peek() (Token, error) {
+	for !dec.peeked {
+		if tok, ok := dec.lexer.NextToken(); ok {
+			dec.tok, dec.peeked = tok, true
+			break
+		}
+		if dec.err != nil {
+			return Token{}, dec.err
+		}
+
+		n, err := dec.r.Read(dec.buf)
+		dec.lexer.Feed(dec.buf[:n])
+		if err == io.EOF {
+			dec.lexer.Close()
+		} else if err != nil {
+			dec.err = err
+		}
+	}
+	return dec.tok, nil
+}
+
+// finish returns the tokens that complete a document cut off by the end of
+// the input, one per call, and then io.EOF
+func (dec *Decoder) finish() (json.Token, error) {
+	d := &dec.core
+	if d.done() || len(d.stack) == 0 {
+		return nil, io.EOF
+	}
+
+	array := d.inArray()
+	if !array && (d.state == stateColon || d.state == stateValue) {
+		// A key without a value
+		d.scalar(nil)
+		return nil, nil
+	}
+
+	d.close(array)
+	if array {
+		return json.Delim(']'), nil
+	}
+	return json.Delim('}'), nil
+}
+
+// unwind pops the containers opened above depth, once a value cut off by the
+// end of the input has been decoded
+func (d *decoder) unwind(depth int) {
+	clear(d.stack[depth:])
+	d.stack = d.stack[:depth]
+	d.afterValue()
+}
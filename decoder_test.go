@@ -0,0 +1,144 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// readTokens calls next until it returns io.EOF, collecting the tokens
+func readTokens(t *testing.T, next func() (json.Token, error)) []json.Token {
+	t.Helper()
+	var tokens []json.Token
+	for {
+		tok, err := next()
+		if err == io.EOF {
+			return tokens
+		}
+		if err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func TestDecoderTokenMatchesEncodingJSON(t *testing.T) {
+	input := `{"a":[1,2.5,"x",true,false,null],"b":{"c":{}}} [] "next"`
+
+	expected := readTokens(t, json.NewDecoder(strings.NewReader(input)).Token)
+	tokens := readTokens(t, NewDecoder(iotest.OneByteReader(strings.NewReader(input))).Token)
+
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Token() = %v, want %v", tokens, expected)
+	}
+}
+
+func TestDecoderTokenTruncated(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []json.Token
+	}{
+		{
+			input:    `{"a":[1,"x`,
+			expected: []json.Token{json.Delim('{'), "a", json.Delim('['), 1.0, "x", json.Delim(']'), json.Delim('}')},
+		},
+		{
+			input:    `{"a":{"b":`,
+			expected: []json.Token{json.Delim('{'), "a", json.Delim('{'), "b", nil, json.Delim('}'), json.Delim('}')},
+		},
+		{
+			input:    `[tr`,
+			expected: []json.Token{json.Delim('['), json.Delim(']')},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens := readTokens(t, NewDecoder(strings.NewReader(tt.input)).Token)
+			if !reflect.DeepEqual(tokens, tt.expected) {
+				t.Errorf("Token() = %v, want %v", tokens, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDecoderDecode(t *testing.T) {
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	dec := NewDecoder(strings.NewReader(`{"items":[{"id":1,"name":"a"},{"id":2,"name":"b"},{"id":3,"na`))
+
+	// Walk into the array, then decode its elements
+	for _, want := range []json.Token{json.Delim('{'), "items", json.Delim('[')} {
+		if tok, err := dec.Token(); err != nil || tok != want {
+			t.Fatalf("Token() = %v, %v, want %v", tok, err, want)
+		}
+	}
+
+	var items []item
+	for dec.More() {
+		var it item
+		if err := dec.Decode(&it); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		items = append(items, it)
+	}
+
+	expected := []item{{1, "a"}, {2, "b"}, {3, ""}}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("Items = %v, want %v", items, expected)
+	}
+
+	// The containers the element was cut off in are closed by Token
+	rest := readTokens(t, dec.Token)
+	if !reflect.DeepEqual(rest, []json.Token{json.Delim(']'), json.Delim('}')}) {
+		t.Errorf("Remaining tokens = %v", rest)
+	}
+}
+
+func TestDecoderDecodeStream(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"n":1} {"n":2}` + "\n" + `{"n":`))
+
+	var values []map[string]any
+	for {
+		var v map[string]any
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		values = append(values, v)
+	}
+
+	expected := []map[string]any{{"n": 1.0}, {"n": 2.0}, {"n": nil}}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Values = %v, want %v", values, expected)
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a" 1}`))
+	if _, err := dec.Token(); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if _, err := dec.Token(); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	_, err := dec.Token()
+	if perr, ok := err.(*ParseError); !ok || perr.Code != CodeExpectedColon {
+		t.Errorf("Token() error = %v, want %s", err, CodeExpectedColon)
+	}
+
+	dec = NewDecoder(iotest.ErrReader(io.ErrClosedPipe))
+	if err := dec.Decode(new(any)); err != io.ErrClosedPipe {
+		t.Errorf("Decode() error = %v, want %v", err, io.ErrClosedPipe)
+	}
+}
@@ -0,0 +1,148 @@
+package flexjson
+
+import (
+	"reflect"
+	"slices"
+)
+
+// ChangeKind is the kind of a Change
+type ChangeKind uint8
+
+const (
+	ChangeAdded   ChangeKind = iota // The value is only in the second document
+	ChangeRemoved                   // The value is only in the first document
+	ChangeChanged                   // The value differs between the documents
+)
+
+// String returns the name of the kind
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeChanged:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// Change is a difference between two documents found by Diff
+type Change struct {
+	Path string     // Path of the value, in the format described in path.go
+	Kind ChangeKind // Whether the value was added, removed, or changed
+	Old  any        // Value in the first document (nil when added)
+	New  any        // Value in the second document (nil when removed)
+}
+
+// Diff compares two parsed documents, such as consecutive snapshots of a
+// stream, and returns the paths where they differ. Objects are compared
+// member by member and arrays element by element, so a change deep inside a
+// document is reported at its own path, and a value that appears or
+// disappears is reported once, at the top of the subtree. A value that
+// changes type, e.g. from an object to a string, is reported as changed.
+//
+// Numbers are equal if they have the same value, whichever type they are
+// stored as, so documents parsed with UseNumber, UseFloat64, or
+// UseBigNumbers compare equal to the defaults. A float64 is compared at its
+// own precision, so a large integer rounded to one is equal to the integer. Objects may be map[string]any
+// or *OrderedMap, and compare equal regardless of key order. Changes are
+// returned in sorted key order, depth first.
+func Diff(a, b map[string]any) []Change {
+	return diffValues(nil, "", a, b)
+}
+
+// diffValues appends the differences between a and b at path to changes
+func diffValues(changes []Change, path string, a, b any) []Change {
+	if objA, ok := objectMembers(a); ok {
+		if objB, ok := objectMembers(b); ok {
+			return diffObjects(changes, path, objA, objB)
+		}
+	}
+	arrA, okA := a.([]interface{})
+	arrB, okB := b.([]interface{})
+	if okA && okB {
+		for i := range max(len(arrA), len(arrB)) {
+			elem := appendIndexPath(path, i)
+			switch {
+			case i >= len(arrA):
+				changes = append(changes, Change{Path: elem, Kind: ChangeAdded, New: arrB[i]})
+			case i >= len(arrB):
+				changes = append(changes, Change{Path: elem, Kind: ChangeRemoved, Old: arrA[i]})
+			default:
+				changes = diffValues(changes, elem, arrA[i], arrB[i])
+			}
+		}
+		return changes
+	}
+
+	if !equalScalars(a, b) {
+		changes = append(changes, Change{Path: path, Kind: ChangeChanged, Old: a, New: b})
+	}
+	return changes
+}
+
+// diffObjects appends the differences between two objects' members
+func diffObjects(changes []Change, path string, a, b map[string]any) []Change {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		member := appendKeyPath(path, key)
+		va, inA := a[key]
+		vb, inB := b[key]
+		switch {
+		case !inA:
+			changes = append(changes, Change{Path: member, Kind: ChangeAdded, New: vb})
+		case !inB:
+			changes = append(changes, Change{Path: member, Kind: ChangeRemoved, Old: va})
+		default:
+			changes = diffValues(changes, member, va, vb)
+		}
+	}
+	return changes
+}
+
+// objectMembers returns the members of an object as a map
+func objectMembers(v any) (map[string]any, bool) {
+	switch obj := v.(type) {
+	case map[string]any:
+		return obj, true
+	case *OrderedMap:
+		m := make(map[string]any, obj.Len())
+		for key, value := range obj.All() {
+			m[key] = value
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// equalScalars reports whether two values that aren't both objects or both
+// arrays are equal, comparing numbers by value
+func equalScalars(a, b any) bool {
+	if x, ok := numberValue(a); ok {
+		y, ok := numberValue(b)
+		if !ok {
+			return false
+		}
+		_, floatA := a.(float64)
+		_, floatB := b.(float64)
+		if floatA || floatB {
+			// Compare at the precision of the float64
+			fx, _ := x.Float64()
+			fy, _ := y.Float64()
+			return fx == fy
+		}
+		return x.Cmp(y) == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,79 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := map[string]any{
+		"same":    "x",
+		"changed": int64(1),
+		"removed": true,
+		"nested":  map[string]any{"keep": nil, "deep": []interface{}{int64(1), int64(2), int64(3)}},
+		"type":    map[string]any{"a": int64(1)},
+	}
+	b := map[string]any{
+		"same":    "x",
+		"changed": int64(2),
+		"added":   []interface{}{"new"},
+		"nested":  map[string]any{"keep": nil, "deep": []interface{}{int64(1), int64(5)}},
+		"type":    "object",
+	}
+
+	expected := []Change{
+		{Path: "added", Kind: ChangeAdded, New: []interface{}{"new"}},
+		{Path: "changed", Kind: ChangeChanged, Old: int64(1), New: int64(2)},
+		{Path: "nested.deep[1]", Kind: ChangeChanged, Old: int64(2), New: int64(5)},
+		{Path: "nested.deep[2]", Kind: ChangeRemoved, Old: int64(3)},
+		{Path: "removed", Kind: ChangeRemoved, Old: true},
+		{Path: "type", Kind: ChangeChanged, Old: map[string]any{"a": int64(1)}, New: "object"},
+	}
+	if got := Diff(a, b); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Diff() = %v, want %v", got, expected)
+	}
+
+	if got := Diff(a, a); len(got) != 0 {
+		t.Errorf("Diff(a, a) = %v, want no changes", got)
+	}
+	if ChangeAdded.String() != "added" || ChangeRemoved.String() != "removed" || ChangeChanged.String() != "changed" {
+		t.Error("ChangeKind.String() mismatched")
+	}
+}
+
+func TestDiffParsingModes(t *testing.T) {
+	input := `{"n": 1, "f": 2.5, "big": 12345678901234567890, "list": [{"k": -0}], "o": {"b": 1, "a": 2}}`
+
+	base, err := Parse(input)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	ordered, err := ParseOrdered(input)
+	if err != nil {
+		t.Fatalf("ParseOrdered() error = %v", err)
+	}
+
+	p := NewParser(NewLexer(input).Tokenize())
+	p.UseNumber()
+	numbers, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	sp := NewStreamingParser(nil)
+	sp.UseBigNumbers()
+	if err := sp.ProcessString(input); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	others := map[string]map[string]any{
+		"ordered": map[string]any{"root": ordered},
+		"numbers": map[string]any{"root": numbers},
+		"big":     map[string]any{"root": sp.GetCurrentOutput()},
+	}
+	for name, other := range others {
+		if got := Diff(map[string]any{"root": base}, other); len(got) != 0 {
+			t.Errorf("Diff() with %s = %v, want no changes", name, got)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package flexjson
+
+// Event ordering
+//
+// Subscribers see a single total order of events that follows the input:
+//
+//   - Events are delivered in the order of the characters that caused them.
+//   - An object key is always reported before its value, and a value is
+//     reported before anything that follows it in the input.
+//   - A container is reported as opened before any of its children, and
+//     reported as closed only after all of its children have been finalized.
+//   - When one change is reported to several subscribers, they are called in
+//     the order they were registered.
+//
+// The same order holds in both dispatch modes, so subscriber code written
+// against synchronous delivery keeps working when dispatch is made async.
+// The exception is the update a watched object or array receives when a
+// value inside it changes: with async dispatch, each update passes a copy of
+// the whole container, so the updates of one Process call are merged into a
+// single one delivered when the call ends, or dropped if the container is
+// closed during the call.
+
+// DispatchMode selects how events are delivered to subscribers
+type DispatchMode uint8
+
+const (
+	// DispatchSync calls subscribers on the parsing goroutine, before
+	// ProcessChar or ProcessString returns. This is the default.
+	DispatchSync DispatchMode = iota
+	// DispatchAsync queues events and calls subscribers from a single
+	// background goroutine, so slow subscribers don't hold up parsing until
+	// the queue is full
+	DispatchAsync
+)
+
+// dispatcher delivers queued events on a background goroutine
+type dispatcher struct {
+	queue chan func()
+	done  chan struct{}
+}
+
+// SetDispatch configures how events are delivered to subscribers. In
+// DispatchAsync mode up to queueSize events are buffered; when the queue is
+// full the parser blocks until the subscribers catch up, so memory use stays
+// bounded. Values passed to async subscribers are snapshots taken when the
+// event happened rather than the live containers being built, and changes
+// inside a watched container are reported once per Process call, as
+// described in dispatch.go. Snapshots share the copies of values that are
+// complete, so they must not be modified.
+//
+// Call Flush to wait for queued events to be delivered, and Close to stop the
+// background goroutine once parsing is finished.
+func (sp *StreamingParser) SetDispatch(mode DispatchMode, queueSize int) {
+	sp.Close()
+	if mode != DispatchAsync {
+		return
+	}
+
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	d := &dispatcher{
+		queue: make(chan func(), queueSize),
+		done:  make(chan struct{}),
+	}
+	go d.run()
+	sp.dispatch = d
+}
+
+// Flush blocks until every queued event has been delivered. It does nothing
+// in DispatchSync mode.
+func (sp *StreamingParser) Flush() {
+	if sp.dispatch == nil {
+		return
+	}
+	flushed := make(chan struct{})
+	sp.dispatch.queue <- func() { close(flushed) }
+	<-flushed
+}
+
+// Close delivers any queued events and stops async dispatch. The parser
+// falls back to DispatchSync afterwards.
+func (sp *StreamingParser) Close() {
+	if sp.dispatch == nil {
+		return
+	}
+	close(sp.dispatch.queue)
+	<-sp.dispatch.done
+	sp.dispatch = nil
+}
+
+// run calls queued events in order until the queue is closed
+func (d *dispatcher) run() {
+	defer close(d.done)
+	for fn := range d.queue {
+		fn()
+	}
+}
+
+// enqueue runs call, either directly or through the async queue
+func (d *decoder) enqueue(call func()) {
+	if d.dispatch == nil {
+		call()
+		return
+	}
+	d.dispatch.queue <- call
+}
+
+// deliver calls fn with v, either directly or through the async queue
+func (d *decoder) deliver(fn WatchFunc, v any, done bool) {
+	if d.dispatch != nil {
+		v = snapshotValue(v)
+	}
+	d.enqueue(func() { fn(v, done) })
+}
+
+// snapshotValue deep-copies the containers in v so they can be read after
+// the parser has moved on
+func snapshotValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = snapshotValue(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = snapshotValue(e)
+		}
+		return s
+	case *OrderedMap:
+		m := &OrderedMap{pairs: make([]Pair, len(v.pairs)), index: make(map[string]int, len(v.pairs))}
+		for i, p := range v.pairs {
+			m.pairs[i] = Pair{Key: p.Key, Value: snapshotValue(p.Value)}
+			m.index[p.Key] = i
+		}
+		return m
+	}
+	return v
+}
@@ -0,0 +1,136 @@
+package flexjson
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestDispatchOrdering(t *testing.T) {
+	input := `{"a":{"b":"xy","c":[1,{"d":null}]},"e":true}`
+
+	record := func(mode DispatchMode, queueSize int) []watchCall {
+		sp := NewStreamingParser(nil)
+		sp.SetDispatch(mode, queueSize)
+		defer sp.Close()
+
+		var calls []watchCall
+		for _, path := range []string{"", "a", "a.b", "a.c[1]", "e"} {
+			sp.Watch(path, func(v any, done bool) {
+				calls = append(calls, watchCall{path, done})
+			})
+		}
+
+		if err := sp.ProcessString(input); err != nil {
+			t.Fatalf("ProcessString() error = %v", err)
+		}
+		sp.Flush()
+		return calls
+	}
+
+	sync := record(DispatchSync, 0)
+
+	// With async dispatch, the updates for changes inside a container are
+	// merged into one at the end of the Process call, which here is dropped
+	// because every container is closed by then
+	var merged []watchCall
+	opened := map[string]bool{}
+	for _, c := range sync {
+		path := c.value.(string)
+		container := path == "" || path == "a" || path == "a.c[1]"
+		if container && !c.done && opened[path] {
+			continue
+		}
+		opened[path] = true
+		merged = append(merged, c)
+	}
+	for _, queueSize := range []int{0, 1, 64} {
+		if async := record(DispatchAsync, queueSize); !reflect.DeepEqual(async, merged) {
+			t.Errorf("Async dispatch (queue %d) order = %v, want %v", queueSize, async, merged)
+		}
+	}
+
+	// Children are finalized before their parents are closed
+	finalized := map[string]int{}
+	for i, c := range sync {
+		if c.done {
+			finalized[c.value.(string)] = i
+		}
+	}
+	if !(finalized["a.b"] < finalized["a"] && finalized["a.c[1]"] < finalized["a"] &&
+		finalized["a"] < finalized["e"] && finalized["e"] < finalized[""]) {
+		t.Errorf("Unexpected finalization order: %v", finalized)
+	}
+}
+
+func TestDispatchAsyncSnapshots(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	sp.SetDispatch(DispatchAsync, 16)
+
+	var values []any
+	sp.Watch("items", func(v any, done bool) {
+		values = append(values, v)
+	})
+
+	for _, chunk := range []string{`{"items":[1,`, `2,`, `3]}`} {
+		if err := sp.ProcessString(chunk); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", chunk, err)
+		}
+	}
+	sp.Close()
+
+	expected := []any{
+		[]interface{}{},
+		[]interface{}{int64(1)},
+		[]interface{}{int64(1), int64(2)},
+		[]interface{}{int64(1), int64(2), int64(3)},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Async values = %v, want %v", values, expected)
+	}
+}
+
+func TestDispatchAsyncMergedUpdates(t *testing.T) {
+	input := benchmarkDocument(20)
+	sp := NewStreamingParser(nil)
+	sp.SetDispatch(DispatchAsync, 0)
+	defer sp.Close()
+
+	var last any
+	sp.Watch("", func(v any, done bool) {
+		last = v
+	})
+
+	for chunk := range slices.Chunk([]byte(input), 7) {
+		if err := sp.ProcessBytes(chunk); err != nil {
+			t.Fatalf("ProcessBytes(%q) error = %v", chunk, err)
+		}
+		sp.Flush()
+		if want := sp.Snapshot(); !reflect.DeepEqual(last, want) {
+			t.Fatalf("After %q, async value = %v, want %v", chunk, last, want)
+		}
+	}
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	input := benchmarkDocument(8000)
+
+	for _, mode := range []DispatchMode{DispatchSync, DispatchAsync} {
+		name := map[DispatchMode]string{DispatchSync: "Sync", DispatchAsync: "Async"}[mode]
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for b.Loop() {
+				sp := NewStreamingParser(nil)
+				sp.SetDispatch(mode, 64)
+				sp.Watch("", func(v any, done bool) {})
+				for chunk := range slices.Chunk([]byte(input), 256) {
+					if err := sp.ProcessBytes(chunk); err != nil {
+						b.Fatal(err)
+					}
+				}
+				sp.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package flexjson
+
+import (
+	"errors"
+	"iter"
+)
+
+// WithDocuments makes a StreamingParser accept a stream of several JSON
+// objects back to back, such as `{"a":1}{"b":2}` or newline-delimited JSON,
+// instead of ignoring the input after the first one. handle is called with
+// each object once it is closed.
+//
+// The output map holds the object being streamed. When the next object
+// starts, a new map is stored in the output, so the maps passed to handle
+// stay as they were; with WithMerge, each object is merged into the same map
+// instead. When a MapSink is set, members go to the sink and handle is passed
+// the empty output map. An object cut off by the end of the stream is left in
+// the output without being passed to handle.
+func WithDocuments(handle func(doc map[string]any)) Option {
+	return func(d *decoder) {
+		d.documents = handle
+	}
+}
+
+// ParseDocuments returns an iterator over the JSON objects in input, which
+// holds several objects back to back, such as newline-delimited JSON. Each
+// object is parsed as Parse does, and the last one may be cut off by the end
+// of the input. Iteration stops after the first error, which is yielded with a
+// nil object. Input with no objects yields nothing. With WithRecovery, the
+// errors recovered from in an object are yielded with it, joined with
+// errors.Join, and iteration carries on.
+func ParseDocuments(input string, opts ...Option) iter.Seq2[map[string]any, error] {
+	return func(yield func(map[string]any, error) bool) {
+		var doc map[string]any
+		d := &decoder{objectsOnly: true}
+		for _, opt := range opts {
+			opt(d)
+		}
+		d.documents = func(root map[string]any) {
+			doc = root
+		}
+
+		// Errors recovered from are yielded with the object they were found in
+		reported := 0
+		issues := func() error {
+			err := joinIssues(d.issues[reported:], input)
+			reported = len(d.issues)
+			return err
+		}
+
+		lexer := NewLexer(input)
+		d.configureLexer(lexer)
+		for tok := range lexer.Tokens() {
+			if tok.Type == TokenEOF {
+				if len(d.stack) > 0 && d.tokenSafely(tok) == nil {
+					// The last object was cut off
+					yield(d.root(), issues())
+				}
+				return
+			}
+
+			if err := d.tokenSafely(tok); err != nil {
+				var perr *ParseError
+				if errors.As(err, &perr) {
+					perr.locate(input)
+				}
+				yield(nil, err)
+				return
+			}
+			if doc != nil {
+				if !yield(doc, issues()) {
+					return
+				}
+				doc = nil
+			}
+		}
+	}
+}
+
+// tokenSafely decodes tok, returning an internal panic as an *InternalError
+func (d *decoder) tokenSafely(tok Token) (err error) {
+	defer recoverInternal(&err, nil)
+	return d.token(tok)
+}
+
+// documentEnd passes the root object to the document handler once it is
+// closed
+func (d *decoder) documentEnd() {
+	if d.documents != nil {
+		d.documents(d.root())
+	}
+}
+
+// nextDocument starts decoding another root value after the last one is
+// complete
+func (d *decoder) nextDocument() {
+	d.reset()
+	if d.output != nil && !d.merge {
+		*d.output = make(map[string]any)
+	}
+}
+
+// root returns the root object, or nil if the root value isn't an object
+func (d *decoder) root() map[string]any {
+	switch root := d.result.(type) {
+	case *map[string]any:
+		return *root
+	case map[string]any:
+		return root
+	}
+	return nil
+}
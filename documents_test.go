@@ -0,0 +1,152 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithDocuments(t *testing.T) {
+	var docs []map[string]any
+	output := map[string]any{}
+	sp := NewStreamingParser(&output, WithDocuments(func(doc map[string]any) {
+		docs = append(docs, doc)
+	}))
+
+	input := "{\"a\":1}{\"b\":[2]}\n{\"c\":{\"d\":true}}\n{\"e\":\"cut"
+	for i := 0; i < len(input); i++ {
+		if err := sp.ProcessString(input[i : i+1]); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", input[i:i+1], err)
+		}
+	}
+
+	expected := []map[string]any{
+		{"a": int64(1)},
+		{"b": []interface{}{int64(2)}},
+		{"c": map[string]any{"d": true}},
+	}
+	if !reflect.DeepEqual(docs, expected) {
+		t.Errorf("Documents = %#v, want %#v", docs, expected)
+	}
+
+	// The object being streamed is in the output
+	if want := (map[string]any{}); !reflect.DeepEqual(output, want) {
+		t.Errorf("Output = %#v, want %#v", output, want)
+	}
+	if err := sp.ProcessString(`"}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if want := (map[string]any{"e": "cut"}); !reflect.DeepEqual(output, want) {
+		t.Errorf("Output = %#v, want %#v", output, want)
+	}
+	if len(docs) != 4 {
+		t.Errorf("Got %d documents, want 4", len(docs))
+	}
+}
+
+func TestWithDocumentsMerge(t *testing.T) {
+	calls := 0
+	output := map[string]any{}
+	sp := NewStreamingParser(&output, WithMerge(true), WithDocuments(func(doc map[string]any) {
+		calls++
+	}))
+	if err := sp.ProcessString(`{"a":{"x":1}} {"a":{"y":2}}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	expected := map[string]any{"a": map[string]any{"x": int64(1), "y": int64(2)}}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("Output = %#v, want %#v", output, expected)
+	}
+	if calls != 2 {
+		t.Errorf("Handler called %d times, want 2", calls)
+	}
+}
+
+func TestStreamingParserIgnoresLaterDocuments(t *testing.T) {
+	output := map[string]any{}
+	sp := NewStreamingParser(&output)
+	if err := sp.ProcessString(`{"a":1}{"b":2}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if want := (map[string]any{"a": int64(1)}); !reflect.DeepEqual(output, want) {
+		t.Errorf("Output = %#v, want %#v", output, want)
+	}
+}
+
+func TestParseDocuments(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []map[string]any
+	}{
+		{
+			name:     "back to back",
+			input:    `{"a":1}{"b":2}`,
+			expected: []map[string]any{{"a": int64(1)}, {"b": int64(2)}},
+		},
+		{
+			name:     "newline delimited",
+			input:    "{\"a\":1}\n{\"b\":2}\n",
+			expected: []map[string]any{{"a": int64(1)}, {"b": int64(2)}},
+		},
+		{
+			name:     "truncated last document",
+			input:    `{"a":1} {"b":[1,2`,
+			expected: []map[string]any{{"a": int64(1)}, {"b": []interface{}{int64(1), int64(2)}}},
+		},
+		{
+			name:     "empty",
+			input:    " \n",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var docs []map[string]any
+			for doc, err := range ParseDocuments(tt.input) {
+				if err != nil {
+					t.Fatalf("ParseDocuments() error = %v", err)
+				}
+				docs = append(docs, doc)
+			}
+			if !reflect.DeepEqual(docs, tt.expected) {
+				t.Errorf("ParseDocuments() = %#v, want %#v", docs, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseDocumentsError(t *testing.T) {
+	var docs []map[string]any
+	var errs []error
+	for doc, err := range ParseDocuments(`{"a":1} [2] {"c":3}`) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		docs = append(docs, doc)
+	}
+
+	if want := []map[string]any{{"a": int64(1)}}; !reflect.DeepEqual(docs, want) {
+		t.Errorf("Documents = %#v, want %#v", docs, want)
+	}
+	var perr *ParseError
+	if len(errs) != 1 || !errors.As(errs[0], &perr) || perr.Code != CodeNotAnObject || perr.Offset != 8 {
+		t.Errorf("Errors = %v, want one CodeNotAnObject error at offset 8", errs)
+	}
+}
+
+func TestParseDocumentsStop(t *testing.T) {
+	count := 0
+	for range ParseDocuments(`{"a":1}{"b":2}{"c":3}`) {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	if count != 2 {
+		t.Errorf("Iterated %d times, want 2", count)
+	}
+}
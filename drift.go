@@ -0,0 +1,150 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// DriftDetector accumulates the types observed at each path across many
+// documents and reports when a path starts carrying a type it has not carried
+// before, such as an "id" that switches from number to string. It is useful
+// for noticing changes in an upstream API. A DriftDetector is safe for
+// concurrent use.
+//
+// Array elements are grouped under a "[*]" path segment, e.g. "items[*].id",
+// so every element of an array contributes to the same statistics. Types are
+// reported as "object", "array", "string", "number", "boolean", or "null".
+type DriftDetector struct {
+	mu        sync.Mutex
+	documents int
+	paths     map[string]map[string]int
+}
+
+// Drift describes a path whose type distribution changed
+type Drift struct {
+	Path     string         // Path of the value, with "[*]" for array elements
+	Type     string         // Newly observed type
+	Previous map[string]int // Number of earlier documents that had each type at Path
+	Document int            // 1-based number of the document the type was first seen in
+}
+
+// NewDriftDetector creates an empty DriftDetector
+func NewDriftDetector() *DriftDetector {
+	return &DriftDetector{paths: make(map[string]map[string]int)}
+}
+
+// Observe records the types in doc and returns a Drift for every path that
+// was present in an earlier document but now holds a type it never held
+// before. Paths seen for the first time are not reported. Drifts are ordered
+// by path and type.
+func (d *DriftDetector) Observe(doc map[string]any) []Drift {
+	seen := make(map[string]map[string]bool)
+	collectTypes(doc, "", seen)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.documents++
+
+	var drifts []Drift
+	for path, types := range seen {
+		counts, known := d.paths[path]
+		if !known {
+			counts = make(map[string]int)
+			d.paths[path] = counts
+		}
+		for typ := range types {
+			if known && counts[typ] == 0 {
+				previous := make(map[string]int, len(counts))
+				for t, n := range counts {
+					previous[t] = n
+				}
+				drifts = append(drifts, Drift{Path: path, Type: typ, Previous: previous, Document: d.documents})
+			}
+		}
+	}
+
+	// Count after comparing so a path with mixed types in one document isn't reported against itself
+	for path, types := range seen {
+		for typ := range types {
+			d.paths[path][typ]++
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Path != drifts[j].Path {
+			return drifts[i].Path < drifts[j].Path
+		}
+		return drifts[i].Type < drifts[j].Type
+	})
+	return drifts
+}
+
+// Stats returns, for each path, the number of documents that had each type at that path
+func (d *DriftDetector) Stats() map[string]map[string]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := make(map[string]map[string]int, len(d.paths))
+	for path, counts := range d.paths {
+		copied := make(map[string]int, len(counts))
+		for t, n := range counts {
+			copied[t] = n
+		}
+		stats[path] = copied
+	}
+	return stats
+}
+
+// Documents returns the number of documents observed
+func (d *DriftDetector) Documents() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.documents
+}
+
+// collectTypes records the type of every value under v, which is at path
+func collectTypes(v any, path string, seen map[string]map[string]bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			child := appendKeyPath(path, k)
+			addType(seen, child, e)
+			collectTypes(e, child, seen)
+		}
+	case []interface{}:
+		child := path + "[*]"
+		for _, e := range v {
+			addType(seen, child, e)
+			collectTypes(e, child, seen)
+		}
+	}
+}
+
+// addType records the type of v at path
+func addType(seen map[string]map[string]bool, path string, v any) {
+	if seen[path] == nil {
+		seen[path] = make(map[string]bool)
+	}
+	seen[path][typeName(v)] = true
+}
+
+// typeName returns the JSON type name of a value from the value model
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case int64, float64, json.Number, *big.Int, *big.Float:
+		return "number"
+	case map[string]any:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "unknown"
+}
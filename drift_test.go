@@ -0,0 +1,67 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDriftDetector(t *testing.T) {
+	d := NewDriftDetector()
+
+	docs := []string{
+		`{"id": 1, "items": [{"n": 1}]}`,
+		`{"id": 2, "items": [{"n": 2}, {"n": null}], "extra": true}`,
+		`{"id": "3", "items": [{"n": 3}]}`,
+		`{"id": "4", "items": []}`,
+	}
+
+	var drifts []Drift
+	for _, doc := range docs {
+		parsed, err := Parse(doc)
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", doc, err)
+		}
+		drifts = append(drifts, d.Observe(parsed)...)
+	}
+
+	expected := []Drift{
+		{Path: "items[*].n", Type: "null", Previous: map[string]int{"number": 1}, Document: 2},
+		{Path: "id", Type: "string", Previous: map[string]int{"number": 2}, Document: 3},
+	}
+	if !reflect.DeepEqual(drifts, expected) {
+		t.Errorf("Observe() drifts = %+v, want %+v", drifts, expected)
+	}
+
+	if d.Documents() != 4 {
+		t.Errorf("Documents() = %d, want 4", d.Documents())
+	}
+
+	stats := d.Stats()
+	if !reflect.DeepEqual(stats["id"], map[string]int{"number": 2, "string": 2}) {
+		t.Errorf("Stats()[id] = %v", stats["id"])
+	}
+	if !reflect.DeepEqual(stats["items"], map[string]int{"array": 4}) {
+		t.Errorf("Stats()[items] = %v", stats["items"])
+	}
+}
+
+func TestDriftDetectorStreamingOutput(t *testing.T) {
+	d := NewDriftDetector()
+
+	sp := NewStreamingParser(nil)
+	if err := sp.ProcessString(`{"tags": ["a"]}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	d.Observe(sp.GetCurrentOutput())
+
+	sp.Reset()
+	if err := sp.ProcessString(`{"tags": [1]}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	drifts := d.Observe(sp.GetCurrentOutput())
+
+	expected := []Drift{{Path: "tags[*]", Type: "number", Previous: map[string]int{"string": 1}, Document: 2}}
+	if !reflect.DeepEqual(drifts, expected) {
+		t.Errorf("Observe() drifts = %+v, want %+v", drifts, expected)
+	}
+}
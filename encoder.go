@@ -0,0 +1,387 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ErrEncoderState is returned by an Encoder method called where the
+// document doesn't allow it, such as WriteKey inside an array
+var ErrEncoderState = errors.New("invalid encoder call")
+
+// Encoder writes a JSON document to an io.Writer a piece at a time: objects
+// and arrays are opened and closed with separate calls, and a string can be
+// written in parts, so a document can be produced as its content becomes
+// available, e.g. to proxy a stream or to feed a StreamingParser in tests.
+// Each call writes its text to the writer at once; wrap the writer in a
+// bufio.Writer to batch small writes.
+//
+// The encoder tracks the structure of the document, adding commas and
+// colons itself, and returns an error wrapping ErrEncoderState for a call
+// that would make the document invalid. Finalize completes the document at
+// any point. After such an error, or an error from the writer, every call
+// returns the same error.
+type Encoder struct {
+	w       io.Writer
+	stack   []encoderFrame // Open objects and arrays, innermost last
+	buf     []byte         // Text of the current call
+	started bool           // Whether the root value has started
+	inStr   bool           // Whether a string started with BeginString is open
+	rune    []byte         // Incomplete UTF-8 sequence held back from a string part
+	err     error
+}
+
+// encoderFrame is an open object or array
+type encoderFrame struct {
+	array bool
+	n     int  // Number of members or elements written
+	key   bool // Whether an object's key has been written without its value
+}
+
+// NewEncoder returns an encoder that writes to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// BeginObject opens an object
+func (e *Encoder) BeginObject() error {
+	return e.open(false)
+}
+
+// EndObject closes the innermost open object
+func (e *Encoder) EndObject() error {
+	return e.close(false)
+}
+
+// BeginArray opens an array
+func (e *Encoder) BeginArray() error {
+	return e.open(true)
+}
+
+// EndArray closes the innermost open array
+func (e *Encoder) EndArray() error {
+	return e.close(true)
+}
+
+// WriteKey writes the key of the next member of the innermost open object
+func (e *Encoder) WriteKey(key string) error {
+	if e.err != nil {
+		return e.err
+	}
+	f := e.top()
+	if f == nil || f.array || f.key || e.inStr {
+		return e.fail("WriteKey called where a key isn't expected")
+	}
+	if f.n > 0 {
+		e.buf = append(e.buf, ',')
+	}
+	e.buf = appendQuoted(e.buf, key)
+	e.buf = append(e.buf, ':')
+	f.key = true
+	return e.flush()
+}
+
+// WriteString writes a string value
+func (e *Encoder) WriteString(s string) error {
+	if err := e.value("WriteString"); err != nil {
+		return err
+	}
+	e.buf = appendQuoted(e.buf, s)
+	return e.flush()
+}
+
+// BeginString starts a string value whose text is written with
+// WriteStringPart, for text that arrives in pieces. A UTF-8 sequence split
+// between parts is held back until the rest of it arrives.
+func (e *Encoder) BeginString() error {
+	if err := e.value("BeginString"); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, '"')
+	e.inStr = true
+	return e.flush()
+}
+
+// WriteStringPart writes the next part of the string started with BeginString
+func (e *Encoder) WriteStringPart(s string) error {
+	if e.err != nil {
+		return e.err
+	}
+	if !e.inStr {
+		return e.fail("WriteStringPart called outside a string")
+	}
+	if len(e.rune) > 0 {
+		s = string(e.rune) + s
+		e.rune = e.rune[:0]
+	}
+	if n := incompleteRuneSuffix(s); n > 0 {
+		e.rune = append(e.rune, s[len(s)-n:]...)
+		s = s[:len(s)-n]
+	}
+	e.buf = appendEscaped(e.buf, s)
+	return e.flush()
+}
+
+// EndString ends the string started with BeginString
+func (e *Encoder) EndString() error {
+	if e.err != nil {
+		return e.err
+	}
+	if !e.inStr {
+		return e.fail("EndString called outside a string")
+	}
+	e.endString()
+	return e.flush()
+}
+
+// WriteInt writes an integer value
+func (e *Encoder) WriteInt(n int64) error {
+	if err := e.value("WriteInt"); err != nil {
+		return err
+	}
+	e.buf = strconv.AppendInt(e.buf, n, 10)
+	return e.flush()
+}
+
+// WriteFloat writes a number value. NaN and infinities return a
+// *json.UnsupportedValueError, as JSON has no numbers for them.
+func (e *Encoder) WriteFloat(f float64) error {
+	if e.err != nil {
+		return e.err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return &json.UnsupportedValueError{Value: reflect.ValueOf(f), Str: strconv.FormatFloat(f, 'g', -1, 64)}
+	}
+	if err := e.value("WriteFloat"); err != nil {
+		return err
+	}
+	e.buf = strconv.AppendFloat(e.buf, f, 'g', -1, 64)
+	return e.flush()
+}
+
+// WriteBool writes true or false
+func (e *Encoder) WriteBool(b bool) error {
+	if err := e.value("WriteBool"); err != nil {
+		return err
+	}
+	e.buf = strconv.AppendBool(e.buf, b)
+	return e.flush()
+}
+
+// WriteNull writes null
+func (e *Encoder) WriteNull() error {
+	if err := e.value("WriteNull"); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, "null"...)
+	return e.flush()
+}
+
+// WriteValue writes v as encoding/json marshals it, on a single line
+func (e *Encoder) WriteValue(v any) error {
+	if e.err != nil {
+		return e.err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := e.value("WriteValue"); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, data...)
+	return e.flush()
+}
+
+// Finalize completes the document: it ends an open string, writes null for
+// a key without a value, and closes the open arrays and objects, innermost
+// first. A document that hasn't started is left empty. Nothing more can be
+// written after it.
+func (e *Encoder) Finalize() error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.inStr {
+		e.endString()
+	}
+	for i := len(e.stack) - 1; i >= 0; i-- {
+		switch f := e.stack[i]; {
+		case f.array:
+			e.buf = append(e.buf, ']')
+		case f.key:
+			e.buf = append(e.buf, "null}"...)
+		default:
+			e.buf = append(e.buf, '}')
+		}
+	}
+	e.stack = e.stack[:0]
+	e.started = true
+	return e.flush()
+}
+
+// Depth returns the number of objects and arrays that are open
+func (e *Encoder) Depth() int {
+	return len(e.stack)
+}
+
+// open opens an object or array
+func (e *Encoder) open(array bool) error {
+	name := "BeginObject"
+	if array {
+		name = "BeginArray"
+	}
+	if err := e.value(name); err != nil {
+		return err
+	}
+	if array {
+		e.buf = append(e.buf, '[')
+	} else {
+		e.buf = append(e.buf, '{')
+	}
+	e.stack = append(e.stack, encoderFrame{array: array})
+	return e.flush()
+}
+
+// close closes the innermost object or array
+func (e *Encoder) close(array bool) error {
+	if e.err != nil {
+		return e.err
+	}
+	f := e.top()
+	switch {
+	case f == nil || f.array != array || e.inStr:
+		if array {
+			return e.fail("EndArray called outside an array")
+		}
+		return e.fail("EndObject called outside an object")
+	case f.key:
+		return e.fail("EndObject called after a key without a value")
+	}
+	if array {
+		e.buf = append(e.buf, ']')
+	} else {
+		e.buf = append(e.buf, '}')
+	}
+	e.stack = e.stack[:len(e.stack)-1]
+	return e.flush()
+}
+
+// value prepares for a value written by the method called name, writing
+// the comma before an array element
+func (e *Encoder) value(name string) error {
+	if e.err != nil {
+		return e.err
+	}
+	f := e.top()
+	switch {
+	case e.inStr:
+		return e.fail(name + " called inside a string")
+	case f == nil && e.started:
+		return e.fail(name + " called after the root value")
+	case f == nil:
+		e.started = true
+	case f.array:
+		if f.n > 0 {
+			e.buf = append(e.buf, ',')
+		}
+		f.n++
+	case !f.key:
+		return e.fail(name + " called where a key is expected")
+	default:
+		f.key = false
+		f.n++
+	}
+	return nil
+}
+
+// endString writes the closing quote of an open string. An incomplete
+// UTF-8 sequence held back is written as U+FFFD, as for any invalid UTF-8.
+func (e *Encoder) endString() {
+	if len(e.rune) > 0 {
+		e.buf = append(e.buf, "\ufffd"...)
+		e.rune = e.rune[:0]
+	}
+	e.buf = append(e.buf, '"')
+	e.inStr = false
+}
+
+// top returns the innermost open object or array, or nil
+func (e *Encoder) top() *encoderFrame {
+	if len(e.stack) == 0 {
+		return nil
+	}
+	return &e.stack[len(e.stack)-1]
+}
+
+// flush writes the text of the current call
+func (e *Encoder) flush() error {
+	if len(e.buf) == 0 {
+		return nil
+	}
+	_, err := e.w.Write(e.buf)
+	e.buf = e.buf[:0]
+	if err != nil {
+		e.err = err
+	}
+	return err
+}
+
+// fail records an error for a call the document doesn't allow
+func (e *Encoder) fail(msg string) error {
+	e.buf = e.buf[:0]
+	e.err = fmt.Errorf("%w: %s", ErrEncoderState, msg)
+	return e.err
+}
+
+// appendQuoted appends s to b as a JSON string
+func appendQuoted(b []byte, s string) []byte {
+	b = append(b, '"')
+	b = appendEscaped(b, s)
+	return append(b, '"')
+}
+
+// appendEscaped appends the text of s to b, escaped for a JSON string.
+// Invalid UTF-8 is written as U+FFFD, and U+2028 and U+2029 are escaped so
+// the text can be embedded in JavaScript, as encoding/json does.
+func appendEscaped(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				b = append(b, '\\', c)
+			case c == '\n':
+				b = append(b, '\\', 'n')
+			case c == '\r':
+				b = append(b, '\\', 'r')
+			case c == '\t':
+				b = append(b, '\\', 't')
+			case c < 0x20:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			default:
+				b = append(b, c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b = append(b, "\ufffd"...)
+		case r == '\u2028' || r == '\u2029':
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xF])
+		default:
+			b = append(b, s[i:i+size]...)
+		}
+		i += size
+	}
+	return b
+}
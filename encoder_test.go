@@ -0,0 +1,182 @@
+package flexjson
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(e *Encoder) error
+		want  string
+	}{
+		{
+			name: "document",
+			write: func(e *Encoder) error {
+				e.BeginObject()
+				e.WriteKey("name")
+				e.WriteString(`a "quoted" <tag>`)
+				e.WriteKey("tags")
+				e.BeginArray()
+				e.WriteInt(1)
+				e.WriteFloat(2.5)
+				e.WriteBool(true)
+				e.WriteNull()
+				e.BeginObject()
+				e.EndObject()
+				e.EndArray()
+				e.WriteKey("raw")
+				e.WriteValue(map[string]int{"b": 2, "a": 1})
+				return e.EndObject()
+			},
+			want: `{"name":"a \"quoted\" <tag>","tags":[1,2.5,true,null,{}],"raw":{"a":1,"b":2}}`,
+		},
+		{
+			name: "string in parts",
+			write: func(e *Encoder) error {
+				e.BeginArray()
+				e.BeginString()
+				e.WriteStringPart("line\n")
+				e.WriteStringPart("caf\xc3")
+				e.WriteStringPart("\xa9 \x01")
+				e.EndString()
+				e.WriteString("")
+				return e.EndArray()
+			},
+			want: "[\"line\\ncaf\u00e9 \\u0001\",\"\"]",
+		},
+		{
+			name: "finalize",
+			write: func(e *Encoder) error {
+				e.BeginObject()
+				e.WriteKey("a")
+				e.BeginArray()
+				e.BeginObject()
+				e.WriteKey("b")
+				e.BeginString()
+				e.WriteStringPart("hel")
+				return e.Finalize()
+			},
+			want: `{"a":[{"b":"hel"}]}`,
+		},
+		{
+			name: "finalize after a key",
+			write: func(e *Encoder) error {
+				e.BeginObject()
+				e.WriteKey("a")
+				return e.Finalize()
+			},
+			want: `{"a":null}`,
+		},
+		{
+			name: "root scalar",
+			write: func(e *Encoder) error {
+				return e.WriteInt(-7)
+			},
+			want: `-7`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			e := NewEncoder(&b)
+			if err := tt.write(e); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("wrote %q, want %q", b.String(), tt.want)
+			}
+			if e.Depth() != 0 {
+				t.Errorf("Depth() = %d, want 0", e.Depth())
+			}
+		})
+	}
+}
+
+func TestEncoderErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(e *Encoder) error
+		want  string // Text written before the error
+	}{
+		{"key in an array", func(e *Encoder) error { e.BeginArray(); return e.WriteKey("a") }, `[`},
+		{"value without a key", func(e *Encoder) error { e.BeginObject(); return e.WriteInt(1) }, `{`},
+		{"two keys", func(e *Encoder) error { e.BeginObject(); e.WriteKey("a"); return e.WriteKey("b") }, `{"a":`},
+		{"mismatched close", func(e *Encoder) error { e.BeginObject(); return e.EndArray() }, `{`},
+		{"close after a key", func(e *Encoder) error { e.BeginObject(); e.WriteKey("a"); return e.EndObject() }, `{"a":`},
+		{"second root", func(e *Encoder) error { e.WriteNull(); return e.WriteNull() }, `null`},
+		{"value in a string", func(e *Encoder) error { e.BeginString(); return e.WriteBool(false) }, `"`},
+		{"part outside a string", func(e *Encoder) error { return e.WriteStringPart("a") }, ``},
+		{"after finalize", func(e *Encoder) error { e.Finalize(); return e.BeginObject() }, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			e := NewEncoder(&b)
+			err := tt.write(e)
+			if !errors.Is(err, ErrEncoderState) {
+				t.Fatalf("error = %v, want ErrEncoderState", err)
+			}
+			if got := e.Finalize(); got != err {
+				t.Errorf("Finalize() after an error = %v, want %v", got, err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("wrote %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoderUnsupportedFloat(t *testing.T) {
+	var b strings.Builder
+	e := NewEncoder(&b)
+	e.BeginArray()
+	if err := e.WriteFloat(math.NaN()); err == nil {
+		t.Fatal("WriteFloat(NaN) returned no error")
+	}
+	e.WriteFloat(1)
+	e.Finalize()
+	if b.String() != `[1]` {
+		t.Errorf("wrote %q, want %q", b.String(), `[1]`)
+	}
+}
+
+func TestEncoderStreamsToParser(t *testing.T) {
+	var b strings.Builder
+	e := NewEncoder(&b)
+	sp := NewStreamingParser(nil)
+	written := 0
+	feed := func() {
+		if err := sp.ProcessString(b.String()[written:]); err != nil {
+			t.Fatalf("ProcessString() error = %v", err)
+		}
+		written = b.Len()
+	}
+
+	e.BeginObject()
+	e.WriteKey("items")
+	e.BeginArray()
+	for i := range 3 {
+		e.WriteInt(int64(i))
+		feed()
+	}
+	e.EndArray()
+	e.WriteKey("text")
+	e.BeginString()
+	e.WriteStringPart("hello ")
+	feed()
+	e.WriteStringPart("world")
+	e.Finalize()
+	feed()
+
+	want := map[string]any{"items": []interface{}{int64(0), int64(1), int64(2)}, "text": "hello world"}
+	if !sp.IsComplete() || !reflect.DeepEqual(sp.GetCurrentOutput(), want) {
+		t.Errorf("output = %v (complete %v), want %v", sp.GetCurrentOutput(), sp.IsComplete(), want)
+	}
+}
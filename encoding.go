@@ -0,0 +1,129 @@
+package flexjson
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// utf8BOM is the UTF-8 encoding of U+FEFF, which some tools write at the
+// start of a file
+const utf8BOM = "\xEF\xBB\xBF"
+
+// byteOrderMarks are the byte order marks of the encodings flexjson doesn't
+// read directly, longest first
+var byteOrderMarks = []struct {
+	mark     string
+	encoding string
+}{
+	{"\x00\x00\xFE\xFF", "UTF-32BE"},
+	{"\xFF\xFE\x00\x00", "UTF-32LE"},
+	{"\xFE\xFF", "UTF-16BE"},
+	{"\xFF\xFE", "UTF-16LE"},
+}
+
+// byteOrderMark returns the UTF-16 or UTF-32 byte order mark that s starts
+// with, and the name of its encoding
+func byteOrderMark(s string) (mark, encoding string) {
+	for _, bom := range byteOrderMarks {
+		if strings.HasPrefix(s, bom.mark) {
+			return bom.mark, bom.encoding
+		}
+	}
+	return "", ""
+}
+
+// skipBOM skips a UTF-8 byte order mark at the start of the input. It
+// returns false if the input so far may be the start of one.
+func (l *Lexer) skipBOM() bool {
+	rest := l.text(l.pos, l.end())
+	switch {
+	case strings.HasPrefix(rest, utf8BOM):
+		// The mark isn't counted in the column of the first token
+		l.pos += len(utf8BOM)
+		l.located = l.pos
+	case rest != "" && strings.HasPrefix(utf8BOM, rest):
+		return l.final
+	}
+	return true
+}
+
+// encodingError builds the ParseError for input that starts with a UTF-16
+// or UTF-32 byte order mark
+func encodingError(tok Token, encoding string) *ParseError {
+	return &ParseError{
+		Code:     CodeUnsupportedEncoding,
+		Offset:   tok.Start,
+		Line:     tok.Line,
+		Column:   tok.Column,
+		Got:      encoding + " byte order mark",
+		Expected: "UTF-8 (convert the input with ToUTF8)",
+	}
+}
+
+// ToUTF8 returns data converted to UTF-8 if it is UTF-16 or UTF-32 text,
+// such as a file exported by Windows tools, which flexjson doesn't read
+// directly. The encoding is detected from a byte order mark, or without one
+// from the zero bytes around the first character, as RFC 4627 describes,
+// since JSON text starts with an ASCII character. A byte order mark is
+// dropped, including a UTF-8 one. An incomplete character at the end, as in
+// a cut-off payload, is dropped too, and invalid characters become U+FFFD.
+// Other data is returned as it is.
+func ToUTF8(data []byte) []byte {
+	s := bytesString(data)
+	if strings.HasPrefix(s, utf8BOM) {
+		return data[len(utf8BOM):]
+	}
+
+	mark, encoding := byteOrderMark(s)
+	if mark == "" {
+		encoding = detectEncoding(data)
+	}
+	data = data[len(mark):]
+
+	switch encoding {
+	case "UTF-16BE", "UTF-16LE":
+		var order binary.ByteOrder = binary.BigEndian
+		if encoding == "UTF-16LE" {
+			order = binary.LittleEndian
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[2*i:])
+		}
+		out := make([]byte, 0, len(data))
+		for _, r := range utf16.Decode(units) {
+			out = utf8.AppendRune(out, r)
+		}
+		return out
+	case "UTF-32BE", "UTF-32LE":
+		var order binary.ByteOrder = binary.BigEndian
+		if encoding == "UTF-32LE" {
+			order = binary.LittleEndian
+		}
+		out := make([]byte, 0, len(data))
+		for i := 0; i+4 <= len(data); i += 4 {
+			// AppendRune writes U+FFFD for values that aren't characters
+			out = utf8.AppendRune(out, rune(order.Uint32(data[i:])))
+		}
+		return out
+	}
+	return data
+}
+
+// detectEncoding returns the encoding of JSON text without a byte order
+// mark from the pattern of zero bytes at its start, or "" for UTF-8
+func detectEncoding(data []byte) string {
+	switch {
+	case len(data) >= 4 && data[0] == 0 && data[1] == 0 && data[2] == 0 && data[3] != 0:
+		return "UTF-32BE"
+	case len(data) >= 4 && data[0] != 0 && data[1] == 0 && data[2] == 0 && data[3] == 0:
+		return "UTF-32LE"
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0:
+		return "UTF-16BE"
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		return "UTF-16LE"
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestByteOrderMark(t *testing.T) {
+	want := map[string]any{"a": int64(1)}
+
+	t.Run("UTF-8 skipped", func(t *testing.T) {
+		for _, input := range []string{utf8BOM + `{"a": 1}`, utf8BOM + ` {"a": 1`} {
+			result, err := Parse(input)
+			if err != nil && !errors.Is(err, ErrPartial) {
+				t.Fatalf("Parse(%q) error = %v", input, err)
+			}
+			if !reflect.DeepEqual(result, want) {
+				t.Errorf("Parse(%q) = %v, want %v", input, result, want)
+			}
+		}
+	})
+
+	t.Run("UTF-8 split across chunks", func(t *testing.T) {
+		output := map[string]any{}
+		sp := NewStreamingParser(&output)
+		for _, chunk := range []string{"\xEF", "\xBB", "\xBF{", `"a": 1}`} {
+			if err := sp.ProcessString(chunk); err != nil {
+				t.Fatalf("ProcessString(%q) error = %v", chunk, err)
+			}
+		}
+		if !reflect.DeepEqual(output, want) || !sp.IsComplete() {
+			t.Errorf("output = %v, complete %v; want %v", output, sp.IsComplete(), want)
+		}
+	})
+
+	t.Run("UTF-8 position", func(t *testing.T) {
+		tokens := NewLexer(utf8BOM + `{"a"}`).Tokenize()
+		if tok := tokens[1]; tok.Start != 4 || tok.Column != 2 {
+			t.Errorf("token %q at offset %d, column %d; want 4, 2", tok.Value, tok.Start, tok.Column)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		input    string
+		encoding string
+	}{
+		{"UTF-16LE", "\xFF\xFE{\x00}\x00", "UTF-16LE"},
+		{"UTF-16BE", "\xFE\xFF\x00{\x00}", "UTF-16BE"},
+		{"UTF-32LE", "\xFF\xFE\x00\x00{\x00\x00\x00", "UTF-32LE"},
+		{"UTF-32BE", "\x00\x00\xFE\xFF\x00\x00\x00{", "UTF-32BE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := func(name string, err error) {
+				var perr *ParseError
+				if !errors.As(err, &perr) || perr.Code != CodeUnsupportedEncoding {
+					t.Fatalf("%s error = %v, want %s", name, err, CodeUnsupportedEncoding)
+				}
+				if perr.Got != tt.encoding+" byte order mark" || perr.Offset != 0 {
+					t.Errorf("%s error = %v", name, err)
+				}
+				if !errors.Is(err, ErrNotUTF8) || !errors.Is(err, ErrInvalid) {
+					t.Errorf("%s error = %v, want ErrNotUTF8 and ErrInvalid", name, err)
+				}
+			}
+
+			_, err := Parse(tt.input, WithBestEffort())
+			check("Parse()", err)
+
+			output := map[string]any{}
+			err = NewStreamingParser(&output).ProcessString(tt.input)
+			check("ProcessString()", err)
+		})
+	}
+}
+
+func TestToUTF8(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"UTF-8", `{"a": "é"}`, `{"a": "é"}`},
+		{"UTF-8 BOM", utf8BOM + `{}`, `{}`},
+		{"UTF-16LE BOM", "\xFF\xFE{\x00\"\x00\xE9\x00\"\x00}\x00", `{"é"}`},
+		{"UTF-16BE BOM", "\xFE\xFF\x00{\x00}", `{}`},
+		{"UTF-16LE", "{\x00}\x00", `{}`},
+		{"UTF-16BE", "\x00{\x00}", `{}`},
+		{"UTF-16 surrogate pair", "\xFF\xFE\x3D\xD8\x00\xDE", "\U0001F600"},
+		{"UTF-16 cut off", "\xFF\xFE{\x00\"", `{`},
+		{"UTF-32LE BOM", "\xFF\xFE\x00\x00{\x00\x00\x00}\x00\x00\x00", `{}`},
+		{"UTF-32BE BOM", "\x00\x00\xFE\xFF\x00\x00\x00{", `{`},
+		{"UTF-32LE", "{\x00\x00\x00}\x00\x00\x00", `{}`},
+		{"UTF-32BE", "\x00\x00\x00{\x00\x00\x00}", `{}`},
+		{"UTF-32 invalid", "\x00\x00\x00{\x00\x11\x00\x00", "{�"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := string(ToUTF8([]byte(tt.input)))
+			if result != tt.expected {
+				t.Errorf("ToUTF8(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("ParseBytes", func(t *testing.T) {
+		input := []byte("\xFF\xFE{\x00\"\x00a\x00\"\x00:\x001\x00}\x00")
+		result, err := ParseBytes(input)
+		want := map[string]any{"a": int64(1)}
+		if err != nil || !reflect.DeepEqual(result, want) {
+			t.Errorf("ParseBytes() = %v, %v; want %v", result, err, want)
+		}
+
+		var v struct{ A int }
+		if err := Unmarshal(input, &v); err != nil || v.A != 1 {
+			t.Errorf("Unmarshal() = %+v, %v", v, err)
+		}
+	})
+}
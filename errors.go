@@ -0,0 +1,254 @@
+package flexjson
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// snippetSize is the number of bytes of context captured on each side of an error
+const snippetSize = 20
+
+// Sentinel errors for each category of parse error. Every ParseError unwraps to
+// one of these, so callers can branch with errors.Is.
+var (
+	ErrUnexpectedToken = errors.New("unexpected token")
+	ErrUnexpectedEOF   = errors.New("unexpected end of JSON")
+	ErrInvalidNumber   = errors.New("invalid number")
+	ErrNotAnObject     = errors.New("input is not a JSON object")
+	ErrValueTooLong    = errors.New("value exceeds the size limit")
+	ErrOutputTooLarge  = errors.New("output exceeds the memory budget")
+	ErrTooManyValues   = errors.New("container exceeds the size limit")
+	ErrShapeMismatch   = errors.New("document doesn't have the expected shape")
+	ErrNotUTF8         = errors.New("input is not UTF-8")
+)
+
+// Sentinel errors that sort parse results by how far they got, for
+// WithBestEffort. A ParseError for input that ended before a value started
+// matches ErrPartial, and one for any other input that isn't valid matches
+// ErrInvalid; errors for size limits and the memory budget match neither.
+var (
+	ErrPartial = errors.New("input is incomplete")
+	ErrInvalid = errors.New("input is invalid")
+)
+
+// ErrorCode is a stable identifier for a class of parse error. Codes never
+// change meaning between releases, so they are safe to match on or to key
+// translated messages by.
+type ErrorCode string
+
+const (
+	CodeUnexpectedCharacter     ErrorCode = "FJ1001" // A character that can't start or continue a value
+	CodeUnexpectedColon         ErrorCode = "FJ1002" // A ':' that doesn't follow an object key
+	CodeUnexpectedToken         ErrorCode = "FJ1003" // A token that can't start a value
+	CodeUnexpectedEOF           ErrorCode = "FJ1004" // Input ended where a value was required
+	CodeExpectedKey             ErrorCode = "FJ1005" // An object member that doesn't start with a string key
+	CodeExpectedColon           ErrorCode = "FJ1006" // An object key not followed by ':'
+	CodeExpectedObjectDelimiter ErrorCode = "FJ1007" // An object value not followed by ',' or '}'
+	CodeExpectedArrayDelimiter  ErrorCode = "FJ1008" // An array value not followed by ',' or ']'
+	CodeInvalidNumber           ErrorCode = "FJ1009" // A number token that can't be converted
+	CodeNotAnObject             ErrorCode = "FJ1010" // A top-level value that isn't an object
+	CodeValueTooLong            ErrorCode = "FJ1011" // A string or number longer than its size limit
+	CodeOutputTooLarge          ErrorCode = "FJ1012" // A value that would take the output over its memory budget
+	CodeTooManyValues           ErrorCode = "FJ1013" // An object key or array element past the container's limit
+	CodeShapeMismatch           ErrorCode = "FJ1014" // A value that doesn't have the shape given with WithShape
+	CodeUnsupportedEncoding     ErrorCode = "FJ1015" // Input that starts with a UTF-16 or UTF-32 byte order mark
+)
+
+// ParseError describes where and why parsing failed
+type ParseError struct {
+	Code     ErrorCode // Stable identifier for the kind of error
+	Offset   int       // Byte offset of the offending input
+	Line     int       // 1-based line number (0 if unknown)
+	Column   int       // 1-based column, counted in characters (0 if unknown)
+	Got      string    // The offending input, or "" for the end of input
+	Expected string    // What the parser expected instead, if known
+	Snippet  string    // Input surrounding the error (for streams, the input leading up to it)
+}
+
+// Error implements the error interface, rendering the message with the
+// catalog set by SetMessageCatalog
+func (e *ParseError) Error() string {
+	return currentCatalog().Message(e)
+}
+
+// Unwrap returns the sentinel error for the error's category
+func (e *ParseError) Unwrap() error {
+	switch e.Code {
+	case CodeUnexpectedEOF:
+		return ErrUnexpectedEOF
+	case CodeInvalidNumber:
+		return ErrInvalidNumber
+	case CodeNotAnObject:
+		return ErrNotAnObject
+	case CodeValueTooLong:
+		return ErrValueTooLong
+	case CodeOutputTooLarge:
+		return ErrOutputTooLarge
+	case CodeTooManyValues:
+		return ErrTooManyValues
+	case CodeShapeMismatch:
+		return ErrShapeMismatch
+	case CodeUnsupportedEncoding:
+		return ErrNotUTF8
+	default:
+		return ErrUnexpectedToken
+	}
+}
+
+// Is reports whether the error matches ErrPartial or ErrInvalid, beside the
+// sentinel for its category returned by Unwrap
+func (e *ParseError) Is(target error) bool {
+	switch e.Code {
+	case CodeValueTooLong, CodeOutputTooLarge, CodeTooManyValues:
+		return false
+	case CodeUnexpectedEOF:
+		return target == ErrPartial
+	}
+	return target == ErrInvalid
+}
+
+// MessageCatalog renders the message for a ParseError, allowing products that
+// embed flexjson to present translated diagnostics
+type MessageCatalog interface {
+	Message(err *ParseError) string
+}
+
+// DefaultCatalog renders English messages
+var DefaultCatalog MessageCatalog = defaultCatalog{}
+
+// catalogHolder lets catalogs of different concrete types share an atomic pointer
+type catalogHolder struct {
+	catalog MessageCatalog
+}
+
+var activeCatalog atomic.Pointer[catalogHolder]
+
+// SetMessageCatalog replaces the catalog used to render ParseError messages.
+// Passing nil restores DefaultCatalog. It is safe to call concurrently with parsing.
+func SetMessageCatalog(catalog MessageCatalog) {
+	if catalog == nil {
+		activeCatalog.Store(nil)
+		return
+	}
+	activeCatalog.Store(&catalogHolder{catalog: catalog})
+}
+
+// currentCatalog returns the catalog in use
+func currentCatalog() MessageCatalog {
+	if h := activeCatalog.Load(); h != nil {
+		return h.catalog
+	}
+	return DefaultCatalog
+}
+
+// TemplateCatalog is a MessageCatalog built from message templates keyed by
+// error code. Templates may reference {code}, {got}, {expected}, {line},
+// {column}, and {offset}. Codes missing from the map fall back to DefaultCatalog.
+type TemplateCatalog map[ErrorCode]string
+
+// Message implements MessageCatalog
+func (c TemplateCatalog) Message(err *ParseError) string {
+	tmpl, ok := c[err.Code]
+	if !ok {
+		return DefaultCatalog.Message(err)
+	}
+
+	return strings.NewReplacer(
+		"{code}", string(err.Code),
+		"{got}", err.Got,
+		"{expected}", err.Expected,
+		"{line}", strconv.Itoa(err.Line),
+		"{column}", strconv.Itoa(err.Column),
+		"{offset}", strconv.Itoa(err.Offset),
+	).Replace(tmpl)
+}
+
+// defaultCatalog renders English messages for every code
+type defaultCatalog struct{}
+
+// Message implements MessageCatalog
+func (defaultCatalog) Message(e *ParseError) string {
+	got := "end of input"
+	if e.Got != "" {
+		got = "'" + e.Got + "'"
+	}
+
+	var msg string
+	if e.Line > 0 {
+		msg = fmt.Sprintf("unexpected %s at line %d, column %d", got, e.Line, e.Column)
+	} else {
+		msg = fmt.Sprintf("unexpected %s at offset %d", got, e.Offset)
+	}
+
+	if e.Expected != "" {
+		msg += ": expected " + e.Expected
+	}
+	return msg
+}
+
+// locate fills in the line, column, and snippet of the error from the full input
+func (e *ParseError) locate(input string) {
+	offset := e.Offset
+	if offset > len(input) {
+		offset = len(input)
+	}
+
+	e.Line, e.Column = position(input, offset)
+
+	start := offset - snippetSize
+	if start < 0 {
+		start = 0
+	}
+	for start > 0 && !utf8.RuneStart(input[start]) {
+		start--
+	}
+
+	end := offset + snippetSize
+	if end > len(input) {
+		end = len(input)
+	}
+	for end < len(input) && !utf8.RuneStart(input[end]) {
+		end++
+	}
+
+	e.Snippet = input[start:end]
+}
+
+// InternalError reports a panic inside flexjson that was recovered instead of
+// crashing the caller. It indicates a bug in flexjson (or in a marshaler it
+// called) rather than bad input.
+type InternalError struct {
+	Panic any    // The recovered panic value
+	State string // Dump of the parser state when the panic occurred
+	Stack []byte // Stack trace of the panic
+}
+
+// Error implements the error interface
+func (e *InternalError) Error() string {
+	return fmt.Sprintf("flexjson internal error: %v", e.Panic)
+}
+
+// Unwrap returns the panic value if it was an error
+func (e *InternalError) Unwrap() error {
+	if err, ok := e.Panic.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// recoverInternal converts a panic into an InternalError stored in err.
+// It must be deferred directly so recover can intercept the panic.
+func recoverInternal(err *error, state func() string) {
+	if r := recover(); r != nil {
+		ierr := &InternalError{Panic: r, Stack: debug.Stack()}
+		if state != nil {
+			ierr.State = state()
+		}
+		*err = ierr
+	}
+}
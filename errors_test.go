@@ -0,0 +1,256 @@
+package flexjson
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseErrorPositions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected ParseError
+	}{
+		{
+			name:  "Missing colon",
+			input: `{"key" 123}`,
+			expected: ParseError{
+				Code:     CodeExpectedColon,
+				Offset:   7,
+				Line:     1,
+				Column:   8,
+				Got:      "123",
+				Expected: "':' after key",
+				Snippet:  `{"key" 123}`,
+			},
+		},
+		{
+			name:  "Non-string key on a later line",
+			input: "{\n  \"a\": 1,\n  true: 2\n}",
+			expected: ParseError{
+				Code:     CodeExpectedKey,
+				Offset:   14,
+				Line:     3,
+				Column:   3,
+				Got:      "true",
+				Expected: "string key",
+				Snippet:  "{\n  \"a\": 1,\n  true: 2\n}",
+			},
+		},
+		{
+			name:  "Empty input",
+			input: ``,
+			expected: ParseError{
+				Code:     CodeUnexpectedEOF,
+				Offset:   0,
+				Line:     1,
+				Column:   1,
+				Got:      "",
+				Expected: "value",
+				Snippet:  "",
+			},
+		},
+		{
+			name:  "Not an object",
+			input: `[1, 2]`,
+			expected: ParseError{
+				Code:     CodeNotAnObject,
+				Offset:   0,
+				Line:     1,
+				Column:   1,
+				Got:      "[",
+				Expected: "object",
+				Snippet:  `[1, 2]`,
+			},
+		},
+		{
+			name:  "Column counts characters",
+			input: `{"é": "ü" "x"}`,
+			expected: ParseError{
+				Code:     CodeExpectedObjectDelimiter,
+				Offset:   12,
+				Line:     1,
+				Column:   11,
+				Got:      `"x"`,
+				Expected: "',' or '}' after object value",
+				Snippet:  `{"é": "ü" "x"}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse() error = %v, want *ParseError", err)
+			}
+
+			if *perr != tt.expected {
+				t.Errorf("Parse() error = %#v, want %#v", *perr, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStreamingParserErrorPositions(t *testing.T) {
+	sp := NewStreamingParser(nil)
+
+	err := sp.ProcessString("{\"name\": \"John\",\n \"age\"::")
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("ProcessString() error = %v, want *ParseError", err)
+	}
+
+	expected := ParseError{
+		Code:     CodeUnexpectedColon,
+		Offset:   24,
+		Line:     2,
+		Column:   8,
+		Got:      ":",
+		Expected: "value",
+		Snippet:  "{\"name\": \"John\",\n \"age\"::",
+	}
+	if *perr != expected {
+		t.Errorf("ProcessString() error = %#v, want %#v", *perr, expected)
+	}
+
+	if perr.Error() != "unexpected ':' at line 2, column 8: expected value" {
+		t.Errorf("Unexpected error message: %s", perr.Error())
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	err := &ParseError{Offset: 3, Expected: "value"}
+	if err.Error() != "unexpected end of input at offset 3: expected value" {
+		t.Errorf("Unexpected error message: %s", err.Error())
+	}
+}
+
+func TestMessageCatalog(t *testing.T) {
+	defer SetMessageCatalog(nil)
+
+	err := &ParseError{
+		Code:     CodeUnexpectedColon,
+		Offset:   4,
+		Line:     1,
+		Column:   5,
+		Got:      ":",
+		Expected: "value",
+	}
+
+	SetMessageCatalog(TemplateCatalog{
+		CodeUnexpectedColon: "{code}: ':' inattendu à la ligne {line}, colonne {column}",
+	})
+	if err.Error() != "FJ1002: ':' inattendu à la ligne 1, colonne 5" {
+		t.Errorf("Unexpected translated message: %s", err.Error())
+	}
+
+	// Codes missing from a template catalog fall back to the default messages
+	other := &ParseError{Code: CodeExpectedKey, Offset: 1, Line: 1, Column: 2, Got: "1", Expected: "string key"}
+	if other.Error() != "unexpected '1' at line 1, column 2: expected string key" {
+		t.Errorf("Unexpected fallback message: %s", other.Error())
+	}
+
+	SetMessageCatalog(nil)
+	if err.Error() != "unexpected ':' at line 1, column 5: expected value" {
+		t.Errorf("Unexpected default message: %s", err.Error())
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		sentinel error
+	}{
+		{name: "Unexpected token", input: `{"a" 1}`, sentinel: ErrUnexpectedToken},
+		{name: "Non-string key", input: `{1: 2}`, sentinel: ErrUnexpectedToken},
+		{name: "Unexpected EOF", input: ``, sentinel: ErrUnexpectedEOF},
+		{name: "Not an object", input: `[1, 2]`, sentinel: ErrNotAnObject},
+		{name: "Invalid number", input: `{"a": 1e999999}`, sentinel: ErrInvalidNumber},
+	}
+
+	sentinels := []error{ErrUnexpectedToken, ErrUnexpectedEOF, ErrNotAnObject, ErrInvalidNumber}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("Parse() error = %v, want errors.Is %v", err, tt.sentinel)
+			}
+
+			for _, other := range sentinels {
+				if other != tt.sentinel && errors.Is(err, other) {
+					t.Errorf("Parse() error = %v unexpectedly matches %v", err, other)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamingSentinelErrors(t *testing.T) {
+	sp := NewStreamingParser(nil)
+
+	err := sp.ProcessString(`{"a":x`)
+	if !errors.Is(err, ErrUnexpectedToken) {
+		t.Errorf("ProcessString() error = %v, want ErrUnexpectedToken", err)
+	}
+}
+
+type panickingMarshaler struct{}
+
+func (panickingMarshaler) MarshalJSON() ([]byte, error) {
+	panic("boom")
+}
+
+func TestHardenedStreamingParser(t *testing.T) {
+	// A parser that wasn't built with NewStreamingParser has no lexer
+	sp := &StreamingParser{}
+	sp.SetHardened(true)
+
+	err := sp.ProcessString(`{"a":[1]}`)
+
+	var ierr *InternalError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("ProcessString() error = %v, want *InternalError", err)
+	}
+	if ierr.State == "" || len(ierr.Stack) == 0 {
+		t.Errorf("Expected state dump and stack trace, got %+v", ierr)
+	}
+
+	// Without hardened mode the panic propagates
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic without hardened mode")
+		}
+	}()
+	sp = &StreamingParser{}
+	_ = sp.ProcessString(`{"a":[1]}`)
+}
+
+func TestHardenedParserKeepsParseErrors(t *testing.T) {
+	p := NewParser(NewLexer(`{"a" 1}`).Tokenize())
+	p.SetHardened(true)
+
+	_, err := p.Parse()
+
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Code != CodeExpectedColon {
+		t.Fatalf("Parse() error = %v, want %s", err, CodeExpectedColon)
+	}
+}
+
+func TestToMapRecoversPanics(t *testing.T) {
+	_, err := ToMap(map[string]any{"bad": panickingMarshaler{}})
+
+	var ierr *InternalError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("ToMap() error = %v, want *InternalError", err)
+	}
+	if ierr.Panic != "boom" {
+		t.Errorf("Unexpected panic value: %v", ierr.Panic)
+	}
+}
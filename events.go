@@ -0,0 +1,121 @@
+package flexjson
+
+// EventHandler receives structural events from a StreamingParser as the input
+// is processed. Every method is given the path of the value it concerns, in
+// the format described in path.go; the root object has the path "".
+// Events are delivered in the order described in dispatch.go.
+//
+// Embed NopHandler to implement only the events you need.
+type EventHandler interface {
+	// OnObjectStart is called when an object is opened
+	OnObjectStart(path string)
+	// OnObjectEnd is called when an object is closed, after all of its values
+	OnObjectEnd(path string)
+	// OnArrayStart is called when an array is opened
+	OnArrayStart(path string)
+	// OnArrayEnd is called when an array is closed, after all of its elements
+	OnArrayEnd(path string)
+	// OnKey is called when an object key is complete. path is the path of
+	// the value the key introduces.
+	OnKey(path string, key string)
+	// OnValue is called when a string, number, boolean, or null is complete
+	OnValue(path string, value any)
+}
+
+// NopHandler is an EventHandler that ignores every event
+type NopHandler struct{}
+
+func (NopHandler) OnObjectStart(path string)      {}
+func (NopHandler) OnObjectEnd(path string)        {}
+func (NopHandler) OnArrayStart(path string)       {}
+func (NopHandler) OnArrayEnd(path string)         {}
+func (NopHandler) OnKey(path string, key string)  {}
+func (NopHandler) OnValue(path string, value any) {}
+
+// SetEventHandler registers h to receive structural events. Pass nil to stop
+// delivering events.
+func (sp *StreamingParser) SetEventHandler(h EventHandler) {
+	sp.ownHandler = h
+	sp.setHandlers()
+}
+
+// SetSkipOutput controls whether the output map is built. When skipped, the
+// output map stays empty and values are only reported to the event handler
+// and watchers, which avoids the cost of building the map when a custom sink
+// consumes the events. Watchers of objects and arrays are passed nil instead
+// of the container. It must be called before any input is processed.
+func (sp *StreamingParser) SetSkipOutput(value bool) {
+	sp.skipOutput = value
+}
+
+// newObject returns the frame for a new object at path
+func (d *decoder) newObject(path string) frame {
+	if d.skipOutput || d.discarding || d.discardValue(path) {
+		return frame{kind: containerSkippedObject}
+	}
+	if d.sink != nil {
+		return frame{kind: containerSink, sink: d.newSinkObject()}
+	}
+	if d.merge && !d.inArray() {
+		if obj, ok := d.existingObject(); ok {
+			return frame{kind: containerObject, obj: obj}
+		}
+	}
+	return frame{kind: containerObject, obj: make(map[string]any)}
+}
+
+// newArray returns the frame for a new array at path
+func (d *decoder) newArray(path string) frame {
+	if d.skipOutput || d.discarding || d.discardValue(path) {
+		return frame{kind: containerSkippedArray}
+	}
+	return frame{kind: containerArray, arr: make([]interface{}, 0)}
+}
+
+// emit delivers an event to the handler
+func (d *decoder) emit(event func(h EventHandler)) {
+	if h := d.handler; h != nil {
+		d.enqueue(func() { event(h) })
+	}
+}
+
+// emitStart reports an object or array being opened at path
+func (d *decoder) emitStart(path string, array bool) {
+	if d.handler == nil {
+		return
+	}
+	if array {
+		d.emit(func(h EventHandler) { h.OnArrayStart(path) })
+	} else {
+		d.emit(func(h EventHandler) { h.OnObjectStart(path) })
+	}
+}
+
+// emitEnd reports the container on top of the stack being closed
+func (d *decoder) emitEnd(array bool) {
+	if d.handler == nil {
+		return
+	}
+	path := d.top().path
+	if array {
+		d.emit(func(h EventHandler) { h.OnArrayEnd(path) })
+	} else {
+		d.emit(func(h EventHandler) { h.OnObjectEnd(path) })
+	}
+}
+
+// emitKey reports a completed key in the object on top of the stack
+func (d *decoder) emitKey(key string) {
+	if d.handler == nil {
+		return
+	}
+	path := appendKeyPath(d.top().path, key)
+	d.emit(func(h EventHandler) { h.OnKey(path, key) })
+}
+
+// valueAdded reports a string, number, or literal stored at path to the
+// event handler and watchers
+func (d *decoder) valueAdded(path string, value any) {
+	d.emit(func(h EventHandler) { h.OnValue(path, value) })
+	d.notify(path, value, true)
+}
@@ -0,0 +1,107 @@
+package flexjson
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// recordingHandler records events as strings
+type recordingHandler struct {
+	events []string
+}
+
+func (r *recordingHandler) OnObjectStart(path string) {
+	r.events = append(r.events, "{ "+path)
+}
+
+func (r *recordingHandler) OnObjectEnd(path string) {
+	r.events = append(r.events, "} "+path)
+}
+
+func (r *recordingHandler) OnArrayStart(path string) {
+	r.events = append(r.events, "[ "+path)
+}
+
+func (r *recordingHandler) OnArrayEnd(path string) {
+	r.events = append(r.events, "] "+path)
+}
+
+func (r *recordingHandler) OnKey(path string, key string) {
+	r.events = append(r.events, "key "+path+" "+key)
+}
+
+func (r *recordingHandler) OnValue(path string, value any) {
+	r.events = append(r.events, fmt.Sprintf("value %s %#v", path, value))
+}
+
+func TestEventHandler(t *testing.T) {
+	input := `{"name":"x","tags":[1,{"ok":true}],"none":null}`
+	expected := []string{
+		"{ ",
+		"key name name",
+		`value name "x"`,
+		"key tags tags",
+		"[ tags",
+		"value tags[0] 1",
+		"{ tags[1]",
+		"key tags[1].ok ok",
+		"value tags[1].ok true",
+		"} tags[1]",
+		"] tags",
+		"key none none",
+		"value none <nil>",
+		"} ",
+	}
+
+	for _, skip := range []bool{false, true} {
+		t.Run(fmt.Sprintf("skip output %v", skip), func(t *testing.T) {
+			output := make(map[string]any)
+			sp := NewStreamingParser(&output)
+			sp.SetSkipOutput(skip)
+
+			h := &recordingHandler{}
+			sp.SetEventHandler(h)
+
+			for _, char := range input {
+				if err := sp.ProcessChar(string(char)); err != nil {
+					t.Fatalf("ProcessChar(%q) error = %v", char, err)
+				}
+			}
+
+			if !reflect.DeepEqual(h.events, expected) {
+				t.Errorf("Events =\n%q\nwant\n%q", h.events, expected)
+			}
+			if skip && len(output) != 0 {
+				t.Errorf("Expected output to be skipped, got %v", output)
+			}
+			if !skip && len(output) != 3 {
+				t.Errorf("Expected output to be built, got %v", output)
+			}
+		})
+	}
+}
+
+// valueHandler only handles values
+type valueHandler struct {
+	NopHandler
+	values []any
+}
+
+func (v *valueHandler) OnValue(path string, value any) {
+	v.values = append(v.values, value)
+}
+
+func TestNopHandler(t *testing.T) {
+	h := &valueHandler{}
+	sp := NewStreamingParser(nil)
+	sp.SetEventHandler(h)
+	if err := sp.ProcessString(`{"a":[1,"b"],"c":false}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	expected := []any{int64(1), "b", false}
+	if !reflect.DeepEqual(h.values, expected) {
+		t.Errorf("Values = %v, want %v", h.values, expected)
+	}
+}
@@ -0,0 +1,193 @@
+package flexjson
+
+import (
+	"context"
+	"io"
+	"iter"
+	"sync"
+)
+
+// EventType identifies the kind of an Event
+type EventType uint8
+
+const (
+	EventObjectStart EventType = iota + 1
+	EventObjectEnd
+	EventArrayStart
+	EventArrayEnd
+	EventKey
+	EventValue
+	EventError
+)
+
+// String returns the name of the event type
+func (t EventType) String() string {
+	switch t {
+	case EventObjectStart:
+		return "ObjectStart"
+	case EventObjectEnd:
+		return "ObjectEnd"
+	case EventArrayStart:
+		return "ArrayStart"
+	case EventArrayEnd:
+		return "ArrayEnd"
+	case EventKey:
+		return "Key"
+	case EventValue:
+		return "Value"
+	case EventError:
+		return "Error"
+	}
+	return "Unknown"
+}
+
+// Event is a parse event delivered by Events
+type Event struct {
+	Type  EventType
+	Path  string // Path of the value the event concerns
+	Key   string // Object key, for EventKey
+	Value any    // Completed value, for EventValue
+	Err   error  // Parse error, for EventError
+}
+
+// ErrorHandler can be implemented by an EventHandler to be told about parse errors
+type ErrorHandler interface {
+	OnError(err error)
+}
+
+// Events returns a channel of parse events, replacing any handler set with
+// SetEventHandler. Events are sent as input is processed, and the parser
+// blocks until each one is received. The channel is closed after the root
+// object is closed, after an EventError, or when ctx is cancelled; once it is
+// closed further events are dropped. With WithDocuments, the stream carries
+// on past each root object, and the channel is closed only after an
+// EventError or when ctx is cancelled at the end of the stream.
+func (sp *StreamingParser) Events(ctx context.Context) <-chan Event {
+	s := &eventStream{
+		ctx:       ctx,
+		ch:        make(chan Event),
+		documents: sp.documents != nil,
+	}
+	s.stop = context.AfterFunc(ctx, s.close)
+	sp.SetEventHandler(eventFunc(s.send))
+	return s.ch
+}
+
+// EventsFrom returns an iterator that reads r in chunks, feeds them to the
+// parser, and yields the resulting events, replacing any handler set with
+// SetEventHandler until the iteration ends, when it is restored. Input is
+// only read as the loop advances. A parse or read
+// error is yielded as an EventError alongside the error, and ends the
+// iteration; reaching the end of r ends it without an error.
+func (sp *StreamingParser) EventsFrom(r io.Reader) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		var pending []Event
+		defer sp.SetEventHandler(sp.ownHandler)
+		sp.SetEventHandler(eventFunc(func(e Event) {
+			pending = append(pending, e)
+		}))
+
+		buf := make([]byte, 4096)
+		for {
+			n, readErr := r.Read(buf)
+			err := sp.ProcessBytes(buf[:n])
+			sp.Flush()
+			for _, e := range pending {
+				if !yield(e, e.Err) {
+					return
+				}
+			}
+			pending = pending[:0]
+			if err != nil {
+				return
+			}
+
+			if readErr == io.EOF {
+				return
+			}
+			if readErr != nil {
+				yield(Event{Type: EventError, Err: readErr}, readErr)
+				return
+			}
+		}
+	}
+}
+
+// eventFunc adapts a function receiving Events to an EventHandler
+type eventFunc func(Event)
+
+func (f eventFunc) OnObjectStart(path string) {
+	f(Event{Type: EventObjectStart, Path: path})
+}
+
+func (f eventFunc) OnObjectEnd(path string) {
+	f(Event{Type: EventObjectEnd, Path: path})
+}
+
+func (f eventFunc) OnArrayStart(path string) {
+	f(Event{Type: EventArrayStart, Path: path})
+}
+
+func (f eventFunc) OnArrayEnd(path string) {
+	f(Event{Type: EventArrayEnd, Path: path})
+}
+
+func (f eventFunc) OnKey(path string, key string) {
+	f(Event{Type: EventKey, Path: path, Key: key})
+}
+
+func (f eventFunc) OnValue(path string, value any) {
+	f(Event{Type: EventValue, Path: path, Value: value})
+}
+
+func (f eventFunc) OnError(err error) {
+	f(Event{Type: EventError, Err: err})
+}
+
+// eventStream sends events to a channel
+type eventStream struct {
+	ctx       context.Context
+	ch        chan Event
+	stop      func() bool
+	mu        sync.Mutex
+	closed    bool
+	documents bool // Whether more root objects may follow the first
+}
+
+// send delivers e unless the stream has been closed or ctx is cancelled. The
+// channel is closed after an error is sent, or after the root object is
+// closed unless more documents may follow.
+func (s *eventStream) send(e Event) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	select {
+	case s.ch <- e:
+	case <-s.ctx.Done():
+	}
+	s.mu.Unlock()
+
+	if e.Type == EventError || (e.Type == EventObjectEnd && e.Path == "" && !s.documents) {
+		s.stop()
+		s.close()
+	}
+}
+
+// close closes the channel once
+func (s *eventStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// emitError reports a parse error to the handler if it implements ErrorHandler
+func (d *decoder) emitError(err error) {
+	if _, ok := d.handler.(ErrorHandler); ok {
+		d.emit(func(h EventHandler) { h.(ErrorHandler).OnError(err) })
+	}
+}
@@ -0,0 +1,209 @@
+package flexjson
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEvents(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	events := sp.Events(context.Background())
+
+	go func() {
+		for _, chunk := range []string{`{"a":[1,`, `"x"]}`, ` {"ignored":true}`} {
+			sp.ProcessString(chunk)
+		}
+	}()
+
+	var got []Event
+	for e := range events {
+		got = append(got, e)
+	}
+
+	expected := []Event{
+		{Type: EventObjectStart, Path: ""},
+		{Type: EventKey, Path: "a", Key: "a"},
+		{Type: EventArrayStart, Path: "a"},
+		{Type: EventValue, Path: "a[0]", Value: int64(1)},
+		{Type: EventValue, Path: "a[1]", Value: "x"},
+		{Type: EventArrayEnd, Path: "a"},
+		{Type: EventObjectEnd, Path: ""},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Events() = %v, want %v", got, expected)
+	}
+}
+
+func TestEventsError(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	events := sp.Events(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- sp.ProcessString(`{"a" x`)
+	}()
+
+	var last Event
+	for e := range events {
+		last = e
+	}
+
+	var perr *ParseError
+	if last.Type != EventError || !errors.As(last.Err, &perr) {
+		t.Errorf("Expected a final error event, got %+v", last)
+	}
+	if err := <-done; err != last.Err {
+		t.Errorf("ProcessString() error = %v, want %v", err, last.Err)
+	}
+}
+
+func TestEventsCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sp := NewStreamingParser(nil)
+	events := sp.Events(ctx)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- sp.ProcessString(`{"a":1,"b":2}`)
+	}()
+
+	// Receive one event, then stop listening
+	if e := <-events; e.Type != EventObjectStart {
+		t.Errorf("First event = %v, want %v", e.Type, EventObjectStart)
+	}
+	cancel()
+
+	// The parser is no longer blocked and the channel is closed
+	if err := <-done; err != nil {
+		t.Errorf("ProcessString() error = %v", err)
+	}
+	for range events {
+	}
+
+	expected := map[string]any{"a": int64(1), "b": int64(2)}
+	if !reflect.DeepEqual(sp.GetCurrentOutput(), expected) {
+		t.Errorf("Output = %v, want %v", sp.GetCurrentOutput(), expected)
+	}
+}
+
+func TestEventsDocuments(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sp := NewStreamingParser(nil, WithDocuments(func(map[string]any) {}))
+	events := sp.Events(ctx)
+
+	go func() {
+		for _, chunk := range []string{`{"a":1}`, "\n", `{"b":[2]}`} {
+			sp.ProcessString(chunk)
+		}
+		// The end of the stream
+		cancel()
+	}()
+
+	var got []Event
+	for e := range events {
+		got = append(got, e)
+	}
+
+	expected := []Event{
+		{Type: EventObjectStart, Path: ""},
+		{Type: EventKey, Path: "a", Key: "a"},
+		{Type: EventValue, Path: "a", Value: int64(1)},
+		{Type: EventObjectEnd, Path: ""},
+		{Type: EventObjectStart, Path: ""},
+		{Type: EventKey, Path: "b", Key: "b"},
+		{Type: EventArrayStart, Path: "b"},
+		{Type: EventValue, Path: "b[0]", Value: int64(2)},
+		{Type: EventArrayEnd, Path: "b"},
+		{Type: EventObjectEnd, Path: ""},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Events() = %v, want %v", got, expected)
+	}
+}
+
+func TestEventsFrom(t *testing.T) {
+	sp := NewStreamingParser(nil)
+
+	var types []EventType
+	for e, err := range sp.EventsFrom(iotest.OneByteReader(strings.NewReader(`{"a":[true],"b":"x"}`))) {
+		if err != nil {
+			t.Fatalf("EventsFrom() error = %v", err)
+		}
+		types = append(types, e.Type)
+	}
+
+	expected := []EventType{
+		EventObjectStart, EventKey, EventArrayStart, EventValue, EventArrayEnd,
+		EventKey, EventValue, EventObjectEnd,
+	}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("EventsFrom() types = %v, want %v", types, expected)
+	}
+}
+
+func TestEventsFromRestoresHandler(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	var got []Event
+	sp.SetEventHandler(eventFunc(func(e Event) { got = append(got, e) }))
+
+	// Stop after the first event; the rest of the input is fed directly
+	for range sp.EventsFrom(strings.NewReader(`{"a":`)) {
+		break
+	}
+	if err := sp.ProcessString(`1}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	expected := []Event{
+		{Type: EventValue, Path: "a", Value: int64(1)},
+		{Type: EventObjectEnd, Path: ""},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("events after EventsFrom = %v, want %v", got, expected)
+	}
+}
+
+func TestEventsFromErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		reader io.Reader
+		check  func(err error) bool
+	}{
+		{
+			name:   "Parse error",
+			reader: strings.NewReader(`{"a" x}`),
+			check: func(err error) bool {
+				var perr *ParseError
+				return errors.As(err, &perr)
+			},
+		},
+		{
+			name:   "Read error",
+			reader: iotest.ErrReader(io.ErrUnexpectedEOF),
+			check: func(err error) bool {
+				return err == io.ErrUnexpectedEOF
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := NewStreamingParser(nil)
+
+			var last Event
+			var lastErr error
+			for e, err := range sp.EventsFrom(tt.reader) {
+				last, lastErr = e, err
+			}
+
+			if last.Type != EventError || !tt.check(lastErr) || last.Err != lastErr {
+				t.Errorf("Last event = %+v, error = %v", last, lastErr)
+			}
+		})
+	}
+}
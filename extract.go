@@ -0,0 +1,103 @@
+package flexjson
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNoJSON is returned by ExtractPartialJSON when text contains no JSON
+// object or array
+var ErrNoJSON = errors.New("no JSON object or array found")
+
+// codeFence opens and closes a Markdown code block
+const codeFence = "```"
+
+// ExtractPartialJSON parses the first JSON object or array found in text that
+// also holds other content, such as a model's reply with the JSON in a
+// ```json code block, a preamble like "Here is the JSON:", or commentary after
+// the value. When text has a code block, the JSON is looked for in the first
+// one before the rest of the text. The value is parsed as Parse does, so one
+// cut off by the end of text is returned as far as it goes, and content after
+// it is ignored.
+//
+// Objects are returned as map[string]any and arrays as []interface{}. A '{'
+// or '[' in the prose that doesn't start a valid value is skipped; if none
+// does, the error for the first one is returned, or ErrNoJSON if there are
+// none. Errors recovered from are returned as Parse returns them.
+func ExtractPartialJSON(text string, opts ...Option) (value any, err error) {
+	defer recoverInternal(&err, nil)
+
+	var first error
+	for _, region := range extractRegions(text) {
+		for i := region[0]; i < region[1]; i++ {
+			if text[i] != '{' && text[i] != '[' {
+				continue
+			}
+			value, issues, err := extractAt(text[:region[1]], i, opts)
+			if err == nil {
+				return value, joinIssues(issues, text)
+			}
+			if first == nil {
+				first = err
+			}
+		}
+	}
+
+	if first != nil {
+		return nil, first
+	}
+	return nil, ErrNoJSON
+}
+
+// extractRegions returns the ranges of text to search for JSON, in order: the
+// contents of the first code block, if there is one, and then the whole text
+func extractRegions(text string) [][2]int {
+	whole := [2]int{0, len(text)}
+
+	open := strings.Index(text, codeFence)
+	if open < 0 {
+		return [][2]int{whole}
+	}
+
+	// The block starts on the line after the fence and its info string
+	start := open + len(codeFence)
+	if newline := strings.IndexByte(text[start:], '\n'); newline >= 0 {
+		start += newline + 1
+	} else {
+		start = len(text)
+	}
+
+	// A block cut off by the end of the text runs to the end
+	end := len(text)
+	if closing := strings.Index(text[start:], codeFence); closing >= 0 {
+		end = start + closing
+	}
+	return [][2]int{{start, end}, whole}
+}
+
+// extractAt parses the value starting at offset i of text, returning the
+// errors recovered from alongside it
+func extractAt(text string, i int, opts []Option) (any, []*ParseError, error) {
+	d := &decoder{}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	lexer := NewLexer(text)
+	d.configureLexer(lexer)
+	lexer.pos = i
+
+	for tok := range lexer.Tokens() {
+		if err := d.token(tok); err != nil {
+			var perr *ParseError
+			if errors.As(err, &perr) {
+				perr.locate(text)
+			}
+			return nil, nil, err
+		}
+		if d.done() {
+			break
+		}
+	}
+	return d.result, d.issues, nil
+}
@@ -0,0 +1,84 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExtractPartialJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected any
+	}{
+		{
+			name:     "bare object",
+			input:    `{"a": 1}`,
+			expected: map[string]any{"a": int64(1)},
+		},
+		{
+			name:     "preamble and commentary",
+			input:    "Here is the JSON:\n{\"name\": \"Jo\", \"tags\": [\"a\"]}\nLet me know if you need anything else!",
+			expected: map[string]any{"name": "Jo", "tags": []interface{}{"a"}},
+		},
+		{
+			name:     "code fence",
+			input:    "Sure! See [the docs] for details.\n```json\n[1, 2, 3]\n```\nThe {braces} above are an example.",
+			expected: []interface{}{int64(1), int64(2), int64(3)},
+		},
+		{
+			name:     "truncated code fence",
+			input:    "```json\n{\"status\": \"ok\", \"items\": [{\"id\": 1}, {\"id\"",
+			expected: map[string]any{"status": "ok", "items": []interface{}{map[string]any{"id": int64(1)}, map[string]any{"id": nil}}},
+		},
+		{
+			name:     "fence without json",
+			input:    "```\nno json here\n```\nResult: {\"ok\": true}",
+			expected: map[string]any{"ok": true},
+		},
+		{
+			name:     "invalid candidates are skipped",
+			input:    "Use {name} or [x] placeholders. {\"name\": \"value\"}",
+			expected: map[string]any{"name": "value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ExtractPartialJSON(tt.input)
+			if err != nil {
+				t.Fatalf("ExtractPartialJSON() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ExtractPartialJSON() = %#v, want %#v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtractPartialJSONErrors(t *testing.T) {
+	if _, err := ExtractPartialJSON("no json at all"); !errors.Is(err, ErrNoJSON) {
+		t.Errorf("ExtractPartialJSON() error = %v, want ErrNoJSON", err)
+	}
+
+	var perr *ParseError
+	_, err := ExtractPartialJSON("line one\nsee {bad}")
+	if !errors.As(err, &perr) {
+		t.Fatalf("ExtractPartialJSON() error = %v, want a *ParseError", err)
+	}
+	if perr.Offset != 14 || perr.Line != 2 || perr.Column != 6 {
+		t.Errorf("Error at offset %d, line %d, column %d, want 14, 2, 6", perr.Offset, perr.Line, perr.Column)
+	}
+}
+
+func TestExtractPartialJSONOptions(t *testing.T) {
+	result, err := ExtractPartialJSON("Output: {name: 'Jo', ok: True,}", WithSyntax(SyntaxJSON5|SyntaxPythonLiterals))
+	if err != nil {
+		t.Fatalf("ExtractPartialJSON() error = %v", err)
+	}
+	expected := map[string]any{"name": "Jo", "ok": true}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ExtractPartialJSON() = %#v, want %#v", result, expected)
+	}
+}
@@ -0,0 +1,157 @@
+package flexjson
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// structField describes how a struct field maps onto an object key
+type structField struct {
+	name      string // Object key
+	index     []int  // Index sequence for reflect.Value.FieldByIndex
+	typ       reflect.Type
+	tagged    bool // Whether the name came from a tag
+	omitEmpty bool // omitempty option
+	omitZero  bool // omitzero option
+	asString  bool // string option: scalars are encoded as strings
+}
+
+var structFieldCache sync.Map // map[reflect.Type][]structField
+
+// structFields returns the object fields of a struct type, following the
+// encoding/json rules: `flexjson` tags take precedence over `json` tags, "-"
+// excludes a field, and fields of embedded structs are promoted unless a
+// shallower or tagged field has the same name.
+func structFields(t reflect.Type) []structField {
+	if cached, ok := structFieldCache.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	fields := dominantFields(collectFields(t, nil, map[reflect.Type]bool{}))
+	cached, _ := structFieldCache.LoadOrStore(t, fields)
+	return cached.([]structField)
+}
+
+// fieldTag returns the tag used for a struct field
+func fieldTag(f reflect.StructField) (string, bool) {
+	if tag, ok := f.Tag.Lookup("flexjson"); ok {
+		return tag, true
+	}
+	return f.Tag.Lookup("json")
+}
+
+// collectFields gathers the candidate fields of t, descending into embedded structs
+func collectFields(t reflect.Type, index []int, visited map[reflect.Type]bool) []structField {
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := fieldTag(f)
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int{}, index...), i)
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// Promote the fields of embedded structs
+				fields = append(fields, collectFields(ft, fieldIndex, visited)...)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		field := structField{
+			name:   f.Name,
+			index:  fieldIndex,
+			typ:    f.Type,
+			tagged: hasTag && name != "",
+		}
+		if name != "" {
+			field.name = name
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty":
+				field.omitEmpty = true
+			case "omitzero":
+				field.omitZero = true
+			case "string":
+				field.asString = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// dominantFields resolves fields that share a name: the shallowest field wins,
+// then a tagged field; if that's still ambiguous the name is dropped
+func dominantFields(fields []structField) []structField {
+	byName := make(map[string][]structField)
+	var order []string
+	for _, f := range fields {
+		if _, ok := byName[f.name]; !ok {
+			order = append(order, f.name)
+		}
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	result := make([]structField, 0, len(order))
+	for _, name := range order {
+		candidates := byName[name]
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return len(candidates[i].index) < len(candidates[j].index)
+		})
+
+		depth := len(candidates[0].index)
+		var best []structField
+		for _, c := range candidates {
+			if len(c.index) == depth {
+				best = append(best, c)
+			}
+		}
+
+		if len(best) > 1 {
+			var tagged []structField
+			for _, c := range best {
+				if c.tagged {
+					tagged = append(tagged, c)
+				}
+			}
+			best = tagged
+		}
+
+		if len(best) == 1 {
+			result = append(result, best[0])
+		}
+	}
+
+	// Keep fields in struct declaration order
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].index, result[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return result
+}
@@ -0,0 +1,66 @@
+package flexjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// Indent formats a JSON document that may be cut off, as json.Indent formats
+// a complete one: each element of an object or array starts on a new line
+// beginning with prefix followed by one or more copies of indent, according
+// to its nesting. The first line doesn't start with prefix, and no newline
+// is added at the end, so the result can be embedded like json.Indent's.
+//
+// The document is first completed as CompleteJSON completes it, so an open
+// string and the open arrays and objects are closed, and what can't be
+// completed, such as a key without a value, is dropped. Input that isn't the
+// start of a JSON document returns a *ParseError.
+func Indent(partial, prefix, indent string) (string, error) {
+	completed, err := CompleteJSON(partial)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := json.Indent(&b, []byte(completed), prefix, indent); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Compact removes the whitespace between the tokens of a JSON document that
+// may be cut off, as json.Compact does for a complete one, for storing
+// streamed captures compactly. Unlike Indent, it doesn't complete the
+// document: the result is cut off where the input is, so a document is
+// stored as it arrived. The tokens are copied byte for byte, and text after
+// a complete document is dropped.
+//
+// Input that isn't the start of a JSON document returns a *ParseError.
+func Compact(partial string) (compacted string, err error) {
+	defer recoverInternal(&err, nil)
+
+	d := &decoder{skipOutput: true}
+	var b strings.Builder
+	b.Grow(len(partial))
+	end := 0
+	for tok := range NewLexer(partial).Tokens() {
+		if tok.Type == TokenEOF || d.done() {
+			break
+		}
+		if err := d.token(tok); err != nil {
+			return "", locateError(err, partial)
+		}
+		b.WriteString(partial[tok.Start:tok.End])
+		end = tok.End
+	}
+
+	// The lexer drops a literal cut off by the end of the input
+	if !d.done() {
+		b.WriteString(strings.TrimLeftFunc(partial[end:], func(r rune) bool {
+			return r < utf8.RuneSelf && isSpace(byte(r))
+		}))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,96 @@
+package flexjson
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIndent(t *testing.T) {
+	tests := []struct {
+		name    string
+		partial string
+		prefix  string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "complete",
+			partial: `{"a":1,"b":[true,null],"c":{}}`,
+			want:    "{\n  \"a\": 1,\n  \"b\": [\n    true,\n    null\n  ],\n  \"c\": {}\n}",
+		},
+		{
+			name:    "cut off in a string",
+			partial: `{"a": [1, {"b": "hel`,
+			want:    "{\n  \"a\": [\n    1,\n    {\n      \"b\": \"hel\"\n    }\n  ]\n}",
+		},
+		{
+			name:    "key without a value",
+			partial: `{"a": "x", "b"`,
+			want:    "{\n  \"a\": \"x\"\n}",
+		},
+		{
+			name:    "prefix",
+			partial: `[1, [2`,
+			prefix:  "> ",
+			want:    "[\n>   1,\n>   [\n>     2\n>   ]\n> ]",
+		},
+		{
+			name:    "escapes kept",
+			partial: `{"a": "\u00e9\n`,
+			want:    "{\n  \"a\": \"\\u00e9\\n\"\n}",
+		},
+		{
+			name:    "invalid",
+			partial: `{"a" 1`,
+			wantErr: ErrInvalid,
+		},
+		{
+			name:    "empty",
+			partial: ``,
+			wantErr: ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Indent(tt.partial, tt.prefix, "  ")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Indent() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Indent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		name    string
+		partial string
+		want    string
+		wantErr error
+	}{
+		{"complete", "{\n  \"a\": [1, 2.5e3],\n  \"b\": {}\n}\n", `{"a":[1,2.5e3],"b":{}}`, nil},
+		{"cut off in a string", `{"a": [1, {"b": "hel lo`, `{"a":[1,{"b":"hel lo`, nil},
+		{"cut off after a comma", `{"a": 1, `, `{"a":1,`, nil},
+		{"cut off in a literal", `[true, nu`, `[true,nu`, nil},
+		{"cut off in a number", `[1, -2.`, `[1,-2.`, nil},
+		{"escapes kept", `{"a" : "x\"y\n"}`, `{"a":"x\"y\n"}`, nil},
+		{"trailing text", `{"a": 1} {"b": 2}`, `{"a":1}`, nil},
+		{"empty", "  ", "", nil},
+		{"invalid", `{"a" 1`, "", ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compact(tt.partial)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Compact() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Compact() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
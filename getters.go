@@ -0,0 +1,119 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"math/big"
+)
+
+// The getters below read a value at a path, in the format described in
+// path.go, from a parsed object, so consumers don't each write the type
+// switches. Nested objects may be map[string]any or *OrderedMap, and arrays
+// []interface{}.
+// Each returns false if there is no value at the path, which in partial
+// output may simply not have arrived yet, or if the value has another type.
+
+// GetString returns the string at path
+func GetString(out map[string]any, path string) (string, bool) {
+	s, ok := lookupPath(out, path).(string)
+	return s, ok
+}
+
+// GetBool returns the boolean at path
+func GetBool(out map[string]any, path string) (bool, bool) {
+	b, ok := lookupPath(out, path).(bool)
+	return b, ok
+}
+
+// GetInt returns the number at path as an int64. Numbers of any
+// representation convert if they have no fractional part and fit, so 2.0 is
+// 2 and 2.5 is not found, and so do strings holding such a number, as models
+// sometimes write "42" for 42.
+func GetInt(out map[string]any, path string) (int64, bool) {
+	f, ok := getNumber(out, path)
+	if !ok || !f.IsInt() {
+		return 0, false
+	}
+	n, acc := f.Int64()
+	if acc != big.Exact {
+		return 0, false
+	}
+	return n, true
+}
+
+// GetFloat returns the number at path as a float64, rounding numbers that
+// have more precision. Strings holding a number convert as they do for
+// GetInt.
+func GetFloat(out map[string]any, path string) (float64, bool) {
+	f, ok := getNumber(out, path)
+	if !ok {
+		return 0, false
+	}
+	v, _ := f.Float64()
+	return v, true
+}
+
+// GetSlice returns the array at path. An array that is still streaming is
+// returned as it is now; it isn't copied, so it must not be modified.
+func GetSlice(out map[string]any, path string) ([]any, bool) {
+	arr, ok := lookupPath(out, path).([]interface{})
+	return arr, ok
+}
+
+// GetObject returns the object at path. The empty path returns out itself.
+func GetObject(out map[string]any, path string) (map[string]any, bool) {
+	obj, ok := lookupPath(out, path).(map[string]any)
+	return obj, ok
+}
+
+// getNumber returns the number at path, or in a string at path
+func getNumber(out map[string]any, path string) (*big.Float, bool) {
+	v := lookupPath(out, path)
+	if s, ok := v.(string); ok && isJSONNumber(s) {
+		v = json.Number(s)
+	}
+	return numberValue(v)
+}
+
+// lookupPath returns the value at path in out, matching keys exactly, or nil
+// if there is none
+func lookupPath(out map[string]any, path string) any {
+	value, _ := findPath(out, path)
+	return value
+}
+
+// findPath returns the value at path below root, matching keys exactly, and
+// whether there is one
+func findPath(root any, path string) (any, bool) {
+	segments, ok := parsePath(path)
+	if !ok {
+		return nil, false
+	}
+
+	value := root
+	for _, seg := range segments {
+		if value, ok = lookupSegment(value, seg); !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// lookupSegment returns the member or element of container selected by seg
+func lookupSegment(container any, seg pathSegment) (any, bool) {
+	switch c := container.(type) {
+	case map[string]any:
+		if seg.index == -1 {
+			v, ok := c[seg.key]
+			return v, ok
+		}
+	case *OrderedMap:
+		if seg.index == -1 {
+			return c.Get(seg.key)
+		}
+	case []interface{}:
+		if seg.index >= 0 && seg.index < len(c) {
+			return c[seg.index], true
+		}
+	}
+	return nil, false
+}
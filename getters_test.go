@@ -0,0 +1,146 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestGetters(t *testing.T) {
+	big1, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	ordered := NewOrderedMap()
+	ordered.Set("k", "ordered")
+	out := map[string]any{
+		"user": map[string]any{
+			"profile": map[string]any{"name": "Ada", "age": int64(36)},
+			"active":  true,
+		},
+		"items":   []interface{}{map[string]any{"id": float64(2)}, map[string]any{"id": 2.5}},
+		"tags":    []interface{}{"x", "y"},
+		"ordered": ordered,
+		"count":   json.Number("7"),
+		"quoted":  "42",
+		"ratio":   "0.5",
+		"huge":    big1,
+		"null":    nil,
+		"a.b":     "dotted",
+	}
+
+	t.Run("GetString", func(t *testing.T) {
+		tests := []struct {
+			path string
+			want string
+			ok   bool
+		}{
+			{"user.profile.name", "Ada", true},
+			{"tags[1]", "y", true},
+			{"ordered.k", "ordered", true},
+			{`["a.b"]`, "dotted", true},
+			{"user.profile.age", "", false},
+			{"user.profile.missing", "", false},
+			{"null", "", false},
+			{"items.0", "", false},
+			{"user[", "", false},
+		}
+		for _, tt := range tests {
+			got, ok := GetString(out, tt.path)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("GetString(%q) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.ok)
+			}
+		}
+	})
+
+	t.Run("GetInt", func(t *testing.T) {
+		tests := []struct {
+			path string
+			want int64
+			ok   bool
+		}{
+			{"user.profile.age", 36, true},
+			{"items[0].id", 2, true},
+			{"items[1].id", 0, false},
+			{"count", 7, true},
+			{"quoted", 42, true},
+			{"ratio", 0, false},
+			{"huge", 0, false},
+			{"user.profile.name", 0, false},
+			{"user.active", 0, false},
+		}
+		for _, tt := range tests {
+			got, ok := GetInt(out, tt.path)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("GetInt(%q) = %d, %v, want %d, %v", tt.path, got, ok, tt.want, tt.ok)
+			}
+		}
+	})
+
+	t.Run("GetFloat", func(t *testing.T) {
+		tests := []struct {
+			path string
+			want float64
+			ok   bool
+		}{
+			{"items[1].id", 2.5, true},
+			{"user.profile.age", 36, true},
+			{"ratio", 0.5, true},
+			{"huge", 1.2345678901234568e29, true},
+			{"user.profile.name", 0, false},
+		}
+		for _, tt := range tests {
+			got, ok := GetFloat(out, tt.path)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("GetFloat(%q) = %v, %v, want %v, %v", tt.path, got, ok, tt.want, tt.ok)
+			}
+		}
+	})
+
+	t.Run("GetBool", func(t *testing.T) {
+		if got, ok := GetBool(out, "user.active"); !got || !ok {
+			t.Errorf("GetBool(user.active) = %v, %v, want true, true", got, ok)
+		}
+		if _, ok := GetBool(out, "quoted"); ok {
+			t.Error("GetBool(quoted) found a boolean")
+		}
+	})
+
+	t.Run("GetSlice", func(t *testing.T) {
+		if got, ok := GetSlice(out, "tags"); !ok || !reflect.DeepEqual(got, []any{"x", "y"}) {
+			t.Errorf("GetSlice(tags) = %v, %v", got, ok)
+		}
+		if got, ok := GetSlice(out, "items"); !ok || len(got) != 2 {
+			t.Errorf("GetSlice(items) = %v, %v", got, ok)
+		}
+		if _, ok := GetSlice(out, "user"); ok {
+			t.Error("GetSlice(user) found an array")
+		}
+	})
+
+	t.Run("GetObject", func(t *testing.T) {
+		if got, ok := GetObject(out, "user.profile"); !ok || got["name"] != "Ada" {
+			t.Errorf("GetObject(user.profile) = %v, %v", got, ok)
+		}
+		if got, ok := GetObject(out, ""); !ok || got["count"] == nil {
+			t.Errorf("GetObject(\"\") = %v, %v", got, ok)
+		}
+		if _, ok := GetObject(out, "items"); ok {
+			t.Error("GetObject(items) found an object")
+		}
+	})
+}
+
+func TestGettersStreaming(t *testing.T) {
+	out := map[string]any{}
+	sp := NewStreamingParser(&out)
+	sp.ProcessString(`{"user": {"name": "Ada", "tags": ["a", "b"`)
+
+	if got, ok := GetString(out, "user.name"); got != "Ada" || !ok {
+		t.Errorf("GetString(user.name) = %q, %v", got, ok)
+	}
+	if got, ok := GetString(out, "user.tags[0]"); got != "a" || !ok {
+		t.Errorf("GetString(user.tags[0]) = %q, %v", got, ok)
+	}
+	if got, ok := GetSlice(out, "user.tags"); !ok || !reflect.DeepEqual(got, []any{"a", "b"}) {
+		t.Errorf("GetSlice(user.tags) = %v, %v", got, ok)
+	}
+}
@@ -0,0 +1,207 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DecodeHookFunc converts a value before it is stored, in the style of
+// mapstructure's decode hooks. path is the path of the value, in the format
+// described in path.go. to is the type of the Go value it is being decoded
+// into, or nil when the value is being stored in a map output. The hook
+// returns the value to store instead, or value itself to leave it unchanged.
+//
+// In a map output, hooks see strings, numbers, booleans, and nulls once they
+// are complete; objects and arrays are built as they stream and aren't passed
+// to hooks. When decoding into Go values, hooks see every value, and a result
+// that is assignable to the destination is stored as it is.
+type DecodeHookFunc func(path string, to reflect.Type, value any) (any, error)
+
+// HookError reports an error returned by a DecodeHookFunc
+type HookError struct {
+	Path string // Path of the value the hook failed on
+	Err  error  // Error returned by the hook
+}
+
+// Error implements the error interface
+func (e *HookError) Error() string {
+	return fmt.Sprintf("flexjson: decode hook failed at %q: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the error returned by the hook
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// ComposeDecodeHooks returns a hook that runs hooks in order, passing the
+// result of each to the next. It stops at the first error.
+func ComposeDecodeHooks(hooks ...DecodeHookFunc) DecodeHookFunc {
+	return func(path string, to reflect.Type, value any) (any, error) {
+		for _, hook := range hooks {
+			var err error
+			if value, err = hook(path, to, value); err != nil {
+				return nil, err
+			}
+		}
+		return value, nil
+	}
+}
+
+// PathDecodeHook returns a hook that runs hook only for values at paths
+// matching pattern. In the pattern, "[*]" matches any array index, e.g.
+// "events[*].at".
+func PathDecodeHook(pattern string, hook DecodeHookFunc) DecodeHookFunc {
+	return func(path string, to reflect.Type, value any) (any, error) {
+		if !matchPath(pattern, path) {
+			return value, nil
+		}
+		return hook(path, to, value)
+	}
+}
+
+// matchPath reports whether path matches pattern, where "[*]" in the pattern
+// matches any array index
+func matchPath(pattern, path string) bool {
+	for {
+		before, after, found := strings.Cut(pattern, "[*]")
+		if !found {
+			return pattern == path
+		}
+		if !strings.HasPrefix(path, before) {
+			return false
+		}
+		path = path[len(before):]
+
+		// An index is a '[' followed by digits and ']'
+		end := strings.IndexByte(path, ']')
+		if len(path) < 3 || path[0] != '[' || end < 2 || strings.Trim(path[1:end], "0123456789") != "" {
+			return false
+		}
+		path, pattern = path[end+1:], after
+	}
+}
+
+// StringToTimeHook returns a hook that parses strings with layout into
+// time.Time values. It converts values decoded into time.Time, and, in a
+// map output, every string, so it is usually combined with PathDecodeHook
+// there.
+func StringToTimeHook(layout string) DecodeHookFunc {
+	timeType := reflect.TypeFor[time.Time]()
+	return func(path string, to reflect.Type, value any) (any, error) {
+		s, ok := value.(string)
+		if !ok || (to != nil && to != timeType) {
+			return value, nil
+		}
+		return time.Parse(layout, s)
+	}
+}
+
+// StringToDurationHook returns a hook that parses strings such as "1m30s"
+// into time.Duration values with time.ParseDuration. Like StringToTimeHook,
+// it converts every string in a map output.
+func StringToDurationHook() DecodeHookFunc {
+	durationType := reflect.TypeFor[time.Duration]()
+	return func(path string, to reflect.Type, value any) (any, error) {
+		s, ok := value.(string)
+		if !ok || (to != nil && to != durationType) {
+			return value, nil
+		}
+		return time.ParseDuration(s)
+	}
+}
+
+// CoerceStringsHook returns a hook that converts strings holding a number
+// or a boolean, as models often write "42" for 42 or "true" for true, to the
+// value they hold. It converts a string decoded into an integer, float, or
+// bool, if the string holds a value of that kind, surrounded by whitespace
+// or not; other strings are left to fail as they would without it. In a map
+// output, every string holding a JSON number becomes an int64 or float64,
+// and "true" and "false" become booleans, so it is usually combined with
+// PathDecodeHook there. GetInt and GetFloat convert numbers in strings
+// without it.
+func CoerceStringsHook() DecodeHookFunc {
+	return func(path string, to reflect.Type, value any) (any, error) {
+		s, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+		s = strings.TrimSpace(s)
+
+		kind := reflect.Invalid // A map output
+		if to != nil {
+			kind = to.Kind()
+		}
+		switch kind {
+		case reflect.Bool, reflect.Invalid:
+			if s == "true" || s == "false" {
+				return s == "true", nil
+			}
+		}
+		if !isJSONNumber(s) {
+			return value, nil
+		}
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64:
+			return json.Number(s), nil
+		case reflect.Invalid:
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return n, nil
+			}
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f, nil
+			}
+		}
+		return value, nil
+	}
+}
+
+// EnumHook returns a hook that converts the names in names to the values of
+// an enum type E. A string that isn't in names is an error when decoding into
+// E, and is left unchanged in a map output.
+func EnumHook[E any](names map[string]E) DecodeHookFunc {
+	enumType := reflect.TypeFor[E]()
+	return func(path string, to reflect.Type, value any) (any, error) {
+		s, ok := value.(string)
+		if !ok || (to != nil && to != enumType) {
+			return value, nil
+		}
+		if e, ok := names[s]; ok {
+			return e, nil
+		}
+		if to == nil {
+			return value, nil
+		}
+		return nil, fmt.Errorf("unknown %s %q", enumType, s)
+	}
+}
+
+// SetDecodeHook sets a hook that converts values before they are stored in
+// the output map. Passing nil removes it. A hook error is returned from the
+// Process method that completed the value, as a *HookError.
+func (sp *StreamingParser) SetDecodeHook(hook DecodeHookFunc) {
+	sp.hook = hook
+}
+
+// SetDecodeHook sets a hook that converts values before they are stored in
+// the parsed value. Passing nil removes it.
+func (p *Parser) SetDecodeHook(hook DecodeHookFunc) {
+	p.config.hook = hook
+}
+
+// applyHook runs the decoder's hook on a value about to be stored at path
+func (d *decoder) applyHook(path string, value any) (any, error) {
+	if d.hook == nil {
+		return value, nil
+	}
+	v, err := d.hook(path, nil, value)
+	if err != nil {
+		return nil, &HookError{Path: path, Err: err}
+	}
+	return v, nil
+}
@@ -0,0 +1,191 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type hookLevel int
+
+const (
+	hookLow hookLevel = iota + 1
+	hookHigh
+)
+
+var hookLevels = map[string]hookLevel{"low": hookLow, "high": hookHigh}
+
+func TestUnmarshalWithHook(t *testing.T) {
+	type task struct {
+		Due     time.Time      `json:"due"`
+		Timeout *time.Duration `json:"timeout"`
+		Level   hookLevel      `json:"level"`
+		Levels  []hookLevel    `json:"levels"`
+		Note    string         `json:"note"`
+	}
+
+	hook := ComposeDecodeHooks(
+		StringToTimeHook(time.DateOnly),
+		StringToDurationHook(),
+		EnumHook(hookLevels),
+	)
+
+	var got task
+	input := `{"due":"2024-05-06","timeout":"1m30s","level":"high","levels":["low","high"],"note":"2024-05-06"}`
+	if err := UnmarshalWithHook([]byte(input), &got, hook); err != nil {
+		t.Fatalf("UnmarshalWithHook() error = %v", err)
+	}
+
+	timeout := 90 * time.Second
+	expected := task{
+		Due:     time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		Timeout: &timeout,
+		Level:   hookHigh,
+		Levels:  []hookLevel{hookLow, hookHigh},
+		Note:    "2024-05-06",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("UnmarshalWithHook() = %+v, want %+v", got, expected)
+	}
+
+	err := UnmarshalWithHook([]byte(`{"level":"medium","levels":["low","x"]}`), &got, hook)
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) || hookErr.Path != "level" {
+		t.Errorf("UnmarshalWithHook() error = %v, want *HookError at level", err)
+	}
+}
+
+func TestStreamingParserDecodeHook(t *testing.T) {
+	output := make(map[string]any)
+	sp := NewStreamingParser(&output)
+	sp.SetDecodeHook(ComposeDecodeHooks(
+		PathDecodeHook("events[*].at", StringToTimeHook(time.RFC3339)),
+		PathDecodeHook("wait", StringToDurationHook()),
+	))
+
+	input := `{"events":[{"at":"2024-01-02T03:04:05Z","name":"2024-01-02T03:04:05Z"}],"wait":"2s","at":"now"}`
+	if err := sp.ProcessString(input); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := output["events"].([]interface{})
+	event := events[0].(map[string]any)
+	if event["at"] != at || event["name"] != "2024-01-02T03:04:05Z" {
+		t.Errorf("Event = %v", event)
+	}
+	if output["wait"] != 2*time.Second || output["at"] != "now" {
+		t.Errorf("Output = %v", output)
+	}
+
+	// A hook error stops parsing like a syntax error
+	sp.Reset()
+	err := sp.ProcessString(`{"wait":"soon"}`)
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) || hookErr.Path != "wait" {
+		t.Errorf("ProcessString() error = %v, want *HookError at wait", err)
+	}
+}
+
+func TestParserDecodeHook(t *testing.T) {
+	p := NewParser(NewLexer(`[{"level":"low"},{"level":"other"}]`).Tokenize())
+	p.SetDecodeHook(EnumHook(hookLevels))
+
+	value, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	expected := []interface{}{map[string]any{"level": hookLow}, map[string]any{"level": "other"}}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("Parse() = %#v, want %#v", value, expected)
+	}
+}
+
+func TestTypedStreamingParserDecodeHook(t *testing.T) {
+	type event struct {
+		At   time.Time `json:"at"`
+		Name string    `json:"name"`
+	}
+
+	tp := NewTypedStreamingParser[event](nil)
+	tp.SetDecodeHook(StringToTimeHook(time.RFC3339))
+	if err := tp.ProcessString(`{"at":"2024-01-02T03:04:05Z","name":"x"}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	expected := event{At: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Name: "x"}
+	if !reflect.DeepEqual(*tp.Value(), expected) {
+		t.Errorf("Value() = %+v, want %+v", *tp.Value(), expected)
+	}
+	// The output map is left as parsed
+	if tp.GetCurrentOutput()["at"] != "2024-01-02T03:04:05Z" {
+		t.Errorf("Output = %v", tp.GetCurrentOutput())
+	}
+}
+
+func TestCoerceStringsHook(t *testing.T) {
+	type reply struct {
+		Count  int     `json:"count"`
+		Small  *uint8  `json:"small"`
+		Score  float64 `json:"score"`
+		Ok     bool    `json:"ok"`
+		Name   string  `json:"name"`
+		Counts []int64 `json:"counts"`
+		Any    any     `json:"any"`
+		Flags  []bool  `json:"flags"`
+	}
+
+	var got reply
+	input := `{"count": "42", "small": " 7 ", "score": "2.5", "ok": "true", "name": "12", "counts": ["1", 2, " 3"], "any": "5", "flags": ["false", true]}`
+	if err := UnmarshalWithHook([]byte(input), &got, CoerceStringsHook()); err != nil {
+		t.Fatalf("UnmarshalWithHook() error = %v", err)
+	}
+	small := uint8(7)
+	expected := reply{Count: 42, Small: &small, Score: 2.5, Ok: true, Name: "12", Counts: []int64{1, 2, 3}, Any: "5", Flags: []bool{false, true}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("UnmarshalWithHook() = %+v, want %+v", got, expected)
+	}
+
+	// Strings that don't hold a value of the field's kind still fail
+	for _, input := range []string{`{"count": "many"}`, `{"count": "2.5"}`, `{"count": "3.0"}`, `{"small": "300"}`, `{"ok": "yes"}`, `{"ok": "1"}`} {
+		var r reply
+		var typeErr *json.UnmarshalTypeError
+		if err := UnmarshalWithHook([]byte(input), &r, CoerceStringsHook()); !errors.As(err, &typeErr) {
+			t.Errorf("UnmarshalWithHook(%s) error = %v, want *json.UnmarshalTypeError", input, err)
+		}
+	}
+
+	p := NewParser(NewLexer(`{"a": "42", "b": "-1.5e3", "c": "true", "d": "007", "e": "x"}`).Tokenize())
+	p.SetDecodeHook(CoerceStringsHook())
+	out, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := map[string]any{"a": int64(42), "b": -1500.0, "c": true, "d": "007", "e": "x"}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("Parse() = %v, want %v", out, want)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		expected      bool
+	}{
+		{"a.b", "a.b", true},
+		{"a.b", "a.bc", false},
+		{"a[*]", "a[12]", true},
+		{"a[*].b", "a[0].b", true},
+		{"a[*].b", "a[x].b", false},
+		{"a[*].b", "a.b", false},
+		{"[*][*]", "[1][2]", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.path); got != tt.expected {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package flexjson
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// httpChunkSize is the size of the reads from a response body
+const httpChunkSize = 4096
+
+// ParseHTTPStream parses the JSON object in the body of resp as it arrives,
+// for building live views of a streamed response. onSnapshot, if not nil, is
+// called after each chunk of the body is processed with a copy of the object
+// parsed so far, which it may keep. The body is closed when parsing ends.
+//
+// Parsing stops when the context of resp's request is cancelled, returning
+// the context's error, and otherwise at the end of the body. The object parsed
+// so far is returned alongside any error; a body cut off before the object is
+// closed isn't an error. For Server-Sent Events, use
+// StreamingParser.ProcessSSE instead.
+func ParseHTTPStream(resp *http.Response, onSnapshot func(snapshot map[string]any), opts ...Option) (map[string]any, error) {
+	defer resp.Body.Close()
+
+	ctx := context.Background()
+	if resp.Request != nil {
+		ctx = resp.Request.Context()
+	}
+	// Closing the body unblocks a read in progress when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { resp.Body.Close() })
+	defer stop()
+
+	output := map[string]any{}
+	sp := NewStreamingParser(&output, opts...)
+	buf := make([]byte, httpChunkSize)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if err := ctx.Err(); err != nil {
+			return output, err
+		}
+
+		if n > 0 {
+			if err := sp.ProcessBytes(buf[:n]); err != nil {
+				return output, err
+			}
+			if onSnapshot != nil {
+				onSnapshot(snapshotValue(output).(map[string]any))
+			}
+		}
+
+		if errors.Is(readErr, io.EOF) {
+			return output, nil
+		}
+		if readErr != nil {
+			return output, readErr
+		}
+	}
+}
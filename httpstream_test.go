@@ -0,0 +1,72 @@
+package flexjson
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseHTTPStream(t *testing.T) {
+	chunks := []string{`{"status": "run`, `ning", "steps": [1, `, `2]}`}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, chunk := range chunks {
+			io.WriteString(w, chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	var snapshots []map[string]any
+	result, err := ParseHTTPStream(resp, func(snapshot map[string]any) {
+		snapshots = append(snapshots, snapshot)
+	})
+	if err != nil {
+		t.Fatalf("ParseHTTPStream() error = %v", err)
+	}
+
+	expected := map[string]any{"status": "running", "steps": []interface{}{int64(1), int64(2)}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ParseHTTPStream() = %#v, want %#v", result, expected)
+	}
+	if len(snapshots) == 0 || !reflect.DeepEqual(snapshots[len(snapshots)-1], expected) {
+		t.Errorf("Last snapshot = %#v, want %#v", snapshots, expected)
+	}
+}
+
+func TestParseHTTPStreamCancel(t *testing.T) {
+	sent := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"a": 1,`)
+		w.(http.Flusher).Flush()
+		close(sent)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	result, err := ParseHTTPStream(resp, func(snapshot map[string]any) {
+		<-sent
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ParseHTTPStream() error = %v, want context.Canceled", err)
+	}
+	if want := (map[string]any{"a": int64(1)}); !reflect.DeepEqual(result, want) {
+		t.Errorf("ParseHTTPStream() = %#v, want %#v", result, want)
+	}
+}
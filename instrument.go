@@ -0,0 +1,38 @@
+package flexjson
+
+// Instrumentation holds callbacks that observability code can attach to a
+// parser, to count or time parsing without enabling the debug trace. Any of
+// them may be nil. They are called synchronously while input is being
+// processed, so they should be quick, and must not call the parser's methods.
+type Instrumentation struct {
+	// OnChunk is called with the size in bytes of each chunk a
+	// StreamingParser is given, before it is processed
+	OnChunk func(n int)
+	// OnToken is called with each token before it is decoded
+	OnToken func(tok Token)
+	// OnError is called with each error the parser stops at or, with
+	// WithRecovery, recovers from
+	OnError func(err error)
+}
+
+// WithInstrumentation attaches the callbacks in inst to the parser, e.g. to
+// record OpenTelemetry spans and counters around parse activity
+func WithInstrumentation(inst Instrumentation) Option {
+	return func(d *decoder) {
+		d.inst = inst
+	}
+}
+
+// instrumentChunk reports a chunk of n bytes to the OnChunk callback
+func (d *decoder) instrumentChunk(n int) {
+	if d.inst.OnChunk != nil {
+		d.inst.OnChunk(n)
+	}
+}
+
+// instrumentError reports err to the OnError callback
+func (d *decoder) instrumentError(err error) {
+	if d.inst.OnError != nil {
+		d.inst.OnError(err)
+	}
+}
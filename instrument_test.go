@@ -0,0 +1,47 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithInstrumentation(t *testing.T) {
+	var chunks []int
+	var tokens []TokenType
+	var errs []error
+	inst := Instrumentation{
+		OnChunk: func(n int) { chunks = append(chunks, n) },
+		OnToken: func(tok Token) { tokens = append(tokens, tok.Type) },
+		OnError: func(err error) { errs = append(errs, err) },
+	}
+
+	sp := NewStreamingParser(nil, WithInstrumentation(inst), WithRecovery())
+	for _, chunk := range []string{`{"a": x, `, `"b": [1]}`} {
+		if err := sp.ProcessString(chunk); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", chunk, err)
+		}
+	}
+
+	if want := []int{9, 9}; !reflect.DeepEqual(chunks, want) {
+		t.Errorf("chunks = %v, want %v", chunks, want)
+	}
+	want := []TokenType{
+		TokenLeftBrace, TokenString, TokenColon, TokenError, TokenComma,
+		TokenString, TokenColon, TokenLeftBracket, TokenNumber, TokenRightBracket, TokenRightBrace,
+	}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("tokens = %v, want %v", tokens, want)
+	}
+	var perr *ParseError
+	if len(errs) != 1 || !errors.As(errs[0], &perr) || perr.Code != CodeUnexpectedCharacter {
+		t.Errorf("errors = %v, want the recovered error", errs)
+	}
+
+	// Parse reports the error it stops at
+	errs = nil
+	_, err := Parse(`{"a": ]`, WithInstrumentation(inst))
+	if err == nil || len(errs) != 1 {
+		t.Errorf("Parse() error = %v, reported errors = %v", err, errs)
+	}
+}
@@ -2,7 +2,13 @@ package flexjson
 
 import (
 	"errors"
+	"fmt"
+	"iter"
 	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
 )
 
 // Token types used by the lexer
@@ -22,442 +28,916 @@ const (
 	TokenTrue
 	TokenFalse
 	TokenNull
+	TokenIdentifier // An unquoted object key, with SyntaxUnquotedKeys
 )
 
 // Token represents a JSON token
 type Token struct {
-	Type  TokenType
+	Type TokenType
+
+	// Value is the text of the token, or the decoded text of a string. It
+	// shares memory with the input, except for strings containing escapes,
+	// whose decoded text is a copy.
 	Value string
+
+	Start  int // Byte offset of the first byte of the token
+	End    int // Byte offset just past the last byte of the token
+	Line   int // 1-based line of the start of the token
+	Column int // 1-based column of the start of the token, counted in characters
+
+	// Truncated reports that the value of a string or number token was cut
+	// short by a size limit set with SetLimits
+	Truncated bool
 }
 
-// Lexer tokenizes JSON input
+// Lexer tokenizes JSON input. A Lexer created with NewLexer tokenizes a
+// complete input, and one created with NewIncrementalLexer is fed input in
+// chunks. Both run the same scanner, so tokens, escapes, and numbers come out
+// the same however the input is split.
+//
+// A character that can't start a token, or an identifier other than true,
+// false, or null, is returned as a TokenError token. A string cut off by the
+// end of the input is returned with the text decoded so far, and a literal
+// cut off by the end of the input is dropped.
 type Lexer struct {
-	input  string
-	pos    int
-	start  int
-	tokens []Token
+	input string // Input that hasn't been discarded; input[0] is at offset base
+	base  int
+	buf   []byte // Backing store of input for a lexer that is fed, grown in place
+	pos   int    // Offset of the next byte to scan
+	start int    // Offset of the token being scanned
+	final bool   // Whether no more input will be fed
+
+	// Position tracking, advanced lazily
+	located   int
+	line      int
+	column    int
+	tokLine   int // Line of the token being scanned
+	tokColumn int // Column of the token being scanned
+
+	// String decoding state, kept so a string can be scanned as its input arrives
+	scanned   int            // Offset up to which the string has been decoded (0 outside strings)
+	str       limitedBuilder // Decoded text, once the string has left the fast path
+	buffered  bool           // Whether str holds the decoded text
+	escape    int            // 1 after a backslash, 2-5 while reading \uXXXX digits
+	hex       rune           // Value of the \uXXXX digits read so far
+	surrogate rune           // High surrogate waiting for its low half
+
+	// Number and identifier scanning state, kept so they aren't rescanned
+	// from their start as their input arrives
+	scannedTo int        // Offset up to which the pending token has been scanned
+	part      numberPart // Part of the pending number reached
+
+	noise []int // Offsets of transport noise to skip between tokens
+
+	// Extensions to JSON
+	syntax  Syntax // Extensions accepted
+	quote   byte   // Quote character of the string being scanned
+	comment uint8  // Kind of comment being skipped (0 outside comments)
+
+	// Size limits
+	maxNumber  int  // Longest number kept, in bytes (0 for no limit)
+	skipNumber bool // Whether the rest of a number over the limit is being skipped
+
+	// Raw text kept for RawValue. It stays in the input, which grows in
+	// place, and is read by offset once the value ends.
+	keepText bool // Whether the text of the pending token is kept
+	holding  bool // Whether input from hold on is kept
+	hold     int  // Offset of the raw value being captured
 }
 
 // NewLexer creates a new JSON lexer
 func NewLexer(input string) *Lexer {
 	return &Lexer{
 		input:  input,
-		pos:    0,
-		start:  0,
-		tokens: []Token{},
+		line:   1,
+		column: 1,
+		final:  true,
+	}
+}
+
+// NewLexerBytes creates a lexer for input without copying it, so the values
+// of its tokens share memory with input. input must not be modified while the
+// lexer or its tokens are in use.
+func NewLexerBytes(input []byte) *Lexer {
+	return NewLexer(bytesString(input))
+}
+
+// NewIncrementalLexer creates a lexer that is fed input in chunks with Feed
+// and read with NextToken. Tokens may be split across chunk boundaries.
+func NewIncrementalLexer() *Lexer {
+	return &Lexer{
+		line:   1,
+		column: 1,
+	}
+}
+
+// reset returns an incremental lexer to its state when it was created,
+// keeping the memory of its buffers
+func (l *Lexer) reset() {
+	noise := l.noise[:0]
+	*l = Lexer{
+		line:   1,
+		column: 1,
+		noise:  noise,
+	}
+}
+
+// Feed appends a chunk of input. Input that has already been tokenized is
+// discarded, so memory use is bounded by the longest token rather than the
+// whole stream.
+func (l *Lexer) Feed(chunk []byte) {
+	l.feed(bytesString(chunk))
+}
+
+// Close marks the end of the input, so a trailing token that would otherwise
+// wait for more input is returned by NextToken, followed by a TokenEOF token
+func (l *Lexer) Close() {
+	l.final = true
+}
+
+// NextToken returns the next complete token. It returns false when more input
+// is needed to finish the next token; once the input is complete it always
+// returns a token, ending with TokenEOF.
+func (l *Lexer) NextToken() (Token, bool) {
+	for {
+		if l.scanned == 0 {
+			if !l.skipSpace() {
+				return Token{}, false
+			}
+			l.start = l.pos
+			l.mark()
+		}
+
+		if l.pos >= l.end() {
+			if !l.final {
+				return Token{}, false
+			}
+			return l.token(TokenEOF, ""), true
+		}
+
+		if tok, ok := l.scan(); ok {
+			return tok, true
+		}
+		if !l.final {
+			return Token{}, false
+		}
+		// A literal cut off by the end of the input was dropped
 	}
 }
 
 // Tokenize converts the input string into tokens
 func (l *Lexer) Tokenize() []Token {
-	for l.pos < len(l.input) {
-		l.start = l.pos
-		l.scanToken()
+	tokens := []Token{}
+	for tok := range l.Tokens() {
+		tokens = append(tokens, tok)
 	}
+	return tokens
+}
+
+// Tokens returns an iterator over the tokens of the input, ending with a
+// TokenEOF token. Tokens are scanned lazily as the loop advances and are not
+// retained, so memory use doesn't grow with the number of tokens.
+func (l *Lexer) Tokens() iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		for {
+			tok, ok := l.NextToken()
+			if !ok || !yield(tok) || tok.Type == TokenEOF {
+				return
+			}
+		}
+	}
+}
+
+// feed appends s to the input, discarding input that has been tokenized
+func (l *Lexer) feed(s string) {
+	l.discard()
+	l.append(s)
+}
 
-	// Add EOF token
-	l.tokens = append(l.tokens, Token{Type: TokenEOF})
-	return l.tokens
+// append adds s to the end of the input. The input is appended to buf in
+// place, so a long pending token isn't copied with every chunk. Bytes in buf
+// are never written again once appended, so the values of tokens already
+// returned, which may share its memory, don't change.
+func (l *Lexer) append(s string) {
+	if l.buf == nil {
+		// The input was set without buf, as by restore
+		l.buf = []byte(l.input)
+	}
+	l.buf = append(l.buf, s...)
+	l.input = bytesString(l.buf)
 }
 
-// scanToken scans the next token
-func (l *Lexer) scanToken() {
-	// Check if we're at the end of input
-	if l.pos >= len(l.input) {
+// feedNoise appends transport noise, which is skipped instead of tokenized
+func (l *Lexer) feedNoise(s string) {
+	l.discard()
+	for i := range len(s) {
+		l.noise = append(l.noise, l.end()+i)
+	}
+	l.append(s)
+}
+
+// discard drops the input before the pending token. A pending string has
+// been decoded up to scanned, so its input is dropped too.
+func (l *Lexer) discard() {
+	cut := l.pos
+	if l.scanned > 0 && !l.keepText {
+		l.buffer()
+		cut = l.scanned
+	}
+	if l.holding {
+		cut = min(cut, l.hold)
+	}
+	if cut == l.base {
 		return
 	}
 
-	c := l.input[l.pos]
+	l.locate(cut)
+	l.input = l.input[cut-l.base:]
+	if l.buf != nil {
+		l.buf = l.buf[cut-l.base:]
+	}
+	l.base = cut
+
+	kept := l.noise[:0]
+	for _, n := range l.noise {
+		if n >= cut {
+			kept = append(kept, n)
+		}
+	}
+	l.noise = kept
+}
+
+// end returns the offset just past the input fed so far
+func (l *Lexer) end() int {
+	return l.base + len(l.input)
+}
+
+// at returns the byte at offset i
+func (l *Lexer) at(i int) byte {
+	return l.input[i-l.base]
+}
+
+// text returns the input between offsets from and to
+func (l *Lexer) text(from, to int) string {
+	return l.input[from-l.base : to-l.base]
+}
+
+// isNoise reports whether the byte at offset i is transport noise
+func (l *Lexer) isNoise(i int) bool {
+	for _, n := range l.noise {
+		if n == i {
+			return true
+		}
+	}
+	return false
+}
+
+// mark records the position of the token starting at start
+func (l *Lexer) mark() {
+	l.locate(l.start)
+	l.tokLine, l.tokColumn = l.line, l.column
+}
+
+// locate advances line and column tracking to offset pos
+func (l *Lexer) locate(pos int) {
+	for ; l.located < pos; l.located++ {
+		c := l.at(l.located)
+		if c == '\n' {
+			l.line++
+			l.column = 1
+		} else if utf8.RuneStart(c) {
+			l.column++
+		}
+	}
+}
+
+// token returns a token spanning from the token start to the current position
+func (l *Lexer) token(tokenType TokenType, value string) Token {
+	return Token{
+		Type:   tokenType,
+		Value:  value,
+		Start:  l.start,
+		End:    l.pos,
+		Line:   l.tokLine,
+		Column: l.tokColumn,
+	}
+}
+
+// scan scans the token at pos. It returns false if the token may continue
+// past the end of the input fed so far.
+func (l *Lexer) scan() (Token, bool) {
+	if l.scanned > 0 {
+		// Continue the string left pending by the last chunk
+		return l.scanString()
+	}
+
+	c := l.at(l.pos)
 
 	switch c {
 	case '{':
-		l.addToken(TokenLeftBrace)
+		return l.single(TokenLeftBrace), true
 	case '}':
-		l.addToken(TokenRightBrace)
+		return l.single(TokenRightBrace), true
 	case '[':
-		l.addToken(TokenLeftBracket)
+		return l.single(TokenLeftBracket), true
 	case ']':
-		l.addToken(TokenRightBracket)
+		return l.single(TokenRightBracket), true
 	case ':':
-		l.addToken(TokenColon)
+		return l.single(TokenColon), true
 	case ',':
-		l.addToken(TokenComma)
+		return l.single(TokenComma), true
 	case '"':
-		l.scanString()
-	case ' ', '\t', '\r', '\n':
-		// Skip whitespace
-		l.pos++
+		return l.scanString()
+	}
+
+	json5 := l.syntax&syntaxJSON5 != 0
+	switch {
+	case c == '\'' && json5:
+		return l.scanString()
+	case isDigit(c) || c == '-' || (json5 && (c == '+' || c == '.')):
+		return l.scanNumber()
+	case isAlpha(c) || (l.syntax&SyntaxUnquotedKeys != 0 && isIdentifierStart(c)):
+		return l.scanIdentifier()
 	default:
-		if isDigit(c) || c == '-' {
-			l.scanNumber()
-		} else if isAlpha(c) {
-			l.scanIdentifier()
-		} else {
-			// Skip unknown characters
-			l.pos++
-		}
+		return l.scanUnknown()
 	}
 }
 
-// addToken adds a token to the token list
-func (l *Lexer) addToken(tokenType TokenType) {
-	value := string(l.input[l.pos])
-	l.tokens = append(l.tokens, Token{Type: tokenType, Value: value})
+// single returns a single-character token
+func (l *Lexer) single(tokenType TokenType) Token {
 	l.pos++
+	return l.token(tokenType, l.text(l.start, l.pos))
 }
 
-// scanString scans a string token (handling quotes and escapes)
-func (l *Lexer) scanString() {
-	l.pos++ // Skip opening quote
+// scanString scans a string token, decoding escapes the same way
+// encoding/json does. Strings without escapes take a fast path that doesn't
+// allocate. Scanning resumes where it left off when more input arrives.
+func (l *Lexer) scanString() (Token, bool) {
+	if l.scanned == 0 {
+		l.quote = l.at(l.pos)
+		l.scanned = l.pos + 1 // Skip opening quote
+		l.str.Reset()
+		l.buffered = l.str.limit > 0 // The limit is applied as the text is buffered
+		l.escape, l.hex, l.surrogate = 0, 0, 0
+	}
 
-	startPos := l.pos
+	for l.scanned < l.end() {
+		c := l.at(l.scanned)
 
-	// Continue until we find a closing quote or reach the end
-	for l.pos < len(l.input) && l.input[l.pos] != '"' {
-		if l.input[l.pos] == '\\' && l.pos+1 < len(l.input) {
-			l.pos++ // Skip escape character
+		if l.escape > 0 {
+			l.scanEscape(c)
+			continue
 		}
-		l.pos++
-	}
 
-	value := l.input[startPos:l.pos]
-	l.tokens = append(l.tokens, Token{Type: TokenString, Value: value})
+		switch c {
+		case l.quote:
+			l.flushSurrogate()
+			value := l.decoded()
+			l.pos = l.scanned + 1 // Skip closing quote
+			l.scanned = 0
+			return l.stringToken(value), true
+		case '\\':
+			l.buffer()
+			l.escape = 1
+		default:
+			if l.buffered {
+				l.flushSurrogate()
+				l.str.WriteByte(c)
+			}
+		}
+		l.scanned++
+	}
 
-	if l.pos < len(l.input) {
-		l.pos++ // Skip closing quote if it exists
+	if !l.final {
+		return Token{}, false
 	}
-}
 
-// scanNumber scans a number token
-func (l *Lexer) scanNumber() {
-	startPos := l.pos
+	// The input ended inside the string. A partial escape, or a high
+	// surrogate whose low half may have been cut off, is dropped.
+	value := l.decoded()
+	l.pos = l.scanned
+	l.scanned = 0
+	return l.stringToken(value), true
+}
 
-	// Handle minus sign
-	if l.input[l.pos] == '-' {
-		l.pos++
+// scanEscape handles the byte c inside an escape sequence
+func (l *Lexer) scanEscape(c byte) {
+	if l.escape >= escapeLineBreak {
+		l.scanJSON5Escape(c)
+		return
 	}
 
-	// Integer part
-	for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
-		l.pos++
-	}
+	if l.escape == 1 {
+		l.scanned++
+		if c == 'u' {
+			// Start of a \uXXXX escape
+			l.escape, l.hex = 2, 0
+			return
+		}
+		if l.syntax&syntaxJSON5 != 0 && l.startJSON5Escape(c) {
+			return
+		}
 
-	// Fractional part
-	if l.pos < len(l.input) && l.input[l.pos] == '.' {
-		l.pos++
-		for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
-			l.pos++
+		l.escape = 0
+		l.flushSurrogate()
+		if b, ok := unescapeChar(c); ok {
+			l.str.WriteByte(b)
+		} else {
+			// Unknown escape - keep the escaped character
+			l.str.WriteByte(c)
 		}
+		return
 	}
 
-	// Exponent part
-	if l.pos < len(l.input) && (l.input[l.pos] == 'e' || l.input[l.pos] == 'E') {
-		l.pos++
-		if l.pos < len(l.input) && (l.input[l.pos] == '+' || l.input[l.pos] == '-') {
-			l.pos++
-		}
-		for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
-			l.pos++
-		}
+	d, ok := hexValue(c)
+	if !ok {
+		// An invalid \uXXXX escape decodes to the replacement character,
+		// and c is scanned as part of the string
+		l.escape = 0
+		l.appendRune(utf8.RuneError)
+		return
 	}
 
-	value := l.input[startPos:l.pos]
-	l.tokens = append(l.tokens, Token{Type: TokenNumber, Value: value})
+	l.scanned++
+	l.hex = l.hex<<4 | d
+	if l.escape++; l.escape == 6 {
+		l.escape = 0
+		l.appendRune(l.hex)
+	}
 }
 
-// scanIdentifier scans identifiers like true, false, null
-func (l *Lexer) scanIdentifier() {
-	startPos := l.pos
-
-	for l.pos < len(l.input) && isAlphaNumeric(l.input[l.pos]) {
-		l.pos++
+// appendRune appends a decoded \uXXXX rune, pairing up surrogates
+func (l *Lexer) appendRune(r rune) {
+	if l.surrogate != 0 {
+		high := l.surrogate
+		l.surrogate = 0
+		if dec := utf16.DecodeRune(high, r); dec != utf8.RuneError {
+			l.str.WriteRune(dec)
+			return
+		}
+		l.str.WriteRune(utf8.RuneError)
 	}
 
-	value := l.input[startPos:l.pos]
-
-	// Check which identifier it is
-	switch value {
-	case "true":
-		l.tokens = append(l.tokens, Token{Type: TokenTrue, Value: value})
-	case "false":
-		l.tokens = append(l.tokens, Token{Type: TokenFalse, Value: value})
-	case "null":
-		l.tokens = append(l.tokens, Token{Type: TokenNull, Value: value})
-	default:
-		// Skip unknown identifiers
+	if utf16.IsSurrogate(r) {
+		if r < 0xdc00 {
+			// High surrogate - wait for the low half, which may arrive in a later chunk
+			l.surrogate = r
+			return
+		}
+		r = utf8.RuneError
 	}
+	l.str.WriteRune(r)
 }
 
-// Helper functions
-func isDigit(c byte) bool {
-	return c >= '0' && c <= '9'
+// flushSurrogate writes a replacement character for an unpaired high surrogate
+func (l *Lexer) flushSurrogate() {
+	if l.surrogate != 0 {
+		l.str.WriteRune(utf8.RuneError)
+		l.surrogate = 0
+	}
 }
 
-func isAlpha(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+// buffer copies the text decoded on the fast path into str
+func (l *Lexer) buffer() {
+	if !l.buffered {
+		l.str.WriteString(l.text(l.pos+1, l.scanned))
+		l.buffered = true
+	}
 }
 
-func isAlphaNumeric(c byte) bool {
-	return isAlpha(c) || isDigit(c)
+// decoded returns the text of the string being scanned, decoded so far
+func (l *Lexer) decoded() string {
+	if l.buffered {
+		return l.str.String()
+	}
+	return l.text(l.pos+1, l.scanned)
 }
 
-// Parser parses tokens into a JSON value
-type Parser struct {
-	tokens  []Token
-	current int
-}
+// scanNumber scans a number token
+func (l *Lexer) scanNumber() (Token, bool) {
+	if l.syntax&syntaxJSON5 != 0 {
+		return l.scanJSON5Number()
+	}
 
-// NewParser creates a new JSON parser
-func NewParser(tokens []Token) *Parser {
-	return &Parser{
-		tokens:  tokens,
-		current: 0,
+	i, end := l.resumeScan()
+	for i < end {
+		next, ok := l.part.next(l.at(i), false)
+		if !ok {
+			break
+		}
+		l.part = next
+		i++
+	}
+
+	if l.maxNumber > 0 && i-l.start > l.maxNumber {
+		return l.longNumber(i), true
+	}
+
+	// More of the number may follow in the next chunk
+	if i == end && !l.final {
+		l.scannedTo = i
+		return Token{}, false
 	}
+
+	l.pos = i
+	return l.token(TokenNumber, l.text(l.start, l.pos)), true
 }
 
-// Parse parses tokens into a JSON value
-func (p *Parser) Parse() (interface{}, error) {
-	if len(p.tokens) == 0 {
-		return nil, errors.New("no tokens to parse")
+// resumeScan returns the offset to continue scanning the pending number or
+// identifier from, which is past the input already scanned if its scan was
+// left waiting for more input, and the end of the input
+func (l *Lexer) resumeScan() (int, int) {
+	if l.scannedTo <= l.pos {
+		l.scannedTo, l.part = l.pos, numberStart
 	}
+	return l.scannedTo, l.end()
+}
 
-	value, err := p.parseValue()
-	if err != nil {
-		return nil, err
+// numberPart is the part of a number that scanning has reached
+type numberPart uint8
+
+const (
+	numberStart    numberPart = iota // Nothing scanned yet
+	numberInteger                    // The sign or digits of the integer part
+	numberFraction                   // The decimal point or digits after it
+	numberExponent                   // The e or E starting the exponent
+	numberExpDigit                   // The exponent's sign or digits
+	numberHex                        // The digits of a JSON5 hexadecimal number
+)
+
+// next returns the part of a number reached after c, and false if c doesn't
+// continue the number. JSON5 numbers may start with '+' or '.'; a JSON5
+// hexadecimal number is switched to by the caller.
+func (p numberPart) next(c byte, json5 bool) (numberPart, bool) {
+	switch {
+	case p == numberHex:
+		_, ok := hexValue(c)
+		return p, ok
+	case isDigit(c):
+		switch p {
+		case numberStart:
+			return numberInteger, true
+		case numberExponent:
+			return numberExpDigit, true
+		}
+		return p, true
+	case p == numberStart && (c == '-' || (json5 && c == '+')):
+		return numberInteger, true
+	case c == '.' && (p == numberInteger || (json5 && p == numberStart)):
+		return numberFraction, true
+	case (c == 'e' || c == 'E') && (p == numberInteger || p == numberFraction):
+		return numberExponent, true
+	case (c == '+' || c == '-') && p == numberExponent:
+		return numberExpDigit, true
 	}
-	return value, nil
+	return p, false
 }
 
-// parseValue parses any JSON value
-func (p *Parser) parseValue() (interface{}, error) {
-	if p.isAtEnd() {
-		return nil, errors.New("unexpected end of JSON")
+// scanIdentifier scans the literals true, false, and null. Scanning stops at
+// the first character that can't continue a literal, so anything else is
+// reported without waiting for the rest of the identifier.
+func (l *Lexer) scanIdentifier() (Token, bool) {
+	if l.syntax&(syntaxJSON5|SyntaxUnquotedKeys|SyntaxPythonLiterals) != 0 {
+		return l.scanWord()
 	}
 
-	token := p.peek()
+	i, end := l.pos, l.end()
 
-	switch token.Type {
-	case TokenLeftBrace:
-		return p.parseObject()
-	case TokenLeftBracket:
-		return p.parseArray()
-	case TokenString:
-		p.advance()
-		return token.Value, nil
-	case TokenNumber:
-		p.advance()
-		// Try parsing as int first
-		if i, err := strconv.ParseInt(token.Value, 10, 64); err == nil {
-			return i, nil
+	for i < end && isAlphaNumeric(l.at(i)) {
+		i++
+		word := l.text(l.pos, i)
+		if !isLiteralPrefix(word) {
+			l.pos = i
+			return l.token(TokenError, word), true
 		}
-		// Try parsing as float
-		if f, err := strconv.ParseFloat(token.Value, 64); err == nil {
-			return f, nil
+		if tokenType, ok := literalType(word); ok {
+			l.pos = i
+			return l.token(tokenType, word), true
 		}
-		return nil, errors.New("invalid number: " + token.Value)
-	case TokenTrue:
-		p.advance()
-		return true, nil
-	case TokenFalse:
-		p.advance()
-		return false, nil
-	case TokenNull:
-		p.advance()
-		return nil, nil
-	case TokenEOF:
-		return nil, errors.New("unexpected end of JSON")
-	default:
-		p.advance()
-		return nil, errors.New("unexpected token: " + token.Value)
 	}
+
+	if i == end {
+		if l.final {
+			// Drop a literal cut off by the end of the input
+			l.pos = i
+		}
+		return Token{}, false
+	}
+
+	// The identifier ended before completing a literal
+	word := l.text(l.pos, i)
+	l.pos = i
+	return l.token(TokenError, word), true
+}
+
+// scanUnknown scans a character that can't start a token
+func (l *Lexer) scanUnknown() (Token, bool) {
+	rest := l.text(l.pos, l.end())
+	if !utf8.FullRuneInString(rest) && !l.final {
+		return Token{}, false
+	}
+
+	_, size := utf8.DecodeRuneInString(rest)
+	if mark, _ := byteOrderMark(rest); l.pos == 0 && mark != "" {
+		// Reported as a whole so the decoder can name the encoding
+		size = len(mark)
+	}
+	l.pos += size
+	return l.token(TokenError, rest[:size]), true
 }
 
-// parseObject parses a JSON object, handling incomplete objects
-func (p *Parser) parseObject() (map[string]interface{}, error) {
-	obj := make(map[string]interface{})
+// inString reports whether a string token is being scanned
+func (l *Lexer) inString() bool {
+	return l.scanned > 0
+}
+
+// escaping reports whether the input ends inside an escape sequence
+func (l *Lexer) escaping() bool {
+	return l.escape > 0
+}
 
-	// Consume the left brace
-	p.advance()
+// pending reports whether the input ends with an unfinished token
+func (l *Lexer) pending() bool {
+	return l.scanned > 0 || l.pos < l.end()
+}
 
-	// Handle empty object
-	if p.check(TokenRightBrace) {
-		p.advance()
-		return obj, nil
+// pendingText returns the text of the unfinished token: the decoded text of
+// a string, or the characters of a number or literal
+func (l *Lexer) pendingText() string {
+	if l.scanned > 0 {
+		return l.decoded()
 	}
+	return l.text(l.pos, l.end())
+}
 
-	for {
-		// End of input - return partial object
-		if p.isAtEnd() {
-			return obj, nil
-		}
+// Helper functions
 
-		// We need a string key
-		if !p.check(TokenString) {
-			// If we don't have a string key but we have EOF, return what we have
-			if p.check(TokenEOF) {
-				return obj, nil
-			}
-			return nil, errors.New("expected string key in object")
-		}
+// bytesString returns b as a string without copying it
+func bytesString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
 
-		// Get the key
-		key := p.peek().Value
-		p.advance()
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
 
-		// We need a colon
-		if !p.check(TokenColon) {
-			// If we don't have a colon but we have EOF, set value to nil and return
-			if p.check(TokenEOF) {
-				obj[key] = nil
-				return obj, nil
-			}
-			return nil, errors.New("expected ':' after key in object")
-		}
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
 
-		// Consume the colon
-		p.advance()
+func isAlphaNumeric(c byte) bool {
+	return isAlpha(c) || isDigit(c)
+}
 
-		// Handle EOF after colon
-		if p.check(TokenEOF) {
-			obj[key] = nil
-			return obj, nil
-		}
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
 
-		// Parse the value
-		value, err := p.parseValue()
-		if err != nil {
-			// If we have an error and we're at EOF, just set to nil and return
-			if p.check(TokenEOF) {
-				obj[key] = nil
-				return obj, nil
-			}
-			return nil, err
-		}
+// isLiteralPrefix reports whether word is the start of true, false, or null
+func isLiteralPrefix(word string) bool {
+	return strings.HasPrefix("true", word) || strings.HasPrefix("false", word) || strings.HasPrefix("null", word)
+}
 
-		// Add the key-value pair
-		obj[key] = value
+// literalType returns the token type of a complete literal
+func literalType(word string) (TokenType, bool) {
+	switch word {
+	case "true":
+		return TokenTrue, true
+	case "false":
+		return TokenFalse, true
+	case "null":
+		return TokenNull, true
+	}
+	return TokenError, false
+}
 
-		// Check for comma or right brace
-		if !p.check(TokenComma) && !p.check(TokenRightBrace) {
-			// If we don't have a comma or right brace but have EOF, return what we have
-			if p.check(TokenEOF) {
-				return obj, nil
-			}
-			return nil, errors.New("expected ',' or '}' after object value")
-		}
+// parseNumber converts number text to an int64, falling back to float64
+func parseNumber(s string) (interface{}, bool) {
+	// Try parsing as int first
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, true
+	}
+	// Try parsing as float
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, true
+	}
+	return nil, false
+}
 
-		// If we're at the end of the object, we're done
-		if p.check(TokenRightBrace) {
-			p.advance()
-			return obj, nil
-		}
+// validNumber reports whether s is a number, even one too large for a float64
+func validNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil || errors.Is(err, strconv.ErrRange)
+}
 
-		// Consume the comma
-		p.advance()
+// unescapeChar returns the byte a single-character escape sequence stands for
+func unescapeChar(c byte) (byte, bool) {
+	switch c {
+	case '"', '\\', '/':
+		return c, true
+	case 'b':
+		return '\b', true
+	case 'f':
+		return '\f', true
+	case 'n':
+		return '\n', true
+	case 'r':
+		return '\r', true
+	case 't':
+		return '\t', true
+	}
+	return 0, false
+}
 
-		// Handle trailing comma at EOF
-		if p.check(TokenEOF) {
-			return obj, nil
-		}
+// hexValue returns the value of a hex digit
+func hexValue(c byte) (rune, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return rune(c - '0'), true
+	case c >= 'a' && c <= 'f':
+		return rune(c - 'a' + 10), true
+	case c >= 'A' && c <= 'F':
+		return rune(c - 'A' + 10), true
 	}
+	return 0, false
 }
 
-// parseArray parses a JSON array, handling incomplete arrays
-func (p *Parser) parseArray() ([]interface{}, error) {
-	arr := make([]interface{}, 0)
+// Parser parses tokens into a JSON value. It runs the same decoder as
+// StreamingParser, so both accept the same input and build the same values.
+type Parser struct {
+	tokens   []Token
+	current  int
+	hardened bool
+	config   decoder       // Settings copied into the decoder for each Parse
+	issues   []*ParseError // Errors the last Parse recovered from
+	complete bool          // Whether the last Parse finished the root value
+}
 
-	// Consume the left bracket
-	p.advance()
+// NewParser creates a new JSON parser
+func NewParser(tokens []Token, opts ...Option) *Parser {
+	p := &Parser{
+		tokens:  tokens,
+		current: 0,
+	}
+	for _, opt := range opts {
+		opt(&p.config)
+	}
+	return p
+}
 
-	// Handle empty array
-	if p.check(TokenRightBracket) {
-		p.advance()
-		return arr, nil
+// Parse parses tokens into a JSON value. Objects are returned as
+// map[string]interface{} and arrays as []interface{}. Tokens after the first
+// complete value are ignored. With WithBestEffort, the value parsed so far is
+// returned alongside an error, and tokens that end before the value is
+// complete return ErrPartial.
+func (p *Parser) Parse() (value interface{}, err error) {
+	if p.hardened {
+		defer recoverInternal(&err, p.dumpState)
 	}
 
-	for {
-		// End of input - return partial array
-		if p.isAtEnd() {
-			return arr, nil
+	d := &decoder{}
+	*d = p.config
+	defer func() { p.issues = d.issues }()
+	p.complete = false
+	truncated := false
+	for p.current < len(p.tokens) && d.state != stateDone {
+		tok := p.tokens[p.current]
+		p.current++
+		truncated = tok.Type == TokenEOF && len(d.stack) > 0
+		if err := d.token(tok); err != nil {
+			return d.bestEffortResult(), err
 		}
+	}
 
-		// Parse the value
-		value, err := p.parseValue()
-		if err != nil {
-			// If we have an error but we're at EOF, return what we have
-			if p.check(TokenEOF) {
-				return arr, nil
-			}
-			return nil, err
+	if d.state != stateDone {
+		// The tokens ended without a TokenEOF token
+		end := Token{Type: TokenEOF}
+		if len(p.tokens) > 0 {
+			last := p.tokens[len(p.tokens)-1]
+			end.Start, end.Line, end.Column = last.Start, last.Line, last.Column
+		}
+		truncated = len(d.stack) > 0
+		if err := d.token(end); err != nil {
+			return d.bestEffortResult(), err
 		}
+	}
+	d.syncArrays()
+	if truncated && d.bestEffort {
+		return d.result, ErrPartial
+	}
+	p.complete = !truncated
+	return d.result, nil
+}
 
-		// Add the value
-		arr = append(arr, value)
+// SetHardened enables hardened mode, in which an internal panic is returned
+// as an *InternalError instead of crashing the caller
+func (p *Parser) SetHardened(value bool) {
+	p.hardened = value
+}
 
-		// Check for comma or right bracket
-		if !p.check(TokenComma) && !p.check(TokenRightBracket) {
-			// If we don't have a comma or right bracket but have EOF, return what we have
-			if p.check(TokenEOF) {
-				return arr, nil
-			}
-			return nil, errors.New("expected ',' or ']' after array value")
-		}
+// UseNumber causes the parser to store numbers as json.Number, keeping their
+// text as written, instead of as int64 or float64
+func (p *Parser) UseNumber() {
+	p.config.numbers = numberJSON
+}
 
-		// If we're at the end of the array, we're done
-		if p.check(TokenRightBracket) {
-			p.advance()
-			return arr, nil
-		}
+// UseBigNumbers causes the parser to store integers that don't fit in an
+// int64 as *big.Int, and numbers that a float64 can't hold exactly (beyond
+// the usual rounding of decimal fractions) as *big.Float. Other numbers are
+// stored as int64 or float64 as usual.
+func (p *Parser) UseBigNumbers() {
+	p.config.numbers = numberBig
+}
 
-		// Consume the comma
-		p.advance()
+// UseFloat64 causes the parser to store every number as a float64, as
+// encoding/json does when decoding into an interface value, so results can
+// be compared with its output
+func (p *Parser) UseFloat64() {
+	p.config.numbers = numberFloat64
+}
 
-		// Handle trailing comma at EOF
-		if p.check(TokenEOF) {
-			return arr, nil
-		}
-	}
+// dumpState describes the parser state for an InternalError
+func (p *Parser) dumpState() string {
+	return fmt.Sprintf("current: %d, tokens: %d", p.current, len(p.tokens))
 }
 
-// Helper methods for parser
-func (p *Parser) advance() {
-	if !p.isAtEnd() {
-		p.current++
+// tokenError builds a ParseError for an unexpected token
+func tokenError(token Token, code ErrorCode, expected string) *ParseError {
+	got := token.Value
+	switch token.Type {
+	case TokenEOF:
+		got = ""
+		code = CodeUnexpectedEOF
+	case TokenString:
+		got = strconv.Quote(token.Value)
+	}
+	return &ParseError{
+		Code:     code,
+		Offset:   token.Start,
+		Line:     token.Line,
+		Column:   token.Column,
+		Got:      got,
+		Expected: expected,
 	}
 }
 
-func (p *Parser) peek() Token {
-	return p.tokens[p.current]
+// Parse parses a partial JSON string into a map[string]any. It always runs in
+// hardened mode, so an internal panic is returned as an *InternalError. With
+// WithRecovery, the errors recovered from are returned joined with
+// errors.Join alongside the object, and with WithBestEffort, the object
+// parsed so far is returned alongside any error.
+func Parse(input string, opts ...Option) (obj map[string]any, err error) {
+	defer recoverInternal(&err, nil)
+	return NewParser(nil, opts...).parseObject(input)
 }
 
-func (p *Parser) check(tokenType TokenType) bool {
-	if p.isAtEnd() {
-		return tokenType == TokenEOF
-	}
-	return p.peek().Type == tokenType
+// ParseBytes parses input like Parse, without first copying it into a
+// string. Strings in the result may share memory with input, so input must
+// not be modified while the result is in use; a buffer that is reused for
+// the next read should be parsed with Parse(string(buf)) instead. UTF-16 and
+// UTF-32 input is converted with ToUTF8 first.
+func ParseBytes(input []byte, opts ...Option) (obj map[string]any, err error) {
+	defer recoverInternal(&err, nil)
+	return NewParser(nil, opts...).parseObject(bytesString(ToUTF8(input)))
 }
 
-func (p *Parser) isAtEnd() bool {
-	return p.current >= len(p.tokens) || p.tokens[p.current].Type == TokenEOF
+// ParseWithStatus parses input like Parse, and also reports whether the
+// document was complete: whether the root object was closed, so the input
+// wasn't cut off. Input that is cut off, or that stops with an error, is not
+// complete.
+func ParseWithStatus(input string, opts ...Option) (obj map[string]any, complete bool, err error) {
+	defer recoverInternal(&err, nil)
+	p := NewParser(nil, opts...)
+	obj, err = p.parseObject(input)
+	return obj, p.complete, err
 }
 
-// Parse parses a partial JSON string into a map[string]any
-func Parse(input string) (map[string]any, error) {
+// parseObject parses input, which must hold an object, for Parse
+func (p *Parser) parseObject(input string) (map[string]any, error) {
 	lexer := NewLexer(input)
+	p.config.configureLexer(lexer)
 	tokens := lexer.Tokenize()
+	p.tokens = tokens
 
-	parser := NewParser(tokens)
-	result, err := parser.Parse()
-	if err != nil {
-		return nil, err
+	p.SetHardened(true)
+	result, err := p.Parse()
+	if err != nil && !p.config.bestEffort {
+		return nil, locateError(err, input)
 	}
+	err = withIssues(locateError(err, input), p.issues, input)
 
 	// If result is already a map, return it
 	if obj, ok := result.(map[string]interface{}); ok {
 		// In Go 1.18+, map[string]any is the same as map[string]interface{}
-		return obj, nil
+		return obj, err
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	// If result is something else, return an error
-	return nil, errors.New("input is not a JSON object")
+	p.complete = false
+	perr := tokenError(tokens[0], CodeNotAnObject, "object")
+	perr.locate(input)
+	return nil, perr
 }
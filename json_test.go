@@ -1,7 +1,9 @@
 package flexjson
 
 import (
+	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -216,3 +218,351 @@ func TestEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestLexerStringEscapes(t *testing.T) {
+	// Complete documents should decode exactly like encoding/json
+	inputs := []string{
+		`{"plain": "hello"}`,
+		`{"simple": "a\"b\\c\/d\be\ff\ng\rh\ti"}`,
+		`{"unicode": "caf\u00e9"}`,
+		`{"upper": "\u00C9\u00c9"}`,
+		`{"pair": "\ud83d\ude00"}`,
+		`{"lone high": "\ud83dx"}`,
+		`{"lone low": "\ude00"}`,
+		`{"high high": "\ud83d\ud83d"}`,
+		`{"keyA": "value"}`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			var expected map[string]any
+			if err := json.Unmarshal([]byte(input), &expected); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+
+			result, err := Parse(input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("Parse() = %q, want %q", result, expected)
+			}
+		})
+	}
+}
+
+func TestLexerPartialStringEscapes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+	}{
+		{
+			name:     "Trailing backslash",
+			input:    `{"key": "abc\`,
+			expected: map[string]any{"key": "abc"},
+		},
+		{
+			name:     "Truncated unicode escape",
+			input:    `{"key": "abc\u00`,
+			expected: map[string]any{"key": "abc"},
+		},
+		{
+			name:     "Truncated surrogate pair",
+			input:    `{"key": "abc\ud83d\ude`,
+			expected: map[string]any{"key": "abc"},
+		},
+		{
+			name:     "High surrogate at end of input",
+			input:    `{"key": "abc\ud83d`,
+			expected: map[string]any{"key": "abc"},
+		},
+		{
+			name:     "Complete escapes before truncation",
+			input:    `{"key": "caf\u00e9\n`,
+			expected: map[string]any{"key": "café\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Parse() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLexerTokenPositions(t *testing.T) {
+	input := "{\"a\": [1, true],\n  \"é\": \"x\\ny\"}"
+
+	tokens := NewLexer(input).Tokenize()
+
+	expected := []Token{
+		{Type: TokenLeftBrace, Value: "{", Start: 0, End: 1, Line: 1, Column: 1},
+		{Type: TokenString, Value: "a", Start: 1, End: 4, Line: 1, Column: 2},
+		{Type: TokenColon, Value: ":", Start: 4, End: 5, Line: 1, Column: 5},
+		{Type: TokenLeftBracket, Value: "[", Start: 6, End: 7, Line: 1, Column: 7},
+		{Type: TokenNumber, Value: "1", Start: 7, End: 8, Line: 1, Column: 8},
+		{Type: TokenComma, Value: ",", Start: 8, End: 9, Line: 1, Column: 9},
+		{Type: TokenTrue, Value: "true", Start: 10, End: 14, Line: 1, Column: 11},
+		{Type: TokenRightBracket, Value: "]", Start: 14, End: 15, Line: 1, Column: 15},
+		{Type: TokenComma, Value: ",", Start: 15, End: 16, Line: 1, Column: 16},
+		{Type: TokenString, Value: "é", Start: 19, End: 23, Line: 2, Column: 3},
+		{Type: TokenColon, Value: ":", Start: 23, End: 24, Line: 2, Column: 6},
+		{Type: TokenString, Value: "x\ny", Start: 25, End: 31, Line: 2, Column: 8},
+		{Type: TokenRightBrace, Value: "}", Start: 31, End: 32, Line: 2, Column: 14},
+		{Type: TokenEOF, Value: "", Start: 32, End: 32, Line: 2, Column: 15},
+	}
+
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Tokenize() =\n%+v\nwant\n%+v", tokens, expected)
+	}
+
+	// Each non-EOF token's span should slice back to its source text
+	for _, tok := range tokens[:len(tokens)-1] {
+		if tok.Type != TokenString && input[tok.Start:tok.End] != tok.Value {
+			t.Errorf("Token %+v spans %q", tok, input[tok.Start:tok.End])
+		}
+	}
+}
+
+func TestLexerTokens(t *testing.T) {
+	input := "{\"a\": [1, \"x\\ny\", null],\n \"b\": {}}"
+
+	var tokens []Token
+	for tok := range NewLexer(input).Tokens() {
+		tokens = append(tokens, tok)
+	}
+
+	expected := NewLexer(input).Tokenize()
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Tokens() =\n%+v\nwant\n%+v", tokens, expected)
+	}
+
+	// Stopping early doesn't scan the rest of the input
+	l := NewLexer(input)
+	for tok := range l.Tokens() {
+		if tok.Type == TokenLeftBracket {
+			break
+		}
+	}
+	if l.pos != 7 {
+		t.Errorf("Lexer advanced to %d after breaking, want 7", l.pos)
+	}
+}
+
+func TestNewLexerBytes(t *testing.T) {
+	input := "{\"a\": [1, \"x\\ny\", null],\n \"b\": {\"c\": \"d\"}}"
+
+	got := NewLexerBytes([]byte(input)).Tokenize()
+	expected := NewLexer(input).Tokenize()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Tokenize() =\n%+v\nwant\n%+v", got, expected)
+	}
+
+	// Tokens without escapes are slices of the input, so scanning them
+	// doesn't allocate
+	data := []byte(`{"key": "value", "list": [1, 2.5, -3e4, true, false, null], "nested": {"a": "b"}}`)
+	allocs := testing.AllocsPerRun(100, func() {
+		l := NewLexerBytes(data)
+		for tok, ok := l.NextToken(); ok && tok.Type != TokenEOF; tok, ok = l.NextToken() {
+		}
+	})
+	if allocs > 1 {
+		t.Errorf("tokenizing allocated %v times, want at most 1", allocs)
+	}
+}
+
+func TestIncrementalLexer(t *testing.T) {
+	input := "{\"greeting\": \"h\\u00e9llo \\\"w\\\"\", \"n\": -12.5e3,\n \"ü\": \"ö\", \"ok\": [true, false, null]}"
+	expected := NewLexer(input).Tokenize()
+
+	// Every way of splitting the input into two chunks yields the same tokens
+	for split := 0; split <= len(input); split++ {
+		l := NewIncrementalLexer()
+
+		var tokens []Token
+		drain := func() {
+			for {
+				tok, ok := l.NextToken()
+				if !ok {
+					return
+				}
+				tokens = append(tokens, tok)
+				if tok.Type == TokenEOF {
+					return
+				}
+			}
+		}
+
+		l.Feed([]byte(input[:split]))
+		drain()
+		l.Feed([]byte(input[split:]))
+		drain()
+		l.Close()
+		drain()
+
+		if !reflect.DeepEqual(tokens, expected) {
+			t.Fatalf("Split at %d: tokens =\n%+v\nwant\n%+v", split, tokens, expected)
+		}
+	}
+}
+
+func TestIncrementalLexerWaitsForInput(t *testing.T) {
+	l := NewIncrementalLexer()
+	l.Feed([]byte(`{"a": 12`))
+
+	var values []string
+	for {
+		tok, ok := l.NextToken()
+		if !ok {
+			break
+		}
+		values = append(values, tok.Value)
+	}
+
+	// The number may continue in the next chunk
+	if !reflect.DeepEqual(values, []string{"{", "a", ":"}) {
+		t.Errorf("Tokens before more input = %q", values)
+	}
+
+	l.Close()
+	tok, ok := l.NextToken()
+	if !ok || tok.Type != TokenNumber || tok.Value != "12" || tok.Start != 6 {
+		t.Errorf("NextToken() after Close = %+v, %v", tok, ok)
+	}
+	if tok, ok := l.NextToken(); !ok || tok.Type != TokenEOF {
+		t.Errorf("NextToken() at end = %+v, %v", tok, ok)
+	}
+}
+
+func TestIncrementalLexerLongToken(t *testing.T) {
+	digits := strings.Repeat("7", 100000)
+	tests := []struct {
+		name   string
+		syntax Syntax
+		input  string
+		want   Token
+	}{
+		{"number", 0, "[-" + digits + ".5e1]", Token{Type: TokenNumber, Value: "-" + digits + ".5e1"}},
+		{"JSON5 number", SyntaxJSON5, "[+" + digits + "]", Token{Type: TokenNumber, Value: digits}},
+		{"unquoted key", SyntaxUnquotedKeys, "{k" + digits + ": 1}", Token{Type: TokenIdentifier, Value: "k" + digits}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Token
+			// Each byte is scanned once, and the input grows in place, so
+			// the cost doesn't grow with the square of the token's length
+			allocs := testing.AllocsPerRun(1, func() {
+				l := NewIncrementalLexer()
+				l.SetSyntax(tt.syntax)
+				for i := range len(tt.input) {
+					l.Feed([]byte(tt.input[i : i+1]))
+					for tok, ok := l.NextToken(); ok; tok, ok = l.NextToken() {
+						if tok.Type == tt.want.Type {
+							got = tok
+						}
+					}
+				}
+			})
+			if got.Value != tt.want.Value {
+				t.Errorf("Token value has %d bytes, want %d", len(got.Value), len(tt.want.Value))
+			}
+			if allocs > 100 {
+				t.Errorf("feeding %d bytes one at a time allocated %v times, want at most 100", len(tt.input), allocs)
+			}
+		})
+	}
+}
+
+func TestParserUseNumber(t *testing.T) {
+	p := NewParser(NewLexer(`{"id":9007199254740993,"ratio":0.10,"big":1e400,"list":[1,-2`).Tokenize())
+	p.UseNumber()
+
+	value, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	expected := map[string]any{
+		"id":    json.Number("9007199254740993"),
+		"ratio": json.Number("0.10"),
+		"big":   json.Number("1e400"),
+		"list":  []interface{}{json.Number("1"), json.Number("-2")},
+	}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("Parse() = %#v, want %#v", value, expected)
+	}
+}
+
+func TestParseWithStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []Option
+		expected map[string]any
+		complete bool
+		wantErr  bool
+	}{
+		{"complete", `{"a": [1, 2]}`, nil, map[string]any{"a": []interface{}{int64(1), int64(2)}}, true, false},
+		{"trailing text", `{"a": 1} and more`, nil, map[string]any{"a": int64(1)}, true, false},
+		{"open object", `{"a": 1`, nil, map[string]any{"a": int64(1)}, false, false},
+		{"open string", `{"a": "x`, nil, map[string]any{"a": "x"}, false, false},
+		{"open nested array", `{"a": [1}`, nil, nil, false, true},
+		{"empty", ``, nil, nil, false, true},
+		{"not an object", `[1]`, nil, nil, false, true},
+		{"recovered", `{"a": x, "b": 2}`, []Option{WithRecovery()}, map[string]any{"b": int64(2)}, true, true},
+		{"best effort", `{"a": 1`, []Option{WithBestEffort()}, map[string]any{"a": int64(1)}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, complete, err := ParseWithStatus(tt.input, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseWithStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if complete != tt.complete {
+				t.Errorf("ParseWithStatus() complete = %v, want %v", complete, tt.complete)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseWithStatus() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+		wantErr  bool
+	}{
+		{"complete", `{"a": "b", "c": [1, true]}`, map[string]any{"a": "b", "c": []interface{}{int64(1), true}}, false},
+		{"partial", `{"a": "b\\n`, map[string]any{"a": `b\n`}, false},
+		{"invalid", `{"a": ]`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseBytes([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseBytes() = %v, want %v", result, tt.expected)
+			}
+			want, wantErr := Parse(tt.input)
+			if !reflect.DeepEqual(result, want) || (err == nil) != (wantErr == nil) {
+				t.Errorf("ParseBytes() = %v, %v; Parse() = %v, %v", result, err, want, wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,603 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidJSONPath is wrapped by the errors CompileJSONPath returns for
+// malformed expressions
+var ErrInvalidJSONPath = errors.New("invalid JSONPath")
+
+// Match is a value selected by a JSONPath expression
+type Match struct {
+	Path     string // Path of the value, in the format described in path.go
+	Value    any    // The value, which for an object or array is the live container
+	Complete bool   // Whether the value is final; an open object or array may still grow
+}
+
+// JSONPath is a compiled JSONPath expression. It supports the subset of
+// JSONPath that is useful on model output:
+//
+//   - the root "$", and child members as ".name", "['name']", or `["name"]`
+//   - wildcards ".*" and "[*]"
+//   - array indices "[0]", counting from the end when negative "[-1]"
+//   - slices "[1:3]", with either bound optional
+//   - unions "[0,2]" and "['a','b']"
+//   - recursive descent "..name", "..*", and "..[0]"
+//   - filters "[?(@.role == 'user')]" comparing a member with a string,
+//     number, true, false, or null with ==, !=, <, <=, >, or >=, and
+//     "[?(@.name)]" testing that a member is present
+//
+// Object members are visited in sorted key order, or in document order for
+// an *OrderedMap.
+type JSONPath struct {
+	expr  string
+	steps []jsonPathStep
+}
+
+// jsonPathStep selects children of each node, or of each node and all of its
+// descendants when recursive
+type jsonPathStep struct {
+	recursive bool
+	selectors []jsonPathSelector
+}
+
+// jsonPathSelector is one selector of a step
+type jsonPathSelector struct {
+	kind   selectorKind
+	key    string
+	index  int
+	start  *int // Slice bounds (nil when omitted)
+	end    *int
+	filter *jsonPathFilter
+}
+
+type selectorKind uint8
+
+const (
+	selectKey selectorKind = iota
+	selectIndex
+	selectWildcard
+	selectSlice
+	selectFilter
+)
+
+// jsonPathFilter tests a member of each child against a literal
+type jsonPathFilter struct {
+	path  *JSONPath // Relative path of the member tested, from '@'
+	op    string    // Comparison operator ("" to test presence)
+	value any       // Literal compared with
+}
+
+// CompileJSONPath parses a JSONPath expression
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	c := &jsonPathCompiler{expr: expr}
+	p, err := c.path('$')
+	if err != nil {
+		return nil, err
+	}
+	if c.pos < len(expr) {
+		return nil, c.errorf("unexpected %q", expr[c.pos:])
+	}
+	return p, nil
+}
+
+// MustCompileJSONPath is like CompileJSONPath but panics if the expression is
+// malformed. It simplifies initializing package variables.
+func MustCompileJSONPath(expr string) *JSONPath {
+	p, err := CompileJSONPath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the expression the path was compiled from
+func (p *JSONPath) String() string {
+	return p.expr
+}
+
+// Find returns the values in v selected by the path, in document order. v is
+// an object or array as flexjson builds it. Every match is reported as
+// Complete; use StreamingParser.Query to tell which values are still growing.
+func (p *JSONPath) Find(v any) []Match {
+	var matches []Match
+	for _, n := range p.eval(v) {
+		matches = append(matches, Match{Path: n.path, Value: n.value, Complete: true})
+	}
+	return matches
+}
+
+// Query returns the values in v selected by the JSONPath expression expr
+func Query(v any, expr string) ([]Match, error) {
+	p, err := CompileJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Find(v), nil
+}
+
+// Query returns the values in the current output selected by the JSONPath
+// expression expr. A match is Complete unless it is an object or array that
+// is still open, or a string stored as it streams with WithPartialStrings.
+// Otherwise, strings, numbers, and literals only appear in the output, and so
+// in the matches, once they are complete. The output stays empty when a
+// MapSink is set or output is skipped, so Query finds nothing then.
+func (sp *StreamingParser) Query(expr string) ([]Match, error) {
+	p, err := CompileJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	partial := ""
+	if sp.stored.depth > 0 {
+		partial = sp.pendingPath()
+	}
+	matches := p.Find(sp.GetCurrentOutput())
+	for i, m := range matches {
+		matches[i].Complete = !sp.isOpen(m.Path) && (partial == "" || m.Path != partial)
+	}
+	return matches, nil
+}
+
+// jsonPathNode is a value reached while evaluating a path
+type jsonPathNode struct {
+	path  string
+	value any
+}
+
+// eval returns the nodes selected by the path from v
+func (p *JSONPath) eval(v any) []jsonPathNode {
+	nodes := []jsonPathNode{{value: v}}
+	for _, step := range p.steps {
+		if step.recursive {
+			var all []jsonPathNode
+			for _, n := range nodes {
+				all = descendants(all, n)
+			}
+			nodes = all
+		}
+
+		var next []jsonPathNode
+		for _, n := range nodes {
+			for _, sel := range step.selectors {
+				next = sel.apply(next, n)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// apply appends the children of n that the selector selects to nodes
+func (sel *jsonPathSelector) apply(nodes []jsonPathNode, n jsonPathNode) []jsonPathNode {
+	switch sel.kind {
+	case selectKey:
+		if v, ok := pointerChild(n.value, sel.key); ok && !isArray(n.value) {
+			nodes = append(nodes, jsonPathNode{appendKeyPath(n.path, sel.key), v})
+		}
+	case selectIndex:
+		if arr, ok := n.value.([]interface{}); ok {
+			i := sel.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				nodes = append(nodes, jsonPathNode{appendIndexPath(n.path, i), arr[i]})
+			}
+		}
+	case selectSlice:
+		if arr, ok := n.value.([]interface{}); ok {
+			start, end := sliceBound(sel.start, len(arr), 0), sliceBound(sel.end, len(arr), len(arr))
+			for i := start; i < end; i++ {
+				nodes = append(nodes, jsonPathNode{appendIndexPath(n.path, i), arr[i]})
+			}
+		}
+	case selectWildcard, selectFilter:
+		for _, child := range children(nil, n) {
+			if sel.kind == selectWildcard || sel.filter.match(child.value) {
+				nodes = append(nodes, child)
+			}
+		}
+	}
+	return nodes
+}
+
+// sliceBound resolves a slice bound against an array of length n
+func sliceBound(bound *int, n, def int) int {
+	if bound == nil {
+		return def
+	}
+	i := *bound
+	if i < 0 {
+		i += n
+	}
+	return min(max(i, 0), n)
+}
+
+// match reports whether the filter selects v
+func (f *jsonPathFilter) match(v any) bool {
+	found := f.path.eval(v)
+	if len(found) == 0 {
+		return false
+	}
+	if f.op == "" {
+		return true
+	}
+	return compareJSONPath(found[0].value, f.op, f.value)
+}
+
+// compareJSONPath compares a value with a filter literal
+func compareJSONPath(a any, op string, b any) bool {
+	var cmp int
+	if x, ok := numberValue(a); ok {
+		y, ok := numberValue(b)
+		if !ok {
+			return op == "!="
+		}
+		cmp = x.Cmp(y)
+	} else if x, ok := a.(string); ok {
+		y, ok := b.(string)
+		if !ok {
+			return op == "!="
+		}
+		cmp = strings.Compare(x, y)
+	} else {
+		// Booleans, nulls, objects, and arrays are only equal or not
+		equal := a == b
+		switch op {
+		case "==":
+			return equal
+		case "!=":
+			return !equal
+		}
+		return false
+	}
+
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// numberValue converts any of the number types flexjson stores to a big.Float
+func numberValue(v any) (*big.Float, bool) {
+	switch n := v.(type) {
+	case int64:
+		return new(big.Float).SetInt64(n), true
+	case int:
+		return new(big.Float).SetInt64(int64(n)), true
+	case float64:
+		if n != n {
+			return nil, false
+		}
+		return new(big.Float).SetFloat64(n), true
+	case json.Number:
+		f, _, err := big.ParseFloat(string(n), 10, 256, big.ToNearestEven)
+		return f, err == nil
+	case *big.Int:
+		return new(big.Float).SetInt(n), true
+	case *big.Float:
+		return n, true
+	}
+	return nil, false
+}
+
+// isArray reports whether v is an array
+func isArray(v any) bool {
+	_, ok := v.([]interface{})
+	return ok
+}
+
+// children appends the members or elements of n to nodes, in order
+func children(nodes []jsonPathNode, n jsonPathNode) []jsonPathNode {
+	switch c := n.value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(c))
+		for key := range c {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			nodes = append(nodes, jsonPathNode{appendKeyPath(n.path, key), c[key]})
+		}
+	case *OrderedMap:
+		for key, v := range c.All() {
+			nodes = append(nodes, jsonPathNode{appendKeyPath(n.path, key), v})
+		}
+	case []interface{}:
+		for i, v := range c {
+			nodes = append(nodes, jsonPathNode{appendIndexPath(n.path, i), v})
+		}
+	}
+	return nodes
+}
+
+// descendants appends n and everything inside it to nodes, depth first
+func descendants(nodes []jsonPathNode, n jsonPathNode) []jsonPathNode {
+	nodes = append(nodes, n)
+	for _, child := range children(nil, n) {
+		nodes = descendants(nodes, child)
+	}
+	return nodes
+}
+
+// jsonPathCompiler parses a JSONPath expression
+type jsonPathCompiler struct {
+	expr string
+	pos  int
+}
+
+// errorf returns an error at the current position
+func (c *jsonPathCompiler) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w %q at offset %d: %s", ErrInvalidJSONPath, c.expr, c.pos, fmt.Sprintf(format, args...))
+}
+
+// path parses a path starting with root ('$', or '@' in a filter), up to the
+// end of the expression or, in a filter, the first character that can't
+// continue it
+func (c *jsonPathCompiler) path(root byte) (*JSONPath, error) {
+	start := c.pos
+	c.skipSpace()
+	if c.pos >= len(c.expr) || c.expr[c.pos] != root {
+		return nil, c.errorf("expected %q", root)
+	}
+	c.pos++
+
+	p := &JSONPath{}
+	for c.pos < len(c.expr) {
+		var step jsonPathStep
+		switch {
+		case strings.HasPrefix(c.expr[c.pos:], ".."):
+			c.pos += 2
+			step.recursive = true
+			if c.pos < len(c.expr) && c.expr[c.pos] == '[' {
+				if err := c.brackets(&step); err != nil {
+					return nil, err
+				}
+				break
+			}
+			sel, err := c.member()
+			if err != nil {
+				return nil, err
+			}
+			step.selectors = []jsonPathSelector{sel}
+		case c.expr[c.pos] == '.':
+			c.pos++
+			sel, err := c.member()
+			if err != nil {
+				return nil, err
+			}
+			step.selectors = []jsonPathSelector{sel}
+		case c.expr[c.pos] == '[':
+			if err := c.brackets(&step); err != nil {
+				return nil, err
+			}
+		default:
+			if root == '$' {
+				return nil, c.errorf("expected '.' or '['")
+			}
+			p.expr = strings.TrimSpace(c.expr[start:c.pos])
+			return p, nil
+		}
+		p.steps = append(p.steps, step)
+	}
+	p.expr = strings.TrimSpace(c.expr[start:c.pos])
+	return p, nil
+}
+
+// member parses a member name or '*' after '.' or '..'
+func (c *jsonPathCompiler) member() (jsonPathSelector, error) {
+	if c.pos < len(c.expr) && c.expr[c.pos] == '*' {
+		c.pos++
+		return jsonPathSelector{kind: selectWildcard}, nil
+	}
+	end := c.pos
+	for end < len(c.expr) && !strings.ContainsRune(".[]()=!<> \t", rune(c.expr[end])) {
+		end++
+	}
+	if end == c.pos {
+		return jsonPathSelector{}, c.errorf("expected a member name")
+	}
+	name := c.expr[c.pos:end]
+	c.pos = end
+	return jsonPathSelector{kind: selectKey, key: name}, nil
+}
+
+// brackets parses a comma-separated list of selectors in brackets
+func (c *jsonPathCompiler) brackets(step *jsonPathStep) error {
+	c.pos++ // '['
+	for {
+		c.skipSpace()
+		sel, err := c.selector()
+		if err != nil {
+			return err
+		}
+		step.selectors = append(step.selectors, sel)
+
+		c.skipSpace()
+		if c.pos >= len(c.expr) {
+			return c.errorf("expected ']'")
+		}
+		switch c.expr[c.pos] {
+		case ']':
+			c.pos++
+			return nil
+		case ',':
+			c.pos++
+		default:
+			return c.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+// selector parses one selector inside brackets
+func (c *jsonPathCompiler) selector() (jsonPathSelector, error) {
+	if c.pos >= len(c.expr) {
+		return jsonPathSelector{}, c.errorf("expected a selector")
+	}
+	switch ch := c.expr[c.pos]; {
+	case ch == '*':
+		c.pos++
+		return jsonPathSelector{kind: selectWildcard}, nil
+	case ch == '\'' || ch == '"':
+		key, err := c.quoted()
+		return jsonPathSelector{kind: selectKey, key: key}, err
+	case ch == '?':
+		c.pos++
+		f, err := c.filter()
+		return jsonPathSelector{kind: selectFilter, filter: f}, err
+	}
+
+	start, err := c.integer()
+	if err != nil {
+		return jsonPathSelector{}, err
+	}
+	c.skipSpace()
+	if c.pos >= len(c.expr) || c.expr[c.pos] != ':' {
+		if start == nil {
+			return jsonPathSelector{}, c.errorf("expected a selector")
+		}
+		return jsonPathSelector{kind: selectIndex, index: *start}, nil
+	}
+	c.pos++
+	c.skipSpace()
+	end, err := c.integer()
+	return jsonPathSelector{kind: selectSlice, start: start, end: end}, err
+}
+
+// integer parses an optional integer
+func (c *jsonPathCompiler) integer() (*int, error) {
+	end := c.pos
+	if end < len(c.expr) && c.expr[end] == '-' {
+		end++
+	}
+	for end < len(c.expr) && c.expr[end] >= '0' && c.expr[end] <= '9' {
+		end++
+	}
+	if end == c.pos {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(c.expr[c.pos:end])
+	if err != nil {
+		return nil, c.errorf("invalid index %q", c.expr[c.pos:end])
+	}
+	c.pos = end
+	return &n, nil
+}
+
+// quoted parses a string in single or double quotes
+func (c *jsonPathCompiler) quoted() (string, error) {
+	quote := c.expr[c.pos]
+	var b strings.Builder
+	for i := c.pos + 1; i < len(c.expr); i++ {
+		switch ch := c.expr[i]; {
+		case ch == quote:
+			c.pos = i + 1
+			return b.String(), nil
+		case ch == '\\' && i+1 < len(c.expr):
+			i++
+			if unescaped, ok := unescapeChar(c.expr[i]); ok {
+				b.WriteByte(unescaped)
+			} else {
+				b.WriteByte(c.expr[i])
+			}
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", c.errorf("unterminated string")
+}
+
+// filter parses a filter expression after '?'
+func (c *jsonPathCompiler) filter() (*jsonPathFilter, error) {
+	c.skipSpace()
+	paren := c.pos < len(c.expr) && c.expr[c.pos] == '('
+	if paren {
+		c.pos++
+	}
+
+	path, err := c.path('@')
+	if err != nil {
+		return nil, err
+	}
+	f := &jsonPathFilter{path: path}
+
+	c.skipSpace()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(c.expr[c.pos:], op) {
+			f.op = op
+			c.pos += len(op)
+			break
+		}
+	}
+	if f.op != "" {
+		c.skipSpace()
+		if f.value, err = c.literal(); err != nil {
+			return nil, err
+		}
+		c.skipSpace()
+	}
+
+	if paren {
+		if c.pos >= len(c.expr) || c.expr[c.pos] != ')' {
+			return nil, c.errorf("expected ')'")
+		}
+		c.pos++
+	}
+	return f, nil
+}
+
+// literal parses a string, number, true, false, or null in a filter
+func (c *jsonPathCompiler) literal() (any, error) {
+	if c.pos < len(c.expr) && (c.expr[c.pos] == '\'' || c.expr[c.pos] == '"') {
+		return c.quoted()
+	}
+	for _, lit := range []struct {
+		text  string
+		value any
+	}{{"true", true}, {"false", false}, {"null", nil}} {
+		if strings.HasPrefix(c.expr[c.pos:], lit.text) {
+			c.pos += len(lit.text)
+			return lit.value, nil
+		}
+	}
+
+	end := c.pos
+	for end < len(c.expr) && strings.IndexByte("+-.0123456789eE", c.expr[end]) >= 0 {
+		end++
+	}
+	if !isJSONNumber(c.expr[c.pos:end]) {
+		return nil, c.errorf("expected a string, number, true, false, or null")
+	}
+	n := json.Number(c.expr[c.pos:end])
+	c.pos = end
+	return n, nil
+}
+
+// skipSpace skips spaces and tabs
+func (c *jsonPathCompiler) skipSpace() {
+	for c.pos < len(c.expr) && (c.expr[c.pos] == ' ' || c.expr[c.pos] == '\t') {
+		c.pos++
+	}
+}
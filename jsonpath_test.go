@@ -0,0 +1,128 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestJSONPathFind(t *testing.T) {
+	doc, err := Parse(`{
+		"choices": [
+			{"index": 0, "message": {"role": "assistant", "content": "Hi"}},
+			{"index": 1, "message": {"role": "user", "content": "Yo"}},
+			{"index": 2, "message": {"role": "assistant", "content": null}}
+		],
+		"usage": {"tokens": 12},
+		"a.b": {"c": [10, 20, 30, 40]}
+	}`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		expr  string
+		paths []string
+	}{
+		{"$", []string{""}},
+		{"$.choices[*].message.content", []string{"choices[0].message.content", "choices[1].message.content", "choices[2].message.content"}},
+		{"$.choices[-1].index", []string{"choices[2].index"}},
+		{"$['choices'][0]['message'].role", []string{"choices[0].message.role"}},
+		{`$["a.b"].c[1:3]`, []string{`["a.b"].c[1]`, `["a.b"].c[2]`}},
+		{`$["a.b"].c[:-3]`, []string{`["a.b"].c[0]`}},
+		{`$["a.b"].c[2:]`, []string{`["a.b"].c[2]`, `["a.b"].c[3]`}},
+		{`$["a.b"].c[3,0]`, []string{`["a.b"].c[3]`, `["a.b"].c[0]`}},
+		{"$.usage.*", []string{"usage.tokens"}},
+		{"$..role", []string{"choices[0].message.role", "choices[1].message.role", "choices[2].message.role"}},
+		{"$..tokens", []string{"usage.tokens"}},
+		{"$..c[0]", []string{`["a.b"].c[0]`}},
+		{"$.choices[?(@.message.role == 'user')].index", []string{"choices[1].index"}},
+		{"$.choices[?(@.message.role != 'user')].index", []string{"choices[0].index", "choices[2].index"}},
+		{"$.choices[?(@.index >= 1)].index", []string{"choices[1].index", "choices[2].index"}},
+		{"$.choices[?@.index<1].index", []string{"choices[0].index"}},
+		{"$.choices[?(@.message.content == null)].index", []string{"choices[2].index"}},
+		{"$.choices[?(@.message)].index", []string{"choices[0].index", "choices[1].index", "choices[2].index"}},
+		{"$.choices[?(@.missing)]", nil},
+		{`$["a.b"].c[?(@ > 25)]`, []string{`["a.b"].c[2]`, `["a.b"].c[3]`}},
+		{"$.missing[*]", nil},
+		{"$.usage[0]", nil},
+		{"$.choices.index", nil},
+	}
+
+	for _, tt := range tests {
+		matches, err := Query(doc, tt.expr)
+		if err != nil {
+			t.Errorf("Query(%q) error = %v", tt.expr, err)
+			continue
+		}
+		var paths []string
+		for _, m := range matches {
+			paths = append(paths, m.Path)
+			if v, ok := Object(doc).Get(m.Path); !ok || !reflect.DeepEqual(v, m.Value) {
+				t.Errorf("Query(%q) match %q = %v, want %v", tt.expr, m.Path, m.Value, v)
+			}
+		}
+		if !reflect.DeepEqual(paths, tt.paths) {
+			t.Errorf("Query(%q) paths = %q, want %q", tt.expr, paths, tt.paths)
+		}
+	}
+}
+
+func TestCompileJSONPathErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"choices",
+		"$.",
+		"$[",
+		"$[0",
+		"$['a",
+		"$[?(@.a == )]",
+		"$[?(@.a == 1]",
+		"$x",
+		"$..",
+	} {
+		if _, err := CompileJSONPath(expr); !errors.Is(err, ErrInvalidJSONPath) {
+			t.Errorf("CompileJSONPath(%q) error = %v, want ErrInvalidJSONPath", expr, err)
+		}
+	}
+
+	p := MustCompileJSONPath("$.a[*]")
+	if p.String() != "$.a[*]" {
+		t.Errorf("String() = %q", p.String())
+	}
+}
+
+func TestStreamingParserQuery(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	if err := sp.ProcessString(`{"choices": [{"message": {"content": "Hello"}}, {"message": {"content": "Wor`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	matches, err := sp.Query("$.choices[*].message")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := []Match{
+		{Path: "choices[0].message", Value: map[string]any{"content": "Hello"}, Complete: true},
+		{Path: "choices[1].message", Value: map[string]any{}, Complete: false},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("Query() = %v, want %v", matches, want)
+	}
+
+	if err := sp.ProcessString(`ld"}}]}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	matches, _ = sp.Query("$..content")
+	want = []Match{
+		{Path: "choices[0].message.content", Value: "Hello", Complete: true},
+		{Path: "choices[1].message.content", Value: "World", Complete: true},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("Query() = %v, want %v", matches, want)
+	}
+
+	if _, err := sp.Query("$["); !errors.Is(err, ErrInvalidJSONPath) {
+		t.Errorf("Query($[) error = %v, want ErrInvalidJSONPath", err)
+	}
+}
@@ -0,0 +1,289 @@
+package flexjson
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithMaxStringLength limits strings, including object keys, to n bytes once
+// decoded. A string over the limit is an error, a *ParseError with the code
+// CodeValueTooLong, unless WithTruncation is given. Parsers keep no more than
+// n bytes of a string however long it is, so untrusted input can't exhaust
+// memory with one. A limit of zero or less disables it.
+func WithMaxStringLength(n int) Option {
+	return func(d *decoder) {
+		d.maxString = max(n, 0)
+	}
+}
+
+// WithMaxNumberLength limits numbers to n bytes of text, as
+// WithMaxStringLength limits strings
+func WithMaxNumberLength(n int) Option {
+	return func(d *decoder) {
+		d.maxNumber = max(n, 0)
+	}
+}
+
+// WithTruncation makes a parser cut values over the limits set with
+// WithMaxStringLength and WithMaxNumberLength short instead of returning an
+// error. A string is cut to the limit, without splitting a character, and
+// marker is appended. A number can't be cut short and stay a number, so it is
+// stored as a string of its first bytes followed by marker.
+func WithTruncation(marker string) Option {
+	return func(d *decoder) {
+		d.truncate = true
+		d.marker = marker
+	}
+}
+
+// SetLimits limits the strings and numbers the lexer returns to maxString
+// bytes of decoded text and maxNumber bytes of text. A value over its limit
+// is cut short and its token is marked as Truncated, and no more of it is
+// kept. A limit of zero or less disables it. It must be called before any
+// tokens are read.
+func (l *Lexer) SetLimits(maxString, maxNumber int) {
+	l.str.limit = max(maxString, 0)
+	l.maxNumber = max(maxNumber, 0)
+}
+
+// configureLexer sets up a lexer for the decoder's syntax and limits
+func (d *decoder) configureLexer(l *Lexer) {
+	l.SetSyntax(d.syntax)
+	l.SetLimits(d.maxString, d.maxNumber)
+	if len(d.raw) > 0 || d.rawLeaves {
+		l.keepText = true
+		d.source = l
+	}
+}
+
+// truncated handles a token cut short by a size limit, returning the value
+// to use instead of its text
+func (d *decoder) truncated(tok Token) (string, error) {
+	if !d.truncate {
+		limit := d.maxString
+		if tok.Type == TokenNumber {
+			limit = d.maxNumber
+		}
+		err := tokenError(tok, CodeValueTooLong, fmt.Sprintf("at most %d bytes", limit))
+		return "", err
+	}
+	return tok.Value + d.marker, nil
+}
+
+// stringToken returns a string token for the decoded value
+func (l *Lexer) stringToken(value string) Token {
+	tok := l.token(TokenString, value)
+	tok.Truncated = l.str.truncated
+	return tok
+}
+
+// longNumber returns the token for a number over the size limit that ends at
+// or continues past offset i, and skips the rest of it
+func (l *Lexer) longNumber(i int) Token {
+	tok := l.token(TokenNumber, l.text(l.start, l.start+l.maxNumber))
+	tok.End, tok.Truncated = i, true
+	l.pos = i
+	l.skipNumber = true
+	return tok
+}
+
+// skipLongNumber skips the rest of a number cut short by a size limit. It
+// returns false if more input is needed to find its end.
+func (l *Lexer) skipLongNumber() bool {
+	for l.skipNumber && l.pos < l.end() {
+		c := l.at(l.pos)
+		if !isAlphaNumeric(c) && c != '.' && c != '+' && c != '-' {
+			l.skipNumber = false
+			break
+		}
+		l.pos++
+	}
+	return !l.skipNumber || l.final
+}
+
+// limitedBuilder is a strings.Builder that keeps no more than limit bytes.
+// Writes past the limit are dropped, and characters are never split.
+type limitedBuilder struct {
+	strings.Builder
+	limit     int  // Bytes kept (0 for no limit)
+	truncated bool // Whether a write was dropped
+}
+
+// Reset empties the builder
+func (b *limitedBuilder) Reset() {
+	b.Builder.Reset()
+	b.truncated = false
+}
+
+// fits reports whether n more bytes can be written, marking the builder
+// truncated if not
+func (b *limitedBuilder) fits(n int) bool {
+	if b.limit > 0 && (b.truncated || b.Len()+n > b.limit) {
+		b.truncated = true
+		return false
+	}
+	return true
+}
+
+// WriteByte appends c unless it doesn't fit. The first byte of a multi-byte
+// character only fits if the whole character does.
+func (b *limitedBuilder) WriteByte(c byte) error {
+	n := 1
+	switch {
+	case c >= 0xf0:
+		n = 4
+	case c >= 0xe0:
+		n = 3
+	case c >= 0xc0:
+		n = 2
+	}
+	if !b.fits(n) {
+		return nil
+	}
+	return b.Builder.WriteByte(c)
+}
+
+// WriteRune appends r unless it doesn't fit
+func (b *limitedBuilder) WriteRune(r rune) (int, error) {
+	n := utf8.RuneLen(r)
+	if n < 0 {
+		n = len(string(utf8.RuneError))
+	}
+	if !b.fits(n) {
+		return 0, nil
+	}
+	return b.Builder.WriteRune(r)
+}
+
+// WriteString appends as much of s as fits, without splitting a character
+func (b *limitedBuilder) WriteString(s string) (int, error) {
+	if !b.fits(len(s)) {
+		if b.limit > b.Len() {
+			s = truncateString(s, b.limit-b.Len())
+			return b.Builder.WriteString(s)
+		}
+		return 0, nil
+	}
+	return b.Builder.WriteString(s)
+}
+
+// Approximate sizes of the parts of the output, in bytes, used by the budget
+// set with WithMaxOutputBytes
+const (
+	valueCost  = 16 // An interface value holding a scalar, or a slice element
+	memberCost = 48 // An object member, on top of its key's bytes and value
+	objectCost = 48 // An empty map
+	arrayCost  = 24 // An empty slice
+)
+
+// WithMaxOutputBytes limits the memory held by the parsed output to about n
+// bytes, so that a service can parse streams of unbounded length. The size is
+// an estimate: each string counts its bytes, and each value, object member,
+// object, and array counts a fixed overhead. A value that would take the
+// output over the budget is an error, a *ParseError with the code
+// CodeOutputTooLarge, unless WithDiscardOverflow is given. A limit of zero or
+// less disables it.
+//
+// The budget covers a single root value: with ParseDocuments or WithDocuments
+// it starts again with each document, unless WithMerge is given.
+func WithMaxOutputBytes(n int) Option {
+	return func(d *decoder) {
+		d.maxOutput = max(n, 0)
+	}
+}
+
+// WithDiscardOverflow makes a parser stop storing values once the budget set
+// with WithMaxOutputBytes is exceeded, instead of returning an error. The
+// output keeps the values stored before then, and the rest of the input is
+// still checked for errors and reported to event handlers and watchers, as
+// with SetSkipOutput.
+func WithDiscardOverflow() Option {
+	return func(d *decoder) {
+		d.discard = true
+	}
+}
+
+// charge adds the size of the value starting with tok to the output's size,
+// and reports an error if it goes over the budget
+func (d *decoder) charge(tok Token) error {
+	if d.maxOutput == 0 || d.discarding || d.skipOutput {
+		return nil
+	}
+
+	var size int
+	switch tok.Type {
+	case TokenLeftBrace:
+		size = objectCost
+	case TokenLeftBracket:
+		size = arrayCost
+	case TokenString, TokenNumber, TokenTrue, TokenFalse, TokenNull:
+		size = valueCost
+		if tok.Type == TokenString || tok.Truncated {
+			size += len(tok.Value)
+		}
+		if tok.Truncated {
+			size += len(d.marker)
+		}
+	default:
+		return nil
+	}
+	if d.filtering() && d.discardValue(d.valuePath()) {
+		return nil
+	}
+	if len(d.stack) > 0 && !d.inArray() {
+		size += memberCost + len(d.top().key)
+	}
+
+	if d.outputSize+size <= d.maxOutput {
+		d.outputSize += size
+		return nil
+	}
+	if d.discard {
+		d.warnf("\tOutput budget exceeded, discarding values\n")
+		d.discarding = true
+		return nil
+	}
+	return tokenError(tok, CodeOutputTooLarge, fmt.Sprintf("at most %d bytes of output", d.maxOutput))
+}
+
+// WithMaxKeys limits objects to n members, so that input such as an object
+// with millions of keys is rejected as soon as it goes over the limit instead
+// of exhausting memory. The key that goes over it is an error, a *ParseError
+// with the code CodeTooManyValues. Repeated keys count each time they appear.
+// A limit of zero or less disables it.
+func WithMaxKeys(n int) Option {
+	return func(d *decoder) {
+		d.maxKeys = max(n, 0)
+	}
+}
+
+// WithMaxElements limits arrays to n elements, as WithMaxKeys limits objects
+func WithMaxElements(n int) Option {
+	return func(d *decoder) {
+		d.maxElements = max(n, 0)
+	}
+}
+
+// count counts tok as a key or element of the container on top of the stack,
+// and reports an error if the container goes over its limit
+func (d *decoder) count(tok Token) error {
+	switch tok.Type {
+	case TokenRightBracket, TokenRightBrace, TokenColon, TokenComma, TokenEOF, TokenError:
+		return nil
+	}
+
+	top := d.top()
+	limit, kind := d.maxKeys, "keys"
+	if d.inArray() {
+		limit, kind = d.maxElements, "elements"
+	}
+	if limit == 0 {
+		return nil
+	}
+	if top.count == limit {
+		return tokenError(tok, CodeTooManyValues, fmt.Sprintf("at most %d %s", limit, kind))
+	}
+	top.count++
+	return nil
+}
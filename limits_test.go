@@ -0,0 +1,203 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSizeLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []Option
+		expected map[string]any
+	}{
+		{
+			name:     "within limits",
+			input:    `{"abcd": "wxyz", "n": 1234}`,
+			opts:     []Option{WithMaxStringLength(4), WithMaxNumberLength(4)},
+			expected: map[string]any{"abcd": "wxyz", "n": int64(1234)},
+		},
+		{
+			name:     "truncated string",
+			input:    `{"a": "hello world", "b": "héllo"}`,
+			opts:     []Option{WithMaxStringLength(5), WithTruncation("…")},
+			expected: map[string]any{"a": "hello…", "b": "héll…"},
+		},
+		{
+			name:     "truncated without splitting a character",
+			input:    `{"a": "abcdé"}`,
+			opts:     []Option{WithMaxStringLength(5), WithTruncation("…")},
+			expected: map[string]any{"a": "abcd…"},
+		},
+		{
+			name:     "truncated key",
+			input:    `{"a long key": 1}`,
+			opts:     []Option{WithMaxStringLength(6), WithTruncation("...")},
+			expected: map[string]any{"a long...": int64(1)},
+		},
+		{
+			name:     "truncated number",
+			input:    `{"n": 123456789012345678901234567890, "m": 7}`,
+			opts:     []Option{WithMaxNumberLength(8), WithTruncation("…")},
+			expected: map[string]any{"n": "12345678…", "m": int64(7)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(tt.input, tt.opts...)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Parse() = %#v, want %#v", result, tt.expected)
+			}
+
+			output := map[string]any{}
+			sp := NewStreamingParser(&output, tt.opts...)
+			for i := 0; i < len(tt.input); i++ {
+				if err := sp.ProcessString(tt.input[i : i+1]); err != nil {
+					t.Fatalf("ProcessString(%q) error = %v", tt.input[i:i+1], err)
+				}
+			}
+			if !reflect.DeepEqual(output, tt.expected) {
+				t.Errorf("Output = %#v, want %#v", output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSizeLimitErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		opts  []Option
+	}{
+		{`{"a": "too long"}`, []Option{WithMaxStringLength(3)}},
+		{`{"too long": 1}`, []Option{WithMaxStringLength(3)}},
+		{`{"a": 12345}`, []Option{WithMaxNumberLength(3)}},
+		{`{"a": -1.5e10}`, []Option{WithMaxNumberLength(3)}},
+	}
+
+	for _, tt := range tests {
+		var perr *ParseError
+		_, err := Parse(tt.input, tt.opts...)
+		if !errors.As(err, &perr) || perr.Code != CodeValueTooLong || !errors.Is(err, ErrValueTooLong) {
+			t.Errorf("Parse(%q) error = %v, want CodeValueTooLong", tt.input, err)
+		}
+	}
+}
+
+func TestSizeLimitsBoundMemory(t *testing.T) {
+	// A string far over the limit, streamed in chunks, keeps only the limit
+	sp := NewStreamingParser(nil, WithMaxStringLength(16), WithTruncation(""))
+	if err := sp.ProcessString(`{"a": "`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	chunk := strings.Repeat(`x\n`, 1000)
+	for range 100 {
+		if err := sp.ProcessString(chunk); err != nil {
+			t.Fatalf("ProcessString() error = %v", err)
+		}
+	}
+	if n := sp.lexer.str.Len(); n > 16 {
+		t.Errorf("Lexer kept %d bytes of the string, want at most 16", n)
+	}
+	if err := sp.ProcessString(`"}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if got, want := sp.GetCurrentOutput()["a"], strings.Repeat("x\n", 8); got != want {
+		t.Errorf("Output = %q, want %q", got, want)
+	}
+}
+
+func TestMaxOutputBytes(t *testing.T) {
+	input := `{"a": "` + strings.Repeat("x", 100) + `", "b": [1, 2, 3], "c": {"d": true}}`
+
+	// Everything fits in a generous budget
+	result, err := Parse(input, WithMaxOutputBytes(1000))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(result) != 3 {
+		t.Errorf("Parse() = %v, want 3 members", result)
+	}
+
+	// A small budget stops at the first value that doesn't fit
+	var perr *ParseError
+	_, err = Parse(input, WithMaxOutputBytes(250))
+	if !errors.As(err, &perr) || perr.Code != CodeOutputTooLarge || !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("Parse() error = %v, want CodeOutputTooLarge", err)
+	}
+	if perr.Offset != strings.Index(input, "[") {
+		t.Errorf("Offset = %d, want %d", perr.Offset, strings.Index(input, "["))
+	}
+
+	// In discard mode the values that fit are kept and the rest is checked
+	expected := map[string]any{"a": strings.Repeat("x", 100)}
+	output := map[string]any{}
+	sp := NewStreamingParser(&output, WithMaxOutputBytes(250), WithDiscardOverflow())
+	if err := sp.ProcessString(input); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if !reflect.DeepEqual(output, expected) || !sp.IsComplete() {
+		t.Errorf("Output = %v, complete = %v, want %v", output, sp.IsComplete(), expected)
+	}
+
+	sp = NewStreamingParser(nil, WithMaxOutputBytes(250), WithDiscardOverflow())
+	if err := sp.ProcessString(`{"a": "` + strings.Repeat("x", 300) + `", "b": [1, }`); err == nil {
+		t.Error("ProcessString() error = nil after the budget was exceeded, want a syntax error")
+	}
+
+	// The budget starts again with each document
+	var docs []map[string]any
+	for doc, err := range ParseDocuments(strings.Repeat(`{"a": "xxxxxxxx"}`, 10), WithMaxOutputBytes(150)) {
+		if err != nil {
+			t.Fatalf("ParseDocuments() error = %v", err)
+		}
+		docs = append(docs, doc)
+	}
+	if len(docs) != 10 {
+		t.Errorf("ParseDocuments() returned %d documents, want 10", len(docs))
+	}
+}
+
+func TestMaxKeysAndElements(t *testing.T) {
+	opts := []Option{WithMaxKeys(2), WithMaxElements(3)}
+
+	tests := []struct {
+		input  string
+		offset int // Offset of the error, or -1 for none
+	}{
+		{`{"a": 1, "b": [1, 2, 3]}`, -1},
+		{`{"a": {"b": 1, "c": 2}, "d": [{"e": 1, "f": 2}]}`, -1},
+		{`{"a": 1, "b": 2, "c": 3}`, 17},
+		{`{"a": [1, 2, 3, 4]}`, 16},
+		{`{"a": [[1, 2, 3], [], [], []]}`, 26},
+		{`{"a": {"b": 1, "c": 2, "d": 3}}`, 23},
+		{`{"a": 1, "a": 1, "a": 1}`, 17},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.input, opts...)
+		if tt.offset < 0 {
+			if err != nil {
+				t.Errorf("Parse(%q) error = %v", tt.input, err)
+			}
+			continue
+		}
+		var perr *ParseError
+		if !errors.As(err, &perr) || perr.Code != CodeTooManyValues || !errors.Is(err, ErrTooManyValues) {
+			t.Errorf("Parse(%q) error = %v, want CodeTooManyValues", tt.input, err)
+		} else if perr.Offset != tt.offset {
+			t.Errorf("Parse(%q) error offset = %d, want %d", tt.input, perr.Offset, tt.offset)
+		}
+
+		sp := NewStreamingParser(nil, opts...)
+		if err := sp.ProcessString(tt.input); !errors.Is(err, ErrTooManyValues) {
+			t.Errorf("ProcessString(%q) error = %v, want ErrTooManyValues", tt.input, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package flexjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Incomplete selects what MarshalPartial writes for the value still
+// streaming: a value whose key has been read, or which has started, but
+// which isn't final yet
+type Incomplete int
+
+const (
+	// IncompleteAsIs writes the value still streaming as the output holds
+	// it: a string stored with WithPartialStrings is written with the text
+	// decoded so far, and a value not in the output yet is left out
+	IncompleteAsIs Incomplete = iota
+	// IncompleteNull writes null in place of the value still streaming, so
+	// its key or index is present
+	IncompleteNull
+	// IncompleteOmit leaves the value still streaming out, even when the
+	// output holds part of it
+	IncompleteOmit
+)
+
+// MarshalOptions configures MarshalPartial
+type MarshalOptions struct {
+	// SortKeys writes the members of *OrderedMap objects sorted by key, like
+	// those of map[string]any, which are always sorted
+	SortKeys bool
+	// Incomplete selects what is written for the value still streaming
+	Incomplete Incomplete
+}
+
+// MarshalPartial writes the current output as valid JSON text, so the state
+// of a document still streaming can be stored or sent on. Objects and arrays
+// that are still open are written with the members they have so far, and
+// the value still streaming is written as opts.Incomplete selects. The
+// members of map[string]any objects are sorted by key, so the same output
+// always gives the same text. Like Snapshot, it must not be called from a
+// watcher or event handler with synchronous dispatch.
+//
+// A parser with a MapSink can only be marshaled if the sink is an
+// *OrderedMap. A number that isn't valid JSON, such as NaN with SyntaxJSON5,
+// returns an error.
+func (sp *StreamingParser) MarshalPartial(opts MarshalOptions) ([]byte, error) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	var root any = *sp.output
+	if sp.sink != nil {
+		m, ok := sp.sink.(*OrderedMap)
+		if !ok {
+			return nil, fmt.Errorf("can't marshal the output of a %T sink", sp.sink)
+		}
+		root = m
+	}
+
+	w := partialWriter{opts: opts}
+	if path, ok := sp.pendingValue(); ok {
+		top := sp.top()
+		w.pending, w.parent, w.key = path, top.path, top.key
+		w.streaming = true
+		w.stored = sp.stored.depth > 0
+	}
+	if err := w.value(root, ""); err != nil {
+		return nil, err
+	}
+	return w.buf.Bytes(), nil
+}
+
+// partialWriter writes the output for MarshalPartial
+type partialWriter struct {
+	buf       bytes.Buffer
+	opts      MarshalOptions
+	streaming bool   // Whether a value is still streaming
+	pending   string // Path of the value still streaming
+	parent    string // Path of the container it belongs to
+	key       string // Its key, if the container is an object
+	stored    bool   // Whether the output holds part of it
+}
+
+// value writes v, found at path
+func (w *partialWriter) value(v any, path string) error {
+	switch v := v.(type) {
+	case map[string]any:
+		return w.object(path, slices.Sorted(maps.Keys(v)), true, func(key string) any {
+			return v[key]
+		})
+	case *OrderedMap:
+		keys := make([]string, len(v.pairs))
+		for i, p := range v.pairs {
+			keys[i] = p.Key
+		}
+		if w.opts.SortKeys {
+			slices.Sort(keys)
+		}
+		return w.object(path, keys, w.opts.SortKeys, func(key string) any {
+			value, _ := v.Get(key)
+			return value
+		})
+	case []interface{}:
+		return w.array(path, v)
+	case *[]interface{}:
+		if v != nil {
+			return w.array(path, *v)
+		}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.buf.Write(data)
+	return nil
+}
+
+// object writes the members of an object, whose keys are given in order
+func (w *partialWriter) object(path string, keys []string, sorted bool, get func(key string) any) error {
+	if w.placeholder() && path == w.parent && !slices.Contains(keys, w.key) {
+		// The value still streaming isn't in the output yet
+		keys = append(keys, w.key)
+		if sorted {
+			slices.Sort(keys)
+		}
+	}
+
+	w.buf.WriteByte('{')
+	first := true
+	for _, key := range keys {
+		child := appendKeyPath(path, key)
+		if w.omitted(child) {
+			continue
+		}
+		if !first {
+			w.buf.WriteByte(',')
+		}
+		first = false
+
+		data, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		w.buf.Write(data)
+		w.buf.WriteByte(':')
+		if err := w.member(get(key), child); err != nil {
+			return err
+		}
+	}
+	w.buf.WriteByte('}')
+	return nil
+}
+
+// array writes the elements of an array
+func (w *partialWriter) array(path string, arr []interface{}) error {
+	w.buf.WriteByte('[')
+	n := 0
+	for i, e := range arr {
+		child := appendIndexPath(path, i)
+		if w.omitted(child) {
+			continue
+		}
+		if n > 0 {
+			w.buf.WriteByte(',')
+		}
+		n++
+		if err := w.member(e, child); err != nil {
+			return err
+		}
+	}
+	if w.placeholder() && path == w.parent && !w.stored {
+		// The element still streaming isn't in the output yet
+		if n > 0 {
+			w.buf.WriteByte(',')
+		}
+		w.buf.WriteString("null")
+	}
+	w.buf.WriteByte(']')
+	return nil
+}
+
+// member writes a member of an object or array, found at path
+func (w *partialWriter) member(v any, path string) error {
+	if w.placeholder() && path == w.pending {
+		w.buf.WriteString("null")
+		return nil
+	}
+	return w.value(v, path)
+}
+
+// placeholder reports whether null is written for the value still streaming
+func (w *partialWriter) placeholder() bool {
+	return w.streaming && w.opts.Incomplete == IncompleteNull
+}
+
+// omitted reports whether the value at path is left out
+func (w *partialWriter) omitted(path string) bool {
+	return w.streaming && w.opts.Incomplete == IncompleteOmit && path == w.pending
+}
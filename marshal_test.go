@@ -0,0 +1,139 @@
+package flexjson
+
+import (
+	"testing"
+)
+
+func TestMarshalPartial(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []Option
+		asIs  string
+		null  string
+		omit  string
+	}{
+		{
+			name:  "complete",
+			input: `{"b": [1, 2.5, "x"], "a": {"c": null, "d": true}}`,
+			asIs:  `{"a":{"c":null,"d":true},"b":[1,2.5,"x"]}`,
+			null:  `{"a":{"c":null,"d":true},"b":[1,2.5,"x"]}`,
+			omit:  `{"a":{"c":null,"d":true},"b":[1,2.5,"x"]}`,
+		},
+		{
+			name:  "open containers",
+			input: `{"b": [1, {"c": 2, `,
+			asIs:  `{"b":[1,{"c":2}]}`,
+			null:  `{"b":[1,{"c":2}]}`,
+			omit:  `{"b":[1,{"c":2}]}`,
+		},
+		{
+			name:  "pending number",
+			input: `{"b": [1, {"c": 2`,
+			asIs:  `{"b":[1,{}]}`,
+			null:  `{"b":[1,{"c":null}]}`,
+			omit:  `{"b":[1,{}]}`,
+		},
+		{
+			name:  "pending key",
+			input: `{"b": 1, "a": `,
+			asIs:  `{"b":1}`,
+			null:  `{"a":null,"b":1}`,
+			omit:  `{"b":1}`,
+		},
+		{
+			name:  "pending element",
+			input: `{"a": [1, tr`,
+			asIs:  `{"a":[1]}`,
+			null:  `{"a":[1,null]}`,
+			omit:  `{"a":[1]}`,
+		},
+		{
+			name:  "repeated key",
+			input: `{"a": 1, "a": "x`,
+			asIs:  `{"a":1}`,
+			null:  `{"a":null}`,
+			omit:  `{}`,
+		},
+		{
+			name:  "partial string",
+			input: `{"a": "hel`,
+			opts:  []Option{WithPartialStrings()},
+			asIs:  `{"a":"hel"}`,
+			null:  `{"a":null}`,
+			omit:  `{}`,
+		},
+		{
+			name:  "partial string in an array",
+			input: `{"a": ["x", "hel`,
+			opts:  []Option{WithPartialStrings()},
+			asIs:  `{"a":["x","hel"]}`,
+			null:  `{"a":["x",null]}`,
+			omit:  `{"a":["x"]}`,
+		},
+		{
+			name:  "not started",
+			input: ``,
+			asIs:  `{}`,
+			null:  `{}`,
+			omit:  `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := NewStreamingParser(nil, tt.opts...)
+			if err := sp.ProcessString(tt.input); err != nil {
+				t.Fatalf("ProcessString() error = %v", err)
+			}
+			for _, c := range []struct {
+				incomplete Incomplete
+				want       string
+			}{
+				{IncompleteAsIs, tt.asIs},
+				{IncompleteNull, tt.null},
+				{IncompleteOmit, tt.omit},
+			} {
+				got, err := sp.MarshalPartial(MarshalOptions{Incomplete: c.incomplete})
+				if err != nil {
+					t.Fatalf("MarshalPartial(%d) error = %v", c.incomplete, err)
+				}
+				if string(got) != c.want {
+					t.Errorf("MarshalPartial(%d) = %s, want %s", c.incomplete, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMarshalPartialOrderedSink(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	sp.SetSink(NewOrderedMap())
+	if err := sp.ProcessString(`{"b": 1, "a": {"d": 2, "c": 3}, "e": `); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	tests := []struct {
+		opts MarshalOptions
+		want string
+	}{
+		{MarshalOptions{}, `{"b":1,"a":{"d":2,"c":3}}`},
+		{MarshalOptions{SortKeys: true}, `{"a":{"c":3,"d":2},"b":1}`},
+		{MarshalOptions{Incomplete: IncompleteNull}, `{"b":1,"a":{"d":2,"c":3},"e":null}`},
+		{MarshalOptions{SortKeys: true, Incomplete: IncompleteNull}, `{"a":{"c":3,"d":2},"b":1,"e":null}`},
+	}
+	for _, tt := range tests {
+		got, err := sp.MarshalPartial(tt.opts)
+		if err != nil {
+			t.Fatalf("MarshalPartial(%+v) error = %v", tt.opts, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("MarshalPartial(%+v) = %s, want %s", tt.opts, got, tt.want)
+		}
+	}
+
+	sp.SetSink(&orderedSink{})
+	if _, err := sp.MarshalPartial(MarshalOptions{}); err == nil {
+		t.Error("MarshalPartial() with another sink returned no error")
+	}
+}
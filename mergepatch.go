@@ -0,0 +1,82 @@
+package flexjson
+
+import (
+	"iter"
+	"maps"
+)
+
+// MergePatch applies patch to target as a JSON Merge Patch (RFC 7386), so
+// partial results from several sources, such as retries or resumed streams,
+// can be folded into one document. target is modified in place:
+//
+//   - a null member of patch removes the member from target
+//   - an object member is merged into the object in target, recursively,
+//     replacing any value there that isn't an object
+//   - any other member replaces the value in target, arrays included
+//
+// Objects may be map[string]any or *OrderedMap in either document; an
+// *OrderedMap in target stays one, with new keys added at the end in the
+// order of the patch. Values taken from patch are copied, so later changes to
+// patch don't affect target.
+func MergePatch(target, patch map[string]any) {
+	mergePatchObject(target, maps.All(patch))
+}
+
+// mergePatchObject merges the members of a patch object into target, which
+// is a map[string]any or *OrderedMap
+func mergePatchObject(target any, members iter.Seq2[string, any]) {
+	for key, value := range members {
+		var current any
+		switch obj := target.(type) {
+		case map[string]any:
+			current = obj[key]
+		case *OrderedMap:
+			current, _ = obj.Get(key)
+		}
+
+		merged, keep := mergePatchValue(current, value)
+		switch obj := target.(type) {
+		case map[string]any:
+			if keep {
+				obj[key] = merged
+			} else {
+				delete(obj, key)
+			}
+		case *OrderedMap:
+			if keep {
+				obj.Set(key, merged)
+			} else {
+				obj.Delete(key)
+			}
+		}
+	}
+}
+
+// mergePatchValue returns the result of merging patch into target, and false
+// if the value is removed
+func mergePatchValue(target, patch any) (any, bool) {
+	var members iter.Seq2[string, any]
+	switch p := patch.(type) {
+	case nil:
+		return nil, false
+	case map[string]any:
+		members = maps.All(p)
+	case *OrderedMap:
+		members = p.All()
+	default:
+		return snapshotValue(patch), true
+	}
+
+	switch target.(type) {
+	case map[string]any, *OrderedMap:
+	default:
+		// Anything but an object is replaced by one
+		if _, ordered := patch.(*OrderedMap); ordered {
+			target = NewOrderedMap()
+		} else {
+			target = make(map[string]any)
+		}
+	}
+	mergePatchObject(target, members)
+	return target, true
+}
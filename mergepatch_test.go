@@ -0,0 +1,76 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	// The examples from RFC 7386, Appendix A
+	tests := []struct {
+		target, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		target, err := Parse(tt.target)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.target, err)
+		}
+		patch, err := Parse(tt.patch)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.patch, err)
+		}
+		expected, err := Parse(tt.expected)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expected, err)
+		}
+
+		MergePatch(target, patch)
+		if !reflect.DeepEqual(target, expected) {
+			t.Errorf("MergePatch(%s, %s) = %v, want %v", tt.target, tt.patch, target, expected)
+		}
+	}
+}
+
+func TestMergePatchOrdered(t *testing.T) {
+	ordered, err := ParseOrdered(`{"z": 1, "a": {"y": 2, "x": 3}}`)
+	if err != nil {
+		t.Fatalf("ParseOrdered() error = %v", err)
+	}
+	patch, err := ParseOrdered(`{"a": {"x": null, "w": 4}, "new": {"q": 1, "p": 2}, "z": 0}`)
+	if err != nil {
+		t.Fatalf("ParseOrdered() error = %v", err)
+	}
+
+	target := map[string]any{"doc": ordered}
+	MergePatch(target, map[string]any{"doc": patch})
+
+	data, err := json.Marshal(target["doc"])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"z":0,"a":{"y":2,"w":4},"new":{"q":1,"p":2}}`; string(data) != want {
+		t.Errorf("MergePatch() = %s, want %s", data, want)
+	}
+
+	// Values from the patch are copied
+	list := []interface{}{int64(1)}
+	out := map[string]any{}
+	MergePatch(out, map[string]any{"list": list})
+	list[0] = int64(2)
+	if out["list"].([]interface{})[0] != int64(1) {
+		t.Error("Changing the patch changed the target")
+	}
+}
@@ -0,0 +1,56 @@
+package flexjson
+
+// NoiseFilter selects which transport keep-alive artifacts the StreamingParser
+// silently discards between JSON tokens. Blank lines are always tolerated as
+// whitespace; the filters cover noise that would otherwise be a parse error.
+type NoiseFilter uint8
+
+const (
+	// FilterNUL drops NUL padding bytes
+	FilterNUL NoiseFilter = 1 << iota
+	// FilterSSEComments drops SSE comment lines such as ": ping"
+	FilterSSEComments
+
+	// FilterNone disables noise filtering
+	FilterNone NoiseFilter = 0
+	// FilterAll enables every noise filter
+	FilterAll = FilterNUL | FilterSSEComments
+)
+
+// SetNoiseFilter configures which keep-alive artifacts are discarded outside of strings
+func (sp *StreamingParser) SetNoiseFilter(filter NoiseFilter) {
+	sp.noise = filter
+}
+
+// skipNoise reports whether c is transport noise that should be discarded
+func (sp *StreamingParser) skipNoise(c string) bool {
+	if sp.noise == FilterNone || sp.lexer.inString() {
+		return false
+	}
+
+	// Skip the rest of an SSE comment line
+	if sp.inComment {
+		if c == "\n" || c == "\r" {
+			sp.inComment = false
+			sp.lastChar = c
+		}
+		return true
+	}
+
+	if sp.noise&FilterNUL != 0 && c == "\x00" {
+		return true
+	}
+
+	// A colon at the start of a line is an SSE comment unless it separates a key from its value
+	if sp.noise&FilterSSEComments != 0 && c == ":" && sp.state != stateColon && sp.atLineStart() {
+		sp.inComment = true
+		return true
+	}
+
+	return false
+}
+
+// atLineStart reports whether the next character begins a new line
+func (sp *StreamingParser) atLineStart() bool {
+	return sp.lastChar == "" || sp.lastChar == "\n" || sp.lastChar == "\r"
+}
@@ -0,0 +1,81 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStreamingParser_NoiseFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter NoiseFilter
+		chunks []string
+	}{
+		{
+			name:   "Blank lines",
+			filter: FilterNone,
+			chunks: []string{"\n\n", `{"name":"John",`, "\n\n", `"age":30}`},
+		},
+		{
+			name:   "NUL padding",
+			filter: FilterNUL,
+			chunks: []string{"\x00\x00", `{"name":"John",`, "\x00", `"age":30}`, "\x00\x00\x00"},
+		},
+		{
+			name:   "SSE comments",
+			filter: FilterSSEComments,
+			chunks: []string{": ping\n", `{"name":"John",`, "\n: keep-alive\n", `"age":30}`, "\n: ping\n"},
+		},
+		{
+			name:   "Key split across lines",
+			filter: FilterAll,
+			chunks: []string{": ping\n", `{"name"`, "\n", `:"John",`, "\x00", `"age"`, "\x00\n", `:30}`},
+		},
+	}
+
+	expected := map[string]any{
+		"name": "John",
+		"age":  int64(30),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := make(map[string]any)
+			sp := NewStreamingParser(&output)
+			sp.SetNoiseFilter(tt.filter)
+
+			for _, chunk := range tt.chunks {
+				if err := sp.ProcessString(chunk); err != nil {
+					t.Fatalf("Error processing chunk %q: %v", chunk, err)
+				}
+			}
+
+			if !reflect.DeepEqual(output, expected) {
+				t.Errorf("Unexpected result. Got %v, expected %v", output, expected)
+			}
+		})
+	}
+}
+
+func TestStreamingParser_NoiseFilterDisabled(t *testing.T) {
+	sp := NewStreamingParser(nil)
+
+	if err := sp.ProcessString("\x00{}"); err == nil {
+		t.Errorf("Expected an error for NUL padding without a noise filter")
+	}
+}
+
+func TestStreamingParser_NoiseFilterKeepsStrings(t *testing.T) {
+	output := make(map[string]any)
+	sp := NewStreamingParser(&output)
+	sp.SetNoiseFilter(FilterAll)
+
+	if err := sp.ProcessString("{\"text\":\"\n: not a comment\"}"); err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+
+	expected := map[string]any{"text": "\n: not a comment"}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("Unexpected result. Got %v, expected %v", output, expected)
+	}
+}
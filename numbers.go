@@ -0,0 +1,71 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// numberMode selects the Go type that numbers are stored as
+type numberMode uint8
+
+const (
+	numberDefault numberMode = iota // int64 when the number is an integer that fits, otherwise float64 (json.Number for an integer too large for both)
+	numberJSON                      // json.Number
+	numberBig                       // As numberDefault, or *big.Int and *big.Float when that would lose precision
+	numberFloat64                   // float64, as encoding/json decodes into interface values
+)
+
+// number converts the text of a number token to a value in the decoder's
+// number mode. It returns false if the text isn't a valid number.
+func (d *decoder) number(s string) (interface{}, bool) {
+	switch d.numbers {
+	case numberJSON:
+		return json.Number(s), validNumber(s)
+	case numberBig:
+		return parseBigNumber(s)
+	case numberFloat64:
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil
+	}
+	if n, ok := parseNumber(s); ok {
+		return n, true
+	}
+	if !strings.ContainsAny(s, ".eE") && validNumber(s) {
+		// An integer too large even for a float64 keeps its text
+		return json.Number(s), true
+	}
+	return nil, false
+}
+
+// parseBigNumber parses s as an int64 or float64 if it fits without losing
+// precision, and otherwise as a *big.Int or *big.Float
+func parseBigNumber(s string) (interface{}, bool) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, true
+	}
+	if !strings.ContainsAny(s, ".eE") {
+		i, ok := new(big.Int).SetString(s, 10)
+		return i, ok
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err == nil && sameDecimal(strconv.FormatFloat(f, 'g', -1, 64), s) {
+		return f, true
+	}
+
+	// Roughly 3.3 bits per decimal digit, with room to spare
+	bf, _, err := big.ParseFloat(s, 10, uint(max(64, 4*len(s))), big.ToNearestEven)
+	return bf, err == nil
+}
+
+// sameDecimal reports whether two decimal numbers are equal
+func sameDecimal(a, b string) bool {
+	ra, ok := new(big.Rat).SetString(a)
+	if !ok {
+		return false
+	}
+	rb, ok := new(big.Rat).SetString(b)
+	return ok && ra.Cmp(rb) == 0
+}
@@ -0,0 +1,114 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBigNumber(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // Type and value, formatted with %T %v
+	}{
+		{"42", "int64 42"},
+		{"-9223372036854775808", "int64 -9223372036854775808"},
+		{"9223372036854775808", "*big.Int 9223372036854775808"},
+		{"-123456789012345678901234567890", "*big.Int -123456789012345678901234567890"},
+		{"0.1", "float64 0.1"},
+		{"2.50", "float64 2.5"},
+		{"1e300", "float64 1e+300"},
+		{"0.12345678901234567890123", "*big.Float 0.12345678901234567890123"},
+		{"1e400", "*big.Float 1e+400"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			value, ok := parseBigNumber(tt.input)
+			if !ok {
+				t.Fatalf("parseBigNumber(%q) failed", tt.input)
+			}
+			got := fmt.Sprintf("%T %v", value, value)
+			if f, isFloat := value.(*big.Float); isFloat {
+				got = "*big.Float " + f.Text('g', -1)
+			}
+			if got != tt.expected {
+				t.Errorf("parseBigNumber(%q) = %s, want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParserUseBigNumbers(t *testing.T) {
+	p := NewParser(NewLexer(`{"small":1,"big":123456789012345678901234567890,"bad":1.2.3}`).Tokenize())
+	p.UseBigNumbers()
+
+	_, err := p.Parse()
+	if err == nil {
+		t.Fatal("Parse() error = nil, want invalid number")
+	}
+
+	output := make(map[string]any)
+	sp := NewStreamingParser(&output)
+	sp.UseBigNumbers()
+	if err := sp.ProcessString(`{"small":1,"big":123456789012345678901234567890}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	want, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	if b, ok := output["big"].(*big.Int); !ok || b.Cmp(want) != 0 {
+		t.Errorf("big = %#v", output["big"])
+	}
+	if output["small"] != int64(1) {
+		t.Errorf("small = %#v", output["small"])
+	}
+}
+
+func TestUseFloat64MatchesEncodingJSON(t *testing.T) {
+	input := `{"age":30,"n":-2,"ratio":0.5,"list":[1,2.5,{"deep":7}]}`
+
+	var expected any
+	if err := json.Unmarshal([]byte(input), &expected); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewParser(NewLexer(input).Tokenize())
+	p.UseFloat64()
+	value, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("Parse() = %#v, want %#v", value, expected)
+	}
+
+	output := make(map[string]any)
+	sp := NewStreamingParser(&output)
+	sp.UseFloat64()
+	if err := sp.ProcessString(input); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("Output = %#v, want %#v", output, expected)
+	}
+}
+
+func TestHugeInteger(t *testing.T) {
+	// Too large even for a float64, but still a number
+	digits := strings.Repeat("9", 100000)
+
+	output := make(map[string]any)
+	sp := NewStreamingParser(&output)
+	if err := sp.ProcessString(`{"n":` + digits + `,"f":1.5}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if output["n"] != json.Number(digits) {
+		t.Errorf("n = %.20v (%T), want a json.Number", output["n"], output["n"])
+	}
+	if output["f"] != 1.5 {
+		t.Errorf("f = %#v, want 1.5", output["f"])
+	}
+}
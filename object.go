@@ -0,0 +1,86 @@
+package flexjson
+
+import (
+	"slices"
+	"strings"
+)
+
+// Object wraps a parsed object for lookups that tolerate the inconsistent key
+// casing of model output, where one response has "userName" and the next
+// "UserName". Any map[string]any converts to it:
+//
+//	name, ok := flexjson.Object(result).Get("user.name")
+type Object map[string]any
+
+// Get returns the value at path, in the format described in path.go, and
+// whether it was found. Each key in the path matches an object key exactly
+// if there is one, and otherwise matches it ignoring case; if several keys
+// differ only in case, the first in sorted order is used. Nested objects may
+// be map[string]any or *OrderedMap, and arrays []interface{}. The empty path
+// returns the object itself.
+func (o Object) Get(path string) (any, bool) {
+	segments, ok := parsePath(path)
+	if !ok {
+		return nil, false
+	}
+
+	var value any = map[string]any(o)
+	for _, seg := range segments {
+		if seg.index == anyIndex {
+			return nil, false
+		}
+		if value, ok = lookupFold(value, seg); !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// Has reports whether there is a value at path, matched as Get matches it
+func (o Object) Has(path string) bool {
+	_, ok := o.Get(path)
+	return ok
+}
+
+// lookupFold returns the member or element of container selected by seg,
+// matching keys case-insensitively when there is no exact match
+func lookupFold(container any, seg pathSegment) (any, bool) {
+	if seg.index >= 0 {
+		arr, ok := container.([]interface{})
+		if !ok || seg.index >= len(arr) {
+			return nil, false
+		}
+		return arr[seg.index], true
+	}
+
+	var keys []string
+	switch obj := container.(type) {
+	case map[string]any:
+		if v, ok := obj[seg.key]; ok {
+			return v, true
+		}
+		for key := range obj {
+			if strings.EqualFold(key, seg.key) {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			return nil, false
+		}
+		return obj[slices.Min(keys)], true
+	case *OrderedMap:
+		if v, ok := obj.Get(seg.key); ok {
+			return v, true
+		}
+		for key := range obj.All() {
+			if strings.EqualFold(key, seg.key) {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			return nil, false
+		}
+		return obj.Get(slices.Min(keys))
+	}
+	return nil, false
+}
@@ -0,0 +1,64 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestObjectGet(t *testing.T) {
+	obj, err := Parse(`{
+		"User": {"firstName": "Ada", "Tags": ["a", {"Kind": "x"}]},
+		"status": "ok", "STATUS": "shouted", "Status": "title",
+		"content.type": "json", "": {"Empty": true}
+	}`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected any
+		found    bool
+	}{
+		{"user.FIRSTNAME", "Ada", true},
+		{"User.firstName", "Ada", true},
+		{"user.tags[0]", "a", true},
+		{"user.tags[1].kind", "x", true},
+		{"status", "ok", true},
+		{"Status", "title", true},
+		{"sTaTuS", "shouted", true},
+		{`["Content.Type"]`, "json", true},
+		{`[""].empty`, true, true},
+		{"user.tags[2]", nil, false},
+		{"user.missing", nil, false},
+		{"status.length", nil, false},
+		{"user..tags", nil, false},
+		{"user.tags[x]", nil, false},
+		{"user.tags[0", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, found := Object(obj).Get(tt.path)
+		if found != tt.found || !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Get(%q) = %v, %v, want %v, %v", tt.path, got, found, tt.expected, tt.found)
+		}
+	}
+
+	if got, _ := Object(obj).Get(""); !reflect.DeepEqual(got, obj) {
+		t.Errorf("Get(\"\") = %v, want the object", got)
+	}
+	if !Object(obj).Has("USER") || Object(obj).Has("users") {
+		t.Error("Has() mismatched")
+	}
+}
+
+func TestObjectGetOrdered(t *testing.T) {
+	ordered, err := ParseOrdered(`{"Outer": {"Inner": [1, 2]}}`)
+	if err != nil {
+		t.Fatalf("ParseOrdered() error = %v", err)
+	}
+	obj := Object{"root": ordered}
+	if got, ok := obj.Get("ROOT.outer.inner[1]"); !ok || got != int64(2) {
+		t.Errorf("Get() = %v, %v, want 2, true", got, ok)
+	}
+}
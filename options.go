@@ -0,0 +1,28 @@
+package flexjson
+
+// Option configures a StreamingParser or Parser when it is created, or a
+// call to Parse
+type Option func(*decoder)
+
+// WithMerge makes a StreamingParser keep the contents of the output map
+// instead of clearing it, when it is created and when it is Reset. Parsed
+// members are merged into it: an object is merged into the object already
+// stored under the same key, recursively, and any other value replaces the
+// value stored under its key. This accumulates the fields of several
+// sequential streams into one map.
+func WithMerge(merge bool) Option {
+	return func(d *decoder) {
+		d.merge = merge
+	}
+}
+
+// existingObject returns the object already stored under the current key of
+// the object on top of the stack, which a new object is merged into
+func (d *decoder) existingObject() (map[string]any, bool) {
+	top := d.top()
+	if top.obj == nil {
+		return nil, false
+	}
+	obj, ok := top.obj[top.key].(map[string]any)
+	return obj, ok
+}
@@ -0,0 +1,56 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithMerge(t *testing.T) {
+	output := map[string]any{
+		"kept":  "yes",
+		"user":  map[string]any{"name": "Jo", "tags": []interface{}{"a"}},
+		"count": int64(1),
+	}
+
+	sp := NewStreamingParser(&output, WithMerge(true))
+	if err := sp.ProcessString(`{"user":{"age":30,"tags":["b"]},"count":2}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	// A second stream is merged in after Reset
+	sp.Reset()
+	if err := sp.ProcessString(`{"user":{"email":"jo@example.com"},"kept":"still"}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	expected := map[string]any{
+		"kept": "still",
+		"user": map[string]any{
+			"name":  "Jo",
+			"age":   int64(30),
+			"tags":  []interface{}{"b"},
+			"email": "jo@example.com",
+		},
+		"count": int64(2),
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("Output = %#v, want %#v", output, expected)
+	}
+}
+
+func TestWithoutMerge(t *testing.T) {
+	output := map[string]any{"old": true}
+
+	sp := NewStreamingParser(&output, WithMerge(false))
+	if len(output) != 0 {
+		t.Errorf("Output = %v, want it cleared", output)
+	}
+
+	if err := sp.ProcessString(`{"a":{"b":1}}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	sp.Reset()
+	if len(output) != 0 {
+		t.Errorf("Output after Reset = %v, want it cleared", output)
+	}
+}
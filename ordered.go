@@ -0,0 +1,149 @@
+package flexjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"iter"
+)
+
+// Pair is a member of an OrderedMap
+type Pair struct {
+	Key   string
+	Value any
+}
+
+// OrderedMap is an object that remembers the order of its keys, for
+// documents such as configuration files whose key order matters when they are
+// written back out. It is a MapSink, so a StreamingParser fills one with
+// SetSink, and ParseOrdered returns one. Nested objects are *OrderedMap too,
+// and arrays are []interface{}.
+//
+// The zero value is an empty map ready to use. An OrderedMap is not safe for
+// concurrent use.
+type OrderedMap struct {
+	pairs []Pair         // Members in the order their keys first appeared
+	index map[string]int // Index of each key's member in pairs
+}
+
+// NewOrderedMap returns an empty OrderedMap
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{}
+}
+
+// Set stores value under key. A new key is added at the end, and an existing
+// key keeps its position.
+func (m *OrderedMap) Set(key string, value any) {
+	if i, ok := m.index[key]; ok {
+		m.pairs[i].Value = value
+		return
+	}
+	if m.index == nil {
+		m.index = make(map[string]int)
+	}
+	m.index[key] = len(m.pairs)
+	m.pairs = append(m.pairs, Pair{Key: key, Value: value})
+}
+
+// NewObject returns an empty OrderedMap for a nested object
+func (m *OrderedMap) NewObject() MapSink {
+	return NewOrderedMap()
+}
+
+// Get returns the value stored under key, and whether it was present
+func (m *OrderedMap) Get(key string) (any, bool) {
+	i, ok := m.index[key]
+	if !ok {
+		return nil, false
+	}
+	return m.pairs[i].Value, true
+}
+
+// Delete removes key, keeping the order of the other keys
+func (m *OrderedMap) Delete(key string) {
+	i, ok := m.index[key]
+	if !ok {
+		return
+	}
+	delete(m.index, key)
+	m.pairs = append(m.pairs[:i], m.pairs[i+1:]...)
+	for _, p := range m.pairs[i:] {
+		m.index[p.Key]--
+	}
+}
+
+// Len returns the number of members
+func (m *OrderedMap) Len() int {
+	return len(m.pairs)
+}
+
+// Keys returns the keys in order
+func (m *OrderedMap) Keys() []string {
+	keys := make([]string, len(m.pairs))
+	for i, p := range m.pairs {
+		keys[i] = p.Key
+	}
+	return keys
+}
+
+// Pairs returns the members in order. The slice must not be modified.
+func (m *OrderedMap) Pairs() []Pair {
+	return m.pairs
+}
+
+// All returns an iterator over the members in order
+func (m *OrderedMap) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, p := range m.pairs {
+			if !yield(p.Key, p.Value) {
+				return
+			}
+		}
+	}
+}
+
+// MarshalJSON implements json.Marshaler, writing the members in order
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, p := range m.pairs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(p.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(p.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ParseOrdered parses input like Parse, but returns the root object as an
+// OrderedMap, with nested objects as *OrderedMap too. Errors recovered from,
+// and with WithBestEffort the object parsed so far, are returned as Parse
+// returns them.
+func ParseOrdered(input string, opts ...Option) (obj *OrderedMap, err error) {
+	defer recoverInternal(&err, nil)
+
+	p := NewParser(nil, opts...)
+	p.config.sink = NewOrderedMap()
+	p.config.objectsOnly = true
+	lexer := NewLexer(input)
+	p.config.configureLexer(lexer)
+	p.tokens = lexer.Tokenize()
+
+	p.SetHardened(true)
+	result, err := p.Parse()
+	if err != nil && !p.config.bestEffort {
+		return nil, locateError(err, input)
+	}
+	obj, _ = result.(*OrderedMap)
+	return obj, withIssues(locateError(err, input), p.issues, input)
+}
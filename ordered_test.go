@@ -0,0 +1,95 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseOrdered(t *testing.T) {
+	input := `{"zeta": 1, "alpha": {"y": true, "b": null}, "mid": [{"k": "v", "a": 2}], "zeta": 3}`
+
+	obj, err := ParseOrdered(input)
+	if err != nil {
+		t.Fatalf("ParseOrdered() error = %v", err)
+	}
+	if keys := obj.Keys(); !reflect.DeepEqual(keys, []string{"zeta", "alpha", "mid"}) {
+		t.Errorf("Keys() = %v", keys)
+	}
+	if v, ok := obj.Get("zeta"); !ok || v != int64(3) {
+		t.Errorf("Get(zeta) = %v, %v, want 3, true", v, ok)
+	}
+	alpha, _ := obj.Get("alpha")
+	if keys := alpha.(*OrderedMap).Keys(); !reflect.DeepEqual(keys, []string{"y", "b"}) {
+		t.Errorf("Nested keys = %v", keys)
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"zeta":3,"alpha":{"y":true,"b":null},"mid":[{"k":"v","a":2}]}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	// Partial input keeps the order seen so far
+	obj, err = ParseOrdered(`{"b": 1, "a": {"d": 2, "c"`)
+	if err != nil {
+		t.Fatalf("ParseOrdered() error = %v", err)
+	}
+	if data, _ := json.Marshal(obj); string(data) != `{"b":1,"a":{"d":2,"c":null}}` {
+		t.Errorf("json.Marshal() = %s", data)
+	}
+
+	if _, err := ParseOrdered(`[1, 2]`); !errors.Is(err, ErrNotAnObject) {
+		t.Errorf("ParseOrdered([1, 2]) error = %v, want ErrNotAnObject", err)
+	}
+}
+
+func TestOrderedMap(t *testing.T) {
+	var m OrderedMap
+	for _, key := range []string{"c", "a", "d", "b"} {
+		m.Set(key, key+key)
+	}
+	m.Set("a", "A")
+	m.Delete("c")
+	m.Delete("missing")
+
+	var pairs []Pair
+	for k, v := range m.All() {
+		pairs = append(pairs, Pair{k, v})
+	}
+	want := []Pair{{"a", "A"}, {"d", "dd"}, {"b", "bb"}}
+	if !reflect.DeepEqual(pairs, want) || !reflect.DeepEqual(m.Pairs(), want) {
+		t.Errorf("All() = %v, want %v", pairs, want)
+	}
+	if v, ok := m.Get("b"); !ok || v != "bb" || m.Len() != 3 {
+		t.Errorf("Get(b) = %v, %v, Len() = %d", v, ok, m.Len())
+	}
+	if _, ok := m.Get("c"); ok {
+		t.Error("Get(c) found a deleted key")
+	}
+}
+
+func TestStreamingParserOrderedMap(t *testing.T) {
+	obj := NewOrderedMap()
+	sp := NewStreamingParser(nil)
+	sp.SetSink(obj)
+
+	input := `{"3": "c", "1": {"x": [1, {"z": 0, "y": 1}]}, "2": "b"}`
+	for i := 0; i < len(input); i++ {
+		if err := sp.ProcessString(input[i : i+1]); err != nil {
+			t.Fatalf("ProcessString() error = %v", err)
+		}
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"3":"c","1":{"x":[1,{"z":0,"y":1}]},"2":"b"}`; string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+}
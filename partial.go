@@ -0,0 +1,99 @@
+package flexjson
+
+// WithPartialStrings makes a StreamingParser store a string value in the
+// output as it streams, instead of only once its closing quote arrives, so
+// that long fields such as the content of a model's reply can be displayed
+// as they are written. After each Process call, a string that is still
+// streaming holds the text decoded so far; it is replaced as more arrives,
+// until the final value is stored. IncompletePaths lists the path of a
+// string that isn't final yet.
+//
+// Strings are stored in an output map, not a MapSink, and not where output is
+// discarded by WithProject, WithSkip, or a memory budget. Parse always stores
+// a string cut off by the end of the input, so the option has no effect on
+// it.
+func WithPartialStrings() Option {
+	return func(d *decoder) {
+		d.partials = true
+	}
+}
+
+// partialValue records a partial string stored in the output, so that it can
+// be taken out again before more input is processed
+type partialValue struct {
+	depth   int    // Stack depth of the container holding it (0 when none)
+	key     string // Key of the string in an object
+	prev    any    // Value the key held before (when hadPrev)
+	hadPrev bool
+}
+
+// endChunk finishes a Process call, sending or storing the string that is
+// still streaming, and the merged updates of watched containers
+func (sp *StreamingParser) endChunk() {
+	sp.deliverMerged()
+	if sp.patches != nil {
+		sp.patchString()
+	}
+	if sp.partials {
+		sp.storePartial()
+	}
+}
+
+// storePartial stores the string that is still streaming in the output
+func (sp *StreamingParser) storePartial() {
+	if !sp.lexer.inString() || !sp.expectingValue() || len(sp.stack) == 0 || sp.skipOutput || sp.discarding {
+		return
+	}
+	path := sp.valuePath()
+	if sp.filtering() && sp.discardValue(path) {
+		return
+	}
+
+	text := sp.lexer.pendingText()
+	top := sp.top()
+	stored := partialValue{depth: len(sp.stack), key: top.key}
+	switch top.kind {
+	case containerRoot, containerObject:
+		stored.prev, stored.hadPrev = top.obj[top.key]
+		top.obj[top.key] = text
+	case containerArray:
+		top.arr = append(top.arr, text)
+		sp.storeArray(len(sp.stack) - 1)
+	default:
+		return
+	}
+	sp.stored = stored
+	sp.changed(path)
+}
+
+// unstorePartial takes the partial string stored by storePartial out of the
+// output again, so the decoder finds the output as it left it
+func (sp *StreamingParser) unstorePartial() {
+	stored := sp.stored
+	if stored.depth == 0 {
+		return
+	}
+	sp.stored = partialValue{}
+
+	f := &sp.stack[stored.depth-1]
+	switch {
+	case f.kind == containerArray:
+		f.arr = f.arr[:len(f.arr)-1]
+		sp.storeArray(stored.depth - 1)
+	case stored.hadPrev:
+		f.obj[stored.key] = stored.prev
+	default:
+		delete(f.obj, stored.key)
+	}
+}
+
+// pendingPath returns the path of the value still streaming, which for a
+// partial string stored in an array is the last element's
+func (sp *StreamingParser) pendingPath() string {
+	if sp.stored.depth > 0 {
+		if top := sp.top(); top.kind == containerArray {
+			return appendIndexPath(top.path, len(top.arr)-1)
+		}
+	}
+	return sp.valuePath()
+}
@@ -0,0 +1,85 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithPartialStrings(t *testing.T) {
+	sp := NewStreamingParser(nil, WithPartialStrings())
+	steps := []struct {
+		chunk      string
+		expected   map[string]any
+		incomplete []string
+	}{
+		{`{"content": "Hel`, map[string]any{"content": "Hel"}, []string{"content"}},
+		{`lo, w`, map[string]any{"content": "Hello, w"}, []string{"content"}},
+		{`orld", "list": ["a`, map[string]any{"content": "Hello, world", "list": []interface{}{"a"}}, []string{"list", "list[0]"}},
+		{`b\n`, map[string]any{"content": "Hello, world", "list": []interface{}{"ab\n"}}, []string{"list", "list[0]"}},
+		{`", "c`, map[string]any{"content": "Hello, world", "list": []interface{}{"ab\n", "c"}}, []string{"list", "list[1]"}},
+		{`"], "n": 1`, map[string]any{"content": "Hello, world", "list": []interface{}{"ab\n", "c"}}, []string{"n"}},
+		{`}`, map[string]any{"content": "Hello, world", "list": []interface{}{"ab\n", "c"}, "n": int64(1)}, []string{}},
+	}
+
+	for _, step := range steps {
+		if err := sp.ProcessString(step.chunk); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", step.chunk, err)
+		}
+		if !reflect.DeepEqual(sp.GetCurrentOutput(), step.expected) {
+			t.Errorf("Output after %q = %#v, want %#v", step.chunk, sp.GetCurrentOutput(), step.expected)
+		}
+		if got := sp.IncompletePaths(); !reflect.DeepEqual(got, step.incomplete) {
+			t.Errorf("IncompletePaths() after %q = %q, want %q", step.chunk, got, step.incomplete)
+		}
+	}
+}
+
+func TestWithPartialStringsKeys(t *testing.T) {
+	// Keys aren't stored before they are complete, and a repeated key keeps
+	// its earlier value until the new one replaces it
+	sp := NewStreamingParser(nil, WithPartialStrings())
+	for _, chunk := range []string{`{"a": "old", "lon`, `g": 1, "a": "ne`} {
+		if err := sp.ProcessString(chunk); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", chunk, err)
+		}
+	}
+	if want := map[string]any{"a": "ne", "long": int64(1)}; !reflect.DeepEqual(sp.GetCurrentOutput(), want) {
+		t.Errorf("Output = %v, want %v", sp.GetCurrentOutput(), want)
+	}
+
+	sp.Reset()
+	if err := sp.ProcessString(`{"x": "y`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if want := map[string]any{"x": "y"}; !reflect.DeepEqual(sp.GetCurrentOutput(), want) {
+		t.Errorf("Output after Reset = %v, want %v", sp.GetCurrentOutput(), want)
+	}
+
+	// Without the option, the string waits for its closing quote
+	sp = NewStreamingParser(nil)
+	if err := sp.ProcessString(`{"x": "y`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if len(sp.GetCurrentOutput()) != 0 {
+		t.Errorf("Output = %v, want none", sp.GetCurrentOutput())
+	}
+}
+
+func TestWithPartialStringsQuery(t *testing.T) {
+	sp := NewStreamingParser(nil, WithPartialStrings())
+	if err := sp.ProcessString(`{"a": "done", "b": ["x`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	matches, err := sp.Query("$..*")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := []Match{
+		{Path: "a", Value: "done", Complete: true},
+		{Path: "b", Value: []interface{}{"x"}, Complete: false},
+		{Path: "b[0]", Value: "x", Complete: false},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("Query() = %v, want %v", matches, want)
+	}
+}
@@ -0,0 +1,96 @@
+package flexjson
+
+// PatchOp is a JSON Patch (RFC 6902) operation. Marshaled with
+// encoding/json, a sequence of them can be applied by any JSON Patch library
+// to rebuild the output, e.g. in a browser receiving them over a websocket.
+type PatchOp struct {
+	Op    string `json:"op"`    // "add" or "replace"
+	Path  string `json:"path"`  // JSON Pointer to the value
+	Value any    `json:"value"` // New value
+}
+
+// WithPatches makes a parser describe the output as it grows as JSON Patch
+// operations passed to handle, so incremental updates can be forwarded
+// instead of whole snapshots:
+//
+//   - the root object is sent first, as an "add" of {} at ""
+//   - each object or array is sent empty, as an "add", when it is opened, and
+//     is filled in by the operations for its members or elements
+//   - each string, number, and literal is sent as an "add" once it is
+//     complete, or a "replace" when a repeated key or WithMerge overwrites a
+//     value
+//
+// A StreamingParser also sends strings as they grow: at the end of each
+// Process call, a string that is still streaming is sent with the text
+// decoded so far, as an "add" the first time and a "replace" after that,
+// with a final "replace" once it is complete. Values that aren't stored, such
+// as those skipped with WithSkip, aren't sent. handle is called while input
+// is being processed, before the value is visible in the output.
+func WithPatches(handle func(op PatchOp)) Option {
+	return func(d *decoder) {
+		d.patches = handle
+	}
+}
+
+// patch sends an operation on the value at path
+func (d *decoder) patch(op, path string, value any) {
+	d.patches(PatchOp{Op: op, Path: pathPointer(path), Value: value})
+}
+
+// patchedValue returns value as it is sent in a patch. A new object or array,
+// whose frame is container, is sent empty and filled in as it grows.
+func patchedValue(value any, container *frame) any {
+	switch {
+	case container == nil:
+		return value
+	case container.isArray():
+		return []interface{}{}
+	}
+	return map[string]any{}
+}
+
+// patchValue sends the operation that stores value in current, the container
+// on top of the stack
+func (d *decoder) patchValue(current *frame, value any) {
+	op := "add"
+	if current.obj != nil {
+		if _, ok := current.obj[current.key]; ok {
+			op = "replace"
+		}
+	}
+	if d.partialOp {
+		// The string was sent as it streamed
+		op = "replace"
+		d.partialOp = false
+	}
+	d.patch(op, d.valuePath(), value)
+}
+
+// patchString sends the string that is still streaming, if it has grown
+// since it was last sent
+func (sp *StreamingParser) patchString() {
+	if !sp.lexer.inString() || !sp.expectingValue() || len(sp.stack) == 0 || sp.skipOutput || sp.discarding {
+		return
+	}
+	path := sp.valuePath()
+	if sp.filtering() && sp.discardValue(path) {
+		return
+	}
+
+	text := sp.lexer.pendingText()
+	if sp.partialOp && text == sp.partialText {
+		return
+	}
+	op := "replace"
+	if !sp.partialOp {
+		op = "add"
+		if top := sp.top(); top.kind == containerRoot {
+			if _, exists := top.obj[top.key]; exists {
+				op = "replace"
+			}
+		}
+	}
+	sp.partialOp = true
+	sp.partialText = text
+	sp.patch(op, path, text)
+}
@@ -0,0 +1,99 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// applyPatch applies add and replace operations, appending when an add
+// targets the index just past the end of an array
+func applyPatch(t *testing.T, doc map[string]any, op PatchOp) map[string]any {
+	t.Helper()
+	if op.Path == "" {
+		return op.Value.(map[string]any)
+	}
+	pointer := op.Path
+	if op.Op == "add" {
+		parent := pointer[:strings.LastIndexByte(pointer, '/')]
+		last := pointer[len(parent)+1:]
+		if container, err := GetPointer(doc, parent); err == nil {
+			if arr, ok := container.([]interface{}); ok && last == strconv.Itoa(len(arr)) {
+				pointer = parent + "/-"
+			}
+		}
+	}
+	if err := SetPointer(doc, pointer, op.Value); err != nil {
+		t.Fatalf("SetPointer(%q) error = %v", pointer, err)
+	}
+	return doc
+}
+
+func TestWithPatches(t *testing.T) {
+	input := `{"id": "a", "choices": [{"text": "Hel` + `lo"}, [1, 2]], "meta": {}, "id": "b", "a/b": null}`
+
+	var ops []PatchOp
+	sp := NewStreamingParser(nil, WithPatches(func(op PatchOp) { ops = append(ops, op) }))
+	for _, chunk := range strings.SplitAfter(input, "Hel") {
+		if err := sp.ProcessString(chunk); err != nil {
+			t.Fatalf("ProcessString() error = %v", err)
+		}
+	}
+
+	expected := []PatchOp{
+		{"add", "", map[string]any{}},
+		{"add", "/id", "a"},
+		{"add", "/choices", []interface{}{}},
+		{"add", "/choices/0", map[string]any{}},
+		{"add", "/choices/0/text", "Hel"},
+		{"replace", "/choices/0/text", "Hello"},
+		{"add", "/choices/1", []interface{}{}},
+		{"add", "/choices/1/0", int64(1)},
+		{"add", "/choices/1/1", int64(2)},
+		{"add", "/meta", map[string]any{}},
+		{"replace", "/id", "b"},
+		{"add", "/a~1b", nil},
+	}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Fatalf("Patches = %v, want %v", ops, expected)
+	}
+
+	// Applying the operations rebuilds the output
+	var doc map[string]any
+	for _, op := range ops {
+		doc = applyPatch(t, doc, op)
+	}
+	if !reflect.DeepEqual(doc, sp.GetCurrentOutput()) {
+		t.Errorf("Patched document = %v, want %v", doc, sp.GetCurrentOutput())
+	}
+
+	data, err := json.Marshal(ops[len(ops)-1])
+	if err != nil || string(data) != `{"op":"add","path":"/a~1b","value":null}` {
+		t.Errorf("json.Marshal() = %s, %v", data, err)
+	}
+}
+
+func TestWithPatchesPartialArrayString(t *testing.T) {
+	var ops []PatchOp
+	sp := NewStreamingParser(nil, WithPatches(func(op PatchOp) { ops = append(ops, op) }))
+	for _, chunk := range []string{`{"a": ["x", "ab`, `c`, `d`, `"]}`} {
+		if err := sp.ProcessString(chunk); err != nil {
+			t.Fatalf("ProcessString() error = %v", err)
+		}
+	}
+
+	expected := []PatchOp{
+		{"add", "", map[string]any{}},
+		{"add", "/a", []interface{}{}},
+		{"add", "/a/0", "x"},
+		{"add", "/a/1", "ab"},
+		{"replace", "/a/1", "abc"},
+		{"replace", "/a/1", "abcd"},
+		{"replace", "/a/1", "abcd"},
+	}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("Patches = %v, want %v", ops, expected)
+	}
+}
@@ -0,0 +1,104 @@
+package flexjson
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Paths identify values inside a document. Object keys are joined with dots
+// and array indices are written in brackets, e.g. "choices[0].delta.content".
+// Keys that are empty or contain '.', '[', ']', '"', or '\' are written as
+// quoted strings in brackets, e.g. `headers["content.type"]`. The root
+// value has the empty path "".
+
+// appendKeyPath returns the path of the value stored under key in the object at path
+func appendKeyPath(path, key string) string {
+	if key == "" || strings.ContainsAny(key, `.[]"\`) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// appendIndexPath returns the path of the element at index in the array at path
+func appendIndexPath(path string, index int) string {
+	return path + "[" + strconv.Itoa(index) + "]"
+}
+
+// pathSegment is one step of a path: an object key or an array index
+type pathSegment struct {
+	key   string
+	index int // Index into an array (-1 for a key, anyIndex for "[*]")
+}
+
+// anyIndex is the index of a "[*]" segment, which matches any array index
+const anyIndex = -2
+
+// matchSegments reports whether the segments of a path match those of a
+// pattern, which may contain "[*]"
+func matchSegments(pattern, path []pathSegment) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != path[i] && (p.index != anyIndex || path[i].index < 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePath splits a path into its segments. "[*]" is accepted as a segment
+// matching any index, for patterns. It reports false if the path is
+// malformed.
+func parsePath(path string) ([]pathSegment, bool) {
+	var segments []pathSegment
+	for i := 0; i < len(path); {
+		switch {
+		case path[i] == '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, false
+			}
+			inner := path[i+1 : i+end]
+			if strings.HasPrefix(inner, `"`) {
+				// A quoted key may contain ']', so find the closing quote
+				quoted, err := strconv.QuotedPrefix(path[i+1:])
+				if err != nil || !strings.HasPrefix(path[i+1+len(quoted):], "]") {
+					return nil, false
+				}
+				key, _ := strconv.Unquote(quoted)
+				segments = append(segments, pathSegment{key: key, index: -1})
+				i += len(quoted) + 2
+				break
+			}
+			if inner == "*" {
+				segments = append(segments, pathSegment{index: anyIndex})
+				i += end + 1
+				break
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 || inner[0] == '+' {
+				return nil, false
+			}
+			segments = append(segments, pathSegment{index: index})
+			i += end + 1
+		case path[i] == '.' && i > 0:
+			i++
+			fallthrough
+		default:
+			end := i
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			if end == i {
+				return nil, false
+			}
+			segments = append(segments, pathSegment{key: path[i:end], index: -1})
+			i = end
+		}
+	}
+	return segments, true
+}
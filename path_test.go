@@ -0,0 +1,25 @@
+package flexjson
+
+import "testing"
+
+func TestAppendPath(t *testing.T) {
+	tests := []struct {
+		got      string
+		expected string
+	}{
+		{got: appendKeyPath("", "name"), expected: "name"},
+		{got: appendKeyPath("user", "name"), expected: "user.name"},
+		{got: appendKeyPath("", "a.b"), expected: `["a.b"]`},
+		{got: appendKeyPath("user", ""), expected: `user[""]`},
+		{got: appendKeyPath("user", `say "hi"`), expected: `user["say \"hi\""]`},
+		{got: appendIndexPath("", 0), expected: "[0]"},
+		{got: appendIndexPath("choices", 3), expected: "choices[3]"},
+		{got: appendKeyPath(appendIndexPath("choices", 0), "delta"), expected: "choices[0].delta"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("Got path %q, want %q", tt.got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package flexjson
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by GetPointer and SetPointer
+var (
+	ErrInvalidPointer  = errors.New("invalid JSON Pointer")
+	ErrPointerNotFound = errors.New("no value at JSON Pointer")
+)
+
+// GetPointer returns the value at pointer, a JSON Pointer (RFC 6901) such as
+// "/items/0/name", in a parsed object. Objects may be map[string]any or
+// *OrderedMap, and arrays []interface{}, as flexjson builds them. The empty
+// pointer returns out itself. A pointer to a value that isn't there, which in
+// partial output may simply not have arrived yet, returns an error wrapping
+// ErrPointerNotFound.
+func GetPointer(out map[string]any, pointer string) (any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any = out
+	for i, tok := range tokens {
+		child, ok := pointerChild(value, tok)
+		if !ok {
+			return nil, pointerNotFound(tokens[:i+1])
+		}
+		value = child
+	}
+	return value, nil
+}
+
+// SetPointer stores value at pointer in a parsed object, replacing the value
+// there or adding a new object member. The containers above it must exist.
+// An array element is replaced by its index, and "-" appends to the array, as
+// in JSON Patch. The root object can't be replaced.
+//
+// Arrays in the output of a StreamingParser are stored again as they grow,
+// so changes to an array that is still streaming may be lost.
+func SetPointer(out map[string]any, pointer string, value any) error {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("%w: the root object can't be replaced", ErrInvalidPointer)
+	}
+	_, err = setPointer(out, tokens, 0, value)
+	return err
+}
+
+// setPointer stores value at tokens[i:] below container, and returns the
+// container to store in its parent, which for an array may be a new slice
+func setPointer(container any, tokens []string, i int, value any) (any, error) {
+	tok := tokens[i]
+	if i < len(tokens)-1 {
+		child, ok := pointerChild(container, tok)
+		if !ok {
+			return nil, pointerNotFound(tokens[:i+1])
+		}
+		v, err := setPointer(child, tokens, i+1, value)
+		if err != nil {
+			return nil, err
+		}
+		value = v
+	}
+
+	switch c := container.(type) {
+	case map[string]any:
+		c[tok] = value
+		return c, nil
+	case *OrderedMap:
+		c.Set(tok, value)
+		return c, nil
+	case []interface{}:
+		if tok == "-" && i == len(tokens)-1 {
+			return append(c, value), nil
+		}
+		index, ok := arrayIndex(tok, len(c))
+		if !ok {
+			return nil, pointerNotFound(tokens[:i+1])
+		}
+		c[index] = value
+		return c, nil
+	}
+	return nil, pointerNotFound(tokens[:i+1])
+}
+
+// pointerChild returns the member or element of container named by tok
+func pointerChild(container any, tok string) (any, bool) {
+	switch c := container.(type) {
+	case map[string]any:
+		v, ok := c[tok]
+		return v, ok
+	case *OrderedMap:
+		return c.Get(tok)
+	case []interface{}:
+		index, ok := arrayIndex(tok, len(c))
+		if !ok {
+			return nil, false
+		}
+		return c[index], true
+	}
+	return nil, false
+}
+
+// arrayIndex parses a reference token as an index into an array of length n.
+// RFC 6901 allows no sign or leading zeros.
+func arrayIndex(tok string, n int) (int, bool) {
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') || strings.Trim(tok, "0123456789") != "" {
+		return 0, false
+	}
+	index, err := strconv.Atoi(tok)
+	if err != nil || index >= n {
+		return 0, false
+	}
+	return index, true
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%w: %q doesn't start with '/'", ErrInvalidPointer, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, tok := range tokens {
+		if !strings.Contains(tok, "~") {
+			continue
+		}
+		if strings.Contains(strings.NewReplacer("~0", "", "~1", "").Replace(tok), "~") {
+			return nil, fmt.Errorf("%w: %q has a '~' not followed by '0' or '1'", ErrInvalidPointer, pointer)
+		}
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+	}
+	return tokens, nil
+}
+
+// formatPointer joins reference tokens into a JSON Pointer
+func formatPointer(tokens []string) string {
+	var b strings.Builder
+	escape := strings.NewReplacer("~", "~0", "/", "~1")
+	for _, tok := range tokens {
+		b.WriteByte('/')
+		escape.WriteString(&b, tok)
+	}
+	return b.String()
+}
+
+// pathPointer converts a path, in the format described in path.go, to a JSON
+// Pointer
+func pathPointer(path string) string {
+	segments, _ := parsePath(path)
+	tokens := make([]string, len(segments))
+	for i, seg := range segments {
+		if seg.index >= 0 {
+			tokens[i] = strconv.Itoa(seg.index)
+		} else {
+			tokens[i] = seg.key
+		}
+	}
+	return formatPointer(tokens)
+}
+
+// pointerNotFound returns the error for a pointer whose tokens lead nowhere
+func pointerNotFound(tokens []string) error {
+	return fmt.Errorf("%w: %q", ErrPointerNotFound, formatPointer(tokens))
+}
@@ -0,0 +1,109 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGetPointer(t *testing.T) {
+	out, err := Parse(`{"items": [{"name": "a"}, {"name": "b"}], "a/b": 1, "m~n": 2, "": 3, "o": {"": {"x": null}}}`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		pointer  string
+		expected any
+		err      error
+	}{
+		{"/items/0/name", "a", nil},
+		{"/items/1", map[string]any{"name": "b"}, nil},
+		{"/a~1b", int64(1), nil},
+		{"/m~0n", int64(2), nil},
+		{"/", int64(3), nil},
+		{"/o//x", nil, nil},
+		{"/items/2", nil, ErrPointerNotFound},
+		{"/items/01", nil, ErrPointerNotFound},
+		{"/items/-", nil, ErrPointerNotFound},
+		{"/items/0/name/first", nil, ErrPointerNotFound},
+		{"/missing", nil, ErrPointerNotFound},
+		{"items", nil, ErrInvalidPointer},
+		{"/m~2n", nil, ErrInvalidPointer},
+	}
+
+	for _, tt := range tests {
+		got, err := GetPointer(out, tt.pointer)
+		if !errors.Is(err, tt.err) || (tt.err != nil) != (err != nil) {
+			t.Errorf("GetPointer(%q) error = %v, want %v", tt.pointer, err, tt.err)
+		} else if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("GetPointer(%q) = %v, want %v", tt.pointer, got, tt.expected)
+		}
+	}
+
+	if got, err := GetPointer(out, ""); err != nil || !reflect.DeepEqual(got, out) {
+		t.Errorf("GetPointer(\"\") = %v, %v, want the object", got, err)
+	}
+
+	ordered, err := ParseOrdered(`{"a": {"b": [true]}}`)
+	if err != nil {
+		t.Fatalf("ParseOrdered() error = %v", err)
+	}
+	if got, err := GetPointer(map[string]any{"o": ordered}, "/o/a/b/0"); err != nil || got != true {
+		t.Errorf("GetPointer() = %v, %v, want true", got, err)
+	}
+}
+
+func TestSetPointer(t *testing.T) {
+	out, err := Parse(`{"items": [{"name": "a"}], "meta": {}}`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	sets := []struct {
+		pointer string
+		value   any
+	}{
+		{"/items/0/name", "A"},
+		{"/items/-", "appended"},
+		{"/items/-", map[string]any{}},
+		{"/items/2/deep", []interface{}{}},
+		{"/items/2/deep/-", 1},
+		{"/meta/a~1b", true},
+		{"/new", nil},
+	}
+	for _, s := range sets {
+		if err := SetPointer(out, s.pointer, s.value); err != nil {
+			t.Fatalf("SetPointer(%q) error = %v", s.pointer, err)
+		}
+	}
+
+	expected := map[string]any{
+		"items": []interface{}{
+			map[string]any{"name": "A"},
+			"appended",
+			map[string]any{"deep": []interface{}{1}},
+		},
+		"meta": map[string]any{"a/b": true},
+		"new":  nil,
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("Output = %v, want %v", out, expected)
+	}
+
+	errs := []struct {
+		pointer string
+		err     error
+	}{
+		{"", ErrInvalidPointer},
+		{"/missing/key", ErrPointerNotFound},
+		{"/items/5", ErrPointerNotFound},
+		{"/items/-/x", ErrPointerNotFound},
+		{"/new/x", ErrPointerNotFound},
+	}
+	for _, tt := range errs {
+		if err := SetPointer(out, tt.pointer, 1); !errors.Is(err, tt.err) {
+			t.Errorf("SetPointer(%q) error = %v, want %v", tt.pointer, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package flexjson
+
+import "sync"
+
+// ParserPool keeps StreamingParsers for reuse, for servers that parse many
+// small documents, so each one doesn't allocate a parser and its stacks
+// anew. It is safe for concurrent use.
+//
+//	pool := flexjson.NewParserPool(flexjson.WithMaxOutputBytes(1 << 20))
+//	sp := pool.Get(nil)
+//	defer pool.Put(sp)
+type ParserPool struct {
+	opts []Option
+	pool sync.Pool
+}
+
+// NewParserPool returns a pool of parsers created with opts
+func NewParserPool(opts ...Option) *ParserPool {
+	return &ParserPool{opts: opts}
+}
+
+// Get returns a parser ready for a new document that will update output, as
+// one from NewStreamingParser would be. If output is nil a new map is used.
+func (pp *ParserPool) Get(output *map[string]any) *StreamingParser {
+	sp, ok := pp.pool.Get().(*StreamingParser)
+	if !ok {
+		return NewStreamingParser(output, pp.opts...)
+	}
+	sp.reuse(output, pp.opts)
+	return sp
+}
+
+// Put returns sp to the pool. sp must not be used afterwards, but its output
+// map is not touched, so it can be kept.
+func (pp *ParserPool) Put(sp *StreamingParser) {
+	pp.pool.Put(sp)
+}
+
+// reuse returns the parser to its state when it was created for output and
+// opts, dropping watchers, handlers, and settings made with methods, but
+// keeping the memory of its stacks and buffers
+func (sp *StreamingParser) reuse(output *map[string]any, opts []Option) {
+	lexer := sp.lexer
+	lexer.reset()
+	clear(sp.stack[:cap(sp.stack)]) // Don't keep the last output alive
+	stack, partialRune := sp.stack[:0], sp.partialRune[:0]
+
+	*sp = StreamingParser{lexer: lexer, partialRune: partialRune}
+	sp.stack = stack
+	sp.init(output, opts)
+}
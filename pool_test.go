@@ -0,0 +1,89 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestParserPool(t *testing.T) {
+	pool := NewParserPool(WithMaxKeys(2))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				out := map[string]any{}
+				sp := pool.Get(&out)
+				if err := sp.ProcessString(`{"n": [1, {"a": "x"}], "i": `); err != nil {
+					t.Errorf("ProcessString() error = %v", err)
+				}
+				sp.ProcessString(`true}`)
+				want := map[string]any{"n": []interface{}{int64(1), map[string]any{"a": "x"}}, "i": true}
+				if !reflect.DeepEqual(out, want) {
+					t.Errorf("goroutine %d: output = %v, want %v", i, out, want)
+				}
+				pool.Put(sp)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParserPoolReuse(t *testing.T) {
+	pool := NewParserPool(WithMaxKeys(1))
+	first := map[string]any{}
+	sp := pool.Get(&first)
+	calls := 0
+	sp.Watch("a", func(v any, done bool) { calls++ })
+	sp.UseNumber()
+	sp.ProcessString(`{"a": [1, "unfinished`)
+	before := calls
+	pool.Put(sp)
+
+	// A reused parser starts afresh with the pool's options
+	second := map[string]any{}
+	sp.reuse(&second, pool.opts)
+	if err := sp.ProcessString(`{"a": 2}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if want := map[string]any{"a": int64(2)}; !reflect.DeepEqual(second, want) {
+		t.Errorf("output = %#v, want %#v", second, want)
+	}
+	if calls != before {
+		t.Errorf("watcher of the earlier document was called %d more times", calls-before)
+	}
+	if want := map[string]any{"a": []interface{}{json.Number("1")}}; !reflect.DeepEqual(first, want) {
+		t.Errorf("earlier output = %#v, want %#v", first, want)
+	}
+
+	sp.reuse(nil, pool.opts)
+	if err := sp.ProcessString(`{"a": 1, "b": 2}`); err == nil {
+		t.Error("reused parser ignored the pool's WithMaxKeys")
+	}
+}
+
+func TestResetKeepsCapacity(t *testing.T) {
+	out := map[string]any{}
+	sp := NewStreamingParser(&out)
+	sp.ProcessString(`{"a": [[[{"b": 1}]]]}`)
+	sp.Reset()
+	if cap(sp.stack) < 5 {
+		t.Errorf("cap(stack) after Reset = %d, want at least 5", cap(sp.stack))
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		sp.Reset()
+		sp.ProcessString(`{"a": [[[true]]]}`)
+	})
+	fresh := testing.AllocsPerRun(100, func() {
+		out := map[string]any{}
+		NewStreamingParser(&out).ProcessString(`{"a": [[[true]]]}`)
+	})
+	if allocs >= fresh {
+		t.Errorf("allocations with Reset = %v, want fewer than %v for a new parser", allocs, fresh)
+	}
+}
@@ -0,0 +1,124 @@
+package flexjson
+
+import "reflect"
+
+// FieldState describes how much of a struct field's value has been streamed
+type FieldState int
+
+const (
+	// FieldMissing means the field's key hasn't been read yet
+	FieldMissing FieldState = iota
+	// FieldPartial means the key has been read but the value is still streaming
+	FieldPartial
+	// FieldComplete means the value has been read in full
+	FieldComplete
+)
+
+// String returns the name of the state
+func (s FieldState) String() string {
+	switch s {
+	case FieldMissing:
+		return "missing"
+	case FieldPartial:
+		return "partial"
+	case FieldComplete:
+		return "complete"
+	}
+	return "unknown"
+}
+
+// Presence reports the state of every field of T, by path. Paths are built
+// from the fields' names as in IncompletePaths, and fields of nested structs
+// (and pointers to structs) are included, e.g. "user.name". Keys are matched
+// to fields as they are when the value is updated.
+func (tp *TypedStreamingParser[T]) Presence() map[string]FieldState {
+	presence := make(map[string]FieldState)
+	collectPresence(reflect.TypeFor[T](), tp.output, "", "", tp.pendingKeys(), presence)
+	return presence
+}
+
+// collectPresence records the state of the fields of t, whose value obj is at
+// path in the document, under their field paths starting at prefix. pending
+// holds the key being read in each open object, by the object's path.
+func collectPresence(t reflect.Type, obj map[string]any, path, prefix string, pending map[string]string, presence map[string]FieldState) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	fields := structFields(t)
+	pendingKey, hasPending := pending[path]
+	if obj == nil {
+		// Nothing has been read at path
+		hasPending = false
+	}
+
+	keys := make(map[string]any, len(obj)+1)
+	for k, v := range obj {
+		keys[k] = v
+	}
+	if hasPending {
+		keys[pendingKey] = nil
+	}
+	folded := foldKeys(keys, fields)
+
+	for _, f := range fields {
+		fieldPath := appendKeyPath(prefix, f.name)
+
+		key := f.name
+		_, found := keys[key]
+		if !found {
+			key, found = foldedKey(folded, f.name)
+		}
+
+		state := FieldMissing
+		switch {
+		case found && hasPending && key == pendingKey:
+			state = FieldPartial
+		case found:
+			state = FieldComplete
+		}
+		presence[fieldPath] = state
+
+		if hasNestedFields(f.typ) {
+			child, _ := obj[key].(map[string]any)
+			if state == FieldMissing {
+				child = nil
+			}
+			collectPresence(f.typ, child, appendKeyPath(path, key), fieldPath, pending, presence)
+		}
+	}
+}
+
+// hasNestedFields reports whether the fields of a value of type t are
+// reported separately: t is a struct, or a pointer to one, that doesn't
+// decode itself
+func hasNestedFields(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	pt := reflect.PointerTo(t)
+	return !pt.Implements(jsonUnmarshalerType) && !pt.Implements(textUnmarshalerType)
+}
+
+// pendingKeys returns the key of the value being read in each open object,
+// by the object's path
+func (d *decoder) pendingKeys() map[string]string {
+	keys := make(map[string]string)
+	top := len(d.stack) - 1
+	for i, f := range d.stack {
+		if f.isArray() {
+			continue
+		}
+		// Below the top, the key's value is the next open container
+		if i < top || d.state == stateColon || d.expectingValue() {
+			keys[f.path] = f.key
+		}
+	}
+	return keys
+}
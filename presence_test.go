@@ -0,0 +1,65 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTypedStreamingParserPresence(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	type reply struct {
+		Title string    `json:"title"`
+		Body  string    `json:"body"`
+		User  *user     `json:"user"`
+		Tags  []string  `json:"tags"`
+		When  time.Time `json:"when"`
+	}
+
+	missing := map[string]FieldState{
+		"title": FieldMissing, "body": FieldMissing, "user": FieldMissing,
+		"user.name": FieldMissing, "user.age": FieldMissing, "tags": FieldMissing, "when": FieldMissing,
+	}
+	with := func(changes map[string]FieldState) map[string]FieldState {
+		m := make(map[string]FieldState)
+		for k, v := range missing {
+			m[k] = v
+		}
+		for k, v := range changes {
+			m[k] = v
+		}
+		return m
+	}
+
+	tests := []struct {
+		chunk    string
+		expected map[string]FieldState
+	}{
+		{`{"tit`, missing},
+		{`le"`, with(map[string]FieldState{"title": FieldPartial})},
+		{`:"Hel`, with(map[string]FieldState{"title": FieldPartial})},
+		{`lo","USER":{"name":"Jo"`, with(map[string]FieldState{
+			"title": FieldComplete, "user": FieldPartial, "user.name": FieldComplete,
+		})},
+		{`},"tags":["a"`, with(map[string]FieldState{
+			"title": FieldComplete, "user": FieldComplete, "user.name": FieldComplete, "tags": FieldPartial,
+		})},
+		{`],"when":"2024-01-02T03:04:05Z"}`, with(map[string]FieldState{
+			"title": FieldComplete, "user": FieldComplete, "user.name": FieldComplete,
+			"tags": FieldComplete, "when": FieldComplete,
+		})},
+	}
+
+	tp := NewTypedStreamingParser[reply](nil)
+	for _, tt := range tests {
+		if err := tp.ProcessString(tt.chunk); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", tt.chunk, err)
+		}
+		if presence := tp.Presence(); !reflect.DeepEqual(presence, tt.expected) {
+			t.Errorf("After %q: Presence() = %v, want %v", tt.chunk, presence, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package flexjson
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// PreviewEllipsis marks the parts of a document that ParsePreview left out.
+// A truncated string ends with it, an array that was cut short ends with it as
+// an element, and an object that was cut short has it as a key, with it as the
+// value.
+const PreviewEllipsis = "…"
+
+// ParsePreview parses the start of a possibly huge or truncated JSON object
+// into a small preview document, for showing a collapsed view before the
+// whole input is parsed. Parsing stops once maxValues values (counting
+// objects and arrays) have been read, so the cost doesn't grow with the size
+// of the input, and string values longer than maxBytesPerString bytes are cut
+// short, with no more of them decoded than is kept. Cut-off content is marked
+// with PreviewEllipsis. A limit of zero or less disables it. The input isn't
+// copied, and the preview doesn't share memory with it.
+func ParsePreview(input []byte, maxValues int, maxBytesPerString int) (obj map[string]any, err error) {
+	defer recoverInternal(&err, nil)
+
+	d := &decoder{objectsOnly: true, truncate: true, marker: PreviewEllipsis}
+	lexer := NewLexerBytes(input)
+	values := 0
+
+	for {
+		// Keys are kept whole; only string values are cut short
+		key := d.expectingKey()
+		lexer.str.limit = max(maxBytesPerString, 0)
+		if key {
+			lexer.str.limit = 0
+		}
+
+		tok, _ := lexer.NextToken()
+		value := d.expectingValue() && startsValue(tok.Type)
+		key = key && (tok.Type == TokenString || tok.Type == TokenIdentifier)
+
+		if (value || key) && maxValues > 0 && values >= maxValues && len(d.stack) > 0 {
+			d.elide()
+			break
+		}
+		if value {
+			values++
+		}
+		if tok.Type == TokenString && lexer.str.limit == 0 {
+			// Text that wasn't decoded into a buffer is part of input
+			tok.Value = strings.Clone(tok.Value)
+		}
+
+		if err := d.token(tok); err != nil {
+			var perr *ParseError
+			if errors.As(err, &perr) {
+				perr.locate(bytesString(input))
+			}
+			return nil, err
+		}
+		if d.done() || tok.Type == TokenEOF {
+			break
+		}
+	}
+
+	obj, _ = d.result.(map[string]any)
+	return obj, nil
+}
+
+// startsValue reports whether a token of type t begins a value
+func startsValue(t TokenType) bool {
+	switch t {
+	case TokenLeftBrace, TokenLeftBracket, TokenString, TokenNumber, TokenTrue, TokenFalse, TokenNull:
+		return true
+	}
+	return false
+}
+
+// truncateString cuts s to at most n bytes without splitting a character
+func truncateString(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// elide closes every open container, marking each one with PreviewEllipsis
+// as having been cut short
+func (d *decoder) elide() {
+	for len(d.stack) > 0 {
+		array := d.inArray()
+		if !array {
+			d.top().key = PreviewEllipsis
+		}
+		d.addValue(PreviewEllipsis)
+		d.close(array)
+	}
+}
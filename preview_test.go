@@ -0,0 +1,131 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParsePreview(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		maxValues int
+		maxBytes  int
+		expected  map[string]any
+	}{
+		{
+			name:      "Within limits",
+			input:     `{"a":[1,2],"b":"short"}`,
+			maxValues: 10,
+			maxBytes:  10,
+			expected:  map[string]any{"a": []interface{}{int64(1), int64(2)}, "b": "short"},
+		},
+		{
+			name:      "Long strings",
+			input:     `{"text":"héllo world","long key":"ok"}`,
+			maxValues: 0,
+			maxBytes:  3,
+			expected:  map[string]any{"text": "hé" + PreviewEllipsis, "long key": "ok"},
+		},
+		{
+			name:      "Array cut short",
+			input:     `{"items":[1,2,3,4,5],"after":true}`,
+			maxValues: 4,
+			expected: map[string]any{
+				"items":         []interface{}{int64(1), int64(2), PreviewEllipsis},
+				PreviewEllipsis: PreviewEllipsis,
+			},
+		},
+		{
+			name:      "Nested object cut short",
+			input:     `{"a":1,"b":{"c":2,"d":3}}`,
+			maxValues: 4,
+			expected: map[string]any{
+				"a":             int64(1),
+				"b":             map[string]any{"c": int64(2), PreviewEllipsis: PreviewEllipsis},
+				PreviewEllipsis: PreviewEllipsis,
+			},
+		},
+		{
+			name:      "Truncated input",
+			input:     `{"a":[1,{"b":"x`,
+			maxValues: 100,
+			expected:  map[string]any{"a": []interface{}{int64(1), map[string]any{"b": "x"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParsePreview([]byte(tt.input), tt.maxValues, tt.maxBytes)
+			if err != nil {
+				t.Fatalf("ParsePreview() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParsePreview() = %#v, want %#v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePreviewStopsEarly(t *testing.T) {
+	// A syntax error past the value budget is never reached
+	input := `{"rows":[` + strings.Repeat(`{"id":1},`, 1000) + `oops`
+
+	result, err := ParsePreview([]byte(input), 5, 0)
+	if err != nil {
+		t.Fatalf("ParsePreview() error = %v", err)
+	}
+
+	rows := result["rows"].([]interface{})
+	if len(rows) != 3 || rows[2] != PreviewEllipsis {
+		t.Errorf("Rows = %#v", rows)
+	}
+}
+
+func TestParsePreviewErrors(t *testing.T) {
+	_, err := ParsePreview([]byte(`[1,2]`), 10, 10)
+	if !errors.Is(err, ErrNotAnObject) {
+		t.Errorf("ParsePreview() error = %v, want ErrNotAnObject", err)
+	}
+}
+
+func TestParsePreviewLongString(t *testing.T) {
+	// Escapes keep the string off the lexer's fast path
+	input := []byte(`{"big":"` + strings.Repeat(`ab\n`, 1<<18) + `","k":"v"}`)
+
+	result, err := ParsePreview(input, 0, 8)
+	if err != nil {
+		t.Fatalf("ParsePreview() error = %v", err)
+	}
+	if want := "ab\nab\nab" + PreviewEllipsis; result["big"] != want {
+		t.Errorf("big = %q, want %q", result["big"], want)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	ParsePreview(input, 0, 8)
+	runtime.ReadMemStats(&after)
+	if bytes := after.TotalAlloc - before.TotalAlloc; bytes > 4096 {
+		t.Errorf("ParsePreview() allocated %d bytes for a %d-byte input, want the string left undecoded", bytes, len(input))
+	}
+}
+
+func TestParsePreviewDoesNotShareInput(t *testing.T) {
+	input := []byte(`{"key":"value","n":[1]}`)
+	result, err := ParsePreview(input, 0, 0)
+	if err != nil {
+		t.Fatalf("ParsePreview() error = %v", err)
+	}
+
+	for i := range input {
+		if input[i] >= 'a' && input[i] <= 'z' {
+			input[i] = 'x'
+		}
+	}
+	if want := map[string]any{"key": "value", "n": []interface{}{int64(1)}}; !reflect.DeepEqual(result, want) {
+		t.Errorf("ParsePreview() = %#v after the input changed, want %#v", result, want)
+	}
+}
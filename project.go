@@ -0,0 +1,90 @@
+package flexjson
+
+// WithProject makes a parser store only the values at paths, in the format
+// described in path.go, with "[*]" matching any array index, e.g.
+// WithProject("usage", "choices[*].delta"). Everything inside a projected
+// value is stored, along with the objects and arrays that lead to it;
+// everything else is parsed, checked for errors, and reported to event
+// handlers and watchers, but discarded, so that memory grows with the
+// projected values rather than the whole document.
+//
+// Discarded elements of an array that leads to a projected value are stored
+// as nil, so the other elements keep their indexes. Malformed paths match
+// nothing.
+func WithProject(paths ...string) Option {
+	return func(d *decoder) {
+		d.projecting = true
+		d.project = d.project[:0]
+		for _, path := range paths {
+			if segments, ok := parsePath(path); ok {
+				d.project = append(d.project, segments)
+			}
+		}
+	}
+}
+
+// WithSkip makes a parser skip the values at paths, in the format described
+// in path.go, with "[*]" matching any array index, e.g.
+// WithSkip("data[*].embedding"). A skipped value is parsed and checked for
+// errors but never stored, nor is anything inside it, so a huge array of
+// floats costs no memory. Skipped values are still reported to event handlers
+// and watchers, at their real paths, so the paths of the values after them
+// are unaffected.
+//
+// A skipped object member is left out of the object, and a skipped array
+// element is stored as nil, so the other elements keep their indexes.
+// WithSkip can be combined with WithProject to skip part of a projected
+// value. Malformed paths match nothing.
+func WithSkip(paths ...string) Option {
+	return func(d *decoder) {
+		d.skip = d.skip[:0]
+		for _, path := range paths {
+			if segments, ok := parsePath(path); ok {
+				d.skip = append(d.skip, segments)
+			}
+		}
+	}
+}
+
+// discardValue reports whether the value at path is projected out, skipped,
+// or inside a raw value
+func (d *decoder) discardValue(path string) bool {
+	if d.rawDepth > 0 {
+		// Inside an object or array kept raw
+		return true
+	}
+	if !d.filtering() {
+		return false
+	}
+	if len(d.stack) > 0 && d.top().skipped() {
+		// Inside a discarded object or array
+		return true
+	}
+
+	segments, ok := parsePath(path)
+	if !ok {
+		return true
+	}
+	for _, pattern := range d.skip {
+		if len(segments) >= len(pattern) && matchSegments(pattern, segments[:len(pattern)]) {
+			return true
+		}
+	}
+	if !d.projecting {
+		return false
+	}
+	for _, pattern := range d.project {
+		// The value is kept if it leads to the pattern or is inside it
+		n := min(len(pattern), len(segments))
+		if matchSegments(pattern[:n], segments[:n]) {
+			return false
+		}
+	}
+	return true
+}
+
+// filtering reports whether any values may be discarded by path, so callers
+// can avoid building paths when none are
+func (d *decoder) filtering() bool {
+	return d.projecting || len(d.skip) > 0 || d.rawDepth > 0
+}
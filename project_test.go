@@ -0,0 +1,174 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithProject(t *testing.T) {
+	input := `{"id": "x", "choices": [{"index": 0, "delta": {"content": "Hi", "tags": [1, 2]}, "logprobs": {"a": [1]}},` +
+		` {"index": 1, "delta": {"content": "!"}}], "usage": {"tokens": 3}, "big": [[1, 2], {"x": [3]}]}`
+
+	tests := []struct {
+		name     string
+		paths    []string
+		expected map[string]any
+	}{
+		{
+			name:  "wildcard",
+			paths: []string{"usage", "choices[*].delta"},
+			expected: map[string]any{
+				"choices": []interface{}{
+					map[string]any{"delta": map[string]any{"content": "Hi", "tags": []interface{}{int64(1), int64(2)}}},
+					map[string]any{"delta": map[string]any{"content": "!"}},
+				},
+				"usage": map[string]any{"tokens": int64(3)},
+			},
+		},
+		{
+			name:  "index",
+			paths: []string{"choices[1].delta.content"},
+			expected: map[string]any{
+				"choices": []interface{}{
+					nil,
+					map[string]any{"delta": map[string]any{"content": "!"}},
+				},
+			},
+		},
+		{
+			name:     "scalar",
+			paths:    []string{"id", "missing.path"},
+			expected: map[string]any{"id": "x"},
+		},
+		{
+			name:     "nothing",
+			paths:    nil,
+			expected: map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(input, WithProject(tt.paths...))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Parse() = %v, want %v", result, tt.expected)
+			}
+
+			sp := NewStreamingParser(nil, WithProject(tt.paths...))
+			for i := 0; i < len(input); i++ {
+				if err := sp.ProcessString(input[i : i+1]); err != nil {
+					t.Fatalf("ProcessString() error = %v", err)
+				}
+			}
+			if !reflect.DeepEqual(sp.GetCurrentOutput(), tt.expected) {
+				t.Errorf("Output = %v, want %v", sp.GetCurrentOutput(), tt.expected)
+			}
+		})
+	}
+}
+
+// valuePaths is an EventHandler that records the paths of values
+type valuePaths struct {
+	NopHandler
+	paths []string
+}
+
+func (h *valuePaths) OnValue(path string, value any) {
+	h.paths = append(h.paths, path)
+}
+
+func TestWithProjectEvents(t *testing.T) {
+	// Discarded values are still reported, at their real paths
+	h := &valuePaths{}
+	sp := NewStreamingParser(nil, WithProject("b"))
+	sp.SetEventHandler(h)
+	if err := sp.ProcessString(`{"a": [1, {"x": 2}, [3]], "b": 4}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if want := []string{"a[0]", "a[1].x", "a[2][0]", "b"}; !reflect.DeepEqual(h.paths, want) {
+		t.Errorf("Value paths = %q, want %q", h.paths, want)
+	}
+	if want := map[string]any{"b": int64(4)}; !reflect.DeepEqual(sp.GetCurrentOutput(), want) {
+		t.Errorf("Output = %v, want %v", sp.GetCurrentOutput(), want)
+	}
+
+	// Errors in discarded values are still reported
+	sp = NewStreamingParser(nil, WithProject("b"))
+	if err := sp.ProcessString(`{"a": [1, }`); err == nil {
+		t.Error("ProcessString() error = nil, want a syntax error")
+	}
+}
+
+func TestWithSkip(t *testing.T) {
+	input := `{"data": [{"id": 1, "embedding": [0.1, 0.2, [0.3]]}, {"id": 2, "embedding": {"v": [0.4]}}],` +
+		` "raw": "...", "meta": [1, 2, 3], "after": true}`
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected map[string]any
+	}{
+		{
+			name: "members",
+			opts: []Option{WithSkip("data[*].embedding", "raw")},
+			expected: map[string]any{
+				"data":  []interface{}{map[string]any{"id": int64(1)}, map[string]any{"id": int64(2)}},
+				"meta":  []interface{}{int64(1), int64(2), int64(3)},
+				"after": true,
+			},
+		},
+		{
+			name: "elements",
+			opts: []Option{WithSkip("data[0]", "meta[1]")},
+			expected: map[string]any{
+				"data":  []interface{}{nil, map[string]any{"id": int64(2), "embedding": map[string]any{"v": []interface{}{0.4}}}},
+				"raw":   "...",
+				"meta":  []interface{}{int64(1), nil, int64(3)},
+				"after": true,
+			},
+		},
+		{
+			name: "with projection",
+			opts: []Option{WithProject("data"), WithSkip("data[*].embedding")},
+			expected: map[string]any{
+				"data": []interface{}{map[string]any{"id": int64(1)}, map[string]any{"id": int64(2)}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(input, tt.opts...)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Parse() = %v, want %v", result, tt.expected)
+			}
+
+			h := &valuePaths{}
+			sp := NewStreamingParser(nil, tt.opts...)
+			sp.SetEventHandler(h)
+			for i := 0; i < len(input); i++ {
+				if err := sp.ProcessString(input[i : i+1]); err != nil {
+					t.Fatalf("ProcessString() error = %v", err)
+				}
+			}
+			if !reflect.DeepEqual(sp.GetCurrentOutput(), tt.expected) {
+				t.Errorf("Output = %v, want %v", sp.GetCurrentOutput(), tt.expected)
+			}
+
+			// Every value is still reported, at its real path
+			want := []string{
+				"data[0].id", "data[0].embedding[0]", "data[0].embedding[1]", "data[0].embedding[2][0]",
+				"data[1].id", "data[1].embedding.v[0]", "raw", "meta[0]", "meta[1]", "meta[2]", "after",
+			}
+			if !reflect.DeepEqual(h.paths, want) {
+				t.Errorf("Value paths = %q, want %q", h.paths, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package flexjson
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxQueryIndex is the largest array index honored in a query string key.
+// Larger numeric segments are treated as object keys so a single parameter
+// can't allocate an enormous array.
+const maxQueryIndex = 1000
+
+// ParseQueryString decodes a URL query string or form-encoded body into the
+// same map[string]any model produced by Parse. Keys use bracket notation for
+// nesting:
+//
+//	a=1             -> {"a": "1"}
+//	a=1&a=2         -> {"a": ["1", "2"]}
+//	a[]=1&a[]=2     -> {"a": ["1", "2"]}
+//	a[b]=1          -> {"a": {"b": "1"}}
+//	a[b][0]=1       -> {"a": {"b": ["1"]}}
+//	a[0][x]=1       -> {"a": [{"x": "1"}]}
+//
+// Values are always strings. Malformed keys are used verbatim and invalid
+// percent-escapes are kept as-is.
+func ParseQueryString(s string) map[string]any {
+	result := make(map[string]any)
+
+	s = strings.TrimPrefix(s, "?")
+	for _, pair := range strings.Split(s, "&") {
+		if pair == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(pair, "=")
+		segments := splitQueryKey(unescapeQuery(key))
+		if segments[0] == "" {
+			continue
+		}
+
+		result[segments[0]] = insertQueryValue(result[segments[0]], segments[1:], unescapeQuery(value))
+	}
+
+	return result
+}
+
+// unescapeQuery decodes a query component, keeping it unchanged if it's malformed
+func unescapeQuery(s string) string {
+	if unescaped, err := url.QueryUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
+
+// splitQueryKey splits "a[b][0]" into ["a", "b", "0"]. Keys that aren't
+// well-formed bracket notation are returned as a single segment.
+func splitQueryKey(key string) []string {
+	open := strings.IndexByte(key, '[')
+	if open <= 0 {
+		return []string{key}
+	}
+
+	segments := []string{key[:open]}
+	rest := key[open:]
+	for rest != "" {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return []string{key}
+		}
+		segments = append(segments, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return segments
+}
+
+// insertQueryValue stores value under the remaining key segments of node and
+// returns the updated node
+func insertQueryValue(node any, segments []string, value string) any {
+	if len(segments) == 0 {
+		// Repeated keys collect into an array
+		switch existing := node.(type) {
+		case string:
+			return []any{existing, value}
+		case []any:
+			return append(existing, value)
+		default:
+			return value
+		}
+	}
+
+	segment, rest := segments[0], segments[1:]
+
+	// Empty brackets append to an array
+	if segment == "" {
+		arr, _ := node.([]any)
+		if len(rest) > 0 && len(arr) > 0 {
+			// a[][x]=1&a[][y]=2 fills in the last element until a key repeats
+			if last, ok := arr[len(arr)-1].(map[string]any); ok {
+				if _, exists := last[rest[0]]; !exists {
+					arr[len(arr)-1] = insertQueryValue(last, rest, value)
+					return arr
+				}
+			}
+		}
+		return append(arr, insertQueryValue(nil, rest, value))
+	}
+
+	// Numeric segments index into an array
+	if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index <= maxQueryIndex {
+		if arr, ok := node.([]any); ok || node == nil {
+			for len(arr) <= index {
+				arr = append(arr, nil)
+			}
+			arr[index] = insertQueryValue(arr[index], rest, value)
+			return arr
+		}
+	}
+
+	obj, ok := node.(map[string]any)
+	if !ok {
+		obj = make(map[string]any)
+	}
+	obj[segment] = insertQueryValue(obj[segment], rest, value)
+	return obj
+}
@@ -0,0 +1,99 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQueryString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+	}{
+		{
+			name:     "Simple pairs",
+			input:    "name=John+Doe&age=30&email=j%40example.com",
+			expected: map[string]any{"name": "John Doe", "age": "30", "email": "j@example.com"},
+		},
+		{
+			name:     "Leading question mark and empty pairs",
+			input:    "?a=1&&b=&c",
+			expected: map[string]any{"a": "1", "b": "", "c": ""},
+		},
+		{
+			name:     "Repeated keys",
+			input:    "tag=a&tag=b&tag=c",
+			expected: map[string]any{"tag": []any{"a", "b", "c"}},
+		},
+		{
+			name:     "Empty brackets",
+			input:    "tag[]=a&tag[]=b",
+			expected: map[string]any{"tag": []any{"a", "b"}},
+		},
+		{
+			name:  "Nested objects",
+			input: "user[name]=John&user[address][city]=Paris&user[address][zip]=75001",
+			expected: map[string]any{
+				"user": map[string]any{
+					"name":    "John",
+					"address": map[string]any{"city": "Paris", "zip": "75001"},
+				},
+			},
+		},
+		{
+			name:     "Indexed arrays",
+			input:    "a[b][0]=1&a[b][1]=2",
+			expected: map[string]any{"a": map[string]any{"b": []any{"1", "2"}}},
+		},
+		{
+			name:  "Arrays of objects",
+			input: "items[0][id]=1&items[0][qty]=2&items[1][id]=3",
+			expected: map[string]any{
+				"items": []any{
+					map[string]any{"id": "1", "qty": "2"},
+					map[string]any{"id": "3"},
+				},
+			},
+		},
+		{
+			name:  "Appended objects",
+			input: "items[][id]=1&items[][qty]=2&items[][id]=3",
+			expected: map[string]any{
+				"items": []any{
+					map[string]any{"id": "1", "qty": "2"},
+					map[string]any{"id": "3"},
+				},
+			},
+		},
+		{
+			name:     "Sparse index",
+			input:    "a[2]=x",
+			expected: map[string]any{"a": []any{nil, nil, "x"}},
+		},
+		{
+			name:     "Huge index is an object key",
+			input:    "a[99999999]=x",
+			expected: map[string]any{"a": map[string]any{"99999999": "x"}},
+		},
+		{
+			name:     "Encoded brackets",
+			input:    "a%5Bb%5D=1",
+			expected: map[string]any{"a": map[string]any{"b": "1"}},
+		},
+		{
+			name:     "Malformed keys are literal",
+			input:    "a[b=1&c]d=2&[x]=3&bad=%zz",
+			expected: map[string]any{"a[b": "1", "c]d": "2", "[x]": "3", "bad": "%zz"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseQueryString(tt.input)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseQueryString() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
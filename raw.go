@@ -0,0 +1,131 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// RawValue is the source text of a value kept unparsed, like
+// json.RawMessage, so that decoding heavy or opaque parts of a document can
+// be deferred or delegated. Parsers store RawValues for the paths given to
+// WithRawValues, and for every string, number, and literal with
+// WithRawLeaves. A string's RawValue includes its quotes and escapes as they
+// were written.
+type RawValue string
+
+// Decode parses the value with flexjson, which accepts any JSON value here,
+// not just an object. opts configure the parser as for Parse.
+func (r RawValue) Decode(opts ...Option) (value any, err error) {
+	defer recoverInternal(&err, nil)
+
+	input := string(r)
+	p := NewParser(nil, opts...)
+	lexer := NewLexer(input)
+	p.config.configureLexer(lexer)
+	p.tokens = lexer.Tokenize()
+
+	p.SetHardened(true)
+	value, err = p.Parse()
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		perr.locate(input)
+	}
+	return value, err
+}
+
+// Unmarshal decodes the value into v with encoding/json
+func (r RawValue) Unmarshal(v any) error {
+	return json.Unmarshal([]byte(r), v)
+}
+
+// MarshalJSON implements json.Marshaler, writing the source text as it is
+func (r RawValue) MarshalJSON() ([]byte, error) {
+	if r == "" {
+		return []byte("null"), nil
+	}
+	return []byte(r), nil
+}
+
+// WithRawValues makes a parser store the values at paths, in the format
+// described in path.go, with "[*]" matching any array index, as RawValues
+// holding their source text instead of parsing them into maps and slices. An
+// object or array is stored once it is closed, so it doesn't appear in the
+// output of a stream until then, and its contents are reported to event
+// handlers and watchers but never stored. A value cut short by a size limit
+// is stored as usual. Malformed paths match nothing.
+func WithRawValues(paths ...string) Option {
+	return func(d *decoder) {
+		d.raw = d.raw[:0]
+		for _, path := range paths {
+			if segments, ok := parsePath(path); ok {
+				d.raw = append(d.raw, segments)
+			}
+		}
+	}
+}
+
+// WithRawLeaves makes a parser store every string, number, and literal as a
+// RawValue, as WithRawValues does for selected paths
+func WithRawLeaves() Option {
+	return func(d *decoder) {
+		d.rawLeaves = true
+	}
+}
+
+// rawValue stores tok as a RawValue if it starts a value that is kept raw,
+// reporting whether it did
+func (d *decoder) rawValue(tok Token) (bool, error) {
+	if d.source == nil || d.rawDepth > 0 || len(d.stack) == 0 || d.skipOutput || d.discarding {
+		return false, nil
+	}
+
+	path := d.valuePath()
+	switch tok.Type {
+	case TokenString, TokenNumber, TokenTrue, TokenFalse, TokenNull:
+		if tok.Truncated || !(d.rawLeaves || d.matchRaw(path)) || d.discardValue(path) {
+			return false, nil
+		}
+		return true, d.scalar(RawValue(d.source.text(tok.Start, tok.End)))
+	case TokenLeftBrace, TokenLeftBracket:
+		if !d.matchRaw(path) || d.discardValue(path) {
+			return false, nil
+		}
+		// The container's contents are discarded until it is closed
+		d.rawDepth = len(d.stack) + 1
+		d.rawStart = tok.Start
+		d.source.holding, d.source.hold = true, tok.Start
+		return true, d.open(tok.Type == TokenLeftBracket)
+	}
+	return false, nil
+}
+
+// endRaw stores the text of the raw object or array that has just been
+// closed, in place of the container
+func (d *decoder) endRaw() error {
+	text := RawValue(d.source.text(d.rawStart, d.tokEnd))
+	d.rawDepth = 0
+	d.source.holding = false
+
+	// Drop the placeholder the container left in its array
+	if top := d.top(); top.kind == containerArray {
+		top.arr = top.arr[:len(top.arr)-1]
+	}
+	return d.scalar(text)
+}
+
+// matchRaw reports whether the value at path is kept raw
+func (d *decoder) matchRaw(path string) bool {
+	if len(d.raw) == 0 {
+		return false
+	}
+	segments, ok := parsePath(path)
+	if !ok {
+		return false
+	}
+	for _, pattern := range d.raw {
+		if matchSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWithRawValues(t *testing.T) {
+	input := `{"id": 1, "args": {"query": "a \"b\"", "n": [1, 2]}, "items": [{"blob": [1, {"x": null}]}, {"blob": "s"}], "tail": true}`
+
+	expected := map[string]any{
+		"id":   int64(1),
+		"args": RawValue(`{"query": "a \"b\"", "n": [1, 2]}`),
+		"items": []interface{}{
+			map[string]any{"blob": RawValue(`[1, {"x": null}]`)},
+			map[string]any{"blob": RawValue(`"s"`)},
+		},
+		"tail": true,
+	}
+	opts := []Option{WithRawValues("args", "items[*].blob")}
+
+	result, err := Parse(input, opts...)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Parse() = %#v, want %#v", result, expected)
+	}
+
+	for _, size := range []int{1, 3, 1000} {
+		sp := NewStreamingParser(nil, opts...)
+		for i := 0; i < len(input); i += size {
+			if err := sp.ProcessString(input[i:min(i+size, len(input))]); err != nil {
+				t.Fatalf("ProcessString() error = %v", err)
+			}
+		}
+		if !reflect.DeepEqual(sp.GetCurrentOutput(), expected) {
+			t.Errorf("Output with %d-byte chunks = %#v, want %#v", size, sp.GetCurrentOutput(), expected)
+		}
+	}
+
+	// An object isn't stored until it is closed
+	sp := NewStreamingParser(nil, opts...)
+	if err := sp.ProcessString(`{"args": {"query": "x"`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if _, ok := sp.GetCurrentOutput()["args"]; ok {
+		t.Errorf("Output = %v, want no args before it is closed", sp.GetCurrentOutput())
+	}
+
+	// Raw values decode on demand
+	args := result["args"].(RawValue)
+	decoded, err := args.Decode()
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if want := map[string]any{"query": `a "b"`, "n": []interface{}{int64(1), int64(2)}}; !reflect.DeepEqual(decoded, want) {
+		t.Errorf("Decode() = %v, want %v", decoded, want)
+	}
+	var target struct {
+		Query string `json:"query"`
+	}
+	if err := args.Unmarshal(&target); err != nil || target.Query != `a "b"` {
+		t.Errorf("Unmarshal() = %+v, %v", target, err)
+	}
+
+	// And marshal back as written, which encoding/json compacts
+	data, err := json.Marshal(result["items"])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `[{"blob":[1,{"x":null}]},{"blob":"s"}]`; string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+}
+
+func TestWithRawLeaves(t *testing.T) {
+	input := `{"a": "é", "b": [1.50, true, null], "c": {"d": -0}}`
+	expected := map[string]any{
+		"a": RawValue(`"é"`),
+		"b": []interface{}{RawValue("1.50"), RawValue("true"), RawValue("null")},
+		"c": map[string]any{"d": RawValue("-0")},
+	}
+
+	result, err := Parse(input, WithRawLeaves())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Parse() = %#v, want %#v", result, expected)
+	}
+
+	sp := NewStreamingParser(nil, WithRawLeaves())
+	for i := 0; i < len(input); i++ {
+		if err := sp.ProcessString(input[i : i+1]); err != nil {
+			t.Fatalf("ProcessString() error = %v", err)
+		}
+	}
+	if !reflect.DeepEqual(sp.GetCurrentOutput(), expected) {
+		t.Errorf("Output = %#v, want %#v", sp.GetCurrentOutput(), expected)
+	}
+
+	if v, err := RawValue("1.50").Decode(); err != nil || v != 1.5 {
+		t.Errorf("Decode() = %v, %v, want 1.5", v, err)
+	}
+}
+
+func TestRawValuesLongStream(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opt   Option
+		key   string
+	}{
+		{"raw array", `{"a":[` + strings.Repeat(`1,`, 100000) + `1]}`, WithRawValues("a"), "a"},
+		{"raw leaf", `{"s":"` + strings.Repeat("x", 200000) + `"}`, WithRawLeaves(), "s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := NewStreamingParser(nil, tt.opt)
+
+			// The text held for the raw value isn't copied as each
+			// character arrives
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			if err := sp.ProcessString(tt.input); err != nil {
+				t.Fatalf("ProcessString() error = %v", err)
+			}
+			runtime.ReadMemStats(&after)
+			if bytes := after.TotalAlloc - before.TotalAlloc; bytes > 200*uint64(len(tt.input)) {
+				t.Errorf("ProcessString() allocated %d bytes for %d bytes of input", bytes, len(tt.input))
+			}
+
+			raw, ok := sp.GetCurrentOutput()[tt.key].(RawValue)
+			if !ok || len(raw) != len(tt.input)-len(`{"a":}`) {
+				t.Errorf("%s is %d bytes of %T, want the raw value", tt.key, len(raw), sp.GetCurrentOutput()[tt.key])
+			}
+		})
+	}
+}
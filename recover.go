@@ -0,0 +1,143 @@
+package flexjson
+
+import "errors"
+
+// WithRecovery makes the parser recover from syntax errors instead of
+// stopping at the first. After an invalid token, the value it was part of is
+// dropped, and tokens are skipped up to the next ',' or closing bracket of
+// the enclosing object or array, where parsing carries on; a key whose value
+// is dropped is left out, and a closing bracket of the wrong kind closes the
+// enclosing object or array anyway. One garbage value then costs only itself
+// rather than the rest of the document.
+//
+// Errors in the root value itself, such as a root value that isn't an
+// object, still stop the parser, as do the size limits and memory budget and
+// errors returned by a decode hook. Errors recovered from are reported to an
+// event handler that implements ErrorHandler.
+func WithRecovery() Option {
+	return func(d *decoder) {
+		d.recovery = true
+	}
+}
+
+// recoverToken decodes the next token, recovering from a syntax error by
+// skipping to the next boundary in the enclosing container
+func (d *decoder) recoverToken(tok Token) error {
+	if d.resyncing {
+		return d.resync(tok)
+	}
+
+	err := d.decodeToken(tok)
+	var perr *ParseError
+	if err == nil || len(d.stack) == 0 || !errors.As(err, &perr) || !recoverable(perr.Code) {
+		return err
+	}
+	d.warnf("\tRecovering from %v\n", err)
+	d.issues = append(d.issues, perr)
+	d.instrumentError(err)
+	d.emitError(err)
+
+	// The value is abandoned. tok is skipped too, unless it is the boundary
+	// itself, as it is after an invalid number.
+	d.state = stateDelimiter
+	d.resyncing = true
+	d.resyncDepth = 0
+	return d.resync(tok)
+}
+
+// resync skips tok unless it is a ',' or a closing bracket in the container
+// on top of the stack, which ends the skipping and is decoded as usual
+func (d *decoder) resync(tok Token) error {
+	switch tok.Type {
+	case TokenLeftBrace, TokenLeftBracket:
+		d.resyncDepth++
+		return nil
+	case TokenRightBrace, TokenRightBracket:
+		if d.resyncDepth > 0 {
+			d.resyncDepth--
+			return nil
+		}
+		// A mismatched bracket is taken as a typo for the right one
+		d.resyncing = false
+		return d.close(d.inArray())
+	case TokenComma:
+		if d.resyncDepth > 0 {
+			return nil
+		}
+	case TokenEOF:
+	default:
+		return nil
+	}
+
+	d.resyncing = false
+	return d.recoverToken(tok)
+}
+
+// recoverable reports whether the parser can recover from an error with code
+func recoverable(code ErrorCode) bool {
+	switch code {
+	case CodeNotAnObject, CodeValueTooLong, CodeOutputTooLarge, CodeTooManyValues, CodeShapeMismatch, CodeUnsupportedEncoding:
+		return false
+	}
+	return true
+}
+
+// Issues returns the errors the parser has recovered from with WithRecovery,
+// in the order they were found, as IssueSyntax issues. They are kept until
+// Reset.
+func (sp *StreamingParser) Issues() []Issue {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return issuesOf(sp.issues)
+}
+
+// Issues returns the errors the last Parse recovered from with WithRecovery,
+// in the order they were found, as IssueSyntax issues
+func (p *Parser) Issues() []Issue {
+	return issuesOf(p.issues)
+}
+
+// issuesOf converts recovered errors to issues
+func issuesOf(errs []*ParseError) []Issue {
+	if len(errs) == 0 {
+		return nil
+	}
+	issues := make([]Issue, len(errs))
+	for i, err := range errs {
+		issues[i] = Issue{
+			Code:     IssueSyntax,
+			Severity: SeverityError,
+			Offset:   err.Offset,
+			Line:     err.Line,
+			Column:   err.Column,
+			Message:  err.Error(),
+		}
+	}
+	return issues
+}
+
+// joinIssues locates recovered errors in input and joins them into one error
+// with errors.Join, or returns nil if there are none
+func joinIssues(errs []*ParseError, input string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	joined := make([]error, len(errs))
+	for i, err := range errs {
+		err.locate(input)
+		joined[i] = err
+	}
+	return errors.Join(joined...)
+}
+
+// withIssues adds the errors recovered from, located in input, to err
+func withIssues(err error, issues []*ParseError, input string) error {
+	joined := joinIssues(issues, input)
+	switch {
+	case joined == nil:
+		return err
+	case err == nil:
+		return joined
+	}
+	return errors.Join(joined, err)
+}
@@ -0,0 +1,205 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithRecovery(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   map[string]any
+		issues []ErrorCode
+	}{
+		{
+			name:   "garbage value",
+			input:  `{"a": 1, "b": xyz, "c": 3}`,
+			want:   map[string]any{"a": int64(1), "c": int64(3)},
+			issues: []ErrorCode{CodeUnexpectedCharacter},
+		},
+		{
+			name:   "missing value",
+			input:  `{"a": , "b": 2}`,
+			want:   map[string]any{"b": int64(2)},
+			issues: []ErrorCode{CodeUnexpectedToken},
+		},
+		{
+			name:   "missing colon",
+			input:  `{"a" 1, "b": 2}`,
+			want:   map[string]any{"b": int64(2)},
+			issues: []ErrorCode{CodeExpectedColon},
+		},
+		{
+			name:   "missing comma",
+			input:  `{"a": 1 "b": 2, "c": 3}`,
+			want:   map[string]any{"a": int64(1), "c": int64(3)},
+			issues: []ErrorCode{CodeExpectedObjectDelimiter},
+		},
+		{
+			name:   "garbage in nested object",
+			input:  `{"a": {"b": @, "c": true}, "d": [1, ?, 3]}`,
+			want:   map[string]any{"a": map[string]any{"c": true}, "d": []interface{}{int64(1), int64(3)}},
+			issues: []ErrorCode{CodeUnexpectedCharacter, CodeUnexpectedCharacter},
+		},
+		{
+			name:   "skipped containers",
+			input:  `{"a": 1 {"x": [1, 2], "y": {}}, "b": 2}`,
+			want:   map[string]any{"a": int64(1), "b": int64(2)},
+			issues: []ErrorCode{CodeExpectedObjectDelimiter},
+		},
+		{
+			name:   "invalid number",
+			input:  `{"a": 1e, "b": 2}`,
+			want:   map[string]any{"b": int64(2)},
+			issues: []ErrorCode{CodeInvalidNumber},
+		},
+		{
+			name:   "value closed by brace",
+			input:  `{"a": [1, 2}, "b": 2}`,
+			want:   map[string]any{"a": []interface{}{int64(1), int64(2)}, "b": int64(2)},
+			issues: []ErrorCode{CodeExpectedArrayDelimiter},
+		},
+		{
+			name:   "truncated after garbage",
+			input:  `{"a": 1, "b": !!!`,
+			want:   map[string]any{"a": int64(1)},
+			issues: []ErrorCode{CodeUnexpectedCharacter},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input, WithRecovery())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+			if codes := joinedCodes(err); !reflect.DeepEqual(codes, tt.issues) {
+				t.Errorf("Parse() error codes = %v, want %v", codes, tt.issues)
+			}
+
+			out := map[string]any{}
+			sp := NewStreamingParser(&out, WithRecovery())
+			for _, c := range tt.input {
+				if err := sp.ProcessChar(string(c)); err != nil {
+					t.Fatalf("ProcessChar(%q) error = %v", c, err)
+				}
+			}
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("streamed output = %v, want %v", out, tt.want)
+			}
+			var codes []ErrorCode
+			for _, issue := range sp.issues {
+				codes = append(codes, issue.Code)
+			}
+			if !reflect.DeepEqual(codes, tt.issues) {
+				t.Errorf("issues = %v, want %v", codes, tt.issues)
+			}
+			if issues := sp.Issues(); len(issues) != len(tt.issues) {
+				t.Errorf("Issues() = %v, want %d issues", issues, len(tt.issues))
+			}
+		})
+	}
+}
+
+// joinedCodes returns the codes of the parse errors joined in err
+func joinedCodes(err error) []ErrorCode {
+	if err == nil {
+		return nil
+	}
+	var codes []ErrorCode
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			codes = append(codes, perr.Code)
+		}
+	}
+	return codes
+}
+
+func TestIssues(t *testing.T) {
+	input := "{\"a\": 1,\n \"b\": @, \"c\": [1 2]}"
+
+	p := NewParser(nil, WithRecovery())
+	lexer := NewLexer(input)
+	p.config.configureLexer(lexer)
+	p.tokens = lexer.Tokenize()
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []Issue{
+		{Code: IssueSyntax, Severity: SeverityError, Offset: 15, Line: 2, Column: 7, Message: "unexpected '@' at line 2, column 7: expected value"},
+		{Code: IssueSyntax, Severity: SeverityError, Offset: 26, Line: 2, Column: 18, Message: "unexpected '2' at line 2, column 18: expected ',' or ']' after array value"},
+	}
+	if got := p.Issues(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parser.Issues() = %v, want %v", got, want)
+	}
+
+	out := map[string]any{}
+	sp := NewStreamingParser(&out, WithRecovery())
+	sp.ProcessString(input)
+	if got := sp.Issues(); !reflect.DeepEqual(got, want) {
+		t.Errorf("StreamingParser.Issues() = %v, want %v", got, want)
+	}
+	sp.Reset()
+	if got := sp.Issues(); got != nil {
+		t.Errorf("Issues() after Reset = %v, want nil", got)
+	}
+}
+
+func TestRecoveryTopLevel(t *testing.T) {
+	obj, err := ParseOrdered(`{"a": ?, "b": 1}`, WithRecovery())
+	if obj == nil || obj.Len() != 1 || len(joinedCodes(err)) != 1 {
+		t.Errorf("ParseOrdered() = %v, %v", obj, err)
+	}
+
+	value, err := ExtractPartialJSON("Here: {\"a\": ?, \"b\": 1} done", WithRecovery())
+	if want := map[string]any{"b": int64(1)}; !reflect.DeepEqual(value, want) || len(joinedCodes(err)) != 1 {
+		t.Errorf("ExtractPartialJSON() = %v, %v", value, err)
+	}
+
+	var docs []map[string]any
+	var codes [][]ErrorCode
+	for doc, err := range ParseDocuments("{\"a\": ?}\n{\"b\": 2}\n{\"c\": !, \"d\": !}", WithRecovery()) {
+		docs = append(docs, doc)
+		codes = append(codes, joinedCodes(err))
+	}
+	wantDocs := []map[string]any{{}, {"b": int64(2)}, {}}
+	wantCodes := [][]ErrorCode{
+		{CodeUnexpectedCharacter},
+		nil,
+		{CodeUnexpectedCharacter, CodeUnexpectedCharacter},
+	}
+	if !reflect.DeepEqual(docs, wantDocs) || !reflect.DeepEqual(codes, wantCodes) {
+		t.Errorf("ParseDocuments() = %v, %v, want %v, %v", docs, codes, wantDocs, wantCodes)
+	}
+}
+
+func TestWithRecoveryFatal(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []Option
+		want  error
+	}{
+		{"not an object", `[1, 2]`, nil, ErrNotAnObject},
+		{"too long", `{"a": "abcdef"}`, []Option{WithMaxStringLength(3)}, ErrValueTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input, append(tt.opts, WithRecovery())...)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithoutRecovery(t *testing.T) {
+	if _, err := Parse(`{"a": 1, "b": xyz, "c": 3}`); err == nil {
+		t.Error("Parse() without recovery succeeded")
+	}
+}
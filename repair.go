@@ -0,0 +1,68 @@
+package flexjson
+
+import (
+	"errors"
+	"io"
+)
+
+// RepairReader passes a JSON document through from another reader unchanged,
+// and if that reader ends in the middle of the document, appends the text that
+// completes it, as computed by CompletionSuffix. Consumers that require valid
+// JSON then never see a truncated document. The document is tracked as it
+// passes through, without buffering it: strings are checked as they arrive,
+// and only an escape cut off by the end of a read is kept until the next.
+//
+// Input that isn't a JSON document is passed through unchanged without being
+// completed, and Err reports why.
+type RepairReader struct {
+	r      io.Reader
+	c      *completer
+	err    error  // Why the input isn't being completed
+	suffix string // Completion not yet read
+	ended  bool   // Whether r has ended
+}
+
+// NewRepairReader returns a reader that passes through the document read
+// from r, completing it if r ends before it is complete
+func NewRepairReader(r io.Reader) *RepairReader {
+	return &RepairReader{r: r, c: newCompleter()}
+}
+
+// Read implements io.Reader. The end of the underlying reader, signalled by
+// io.EOF or io.ErrUnexpectedEOF, is reported as io.EOF once the completion
+// has been read; other errors are returned as they are.
+func (rr *RepairReader) Read(p []byte) (int, error) {
+	if rr.ended {
+		if rr.suffix == "" {
+			return 0, io.EOF
+		}
+		n := copy(p, rr.suffix)
+		rr.suffix = rr.suffix[n:]
+		return n, nil
+	}
+
+	n, err := rr.r.Read(p)
+	if n > 0 && rr.err == nil {
+		rr.c.lexer.feed(string(p[:n]))
+		rr.err = rr.c.drain()
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		rr.ended = true
+		if rr.err == nil {
+			rr.suffix, rr.err = rr.c.suffix()
+		}
+		if n > 0 {
+			return n, nil
+		}
+		return rr.Read(p)
+	}
+	return n, err
+}
+
+// Err returns the error that stopped the input from being completed: a
+// *ParseError for input that isn't a JSON document, or for input that ended
+// before any value. It returns nil while the input is a valid document.
+func (rr *RepairReader) Err() error {
+	return rr.err
+}
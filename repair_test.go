@@ -0,0 +1,120 @@
+package flexjson
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRepairReader(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"a": [1, 2]}`, `{"a": [1, 2]}`},
+		{`{"a": [1, {"b": "te`, `{"a": [1, {"b": "te"}]}`},
+		{`{"a": tr`, `{"a": true}`},
+		{`{"a": 1, "b":`, `{"a": 1, "b":null}`},
+		{"[\"x\\u00", "[\"x\\u0000\"]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			// Read one byte at a time, so tokens are split across reads
+			rr := NewRepairReader(iotest.OneByteReader(strings.NewReader(tt.input)))
+			out, err := io.ReadAll(rr)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("ReadAll() = %q, want %q", out, tt.expected)
+			}
+			if rr.Err() != nil {
+				t.Errorf("Err() = %v, want nil", rr.Err())
+			}
+		})
+	}
+}
+
+func TestRepairReaderInvalid(t *testing.T) {
+	input := `{"a": ] "b`
+	rr := NewRepairReader(strings.NewReader(input))
+	out, err := io.ReadAll(rr)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(out) != input {
+		t.Errorf("ReadAll() = %q, want the input unchanged", out)
+	}
+	if !errors.Is(rr.Err(), ErrUnexpectedToken) {
+		t.Errorf("Err() = %v, want ErrUnexpectedToken", rr.Err())
+	}
+}
+
+func TestRepairReaderInvalidString(t *testing.T) {
+	tests := []string{
+		"{\"a\": \"x\ny",
+		`{"a": "\q`,
+		`["\u12x", 1`,
+	}
+
+	for _, input := range tests {
+		// Whole, and one byte at a time so escapes are split across reads
+		for _, r := range []io.Reader{strings.NewReader(input), iotest.OneByteReader(strings.NewReader(input))} {
+			rr := NewRepairReader(r)
+			out, err := io.ReadAll(rr)
+			if err != nil {
+				t.Fatalf("ReadAll(%q) error = %v", input, err)
+			}
+			if string(out) != input {
+				t.Errorf("ReadAll(%q) = %q, want the input unchanged", input, out)
+			}
+			var perr *ParseError
+			if !errors.As(rr.Err(), &perr) {
+				t.Errorf("Err() for %q = %v, want a *ParseError", input, rr.Err())
+			}
+		}
+	}
+}
+
+func TestRepairReaderLongString(t *testing.T) {
+	input := `["` + strings.Repeat(`ab\u00e9\n`, 50000) + `\u00`
+	rr := NewRepairReader(iotest.OneByteReader(strings.NewReader(input)))
+
+	// Only the unchecked end of the string is kept as it passes through
+	var out []byte
+	p := make([]byte, 1)
+	for {
+		n, err := rr.Read(p)
+		out = append(out, p[:n]...)
+		if kept := len(rr.c.lexer.input); kept > 8 {
+			t.Fatalf("after %d bytes, %d bytes of input are kept", len(out), kept)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+	}
+	if want := input + `00"]`; string(out) != want {
+		t.Errorf("ReadAll() = %d bytes ending %q, want %d bytes", len(out), out[max(len(out)-10, 0):], len(want))
+	}
+	if rr.Err() != nil {
+		t.Errorf("Err() = %v, want nil", rr.Err())
+	}
+}
+
+func TestRepairReaderError(t *testing.T) {
+	readErr := errors.New("connection reset")
+	rr := NewRepairReader(io.MultiReader(strings.NewReader(`{"a": 1`), iotest.ErrReader(readErr)))
+	out, err := io.ReadAll(rr)
+	if !errors.Is(err, readErr) {
+		t.Errorf("ReadAll() error = %v, want %v", err, readErr)
+	}
+	if string(out) != `{"a": 1` {
+		t.Errorf("ReadAll() = %q, want the input read so far", out)
+	}
+}
@@ -0,0 +1,392 @@
+package flexjson
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+)
+
+// ErrInvalidState is returned by ResumeStreamingParser for state it can't
+// resume from
+var ErrInvalidState = errors.New("invalid parser state")
+
+// stateVersion is the version of the format written by SaveState
+const stateVersion = 1
+
+func init() {
+	// Types that values in the output may have, so gob can encode them
+	gob.Register(map[string]any{})
+	gob.Register([]interface{}{})
+	gob.Register(json.Number(""))
+	gob.Register(RawValue(""))
+	gob.Register(&big.Int{})
+	gob.Register(&big.Float{})
+}
+
+// savedParser is the state of a StreamingParser written by SaveState
+type savedParser struct {
+	Version     int
+	Output      map[string]any
+	Containers  []savedContainer
+	State       decoderState
+	PendingErr  *ParseError
+	OutputSize  int
+	Discarding  bool
+	RawDepth    int
+	RawStart    int
+	TokEnd      int
+	Spans       map[string][2]int
+	Tracking    bool     // Whether ChangedPaths has been called
+	Changes     []string // Paths changed since ChangedPaths was last called
+	PartialOp   bool
+	Numbers     numberMode
+	Noise       NoiseFilter
+	Hardened    bool
+	Resyncing   bool
+	ResyncDepth int
+	Issues      []*ParseError
+
+	Lexer       savedLexer
+	Partial     int
+	PartialText string
+	LastChar    string
+	InComment   bool
+	PartialRune []byte
+	Offset      int
+	Recent      string
+}
+
+// savedContainer is an open object or array in savedParser
+type savedContainer struct {
+	Kind  containerKind
+	Key   string // Current key
+	Path  string
+	Count int      // Number of members or elements
+	N     int      // Number of elements of a skipped array
+	Seen  []string // Keys read, when its schema requires members
+}
+
+// savedLexer is the state of a Lexer in savedParser. The lexer's syntax and
+// limits come from the options instead.
+type savedLexer struct {
+	Input     string
+	Base      int
+	Pos       int
+	Start     int
+	Final     bool
+	Located   int
+	Line      int
+	Column    int
+	TokLine   int
+	TokColumn int
+	Scanned   int
+	Str       string
+	Truncated bool
+	Buffered  bool
+	Escape    int
+	Hex       rune
+	Surrogate rune
+	Noise     []int
+	Quote     byte
+	Comment   uint8
+	Skip      bool
+	Holding   bool
+	Hold      int
+}
+
+// SaveState returns the state of the parser, including its output, so the
+// stream can be checkpointed and resumed later, or in another process, with
+// ResumeStreamingParser. Input that has been processed isn't kept, so the
+// state is about the size of the output.
+//
+// Settings made with methods, such as UseNumber and SetNoiseFilter, are saved
+// with the state, but options are not. Watchers, event handlers, the sink,
+// and the raw buffer are not part of the state either. Values in the output
+// are encoded with encoding/gob, so a type made by a decode hook must be
+// registered with gob.Register. The state of a parser with a MapSink can't be
+// saved.
+func (sp *StreamingParser) SaveState() ([]byte, error) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if sp.sink != nil {
+		return nil, errors.New("can't save the state of a parser with a MapSink")
+	}
+
+	// The output is saved without the partial string, as the decoder sees it
+	sp.unstorePartial()
+	if sp.partials {
+		defer sp.storePartial()
+	}
+
+	saved := savedParser{
+		Version:     stateVersion,
+		Output:      *sp.output,
+		State:       sp.state,
+		OutputSize:  sp.outputSize,
+		Discarding:  sp.discarding,
+		RawDepth:    sp.rawDepth,
+		RawStart:    sp.rawStart,
+		TokEnd:      sp.tokEnd,
+		Spans:       sp.spans,
+		Tracking:    sp.changes != nil,
+		PartialOp:   sp.partialOp,
+		Numbers:     sp.numbers,
+		Noise:       sp.noise,
+		Hardened:    sp.hardened,
+		Resyncing:   sp.resyncing,
+		ResyncDepth: sp.resyncDepth,
+		Issues:      sp.issues,
+		Lexer:       sp.lexer.save(),
+		Partial:     sp.partial,
+		PartialText: sp.partialText,
+		LastChar:    sp.lastChar,
+		InComment:   sp.inComment,
+		PartialRune: sp.partialRune,
+		Offset:      sp.offset,
+		Recent:      sp.recent,
+	}
+	if perr, ok := sp.pendingErr.(*ParseError); ok {
+		saved.PendingErr = perr
+	}
+	if sp.changes != nil {
+		saved.Changes = sp.changes.paths
+	}
+	for _, f := range sp.stack {
+		saved.Containers = append(saved.Containers, savedContainer{
+			Kind:  f.kind,
+			Key:   f.key,
+			Path:  f.path,
+			Count: f.count,
+			N:     f.n,
+			Seen:  slices.Sorted(maps.Keys(f.seen)),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&saved); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ResumeStreamingParser creates a StreamingParser that carries on from state
+// written by SaveState, storing the output saved with it in out. The options
+// should be the ones the saved parser was created with. Watchers and event
+// handlers must be registered again; events already delivered before the
+// state was saved are not repeated.
+func ResumeStreamingParser(state []byte, out *map[string]any, opts ...Option) (*StreamingParser, error) {
+	var saved savedParser
+	if err := gob.NewDecoder(bytes.NewReader(state)).Decode(&saved); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidState, err)
+	}
+	if saved.Version != stateVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidState, saved.Version)
+	}
+
+	sp := NewStreamingParser(out, opts...)
+	if sp.sink != nil {
+		return nil, errors.New("can't resume a parser with a MapSink")
+	}
+	clear(*sp.output)
+	for key, value := range saved.Output {
+		(*sp.output)[key] = restoreEmpty(value)
+	}
+
+	for _, c := range saved.Containers {
+		f, ok := sp.reopen(c.Kind, c.N)
+		if !ok {
+			return nil, fmt.Errorf("%w: no %s container at %q in the output", ErrInvalidState, c.Kind, c.Path)
+		}
+		f.path, f.count = c.Path, c.Count
+		if sp.schema != nil && (saved.RawDepth == 0 || len(sp.stack) < saved.RawDepth-1) {
+			// The schema isn't saved, so it is found again from the options;
+			// a raw container and its contents aren't checked
+			sp.reopenSchema(&f, c.Seen)
+		}
+		f.key = c.Key
+		sp.push(f)
+	}
+	if len(sp.stack) > 0 {
+		sp.result = sp.stack[0].value()
+	} else if saved.State == stateDone {
+		sp.result = *sp.output
+	}
+
+	sp.state = saved.State
+	if saved.PendingErr != nil {
+		sp.pendingErr = saved.PendingErr
+	}
+	sp.outputSize = saved.OutputSize
+	sp.discarding = saved.Discarding
+	sp.rawDepth = saved.RawDepth
+	sp.rawStart = saved.RawStart
+	sp.tokEnd = saved.TokEnd
+	if sp.spans != nil {
+		maps.Copy(sp.spans, saved.Spans)
+	}
+	if saved.Tracking {
+		sp.changes = &changeSet{seen: make(map[string]bool)}
+		for _, path := range saved.Changes {
+			sp.changes.add(path)
+		}
+	}
+	sp.partialOp = saved.PartialOp
+	sp.numbers = saved.Numbers
+	sp.noise = saved.Noise
+	sp.hardened = saved.Hardened
+	sp.resyncing = saved.Resyncing
+	sp.resyncDepth = saved.ResyncDepth
+	sp.issues = saved.Issues
+
+	sp.lexer.restore(saved.Lexer)
+	sp.partial = saved.Partial
+	sp.partialText = saved.PartialText
+	sp.lastChar = saved.LastChar
+	sp.inComment = saved.InComment
+	sp.partialRune = saved.PartialRune
+	sp.offset = saved.Offset
+	sp.recent = saved.Recent
+
+	if sp.partials {
+		sp.storePartial()
+	}
+	return sp, nil
+}
+
+// reopen returns the frame of the container of the given kind to push onto
+// the stack, found in the output below the container on top of the stack. n
+// is the number of elements of a skipped array.
+func (sp *StreamingParser) reopen(kind containerKind, n int) (frame, bool) {
+	switch kind {
+	case containerRoot:
+		return frame{kind: kind, obj: *sp.output}, len(sp.stack) == 0
+	case containerSkippedObject, containerSkippedArray:
+		return frame{kind: kind, n: n}, true
+	}
+	if len(sp.stack) == 0 {
+		return frame{}, false
+	}
+
+	var value any
+	switch parent := sp.top(); parent.kind {
+	case containerRoot, containerObject:
+		value = parent.obj[parent.key]
+	case containerArray:
+		if len(parent.arr) == 0 {
+			return frame{}, false
+		}
+		value = parent.arr[len(parent.arr)-1]
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		return frame{kind: containerObject, obj: v}, kind == containerObject
+	case []interface{}:
+		return frame{kind: containerArray, arr: v}, kind == containerArray
+	}
+	return frame{}, false
+}
+
+// restoreEmpty replaces the nil slices and maps that gob decodes empty arrays
+// and objects as with empty ones, as the parser stores them
+func restoreEmpty(value any) any {
+	switch v := value.(type) {
+	case []interface{}:
+		if v == nil {
+			return []interface{}{}
+		}
+		for i, elem := range v {
+			v[i] = restoreEmpty(elem)
+		}
+	case map[string]any:
+		if v == nil {
+			return map[string]any{}
+		}
+		for key, elem := range v {
+			v[key] = restoreEmpty(elem)
+		}
+	}
+	return value
+}
+
+// String returns the name of the kind
+func (k containerKind) String() string {
+	switch k {
+	case containerRoot:
+		return "root"
+	case containerObject:
+		return "object"
+	case containerArray:
+		return "array"
+	case containerSkippedObject:
+		return "skipped object"
+	case containerSkippedArray:
+		return "skipped array"
+	case containerSink:
+		return "sink"
+	}
+	return "unknown"
+}
+
+// save returns the state of the lexer
+func (l *Lexer) save() savedLexer {
+	return savedLexer{
+		Input:     l.input,
+		Base:      l.base,
+		Pos:       l.pos,
+		Start:     l.start,
+		Final:     l.final,
+		Located:   l.located,
+		Line:      l.line,
+		Column:    l.column,
+		TokLine:   l.tokLine,
+		TokColumn: l.tokColumn,
+		Scanned:   l.scanned,
+		Str:       l.str.String(),
+		Truncated: l.str.truncated,
+		Buffered:  l.buffered,
+		Escape:    l.escape,
+		Hex:       l.hex,
+		Surrogate: l.surrogate,
+		Noise:     l.noise,
+		Quote:     l.quote,
+		Comment:   l.comment,
+		Skip:      l.skipNumber,
+		Holding:   l.holding,
+		Hold:      l.hold,
+	}
+}
+
+// restore sets the lexer's state to s
+func (l *Lexer) restore(s savedLexer) {
+	l.input, l.buf = s.Input, nil
+	l.base = s.Base
+	l.pos = s.Pos
+	l.start = s.Start
+	l.final = s.Final
+	l.located = s.Located
+	l.line = s.Line
+	l.column = s.Column
+	l.tokLine = s.TokLine
+	l.tokColumn = s.TokColumn
+	l.scanned = s.Scanned
+	l.str.Reset()
+	l.str.WriteString(s.Str)
+	l.str.truncated = s.Truncated
+	l.buffered = s.Buffered
+	l.escape = s.Escape
+	l.hex = s.Hex
+	l.surrogate = s.Surrogate
+	l.noise = slices.Clone(s.Noise)
+	l.quote = s.Quote
+	l.comment = s.Comment
+	l.skipNumber = s.Skip
+	l.holding = s.Holding
+	l.hold = s.Hold
+}
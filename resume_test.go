@@ -0,0 +1,109 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSaveStateResume(t *testing.T) {
+	input := `{"name": "café \"x\" 🎉", "n": -12.5e3, "big": 123456789012345678901234567890,` +
+		` "list": [1, [true, null], {"k": "v"}, "s"], "obj": {"a": {"b": [false]}}, "skip": {"x": [1, 2]}, "raw": [1, {"y": 2}]}`
+
+	tests := []struct {
+		name  string
+		opts  []Option
+		setup func(sp *StreamingParser)
+	}{
+		{"default", nil, nil},
+		{"partial strings", []Option{WithPartialStrings()}, nil},
+		{"skip", []Option{WithSkip("skip")}, nil},
+		{"raw values", []Option{WithRawValues("raw")}, nil},
+		{"big numbers", nil, (*StreamingParser).UseBigNumbers},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := map[string]any{}
+			sp := NewStreamingParser(&want, tt.opts...)
+			if tt.setup != nil {
+				tt.setup(sp)
+			}
+			if err := sp.ProcessString(input); err != nil {
+				t.Fatalf("ProcessString() error = %v", err)
+			}
+
+			for i := range len(input) {
+				first := map[string]any{}
+				sp := NewStreamingParser(&first, tt.opts...)
+				if tt.setup != nil {
+					tt.setup(sp)
+				}
+				if err := sp.ProcessString(input[:i]); err != nil {
+					t.Fatalf("ProcessString(%q) error = %v", input[:i], err)
+				}
+				state, err := sp.SaveState()
+				if err != nil {
+					t.Fatalf("SaveState() after %q error = %v", input[:i], err)
+				}
+
+				got := map[string]any{}
+				resumed, err := ResumeStreamingParser(state, &got, tt.opts...)
+				if err != nil {
+					t.Fatalf("ResumeStreamingParser() after %q error = %v", input[:i], err)
+				}
+				if !reflect.DeepEqual(got, first) {
+					t.Fatalf("resumed output after %q = %v, want %v", input[:i], got, first)
+				}
+				if err := resumed.ProcessString(input[i:]); err != nil {
+					t.Fatalf("ProcessString(%q) after resuming error = %v", input[i:], err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("output resumed after %q = %v, want %v", input[:i], got, want)
+				}
+				if !resumed.IsComplete() {
+					t.Fatalf("parser resumed after %q is not complete", input[:i])
+				}
+			}
+		})
+	}
+}
+
+func TestSaveStateKeepsTracking(t *testing.T) {
+	out := map[string]any{}
+	sp := NewStreamingParser(&out, WithSpans())
+	sp.ProcessString(`{"a": 1, "b": [`)
+	sp.ChangedPaths()
+	sp.ProcessString(`2,`)
+
+	state, err := sp.SaveState()
+	if err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	got := map[string]any{}
+	resumed, err := ResumeStreamingParser(state, &got, WithSpans())
+	if err != nil {
+		t.Fatalf("ResumeStreamingParser() error = %v", err)
+	}
+	if paths := resumed.ChangedPaths(); !reflect.DeepEqual(paths, []string{"b[0]"}) {
+		t.Errorf("ChangedPaths() = %v, want [b[0]]", paths)
+	}
+	resumed.ProcessString(` 3]}`)
+	wantSpans := map[string][2]int{"": {0, 21}, "a": {6, 7}, "b": {14, 20}, "b[0]": {15, 16}, "b[1]": {18, 19}}
+	if spans := resumed.Spans(); !reflect.DeepEqual(spans, wantSpans) {
+		t.Errorf("Spans() = %v, want %v", spans, wantSpans)
+	}
+}
+
+func TestResumeStreamingParserErrors(t *testing.T) {
+	if _, err := ResumeStreamingParser([]byte("not a state"), nil); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("ResumeStreamingParser(garbage) error = %v, want ErrInvalidState", err)
+	}
+
+	sp := NewStreamingParser(nil)
+	sp.SetSink(NewOrderedMap())
+	sp.ProcessString(`{"a": 1`)
+	if _, err := sp.SaveState(); err == nil {
+		t.Error("SaveState() with a MapSink succeeded")
+	}
+}
@@ -0,0 +1,114 @@
+package flexjson
+
+// Scanner checks JSON input as it arrives without building any output, for
+// when all that matters is whether the input so far is valid and whether the
+// document is complete. It tokenizes and checks structure like a
+// StreamingParser, with the same options for syntax and limits, but keeps
+// only the kinds of the open objects and arrays, so it costs a fraction of
+// parsing. Unlike a StreamingParser, it accepts a root value of any type.
+// Input after a complete root value is ignored, as Parse ignores it.
+type Scanner struct {
+	lexer *Lexer
+	d     decoder
+	err   error // First error found
+}
+
+// NewScanner creates a Scanner configured with opts
+func NewScanner(opts ...Option) *Scanner {
+	s := &Scanner{lexer: NewIncrementalLexer()}
+	for _, opt := range opts {
+		opt(&s.d)
+	}
+	s.d.skipOutput = true
+	s.d.sink = nil
+	s.d.configureLexer(s.lexer)
+	return s
+}
+
+// Write checks the next chunk of input. It returns the first error found,
+// a *ParseError, here or in an earlier chunk; input after an error isn't
+// checked. Write always reports that all of p was consumed, so a Scanner
+// can be the destination of io.Copy or an io.MultiWriter.
+func (s *Scanner) Write(p []byte) (int, error) {
+	if s.err == nil {
+		s.lexer.Feed(p)
+		s.err = s.scan()
+	}
+	return len(p), s.err
+}
+
+// WriteString checks the next chunk of input, as Write does
+func (s *Scanner) WriteString(chunk string) (int, error) {
+	if s.err == nil {
+		s.lexer.feed(chunk)
+		s.err = s.scan()
+	}
+	return len(chunk), s.err
+}
+
+// Close marks the end of the input, which completes a root number or
+// literal that could otherwise continue. It returns the first error found,
+// or ErrPartial if the document isn't complete.
+func (s *Scanner) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	s.lexer.Close()
+	if s.err = s.scan(); s.err != nil {
+		return s.err
+	}
+	if !s.d.done() {
+		return ErrPartial
+	}
+	return nil
+}
+
+// scan checks the tokens completed by the input so far
+func (s *Scanner) scan() error {
+	for {
+		tok, ok := s.lexer.NextToken()
+		if !ok || tok.Type == TokenEOF {
+			// The end of the input isn't an error here; Close reports it
+			return nil
+		}
+		if err := s.d.token(tok); err != nil {
+			return err
+		}
+	}
+}
+
+// Valid reports whether the input so far is valid: it has no errors, so it
+// is the start of a JSON document, or all of one
+func (s *Scanner) Valid() bool {
+	return s.err == nil
+}
+
+// Complete reports whether the input so far holds a complete root value
+func (s *Scanner) Complete() bool {
+	return s.err == nil && s.d.done()
+}
+
+// Err returns the first error found, or nil
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// Depth returns the number of objects and arrays that are open
+func (s *Scanner) Depth() int {
+	return len(s.d.stack)
+}
+
+// Issues returns the errors the scanner has recovered from with
+// WithRecovery, as StreamingParser.Issues does
+func (s *Scanner) Issues() []Issue {
+	return issuesOf(s.d.issues)
+}
+
+// Reset prepares the scanner for a new document, keeping its options
+func (s *Scanner) Reset() {
+	s.d.reset()
+	s.d.issues = s.d.issues[:0]
+	s.lexer.reset()
+	s.d.configureLexer(s.lexer)
+	s.err = nil
+}
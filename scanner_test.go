@@ -0,0 +1,84 @@
+package flexjson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScanner(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []string
+		opts     []Option
+		valid    bool
+		complete bool
+		depth    int
+		closeErr error
+	}{
+		{"complete", []string{`{"a": [1, `, `{"b": "x"}]}`}, nil, true, true, 0, nil},
+		{"prefix", []string{`{"a": [1, {"b": "x`}, nil, true, false, 3, ErrPartial},
+		{"empty", nil, nil, true, false, 0, ErrPartial},
+		{"root number", []string{`12`, `34`}, nil, true, false, 0, nil},
+		{"root string", []string{`"ab`, `c"`}, nil, true, true, 0, nil},
+		{"trailing text", []string{`{} and more`}, nil, true, true, 0, nil},
+		{"invalid", []string{`{"a": [1 2]}`, `{}`}, nil, false, false, 2, ErrInvalid},
+		{"missing colon", []string{`{"a" 1}`}, nil, false, false, 1, ErrInvalid},
+		{"extension", []string{`{a: 'b',}`}, []Option{WithSyntax(SyntaxJSON5)}, true, true, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(tt.opts...)
+			for _, chunk := range tt.chunks {
+				n, err := s.Write([]byte(chunk))
+				if n != len(chunk) || err != s.Err() {
+					t.Errorf("Write(%q) = %d, %v", chunk, n, err)
+				}
+			}
+			if s.Valid() != tt.valid {
+				t.Errorf("Valid() = %v, want %v (error %v)", s.Valid(), tt.valid, s.Err())
+			}
+			if s.Complete() != tt.complete {
+				t.Errorf("Complete() = %v, want %v", s.Complete(), tt.complete)
+			}
+			if s.Depth() != tt.depth {
+				t.Errorf("Depth() = %d, want %d", s.Depth(), tt.depth)
+			}
+			if err := s.Close(); !errors.Is(err, tt.closeErr) {
+				t.Errorf("Close() = %v, want %v", err, tt.closeErr)
+			}
+		})
+	}
+}
+
+func TestScannerReset(t *testing.T) {
+	s := NewScanner()
+	s.WriteString(`{"a": ]`)
+	if s.Valid() {
+		t.Fatal("Valid() = true for invalid input")
+	}
+
+	s.Reset()
+	if _, err := s.WriteString(`{"a": 1}`); err != nil || !s.Complete() {
+		t.Errorf("after Reset, WriteString() error = %v, Complete() = %v", err, s.Complete())
+	}
+}
+
+func TestScannerDoesNotBuildOutput(t *testing.T) {
+	input := []byte(`{"items": [` + strings.Repeat(`{"id": 1, "tags": ["a", "b"]}, `, 100) + `null]}`)
+	s := NewScanner()
+	s.Write(input)
+	s.Reset()
+
+	scanned := testing.AllocsPerRun(20, func() {
+		s.Reset()
+		s.Write(input)
+	})
+	parsed := testing.AllocsPerRun(20, func() {
+		NewStreamingParser(nil).ProcessBytes(input)
+	})
+	if scanned*10 > parsed {
+		t.Errorf("Scanner allocated %v times, want a tenth of the %v of parsing", scanned, parsed)
+	}
+}
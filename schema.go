@@ -0,0 +1,300 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Schema is the subset of JSON Schema that a parser can check as a document
+// streams in: types, required members, enums, the schemas of members and
+// elements, and whether other members are allowed. Other keywords are
+// ignored. A nil *Schema accepts any value.
+type Schema struct {
+	Type       SchemaTypes        `json:"type,omitempty"`       // Types the value may have; any type if empty
+	Properties map[string]*Schema `json:"properties,omitempty"` // Schemas of an object's members
+	Required   []string           `json:"required,omitempty"`   // Keys an object must have
+	Enum       []any              `json:"enum,omitempty"`       // Values a string, number, or literal may have; any if empty
+	Items      *Schema            `json:"items,omitempty"`      // Schema of an array's elements
+
+	// AdditionalProperties, when false, allows only the members in
+	// Properties. Only the boolean form of the keyword is supported.
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+}
+
+// SchemaTypes is the "type" keyword of a Schema: one or more of "object",
+// "array", "string", "number", "integer", "boolean", and "null". In JSON it
+// is a string, or an array of strings for more than one type.
+type SchemaTypes []string
+
+// UnmarshalJSON implements json.Unmarshaler, accepting a string or an array
+// of strings
+func (t *SchemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = SchemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("schema type must be a string or an array of strings: %w", err)
+	}
+	*t = many
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing a single type as a string
+func (t SchemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// ParseSchema reads a Schema from a JSON Schema document, ignoring the
+// keywords it doesn't support. An unknown type name returns an error.
+func ParseSchema(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// check reports a type name in s or a nested schema that isn't valid
+func (s *Schema) check() error {
+	if s == nil {
+		return nil
+	}
+	for _, t := range s.Type {
+		switch t {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return fmt.Errorf("unknown schema type %q", t)
+		}
+	}
+	for _, p := range s.Properties {
+		if err := p.check(); err != nil {
+			return err
+		}
+	}
+	return s.Items.check()
+}
+
+// SchemaViolation is a way in which a document doesn't match its Schema
+type SchemaViolation struct {
+	Path    string // Path of the value, in the format described in path.go
+	Keyword string // Keyword not satisfied: "type", "enum", "required", or "additionalProperties"
+	Message string // Description of the violation
+}
+
+// String formats the violation as "path: message", with "(root)" for the
+// root value
+func (v SchemaViolation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return path + ": " + v.Message
+}
+
+// WithSchema makes a parser check the document against schema, passing
+// each violation to handle as soon as it can be observed, rather than once
+// the document is complete: a value of the wrong type when it starts (or,
+// for a string, number, or literal, when it is complete), a value outside an
+// enum when it is complete, a member that isn't allowed when its key is
+// read, and a missing required member when its object is closed. An object
+// cut off by the end of the input isn't reported as missing members, which
+// could still arrive. Violations don't stop parsing; use WithShape to stop
+// at the first one.
+//
+// Values are checked as they are in the input, before a decode hook
+// converts them; values kept as RawValue aren't checked. handle is called
+// while input is being processed.
+func WithSchema(schema *Schema, handle func(v SchemaViolation)) Option {
+	return func(d *decoder) {
+		d.schema = schema
+		d.violation = handle
+		d.guard = false
+	}
+}
+
+// valueSchema returns the schema of the next value, or nil if it isn't
+// constrained
+func (d *decoder) valueSchema() *Schema {
+	if len(d.stack) == 0 {
+		return d.schema
+	}
+	top := d.top()
+	switch {
+	case top.schema == nil:
+		return nil
+	case top.isArray():
+		return top.schema.Items
+	}
+	return top.schema.Properties[top.key]
+}
+
+// checkScalar checks a string, number, or literal against its schema
+func (d *decoder) checkScalar(value any) error {
+	s := d.valueSchema()
+	if _, raw := value.(RawValue); s == nil || raw {
+		return nil
+	}
+	if err := d.checkType(s, typeName(value), value); err != nil || len(s.Enum) == 0 {
+		return err
+	}
+	for _, allowed := range s.Enum {
+		if equalScalars(value, allowed) {
+			return nil
+		}
+	}
+	path := d.valuePath()
+	return d.violate(path, "enum",
+		fmt.Sprintf("%s isn't one of the allowed values", formatScalar(value)),
+		"one of the allowed values at "+describePath(path))
+}
+
+// checkContainer checks a new object or array against its schema, and sets
+// up its frame f to check its members or elements
+func (d *decoder) checkContainer(f *frame, array bool) error {
+	s := d.valueSchema()
+	if s == nil || d.rawDepth > 0 {
+		return nil
+	}
+	got := "object"
+	if array {
+		got = "array"
+	}
+	if err := d.checkType(s, got, nil); err != nil {
+		return err
+	}
+	f.schema = s
+	if len(s.Required) > 0 && !array {
+		f.seen = make(map[string]bool, len(s.Required))
+	}
+	return nil
+}
+
+// reopenSchema sets up the frame f of a container reopened on top of the
+// stack, as by ResumeStreamingParser, to check its members or elements as
+// checkContainer did when it was opened. seen holds the keys it had read.
+func (d *decoder) reopenSchema(f *frame, seen []string) {
+	s := d.valueSchema()
+	array := f.isArray()
+	got := "object"
+	if array {
+		got = "array"
+	}
+	if s == nil || (len(s.Type) > 0 && !slices.Contains(s.Type, got)) {
+		// The container was reported and isn't checked further
+		return
+	}
+	f.schema = s
+	if len(s.Required) > 0 && !array {
+		f.seen = make(map[string]bool, len(s.Required))
+		for _, key := range seen {
+			f.seen[key] = true
+		}
+	}
+}
+
+// checkType checks that a value whose type is named got has a type the
+// schema allows
+func (d *decoder) checkType(s *Schema, got string, value any) error {
+	if len(s.Type) == 0 {
+		return nil
+	}
+	for _, t := range s.Type {
+		if t == got || (t == "integer" && got == "number" && isInteger(value)) {
+			return nil
+		}
+	}
+	path, want := d.valuePath(), strings.Join(s.Type, " or ")
+	return d.violate(path, "type",
+		fmt.Sprintf("expected %s, got %s", want, got),
+		want+" at "+describePath(path))
+}
+
+// checkKey checks a key read in the object on top of the stack
+func (d *decoder) checkKey(key string) error {
+	top := d.top()
+	if top.schema == nil {
+		return nil
+	}
+	if top.seen != nil {
+		top.seen[key] = true
+	}
+	if allowed := top.schema.AdditionalProperties; allowed != nil && !*allowed {
+		if _, ok := top.schema.Properties[key]; !ok {
+			return d.violate(appendKeyPath(top.path, key), "additionalProperties",
+				fmt.Sprintf("member %q isn't allowed", key),
+				"an allowed member of "+describePath(top.path))
+		}
+	}
+	return nil
+}
+
+// checkRequired checks that the object on top of the stack, which is being
+// closed, has its required members
+func (d *decoder) checkRequired() error {
+	top := d.top()
+	if top.seen == nil {
+		return nil
+	}
+	for _, key := range top.schema.Required {
+		if top.seen[key] {
+			continue
+		}
+		err := d.violate(top.path, "required",
+			fmt.Sprintf("missing required member %q", key),
+			fmt.Sprintf("member %q in %s", key, describePath(top.path)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// violate reports a schema violation, described by msg, to the handler set
+// with WithSchema. With WithShape, it returns an error instead, saying what
+// was expected.
+func (d *decoder) violate(path, keyword, msg, expected string) error {
+	if d.guard {
+		return tokenError(d.guardTok, CodeShapeMismatch, expected)
+	}
+	if d.violation != nil {
+		d.violation(SchemaViolation{Path: path, Keyword: keyword, Message: msg})
+	}
+	return nil
+}
+
+// describePath names the value at path in a message
+func describePath(path string) string {
+	if path == "" {
+		return "the root"
+	}
+	return path
+}
+
+// isInteger reports whether a number has no fractional part
+func isInteger(value any) bool {
+	n, ok := numberValue(value)
+	return ok && n.IsInt()
+}
+
+// formatScalar formats a string, number, or literal as it is written in JSON
+func formatScalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,213 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["id", "status"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "integer"},
+		"status": {"enum": ["ok", "failed"]},
+		"score": {"type": ["number", "null"]},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"owner": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {"name": {"type": "string"}}
+		}
+	}
+}`
+
+func TestWithSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []SchemaViolation
+	}{
+		{
+			name:  "valid",
+			input: `{"id": 7, "status": "ok", "score": null, "tags": ["a"], "owner": {"name": "x"}}`,
+		},
+		{
+			name:  "wrong types",
+			input: `{"id": 7.5, "status": "ok", "score": "high", "tags": ["a", 2], "owner": []}`,
+			want: []SchemaViolation{
+				{Path: "id", Keyword: "type", Message: "expected integer, got number"},
+				{Path: "score", Keyword: "type", Message: "expected number or null, got string"},
+				{Path: "tags[1]", Keyword: "type", Message: "expected string, got number"},
+				{Path: "owner", Keyword: "type", Message: "expected object, got array"},
+			},
+		},
+		{
+			name:  "enum",
+			input: `{"id": 1, "status": "pending"}`,
+			want: []SchemaViolation{
+				{Path: "status", Keyword: "enum", Message: `"pending" isn't one of the allowed values`},
+			},
+		},
+		{
+			name:  "required",
+			input: `{"owner": {}}`,
+			want: []SchemaViolation{
+				{Path: "owner", Keyword: "required", Message: `missing required member "name"`},
+				{Path: "", Keyword: "required", Message: `missing required member "id"`},
+				{Path: "", Keyword: "required", Message: `missing required member "status"`},
+			},
+		},
+		{
+			name:  "cut off before required members",
+			input: `{"owner": {"name": "x"`,
+		},
+		{
+			name:  "additional member",
+			input: `{"id": 1, "status": "ok", "extra": {"id": "x"}}`,
+			want: []SchemaViolation{
+				{Path: "extra", Keyword: "additionalProperties", Message: `member "extra" isn't allowed`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []SchemaViolation
+			_, err := Parse(tt.input, WithSchema(schema, func(v SchemaViolation) {
+				got = append(got, v)
+			}))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("violations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithSchemaSkipsRawValues(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+
+	var got []SchemaViolation
+	_, err = Parse(`{"id": 1, "status": "ok", "tags": [1, 2], "owner": {"name": 5}}`,
+		WithRawValues("tags", "owner.name"),
+		WithSchema(schema, func(v SchemaViolation) { got = append(got, v) }))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got) > 0 {
+		t.Errorf("violations = %v, want none", got)
+	}
+}
+
+func TestWithSchemaReportsEarly(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+
+	input := `{"owner": [], "unknown": 1, "id": "7", "status": "ok"`
+	var at []int // Bytes processed when each violation was reported
+	offset := 0
+	sp := NewStreamingParser(nil, WithSchema(schema, func(v SchemaViolation) {
+		at = append(at, offset)
+	}))
+	for _, c := range input {
+		offset++
+		if err := sp.ProcessChar(string(c)); err != nil {
+			t.Fatalf("ProcessChar() error = %v", err)
+		}
+	}
+
+	// The array is reported at '[', the key after its closing quote, and
+	// the string after its closing quote
+	want := []int{11, 23, 37}
+	if !reflect.DeepEqual(at, want) {
+		t.Errorf("violations reported after %v bytes, want %v", at, want)
+	}
+}
+
+func TestWithSchemaCloneAndResume(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"required": ["id"], "properties": {"items": {"type": "array",
+		"items": {"required": ["name"], "properties": {"name": {"type": "string"}}}}}}`))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+
+	inputs := []string{
+		`{"items":[{"name":1},{}]}`,
+		`{"id":1,"items":[{"name":"x"},{"name":"y"}]}`,
+	}
+	for _, input := range inputs {
+		var want []SchemaViolation
+		if _, err := Parse(input, WithSchema(schema, func(v SchemaViolation) { want = append(want, v) })); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+
+		// A copy made, or a parser resumed, in the middle of the document
+		// carries on checking it where the original left off
+		for i := range len(input) {
+			var got []SchemaViolation
+			handle := func(v SchemaViolation) { got = append(got, v) }
+			sp := NewStreamingParser(nil, WithSchema(schema, handle))
+			if err := sp.ProcessString(input[:i]); err != nil {
+				t.Fatalf("ProcessString(%q) error = %v", input[:i], err)
+			}
+			state, err := sp.SaveState()
+			if err != nil {
+				t.Fatalf("SaveState() error = %v", err)
+			}
+			before := len(got)
+
+			if err := sp.Clone().ProcessString(input[i:]); err != nil {
+				t.Fatalf("Clone().ProcessString(%q) error = %v", input[i:], err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("violations with a clone made after %q = %v, want %v", input[:i], got, want)
+			}
+
+			got = got[:before]
+			out := make(map[string]any)
+			resumed, err := ResumeStreamingParser(state, &out, WithSchema(schema, handle))
+			if err != nil {
+				t.Fatalf("ResumeStreamingParser() error = %v", err)
+			}
+			if err := resumed.ProcessString(input[i:]); err != nil {
+				t.Fatalf("resumed ProcessString(%q) error = %v", input[i:], err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("violations when resumed after %q = %v, want %v", input[:i], got, want)
+			}
+		}
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	want := &Schema{
+		Type:  SchemaTypes{"array"},
+		Items: &Schema{Type: SchemaTypes{"string", "null"}, Enum: []any{"a", nil}},
+	}
+	got, err := ParseSchema([]byte(`{"type": "array", "items": {"type": ["string", "null"], "enum": ["a", null]}, "title": "ignored"}`))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSchema() = %+v, want %+v", got, want)
+	}
+
+	for _, input := range []string{`{"type": "text"}`, `{"items": {"type": 5}}`, `[]`} {
+		if _, err := ParseSchema([]byte(input)); err == nil {
+			t.Errorf("ParseSchema(%s) returned no error", input)
+		}
+	}
+}
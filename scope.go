@@ -0,0 +1,213 @@
+package flexjson
+
+import "strings"
+
+// Scope is a view of the part of a StreamingParser's document below a path,
+// for fan-out where different components own different parts of the
+// document. Paths given to its methods are relative to the scope, and so are
+// the paths its watchers and event handlers receive; the scope's own value
+// has the path "". The parser still does all the parsing, so a Scope has no
+// input methods of its own.
+type Scope struct {
+	sp   *StreamingParser
+	path string // Path of the scope's value in the document
+}
+
+// At returns a Scope for the value at path, in the format described in
+// path.go. The value doesn't need to exist yet.
+func (sp *StreamingParser) At(path string) *Scope {
+	return &Scope{sp: sp, path: path}
+}
+
+// At returns a Scope for the value at path below this scope
+func (s *Scope) At(path string) *Scope {
+	return &Scope{sp: s.sp, path: joinPath(s.path, path)}
+}
+
+// Path returns the path of the scope's value in the document
+func (s *Scope) Path() string {
+	return s.path
+}
+
+// Value returns a deep copy of the scope's value, as Snapshot copies the
+// output, and whether it has arrived
+func (s *Scope) Value() (any, bool) {
+	s.sp.mu.Lock()
+	defer s.sp.mu.Unlock()
+
+	value, ok := findPath(*s.sp.output, s.path)
+	if !ok {
+		return nil, false
+	}
+	return snapshotValue(value), true
+}
+
+// Output returns a deep copy of the scope's value if it is an object, or nil
+// if it isn't, or hasn't arrived yet
+func (s *Scope) Output() map[string]any {
+	value, _ := s.Value()
+	obj, _ := value.(map[string]any)
+	return obj
+}
+
+// State returns the state of the value at path below the scope, as
+// StreamingParser.State does
+func (s *Scope) State(path string) ValueState {
+	return s.sp.State(joinPath(s.path, path))
+}
+
+// IsComplete reports whether the scope's value is complete
+func (s *Scope) IsComplete() bool {
+	return s.State("") == ValueFinal
+}
+
+// Watch subscribes fn to the value at path below the scope, as
+// StreamingParser.Watch does
+func (s *Scope) Watch(path string, fn WatchFunc) {
+	s.sp.Watch(joinPath(s.path, path), fn)
+}
+
+// SetEventHandler registers h to receive the structural events for the
+// scope's value and the values inside it, with paths relative to the scope.
+// Each scope's handler is kept alongside the parser's own handler and those
+// of other scopes, and parse errors are reported to all of them.
+func (s *Scope) SetEventHandler(h EventHandler) {
+	s.sp.scoped = append(s.sp.scoped, &scopedHandler{path: s.path, h: h})
+	s.sp.setHandlers()
+}
+
+// setHandlers updates the handler that events are delivered to, after a
+// handler of the parser or of a scope has been set
+func (sp *StreamingParser) setHandlers() {
+	switch {
+	case len(sp.scoped) == 0:
+		sp.handler = sp.ownHandler
+	case sp.ownHandler == nil && len(sp.scoped) == 1:
+		sp.handler = sp.scoped[0]
+	default:
+		handlers := make(eventHandlers, 0, len(sp.scoped)+1)
+		if sp.ownHandler != nil {
+			handlers = append(handlers, sp.ownHandler)
+		}
+		sp.handler = append(handlers, sp.scoped...)
+	}
+}
+
+// joinPath returns the path of the value at path below the value at base
+func joinPath(base, path string) string {
+	switch {
+	case base == "":
+		return path
+	case path == "" || path[0] == '[':
+		return base + path
+	}
+	return base + "." + path
+}
+
+// scopedHandler passes on the events at or below path to h, with paths
+// relative to path
+type scopedHandler struct {
+	path string
+	h    EventHandler
+}
+
+// relative returns path relative to the scope, and whether it is in the scope
+func (s *scopedHandler) relative(path string) (string, bool) {
+	if path == s.path {
+		return "", true
+	}
+	if !isPathPrefix(s.path, path) {
+		return "", false
+	}
+	return strings.TrimPrefix(path[len(s.path):], "."), true
+}
+
+func (s *scopedHandler) OnObjectStart(path string) {
+	if rel, ok := s.relative(path); ok {
+		s.h.OnObjectStart(rel)
+	}
+}
+
+func (s *scopedHandler) OnObjectEnd(path string) {
+	if rel, ok := s.relative(path); ok {
+		s.h.OnObjectEnd(rel)
+	}
+}
+
+func (s *scopedHandler) OnArrayStart(path string) {
+	if rel, ok := s.relative(path); ok {
+		s.h.OnArrayStart(rel)
+	}
+}
+
+func (s *scopedHandler) OnArrayEnd(path string) {
+	if rel, ok := s.relative(path); ok {
+		s.h.OnArrayEnd(rel)
+	}
+}
+
+func (s *scopedHandler) OnKey(path string, key string) {
+	// The key of the scope's own value belongs to its parent
+	if rel, ok := s.relative(path); ok && rel != "" {
+		s.h.OnKey(rel, key)
+	}
+}
+
+func (s *scopedHandler) OnValue(path string, value any) {
+	if rel, ok := s.relative(path); ok {
+		s.h.OnValue(rel, value)
+	}
+}
+
+func (s *scopedHandler) OnError(err error) {
+	if eh, ok := s.h.(ErrorHandler); ok {
+		eh.OnError(err)
+	}
+}
+
+// eventHandlers delivers each event to several handlers in turn
+type eventHandlers []EventHandler
+
+func (hs eventHandlers) OnObjectStart(path string) {
+	for _, h := range hs {
+		h.OnObjectStart(path)
+	}
+}
+
+func (hs eventHandlers) OnObjectEnd(path string) {
+	for _, h := range hs {
+		h.OnObjectEnd(path)
+	}
+}
+
+func (hs eventHandlers) OnArrayStart(path string) {
+	for _, h := range hs {
+		h.OnArrayStart(path)
+	}
+}
+
+func (hs eventHandlers) OnArrayEnd(path string) {
+	for _, h := range hs {
+		h.OnArrayEnd(path)
+	}
+}
+
+func (hs eventHandlers) OnKey(path string, key string) {
+	for _, h := range hs {
+		h.OnKey(path, key)
+	}
+}
+
+func (hs eventHandlers) OnValue(path string, value any) {
+	for _, h := range hs {
+		h.OnValue(path, value)
+	}
+}
+
+func (hs eventHandlers) OnError(err error) {
+	for _, h := range hs {
+		if eh, ok := h.(ErrorHandler); ok {
+			eh.OnError(err)
+		}
+	}
+}
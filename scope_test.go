@@ -0,0 +1,124 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScopeEvents(t *testing.T) {
+	out := map[string]any{}
+	sp := NewStreamingParser(&out)
+	all := &recordingHandler{}
+	sp.SetEventHandler(all)
+	data := &recordingHandler{}
+	sp.At("result").At("data").SetEventHandler(data)
+	items := &recordingHandler{}
+	sp.At("result.items").SetEventHandler(items)
+
+	sp.ProcessString(`{"result": {"data": {"name": "x", "tags": [1]}, "items": [{"id": 2}], "database": 3}}`)
+
+	wantData := []string{
+		"{ ",
+		"key name name",
+		`value name "x"`,
+		"key tags tags",
+		"[ tags",
+		"value tags[0] 1",
+		"] tags",
+		"} ",
+	}
+	if !reflect.DeepEqual(data.events, wantData) {
+		t.Errorf("data events = %q, want %q", data.events, wantData)
+	}
+	wantItems := []string{
+		"[ ",
+		"{ [0]",
+		"key [0].id id",
+		"value [0].id 2",
+		"} [0]",
+		"] ",
+	}
+	if !reflect.DeepEqual(items.events, wantItems) {
+		t.Errorf("items events = %q, want %q", items.events, wantItems)
+	}
+	if len(all.events) != 23 {
+		t.Errorf("parser handler got %d events, want 23: %q", len(all.events), all.events)
+	}
+}
+
+func TestScopeValue(t *testing.T) {
+	out := map[string]any{}
+	sp := NewStreamingParser(&out)
+	data := sp.At("result.data")
+
+	if v, ok := data.Value(); ok {
+		t.Errorf("Value() before input = %v, want none", v)
+	}
+	if data.State("") != ValueMissing {
+		t.Errorf("State() before input = %v, want missing", data.State(""))
+	}
+
+	sp.ProcessString(`{"result": {"data": {"name": "x", "tags": [1, `)
+	if got, want := data.Output(), map[string]any{"name": "x", "tags": []interface{}{int64(1)}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Output() = %v, want %v", got, want)
+	}
+	if data.IsComplete() {
+		t.Error("IsComplete() = true for an open object")
+	}
+	if got := data.State("name"); got != ValueFinal {
+		t.Errorf("State(name) = %v, want final", got)
+	}
+	if got := data.At("tags").State(""); got != ValuePartial {
+		t.Errorf("tags State() = %v, want partial", got)
+	}
+	if got, ok := data.At("tags[0]").Value(); !ok || got != int64(1) {
+		t.Errorf("tags[0] Value() = %v, %v", got, ok)
+	}
+
+	// The copy doesn't change with the output
+	snapshot := data.Output()
+	sp.ProcessString(`2]}}}`)
+	if !data.IsComplete() {
+		t.Error("IsComplete() = false for a closed object")
+	}
+	if len(snapshot["tags"].([]interface{})) != 1 {
+		t.Errorf("Output() copy changed to %v", snapshot)
+	}
+	if data.At("name").Output() != nil {
+		t.Error("Output() of a string is not nil")
+	}
+}
+
+func TestScopeWatch(t *testing.T) {
+	out := map[string]any{}
+	sp := NewStreamingParser(&out)
+	var got []any
+	sp.At("choices[0]").Watch("delta.content", func(v any, done bool) {
+		if done {
+			got = append(got, v)
+		}
+	})
+	sp.ProcessString(`{"choices": [{"delta": {"content": "hi"}}, {"delta": {"content": "no"}}]}`)
+	if !reflect.DeepEqual(got, []any{"hi"}) {
+		t.Errorf("watched values = %v, want [hi]", got)
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	tests := []struct {
+		base, path, want string
+	}{
+		{"", "a", "a"},
+		{"a", "", "a"},
+		{"a", "b", "a.b"},
+		{"a", "[0]", "a[0]"},
+		{"a", `["b.c"]`, `a["b.c"]`},
+		{"a[0]", "b.c", "a[0].b.c"},
+	}
+
+	for _, tt := range tests {
+		if got := joinPath(tt.base, tt.path); got != tt.want {
+			t.Errorf("joinPath(%q, %q) = %q, want %q", tt.base, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package flexjson
+
+// ParseShape returns a Schema for documents shaped like example, for
+// WithShape. Each value in example gives the type of the value at its path:
+// an object's members give the types of the members with the same keys, the
+// first element of an array gives the type of every element, and null
+// allows any value. Keys missing from example are allowed, and keys in it
+// aren't required, so
+//
+//	{"tool_calls": [{"name": "", "arguments": {}}]}
+//
+// describes a root object whose "tool_calls", if present, is an array of
+// objects with a string "name" and an object "arguments".
+func ParseShape(example string) (*Schema, error) {
+	value, err := NewParser(NewLexer(example).Tokenize(), WithBestEffort()).Parse()
+	if err != nil {
+		return nil, locateError(err, example)
+	}
+	return shapeOf(value), nil
+}
+
+// shapeOf returns the schema for values shaped like the example value
+func shapeOf(value any) *Schema {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		s := &Schema{Type: SchemaTypes{"object"}, Properties: make(map[string]*Schema, len(v))}
+		for key, member := range v {
+			s.Properties[key] = shapeOf(member)
+		}
+		return s
+	case []interface{}:
+		s := &Schema{Type: SchemaTypes{"array"}}
+		if len(v) > 0 {
+			s.Items = shapeOf(v[0])
+		}
+		return s
+	}
+	return &Schema{Type: SchemaTypes{typeName(value)}}
+}
+
+// WithShape makes a parser stop with a *ParseError with the code
+// CodeShapeMismatch as soon as the document doesn't match shape, so that a
+// stream that is clearly wrong, such as a model's reply that isn't the
+// expected tool call, can be abandoned within its first few tokens. A shape
+// is usually made from an example with ParseShape, but may be any Schema,
+// which is then checked as WithSchema checks it. WithShape replaces
+// WithSchema. A clone of the parser, and a parser resumed from its state with
+// the same option, carry on checking where it left off.
+func WithShape(shape *Schema) Option {
+	return func(d *decoder) {
+		d.schema = shape
+		d.violation = nil
+		d.guard = shape != nil
+	}
+}
@@ -0,0 +1,140 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseShape(t *testing.T) {
+	got, err := ParseShape(`{"tool_calls": [{"name": "", "arguments": {}, "id": null}], "count": 0}`)
+	if err != nil {
+		t.Fatalf("ParseShape() error = %v", err)
+	}
+	want := &Schema{
+		Type: SchemaTypes{"object"},
+		Properties: map[string]*Schema{
+			"tool_calls": {
+				Type: SchemaTypes{"array"},
+				Items: &Schema{
+					Type: SchemaTypes{"object"},
+					Properties: map[string]*Schema{
+						"name":      {Type: SchemaTypes{"string"}},
+						"arguments": {Type: SchemaTypes{"object"}, Properties: map[string]*Schema{}},
+						"id":        nil,
+					},
+				},
+			},
+			"count": {Type: SchemaTypes{"number"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseShape() = %+v, want %+v", got, want)
+	}
+
+	for _, example := range []string{`{"a": [`, `{"a" 1}`, ``} {
+		if _, err := ParseShape(example); err == nil {
+			t.Errorf("ParseShape(%q) returned no error", example)
+		}
+	}
+}
+
+func TestWithShape(t *testing.T) {
+	shape, err := ParseShape(`{"tool_calls": [{"name": "", "arguments": {}}]}`)
+	if err != nil {
+		t.Fatalf("ParseShape() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		err   string // Error message, or "" for none
+	}{
+		{"matches", `{"tool_calls": [{"name": "f", "arguments": {"x": 1}, "extra": true}], "text": ""}`, ""},
+		{"cut off", `{"tool_calls": [{"name": "f", "argu`, ""},
+		{"root array", `[{"tool_calls": []}]`, "unexpected '[' at line 1, column 1: expected object at the root"},
+		{"wrong member type", `{"tool_calls": "none"}`, `unexpected '"none"' at line 1, column 16: expected array at tool_calls`},
+		{"wrong element type", `{"tool_calls": [{"name": "f"}, 3]}`, "unexpected '3' at line 1, column 32: expected object at tool_calls[1]"},
+		{"nested", `{"tool_calls": [{"arguments": "{}"}]}`, `unexpected '"{}"' at line 1, column 31: expected object at tool_calls[0].arguments`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input, WithShape(shape))
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("Parse() error = %v, want none", err)
+			case tt.err != "" && (err == nil || err.Error() != tt.err):
+				t.Errorf("Parse() error = %v, want %s", err, tt.err)
+			case tt.err != "" && !errors.Is(err, ErrShapeMismatch):
+				t.Errorf("Parse() error = %v, want ErrShapeMismatch", err)
+			}
+		})
+	}
+}
+
+func TestWithShapeStopsEarly(t *testing.T) {
+	shape, err := ParseShape(`{"tool_calls": []}`)
+	if err != nil {
+		t.Fatalf("ParseShape() error = %v", err)
+	}
+
+	sp := NewStreamingParser(nil, WithShape(shape))
+	err = sp.ProcessString(`{"tool_calls": {"name": "a very long generation`)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Code != CodeShapeMismatch || perr.Offset != 15 {
+		t.Errorf("ProcessString() error = %#v, want CodeShapeMismatch at offset 15", err)
+	}
+}
+
+func TestWithShapeSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`{"id": 1, "status": "ok"}`, ""},
+		{`{"id": 1, "status": "late"}`, `unexpected '"late"' at line 1, column 21: expected one of the allowed values at status`},
+		{`{"id": 1, "other": 2}`, `unexpected '"other"' at line 1, column 11: expected an allowed member of the root`},
+		{`{"id": 1}`, `unexpected '}' at line 1, column 9: expected member "status" in the root`},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.input, WithShape(schema))
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("Parse(%s) error = %v, want %s", tt.input, err, tt.err)
+		}
+	}
+}
+
+func TestWithShapeCloneAndResume(t *testing.T) {
+	shape, err := ParseShape(`{"tool_calls": [{"name": "", "arguments": {}}]}`)
+	if err != nil {
+		t.Fatalf("ParseShape() error = %v", err)
+	}
+
+	sp := NewStreamingParser(nil, WithShape(shape))
+	if err := sp.ProcessString(`{"tool_calls":[{"name":"f"`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	state, err := sp.SaveState()
+	if err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	out := make(map[string]any)
+	resumed, err := ResumeStreamingParser(state, &out, WithShape(shape))
+	if err != nil {
+		t.Fatalf("ResumeStreamingParser() error = %v", err)
+	}
+
+	// The copies keep guarding the shape where the original left off
+	rest := `,"arguments":"nope"}]}`
+	for name, p := range map[string]*StreamingParser{"clone": sp.Clone(), "resumed": resumed, "original": sp} {
+		if err := p.ProcessString(rest); !errors.Is(err, ErrShapeMismatch) {
+			t.Errorf("%s: ProcessString(%q) error = %v, want ErrShapeMismatch", name, rest, err)
+		}
+	}
+}
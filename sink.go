@@ -0,0 +1,38 @@
+package flexjson
+
+// MapSink receives the members of a parsed object. Setting a sink on a
+// StreamingParser directs parsed values into an alternative structure, such
+// as a sync.Map, an ordered map, a database row, or a columnar builder,
+// instead of the *map[string]any passed to NewStreamingParser.
+//
+// Set may be called more than once for the same key as a document streams in;
+// the last call holds the current value. Nested objects are created with
+// NewObject on the sink of the object or array that contains them, and are
+// passed to Set (or stored in an array) before their own members arrive.
+// Arrays are stored as []interface{}, as they are without a sink, and are
+// passed to Set again as they grow: after each chunk of input that adds to
+// them, and when they are closed.
+type MapSink interface {
+	// Set stores value under key, replacing any earlier value
+	Set(key string, value any)
+	// NewObject returns an empty sink for a nested object
+	NewObject() MapSink
+}
+
+// SetSink directs parsed values into sink instead of the output map, which
+// stays empty. It must be called before any input is processed. Reset does not
+// clear the sink; the caller owns its contents. Pass nil to go back to the
+// output map.
+func (sp *StreamingParser) SetSink(sink MapSink) {
+	sp.sink = sink
+}
+
+// newSinkObject creates a nested object using the nearest enclosing sink
+func (d *decoder) newSinkObject() MapSink {
+	for i := len(d.stack) - 1; i >= 0; i-- {
+		if d.stack[i].kind == containerSink {
+			return d.stack[i].sink.NewObject()
+		}
+	}
+	return d.sink.NewObject()
+}
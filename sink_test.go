@@ -0,0 +1,87 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+// orderedSink is a MapSink that remembers key order
+type orderedSink struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedSink() *orderedSink {
+	return &orderedSink{values: make(map[string]any)}
+}
+
+func (o *orderedSink) Set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *orderedSink) NewObject() MapSink {
+	return newOrderedSink()
+}
+
+// rowSink is a MapSink that flattens nested objects into a single row
+type rowSink struct {
+	prefix string
+	row    map[string]any
+}
+
+func (r *rowSink) Set(key string, value any) {
+	if child, ok := value.(*rowSink); ok {
+		// Nested objects are set before their members arrive
+		child.prefix = r.prefix + key + "_"
+		return
+	}
+	r.row[r.prefix+key] = value
+}
+
+func (r *rowSink) NewObject() MapSink {
+	return &rowSink{row: r.row}
+}
+
+func TestMapSinkOrdered(t *testing.T) {
+	sink := newOrderedSink()
+	output := make(map[string]any)
+	sp := NewStreamingParser(&output)
+	sp.SetSink(sink)
+
+	if err := sp.ProcessString(`{"z":1,"a":{"y":true,"b":null},"m":[{"k":"v"}]}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(sink.keys, []string{"z", "a", "m"}) {
+		t.Errorf("Root keys = %v", sink.keys)
+	}
+	nested, ok := sink.values["a"].(*orderedSink)
+	if !ok || !reflect.DeepEqual(nested.keys, []string{"y", "b"}) {
+		t.Errorf("Nested object = %#v", sink.values["a"])
+	}
+	arr := sink.values["m"].([]interface{})
+	if elem, ok := arr[0].(*orderedSink); !ok || elem.values["k"] != "v" {
+		t.Errorf("Array element = %#v", arr[0])
+	}
+	if len(output) != 0 {
+		t.Errorf("Expected output map to stay empty, got %v", output)
+	}
+}
+
+func TestMapSinkFlattened(t *testing.T) {
+	sink := &rowSink{row: make(map[string]any)}
+	sp := NewStreamingParser(nil)
+	sp.SetSink(sink)
+
+	if err := sp.ProcessString(`{"id":7,"user":{"name":"ann","role":{"admin":false}}}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	expected := map[string]any{"id": int64(7), "user_name": "ann", "user_role_admin": false}
+	if !reflect.DeepEqual(sink.row, expected) {
+		t.Errorf("Row = %v, want %v", sink.row, expected)
+	}
+}
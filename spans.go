@@ -0,0 +1,69 @@
+package flexjson
+
+// WithSpans makes a parser record where each value came from in the input:
+// the byte offsets of the start of its text and just past its end, by path,
+// in the format described in path.go. A string's span includes its quotes.
+// Spans are returned by StreamingParser.Spans and ParseSpans.
+func WithSpans() Option {
+	return func(d *decoder) {
+		d.spans = make(map[string][2]int)
+	}
+}
+
+// Spans returns the source offsets of the values parsed so far, by path, as
+// [start, end] pairs, so that input[start:end] is the text of the value. An
+// object or array that is still open has an end of -1. Offsets count the
+// bytes given to the parser, including transport noise. It returns nil
+// unless the parser was created with WithSpans. The map is live; it must not
+// be modified, and it changes as more input is processed.
+func (sp *StreamingParser) Spans() map[string][2]int {
+	return sp.spans
+}
+
+// ParseSpans parses input like Parse, and also returns the source offsets of
+// every value, as StreamingParser.Spans does. Errors recovered from, and with
+// WithBestEffort the object parsed so far, are returned as Parse returns
+// them.
+func ParseSpans(input string, opts ...Option) (obj map[string]any, spans map[string][2]int, err error) {
+	defer recoverInternal(&err, nil)
+
+	p := NewParser(nil, append(opts, WithSpans())...)
+	lexer := NewLexer(input)
+	p.config.configureLexer(lexer)
+	p.tokens = lexer.Tokenize()
+
+	p.SetHardened(true)
+	result, err := p.Parse()
+	if err != nil && !p.config.bestEffort {
+		return nil, nil, locateError(err, input)
+	}
+	err = withIssues(locateError(err, input), p.issues, input)
+	obj, ok := result.(map[string]any)
+	if !ok {
+		if err != nil {
+			return nil, nil, err
+		}
+		perr := tokenError(p.tokens[0], CodeNotAnObject, "object")
+		perr.locate(input)
+		return nil, nil, perr
+	}
+	return obj, p.config.spans, err
+}
+
+// spanStart records the start of the value beginning with tok
+func (d *decoder) spanStart(tok Token) {
+	switch tok.Type {
+	case TokenLeftBrace, TokenLeftBracket:
+		d.spans[d.valuePath()] = [2]int{tok.Start, -1}
+	case TokenString, TokenNumber, TokenTrue, TokenFalse, TokenNull:
+		d.spans[d.valuePath()] = [2]int{tok.Start, tok.End}
+	}
+}
+
+// spanEnd records the end of the object or array on top of the stack
+func (d *decoder) spanEnd() {
+	path := d.top().path
+	span := d.spans[path]
+	span[1] = d.tokEnd
+	d.spans[path] = span
+}
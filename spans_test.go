@@ -0,0 +1,75 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSpans(t *testing.T) {
+	input := `{"a": "x\"y", "b": [1, {"c": null}], "d": {}}`
+
+	obj, spans, err := ParseSpans(input)
+	if err != nil {
+		t.Fatalf("ParseSpans() error = %v", err)
+	}
+	if len(obj) != 3 {
+		t.Errorf("ParseSpans() = %v", obj)
+	}
+
+	expected := map[string]string{
+		"":       input,
+		"a":      `"x\"y"`,
+		"b":      `[1, {"c": null}]`,
+		"b[0]":   `1`,
+		"b[1]":   `{"c": null}`,
+		"b[1].c": `null`,
+		"d":      `{}`,
+	}
+	got := make(map[string]string)
+	for path, span := range spans {
+		got[path] = input[span[0]:span[1]]
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Spans = %q, want %q", got, expected)
+	}
+
+	if _, _, err := ParseSpans(`[1]`); err == nil {
+		t.Error("ParseSpans([1]) error = nil, want an error")
+	}
+}
+
+func TestStreamingParserSpans(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	if sp.Spans() != nil {
+		t.Error("Spans() != nil without WithSpans")
+	}
+
+	sp = NewStreamingParser(nil, WithSpans())
+	if err := sp.ProcessString(`{"é": "ü", "list": [10, `); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	want := map[string][2]int{
+		"":        {0, -1},
+		"é":       {7, 11},
+		"list":    {21, -1},
+		"list[0]": {22, 24},
+	}
+	if !reflect.DeepEqual(sp.Spans(), want) {
+		t.Errorf("Spans() = %v, want %v", sp.Spans(), want)
+	}
+
+	if err := sp.ProcessString(`20]}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	want[""] = [2]int{0, 30}
+	want["list"] = [2]int{21, 29}
+	want["list[1]"] = [2]int{26, 28}
+	if !reflect.DeepEqual(sp.Spans(), want) {
+		t.Errorf("Spans() = %v, want %v", sp.Spans(), want)
+	}
+
+	sp.Reset()
+	if len(sp.Spans()) != 0 {
+		t.Errorf("Spans() after Reset = %v, want none", sp.Spans())
+	}
+}
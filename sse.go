@@ -0,0 +1,59 @@
+package flexjson
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// sseDone is the data of the event that ends an OpenAI-style stream
+const sseDone = "[DONE]"
+
+// ProcessSSE reads a Server-Sent Events stream from r and processes the data
+// of each event, without its "data:" framing. Comment lines and fields other
+// than data, such as event and id, are skipped. Each data line is processed
+// as soon as it is read, so the output is updated live, and the lines of an
+// event with several are joined by newlines as the SSE format specifies.
+//
+// Reading stops at an event whose data is "[DONE]", as sent at the end of
+// OpenAI-style streams, or at the end of r, and ProcessSSE then returns nil.
+// It stops early with the first parse or read error.
+//
+// Use WithDocuments when each event holds a complete JSON object, as in most
+// APIs, to be handed each one; without it, the data of the events is parsed as
+// the fragments of a single object.
+func (sp *StreamingParser) ProcessSSE(r io.Reader) error {
+	br := bufio.NewReader(r)
+	lines := 0 // Data lines in the current event
+	for {
+		line, readErr := br.ReadString('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return readErr
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch {
+		case line == "":
+			// A blank line ends the event
+			lines = 0
+		case field == "data":
+			if lines == 0 && strings.TrimSpace(value) == sseDone {
+				return nil
+			}
+			if lines > 0 {
+				value = "\n" + value
+			}
+			lines++
+			if err := sp.ProcessString(value); err != nil {
+				return err
+			}
+		}
+
+		if readErr != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProcessSSE(t *testing.T) {
+	stream := ": keep-alive\n" +
+		"event: delta\n" +
+		"data: {\"message\": \"Hel\n" +
+		"\n" +
+		"id: 2\r\n" +
+		"data:lo\", \"tags\": [\r\n" +
+		"data: \"a\"]}\r\n" +
+		"\r\n" +
+		"data: [DONE]\n" +
+		"\n" +
+		"data: {\"ignored\": true}\n\n"
+
+	output := map[string]any{}
+	sp := NewStreamingParser(&output)
+	if err := sp.ProcessSSE(strings.NewReader(stream)); err != nil {
+		t.Fatalf("ProcessSSE() error = %v", err)
+	}
+
+	expected := map[string]any{"message": "Hello", "tags": []interface{}{"a"}}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("Output = %#v, want %#v", output, expected)
+	}
+}
+
+func TestProcessSSEDocuments(t *testing.T) {
+	stream := "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"!\"}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"con"
+
+	var docs []map[string]any
+	output := map[string]any{}
+	sp := NewStreamingParser(&output, WithDocuments(func(doc map[string]any) {
+		docs = append(docs, doc)
+	}))
+	if err := sp.ProcessSSE(strings.NewReader(stream)); err != nil {
+		t.Fatalf("ProcessSSE() error = %v", err)
+	}
+
+	delta := func(content string) map[string]any {
+		return map[string]any{"choices": []interface{}{map[string]any{"delta": map[string]any{"content": content}}}}
+	}
+	if expected := []map[string]any{delta("Hi"), delta("!")}; !reflect.DeepEqual(docs, expected) {
+		t.Errorf("Documents = %#v, want %#v", docs, expected)
+	}
+	if sp.OpenContainers() != 4 {
+		t.Errorf("OpenContainers() = %d, want 4 for the truncated event", sp.OpenContainers())
+	}
+}
+
+func TestProcessSSEError(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	err := sp.ProcessSSE(strings.NewReader("data: {\"a\": ]\n\n"))
+	if !errors.Is(err, ErrUnexpectedToken) {
+		t.Errorf("ProcessSSE() error = %v, want ErrUnexpectedToken", err)
+	}
+}
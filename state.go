@@ -0,0 +1,116 @@
+package flexjson
+
+// ValueState describes whether a value in the output of a StreamingParser is
+// settled
+type ValueState int
+
+const (
+	// ValueMissing means nothing has been read at the path
+	ValueMissing ValueState = iota
+	// ValuePending means the value's key has been read, or the value has
+	// started, but nothing of it is in the output yet
+	ValuePending
+	// ValuePartial means the value is in the output but may still change: an
+	// object or array that is still open, or a string stored as it streams
+	// with WithPartialStrings
+	ValuePartial
+	// ValueFinal means the value is in the output and won't change, unless a
+	// repeated key replaces it
+	ValueFinal
+)
+
+// String returns the name of the state
+func (s ValueState) String() string {
+	switch s {
+	case ValueMissing:
+		return "missing"
+	case ValuePending:
+		return "pending"
+	case ValuePartial:
+		return "partial"
+	case ValueFinal:
+		return "final"
+	}
+	return "unknown"
+}
+
+// State returns the state of the value at path, in the format described in
+// path.go, so a consumer can tell a value that may still grow from one that
+// is settled. Values inside an open object or array are final once they are
+// complete themselves. The root object has the path "".
+func (sp *StreamingParser) State(path string) ValueState {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if state, ok := sp.streamingState(path); ok {
+		return state
+	}
+	if !sp.started() {
+		return ValueMissing
+	}
+	if _, ok := findPath(*sp.output, path); !ok {
+		return ValueMissing
+	}
+	return ValueFinal
+}
+
+// States returns the state of every value in the output, and of the value
+// still streaming, by path. Final values are included, so the map grows with
+// the output.
+func (sp *StreamingParser) States() map[string]ValueState {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	states := make(map[string]ValueState)
+	if !sp.started() {
+		return states
+	}
+	for _, n := range descendants(nil, jsonPathNode{value: *sp.output}) {
+		if state, ok := sp.streamingState(n.path); ok {
+			states[n.path] = state
+		} else {
+			states[n.path] = ValueFinal
+		}
+	}
+	if path, ok := sp.pendingValue(); ok {
+		if _, stored := states[path]; !stored {
+			states[path] = ValuePending
+		}
+	}
+	return states
+}
+
+// streamingState returns the state of the value at path if it is still
+// streaming
+func (sp *StreamingParser) streamingState(path string) (ValueState, bool) {
+	if sp.isOpen(path) {
+		return ValuePartial, true
+	}
+	if pending, ok := sp.pendingValue(); ok && pending == path {
+		if sp.stored.depth > 0 {
+			return ValuePartial, true
+		}
+		return ValuePending, true
+	}
+	return ValueMissing, false
+}
+
+// started reports whether the root object has been opened
+func (sp *StreamingParser) started() bool {
+	return len(sp.stack) > 0 || sp.done()
+}
+
+// pendingValue returns the path of the value whose key has been read, or
+// which has started, but which hasn't been stored
+func (sp *StreamingParser) pendingValue() (string, bool) {
+	if len(sp.stack) == 0 {
+		return "", false
+	}
+	switch {
+	case sp.state == stateColon, sp.state == stateValue && !sp.inArray():
+		return sp.pendingPath(), true
+	case sp.expectingValue() && sp.lexer.pending():
+		return sp.pendingPath(), true
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStreamingParserState(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  string
+		want  ValueState
+	}{
+		{"nothing read", ``, "", ValueMissing},
+		{"open root", `{"a": 1`, "", ValuePartial},
+		{"closed root", `{"a": 1}`, "", ValueFinal},
+		{"key read", `{"a"`, "a", ValuePending},
+		{"colon read", `{"a":`, "a", ValuePending},
+		{"string streaming", `{"a": "he`, "a", ValuePending},
+		{"number streaming", `{"a": 12`, "a", ValuePending},
+		{"number complete", `{"a": 12,`, "a", ValueFinal},
+		{"string complete", `{"a": "hi"`, "a", ValueFinal},
+		{"open array", `{"a": [1, 2`, "a", ValuePartial},
+		{"element complete", `{"a": [1, 2`, "a[0]", ValueFinal},
+		{"element streaming", `{"a": [1, 2`, "a[1]", ValuePending},
+		{"open nested object", `{"a": {"b": true`, "a", ValuePartial},
+		{"nested value", `{"a": {"b": true`, "a.b", ValueFinal},
+		{"closed array", `{"a": [1], "b"`, "a", ValueFinal},
+		{"missing key", `{"a": 1`, "b", ValueMissing},
+		{"key on array", `{"a": [1]}`, "a.0", ValueMissing},
+		{"index out of range", `{"a": [1]}`, "a[1]", ValueMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := map[string]any{}
+			sp := NewStreamingParser(&out)
+			sp.ProcessString(tt.input)
+			if got := sp.State(tt.path); got != tt.want {
+				t.Errorf("State(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamingParserStatePartialStrings(t *testing.T) {
+	out := map[string]any{}
+	sp := NewStreamingParser(&out, WithPartialStrings())
+	sp.ProcessString(`{"a": ["x", "he`)
+	if got := sp.State("a[1]"); got != ValuePartial {
+		t.Errorf("State(a[1]) = %v, want partial", got)
+	}
+	sp.ProcessString(`llo"`)
+	if got := sp.State("a[1]"); got != ValueFinal {
+		t.Errorf("State(a[1]) = %v, want final", got)
+	}
+}
+
+func TestStreamingParserStates(t *testing.T) {
+	out := map[string]any{}
+	sp := NewStreamingParser(&out)
+	sp.ProcessString(`{"a": [1, {"b": "x"}], "c": {"d": 2}, "e": "te`)
+
+	want := map[string]ValueState{
+		"":       ValuePartial,
+		"a":      ValueFinal,
+		"a[0]":   ValueFinal,
+		"a[1]":   ValueFinal,
+		"a[1].b": ValueFinal,
+		"c":      ValueFinal,
+		"c.d":    ValueFinal,
+		"e":      ValuePending,
+	}
+	if got := sp.States(); !reflect.DeepEqual(got, want) {
+		t.Errorf("States() = %v, want %v", got, want)
+	}
+}
+
+func TestValueStateString(t *testing.T) {
+	tests := []struct {
+		state ValueState
+		want  string
+	}{
+		{ValueMissing, "missing"},
+		{ValuePending, "pending"},
+		{ValuePartial, "partial"},
+		{ValueFinal, "final"},
+		{ValueState(9), "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.want {
+			t.Errorf("ValueState(%d).String() = %q, want %q", tt.state, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package flexjson
+
+import "time"
+
+// Stats describes the work a StreamingParser has done since it was created
+// or last Reset, for sizing limits and monitoring production behavior
+type Stats struct {
+	Bytes         int           // Bytes of input processed, including transport noise
+	Tokens        int           // Tokens decoded
+	Values        int           // Values created: objects, arrays, strings, numbers, and literals
+	MaxDepth      int           // Deepest nesting of objects and arrays reached, the root object counting as 1
+	StringBytes   int           // Bytes of decoded strings, keys included
+	LongestString int           // Length in bytes of the longest decoded string
+	Duration      time.Duration // Time spent processing input
+}
+
+// Stats returns the parser's statistics
+func (sp *StreamingParser) Stats() Stats {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	stats := sp.stats
+	stats.Bytes = sp.offset
+	return stats
+}
+
+// countToken updates the statistics for tok
+func (d *decoder) countToken(tok Token) {
+	if tok.Type == TokenEOF {
+		return
+	}
+	d.stats.Tokens++
+	if tok.Type == TokenString {
+		d.stats.StringBytes += len(tok.Value)
+		d.stats.LongestString = max(d.stats.LongestString, len(tok.Value))
+	}
+}
+
+// timeProcessing adds the time since start to the time spent processing input
+func (sp *StreamingParser) timeProcessing(start time.Time) {
+	sp.stats.Duration += time.Since(start)
+}
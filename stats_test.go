@@ -0,0 +1,34 @@
+package flexjson
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	for _, chunk := range []string{`{"name": "flex`, `json", "tags": [[1], {"x": true}]}`} {
+		if err := sp.ProcessString(chunk); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", chunk, err)
+		}
+	}
+
+	stats := sp.Stats()
+	if stats.Duration <= 0 {
+		t.Errorf("Duration = %v, want more than 0", stats.Duration)
+	}
+	stats.Duration = 0
+	want := Stats{
+		Bytes:         48,
+		Tokens:        19,
+		Values:        7,
+		MaxDepth:      3,
+		StringBytes:   17,
+		LongestString: 8,
+	}
+	if stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+
+	sp.Reset()
+	if stats := sp.Stats(); stats != (Stats{}) {
+		t.Errorf("Stats() after Reset = %+v, want zero", stats)
+	}
+}
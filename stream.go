@@ -1,473 +1,377 @@
 package flexjson
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"strconv"
+	"runtime/debug"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // StreamingParser is a simplified JSON parser that processes JSON character by character
-// and updates an output map as it goes along.
+// and updates an output map as it goes along. It feeds an incremental Lexer
+// and decodes its tokens with the same core as Parser, so both handle
+// escapes, numbers, and errors the same way.
 type StreamingParser struct {
-	output       *map[string]any // Pointer to the output map
-	stack        []interface{}   // Stack of containers (maps/slices)
-	keys         []string        // Stack of keys
-	paths        []string        // Current path in the JSON
-	buffer       string          // Buffer for the current token
-	isEscaping   bool            // Whether we're currently escaping a character
-	inString     bool            // Whether we're currently inside a string
-	expectingKey bool            // Whether we're expecting a key
-	expectColon  bool            // Whether we're expecting a colon
-	lastChar     string          // Last processed character
-	debug        bool            // Whether to print debug messages
+	decoder                    // Builds the output from the tokens
+	lexer       *Lexer         // Tokenizes the input as it arrives
+	partial     int            // Length of the partial string last reported to watchers (-1 when none)
+	partialText string         // Partial string last sent as a patch
+	stored      partialValue   // Partial string stored in the output between Process calls
+	lastChar    string         // Last processed character
+	debug       bool           // Whether to print debug messages
+	trace       DebugOptions   // Debug trace configuration
+	traceOn     bool           // Whether the current character is being traced
+	traceChars  int            // Number of characters seen while tracing
+	traceLines  int            // Number of trace lines written
+	raw         *bytes.Buffer  // Raw passthrough buffer (nil when disabled)
+	noise       NoiseFilter    // Transport noise to discard outside of strings
+	inComment   bool           // Whether we're skipping an SSE comment line
+	partialRune []byte         // Incomplete UTF-8 sequence held back from the last chunk
+	offset      int            // Byte offset of the next character
+	recent      string         // Recently processed input, used for error snippets
+	hardened    bool           // Whether to convert internal panics into errors
+	ownHandler  EventHandler   // Handler set with SetEventHandler
+	scoped      []EventHandler // Handlers set on scopes made with At
+	mu          sync.Mutex     // Held while input is processed, so Snapshot sees a consistent output
 }
 
-// NewStreamingParser creates a new StreamingParser that will update the provided map
-func NewStreamingParser(output *map[string]any) *StreamingParser {
+// NewStreamingParser creates a new StreamingParser that will update the
+// provided map. The map is cleared first unless WithMerge is given.
+func NewStreamingParser(output *map[string]any, opts ...Option) *StreamingParser {
+	sp := &StreamingParser{lexer: NewIncrementalLexer()}
+	sp.init(output, opts)
+	return sp
+}
+
+// init configures the parser for output and opts, keeping the capacity of
+// the stacks
+func (sp *StreamingParser) init(output *map[string]any, opts []Option) {
 	if output == nil {
 		m := make(map[string]any)
 		output = &m
 	}
 
-	// Clear the output map to start fresh
-	for k := range *output {
-		delete(*output, k)
+	sp.decoder = decoder{
+		stack:       sp.stack[:0],
+		output:      output,
+		objectsOnly: true,
+	}
+	sp.partial = -1
+	for _, opt := range opts {
+		opt(&sp.decoder)
+	}
+	sp.configureLexer(sp.lexer)
+	sp.logf = sp.log
+	if sp.logger != nil {
+		sp.debug = true
 	}
 
-	return &StreamingParser{
-		output:       output,
-		stack:        []interface{}{output},
-		keys:         []string{},
-		paths:        []string{},
-		buffer:       "",
-		isEscaping:   false,
-		inString:     false,
-		expectingKey: true,
-		expectColon:  false,
-		lastChar:     "",
+	// Clear the output map to start fresh
+	if !sp.merge {
+		clear(*output)
 	}
 }
 
 // ProcessString processes a chunk of JSON data character by character
-func (sp *StreamingParser) ProcessString(chunk string) error {
-	for _, c := range chunk {
-		err := sp.ProcessChar(string(c))
-		if err != nil {
-			return err
-		}
+func (sp *StreamingParser) ProcessString(chunk string) (err error) {
+	defer sp.guard(&err)
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	defer sp.syncArrays()
+	defer sp.timeProcessing(time.Now())
+	sp.unstorePartial()
+	sp.instrumentChunk(len(chunk))
+	if sp.raw != nil {
+		sp.raw.WriteString(chunk)
 	}
-	return nil
-}
 
-// ProcessChar processes a single character in the JSON stream
-func (sp *StreamingParser) ProcessChar(c string) error {
-	sp.log("- %s\texpecting key: %v, expecting colon: %v, isEscaping: %v, inString: %v, buffer: %s\n", c,
-		sp.expectingKey, sp.expectColon, sp.isEscaping, sp.inString, sp.buffer)
-
-	if (c == "," || c == "}" || c == "]") && sp.buffer != "" {
-		// Try to parse as a number
-		if value, err := sp.parseNumber(); err == nil {
-			sp.log("\tAdding number value: %v\n", value)
-			sp.addValue(value)
-			sp.buffer = ""
-		}
+	if len(sp.partialRune) > 0 {
+		chunk = string(sp.partialRune) + chunk
+		sp.partialRune = sp.partialRune[:0]
 	}
 
-	// Handle string state (special handling for escaping)
-	if sp.inString {
-		if sp.isEscaping {
-			// We're currently escaping
-			sp.log("\tEscaping character\n")
-			sp.buffer += c
-			sp.isEscaping = false
-			sp.lastChar = c
-			return nil
-		}
-
-		if c == "\\" {
-			sp.log("\tStart of escaping character\n")
-			sp.isEscaping = true
-			sp.lastChar = c
-			return nil
-		}
-
-		if c == "\"" {
-			sp.log("End of string\n")
-			// End of string
-			sp.inString = false
-
-			// Handle differently based on context
-			if sp.expectingKey {
-				sp.log("\tStoring as key\n")
-				// We just parsed a key
-				sp.keys = append(sp.keys, sp.buffer)
-				sp.expectingKey = false
-				sp.expectColon = true
-			} else {
-				sp.log("\tAdding as value\n")
-				// We just parsed a string value
-				sp.addValue(sp.buffer)
-			}
+	// Hold back a multi-byte sequence that continues in the next chunk
+	if n := incompleteRuneSuffix(chunk); n > 0 {
+		sp.partialRune = append(sp.partialRune, chunk[len(chunk)-n:]...)
+		chunk = chunk[:len(chunk)-n]
+	}
 
-			sp.buffer = ""
-			sp.lastChar = c
-			return nil
+	// A UTF-16 or UTF-32 byte order mark isn't valid UTF-8, so it is fed
+	// whole for the lexer to report, rather than as replacement characters
+	if mark, _ := byteOrderMark(chunk); sp.offset == 0 && mark != "" {
+		if err := sp.step(mark, len(mark)); err != nil {
+			return err
 		}
-
-		// Regular character in string
-		sp.buffer += c
-		sp.lastChar = c
-		return nil
+		chunk = chunk[len(mark):]
 	}
 
-	// Handle other states
-	switch c {
-	case " ", "\t", "\r", "\n":
-		sp.log("Whitespace\n")
-		// Skip whitespace
-		sp.lastChar = c
-		return nil
-
-	case "{":
-		sp.log("Start of object\n")
-		// Start of an object
-		if len(sp.stack) == 1 && len(sp.keys) == 0 {
-			// Root object - already setup in our output
-			sp.log("\tRoot object\n")
-			sp.expectingKey = true
-			sp.lastChar = c
-			return nil
+	for i := 0; i < len(chunk); {
+		r, size := utf8.DecodeRuneInString(chunk[i:])
+		c := chunk[i : i+size]
+		if r == utf8.RuneError && size == 1 {
+			// An invalid byte is parsed as U+FFFD, but counted as one byte
+			c = string(utf8.RuneError)
 		}
-
-		sp.log("\tCreating new object\n")
-		// Create new object
-		newObj := make(map[string]any)
-
-		// Add it to its parent
-		sp.addValue(newObj)
-
-		// Push it onto the stack
-		sp.stack = append(sp.stack, newObj)
-		sp.expectingKey = true
-		sp.lastChar = c
-		return nil
-
-	case "}":
-		sp.log("End of object\n")
-		// End of an object
-		if len(sp.stack) > 1 {
-			sp.stack = sp.stack[:len(sp.stack)-1] // Pop from stack
-
-			// If we have keys, also pop the last key
-			if len(sp.keys) > 0 {
-				sp.keys = sp.keys[:len(sp.keys)-1]
-			}
+		if err := sp.step(c, size); err != nil {
+			return err
 		}
-		sp.expectingKey = false
-		sp.expectColon = false
-		sp.lastChar = c
-		return nil
-
-	case "[":
-		sp.log("Start of array\n")
-		// Start of an array
-		newArray := make([]interface{}, 0)
+		i += size
+	}
+	sp.endChunk()
+	return nil
+}
 
-		// Add it to its parent
-		sp.addValue(&newArray)
+// ProcessBytes processes a chunk of raw bytes. UTF-8 sequences split across
+// chunk boundaries are buffered until the rest of the sequence arrives.
+func (sp *StreamingParser) ProcessBytes(chunk []byte) error {
+	return sp.ProcessString(string(chunk))
+}
 
-		// Push it onto the stack
-		sp.stack = append(sp.stack, &newArray)
-		sp.expectingKey = false
-		sp.lastChar = c
-		return nil
+// ProcessChar processes a single character in the JSON stream
+func (sp *StreamingParser) ProcessChar(c string) (err error) {
+	defer sp.guard(&err)
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	defer sp.syncArrays()
+	defer sp.timeProcessing(time.Now())
+	sp.unstorePartial()
+	sp.instrumentChunk(len(c))
+	if sp.raw != nil {
+		sp.raw.WriteString(c)
+	}
+	if err := sp.step(c, len(c)); err != nil {
+		return err
+	}
+	sp.endChunk()
+	return nil
+}
 
-	case "]":
-		sp.log("End of array")
-		// End of an array
-		if len(sp.stack) > 1 {
-			sp.stack = sp.stack[:len(sp.stack)-1] // Pop from stack
+// step processes a single character, which took size bytes of the input,
+// keeping the position tracking up to date
+func (sp *StreamingParser) step(c string, size int) (err error) {
+	err = sp.processChar(c)
+	if err != nil {
+		sp.emitError(err)
+	}
+	sp.advance(c, size)
+	return err
+}
 
-			// If we have keys, also pop the last key
-			if len(sp.keys) > 0 {
-				sp.keys = sp.keys[:len(sp.keys)-1]
-			}
-		}
-		sp.expectingKey = false
-		sp.expectColon = false
-		sp.lastChar = c
-		return nil
+// processChar feeds a single character to the lexer and decodes the tokens it completes
+func (sp *StreamingParser) processChar(c string) error {
+	sp.traceChar(c)
 
-	case "\"":
-		sp.log("Start of string\n")
-		// Start of a string
-		sp.inString = true
-		sp.buffer = ""
-		sp.lastChar = c
-		return nil
+	if sp.skipNoise(c) {
+		sp.debugf("\tSkipping transport noise\n")
+		sp.lexer.feedNoise(c)
+	} else {
+		sp.lexer.feed(c)
+	}
+	sp.lastChar = c
 
-	case ":":
-		sp.log("Colon. Expecting: %#v\n", sp.expectColon)
-		// Colon after key
-		if !sp.expectColon {
-			return errors.New(
-				fmt.Sprintf("unexpected ':' - state: %#v", sp),
-			)
+	for {
+		tok, ok := sp.lexer.NextToken()
+		if !ok {
+			break
 		}
-		sp.expectColon = false
-		sp.lastChar = c
-		return nil
-
-	case ",":
-		sp.log("Comma\n")
-		// Comma between values or key-value pairs
-		// After a comma, if the parent is an object, we expect a key
-		if parent, ok := sp.getCurrentContainer(); ok {
-			switch parent.(type) {
-			case *map[string]any, map[string]any:
-				sp.log("\tParent is an object. Expecting key\n")
-				sp.expectingKey = true
-			case *[]interface{}:
-				sp.log("\tParent is an array. Not expecting key\n")
-				sp.expectingKey = false
-			default:
-				sp.log("\tWarning: Parent is not an object or array. Not expecting key. Parent: %#v\n", parent)
+		if err := sp.token(tok); err != nil {
+			var perr *ParseError
+			if errors.As(err, &perr) {
+				perr.Snippet = sp.recent + c
 			}
+			return err
 		}
-		sp.lastChar = c
-		return nil
-
-	case "t":
-		// Start of 'true'
-		if sp.buffer != "" {
-			return errors.New("unexpected 't'")
-		}
-		sp.buffer = "t"
-		sp.lastChar = c
-		return nil
-
-	case "r":
-		// Part of 'true'
-		if sp.buffer == "t" {
-			sp.buffer = "tr"
-			sp.lastChar = c
-			return nil
-		}
-		return errors.New("unexpected 'r'")
-
-	case "u":
-		// Part of 'true'
-		if sp.buffer == "tr" {
-			sp.buffer = "tru"
-			sp.lastChar = c
-			return nil
-		}
-
-		// Part of 'null'
-		if sp.buffer == "n" {
-			sp.buffer = "nu"
-			sp.lastChar = c
-			return nil
-		}
+	}
 
-		return errors.New("unexpected 'u'")
+	sp.notifyString()
+	return nil
+}
 
-	case "e":
-		// End of 'true' or part of 'false'
-		if sp.buffer == "tru" {
-			// Complete 'true'
-			sp.addValue(true)
-			sp.buffer = ""
-			sp.lastChar = c
-			return nil
-		}
-		if sp.buffer == "fals" {
-			// Complete 'false'
-			sp.addValue(false)
-			sp.buffer = ""
-			sp.lastChar = c
-			return nil
+// incompleteRuneSuffix returns the length of a truncated UTF-8 sequence at the end of s
+func incompleteRuneSuffix(s string) int {
+	for i := len(s) - 1; i >= 0 && i >= len(s)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			if utf8.FullRuneInString(s[i:]) {
+				return 0
+			}
+			return len(s) - i
 		}
-		return errors.New("unexpected 'e'")
+	}
+	return 0
+}
 
-	case "f":
-		// Start of 'false'
-		if sp.buffer != "" {
-			return errors.New("unexpected 'f'")
-		}
-		sp.buffer = "f"
-		sp.lastChar = c
-		return nil
+// IsComplete reports whether the input seen so far forms a complete JSON document
+func (sp *StreamingParser) IsComplete() bool {
+	return sp.done()
+}
 
-	case "a":
-		// Part of 'false'
-		if sp.buffer == "f" {
-			sp.buffer = "fa"
-			sp.lastChar = c
-			return nil
-		}
-		return errors.New("unexpected 'a'")
-
-	case "l":
-		// Part of 'false'
-		if sp.buffer == "fa" {
-			sp.buffer = "fal"
-			sp.lastChar = c
-			return nil
-		}
+// OpenContainers returns the number of objects and arrays (including the root)
+// that have been opened but not yet closed
+func (sp *StreamingParser) OpenContainers() int {
+	return len(sp.stack)
+}
 
-		// Part of 'null'
-		if sp.buffer == "nu" {
-			sp.buffer = "nul"
-			sp.lastChar = c
-			return nil
-		}
-		if sp.buffer == "nul" {
-			// Complete 'null'
-			sp.addValue(nil)
-			sp.buffer = ""
-			sp.lastChar = c
-			return nil
-		}
-		return errors.New("unexpected 'l'")
-	case "s":
-		// Part of 'false'
-		if sp.buffer == "fal" {
-			sp.buffer = "fals"
-			sp.lastChar = c
-			return nil
-		}
-		return errors.New("unexpected 's'")
+// advance updates the position tracking after processing c, which took size
+// bytes of the input
+func (sp *StreamingParser) advance(c string, size int) {
+	sp.offset += size
 
-	case "n":
-		// Start of 'null'
-		if sp.buffer != "" {
-			return errors.New("unexpected 'n'")
-		}
-		sp.buffer = "n"
-		sp.lastChar = c
-		return nil
-	default:
-		if (c >= "0" && c <= "9") || c == "-" || c == "." || c == "+" || c == "e" || c == "E" {
-			sp.buffer += c
-			sp.lastChar = c
-			return nil
+	sp.recent += c
+	if len(sp.recent) > 2*snippetSize {
+		start := len(sp.recent) - snippetSize
+		for start < len(sp.recent) && !utf8.RuneStart(sp.recent[start]) {
+			start++
 		}
-
-		return errors.New("unexpected character: " + c)
+		sp.recent = sp.recent[start:]
 	}
 }
 
-// parseNumber parses the current buffer as a number
-func (sp *StreamingParser) parseNumber() (interface{}, error) {
-	// Try to parse as integer first
-	if i, err := strconv.ParseInt(sp.buffer, 10, 64); err == nil {
-		return i, nil
+// IncompletePaths returns the paths of values that are still being streamed:
+// open objects and arrays, and strings, numbers, or literals that have started
+// but not finished. Paths are ordered from outermost to innermost. The root
+// object is not included; use IsComplete to check whether it has been closed.
+func (sp *StreamingParser) IncompletePaths() []string {
+	paths := make([]string, 0, len(sp.stack))
+	for i := 1; i < len(sp.stack); i++ {
+		paths = append(paths, sp.stack[i].path)
 	}
 
-	// Try to parse as float
-	if f, err := strconv.ParseFloat(sp.buffer, 64); err == nil {
-		return f, nil
+	// A scalar value in progress
+	if sp.expectingValue() && sp.lexer.pending() {
+		paths = append(paths, sp.pendingPath())
 	}
 
-	return nil, errors.New("invalid number: " + sp.buffer)
+	return paths
 }
 
-// getCurrentContainer gets the current container (map or slice) from the stack
-func (sp *StreamingParser) getCurrentContainer() (interface{}, bool) {
-	if len(sp.stack) == 0 {
-		return nil, false
+// Reset resets the parser state. The output map is cleared unless the parser
+// was created with WithMerge, and the statistics start over. The memory of
+// the internal stacks and buffers is kept for the next document.
+func (sp *StreamingParser) Reset() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.unstorePartial()
+
+	if !sp.merge {
+		clear(*sp.output)
 	}
-	return sp.stack[len(sp.stack)-1], true
-}
 
-// addValue adds a value to the current container
-func (sp *StreamingParser) addValue(value interface{}) {
-	if len(sp.stack) == 0 {
-		return
+	// Reset parser state
+	sp.reset()
+	sp.issues = sp.issues[:0]
+	sp.stats = Stats{}
+	sp.lexer.reset()
+	sp.configureLexer(sp.lexer)
+	sp.partial = -1
+	sp.partialText = ""
+	sp.lastChar = ""
+	sp.inComment = false
+	sp.partialRune = sp.partialRune[:0]
+	sp.offset = 0
+	sp.recent = ""
+	if sp.raw != nil {
+		sp.raw.Reset()
 	}
+}
 
-	current := sp.stack[len(sp.stack)-1]
+// SetHardened enables hardened mode, in which an internal panic while
+// processing input is returned as an *InternalError instead of crashing the caller
+func (sp *StreamingParser) SetHardened(value bool) {
+	sp.hardened = value
+}
 
-	switch container := current.(type) {
-	case *map[string]any:
-		// Add to map with the current key
-		if len(sp.keys) > 0 {
-			key := sp.keys[len(sp.keys)-1]
-			(*container)[key] = value
+// UseNumber causes the parser to store numbers in the output as json.Number,
+// keeping their text as written, instead of as int64 or float64
+func (sp *StreamingParser) UseNumber() {
+	sp.numbers = numberJSON
+}
 
-			// Don't remove the key here, it gets removed when we close the object
-		}
-	case map[string]any:
-		// Add to map with the current key
-		if len(sp.keys) > 0 {
-			key := sp.keys[len(sp.keys)-1]
-			container[key] = value
+// UseBigNumbers causes the parser to store integers that don't fit in an
+// int64 as *big.Int, and numbers that a float64 can't hold exactly as
+// *big.Float, as Parser.UseBigNumbers does
+func (sp *StreamingParser) UseBigNumbers() {
+	sp.numbers = numberBig
+}
 
-			// Don't remove the key here, it gets removed when we close the object
-		}
-	case *[]interface{}:
-		// Add to slice
-		*container = append(*container, value)
-	case []interface{}:
-		// Add to slice
-		newSlice := append(container, value)
-
-		// Update the parent with the new slice
-		if len(sp.stack) >= 2 {
-			parent := sp.stack[len(sp.stack)-2]
-
-			switch p := parent.(type) {
-			case *map[string]any:
-				// Parent is a map
-				if len(sp.keys) >= 2 {
-					key := sp.keys[len(sp.keys)-2]
-					(*p)[key] = newSlice
-				}
-			case map[string]any:
-				// Parent is a map
-				if len(sp.keys) >= 2 {
-					key := sp.keys[len(sp.keys)-2]
-					p[key] = newSlice
-				}
-			}
-		}
+// UseFloat64 causes the parser to store every number in the output as a
+// float64, as encoding/json does when decoding into an interface value
+func (sp *StreamingParser) UseFloat64() {
+	sp.numbers = numberFloat64
+}
 
-		// Update the current container in the stack
-		sp.stack[len(sp.stack)-1] = newSlice
+// guard recovers from a panic in hardened mode. It must be deferred directly.
+func (sp *StreamingParser) guard(err *error) {
+	if !sp.hardened {
+		return
+	}
+	if r := recover(); r != nil {
+		*err = &InternalError{Panic: r, State: sp.dumpState(), Stack: debug.Stack()}
 	}
 }
 
-// Reset resets the parser state
-func (sp *StreamingParser) Reset() {
-	// Clear the output map
-	for k := range *sp.output {
-		delete(*sp.output, k)
+// dumpState describes the parser state for an InternalError
+func (sp *StreamingParser) dumpState() string {
+	keys := make([]string, len(sp.stack))
+	paths := make([]string, len(sp.stack))
+	for i, f := range sp.stack {
+		keys[i], paths[i] = f.key, f.path
 	}
-
-	// Reset parser state
-	sp.stack = []interface{}{sp.output}
-	sp.keys = []string{}
-	sp.paths = []string{}
-	sp.buffer = ""
-	sp.isEscaping = false
-	sp.inString = false
-	sp.expectingKey = true
-	sp.expectColon = false
-	sp.lastChar = ""
+	state := fmt.Sprintf(
+		"offset: %d, state: %d, stack: %d, keys: %q, paths: %q",
+		sp.offset, sp.state, len(sp.stack), keys, paths,
+	)
+	if l := sp.lexer; l != nil {
+		state += fmt.Sprintf(", line: %d, column: %d, pending: %q, inString: %v, escaping: %v",
+			l.line, l.column, l.pendingText(), l.inString(), l.escaping())
+	}
+	return state
 }
 
+// SetDebug enables or disables verbose debug tracing of every character to
+// stdout, or to the logger given with WithLogger. Use SetDebugOptions to
+// sample or limit the trace.
 func (sp *StreamingParser) SetDebug(value bool) {
 	sp.debug = value
 }
 
-func (sp *StreamingParser) log(msg string, args ...interface{}) {
-	if sp.debug {
-		fmt.Printf(msg, args...)
+// GetCurrentOutput returns the current output map, which stays empty when a MapSink is set
+func (sp *StreamingParser) GetCurrentOutput() map[string]any {
+	return *sp.output
+}
+
+// Snapshot returns a deep copy of the current output, taken while no input is
+// being processed, so another goroutine can read it safely while the parser
+// carries on. Arrays are copied as []interface{} and objects as
+// map[string]any, or *OrderedMap for objects that are ordered maps. Snapshot
+// must not be called from a watcher or event handler with synchronous
+// dispatch, which runs while input is being processed.
+func (sp *StreamingParser) Snapshot() map[string]any {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return snapshotValue(*sp.output).(map[string]any)
+}
+
+// SetRawBuffer enables raw passthrough mode: every byte fed to the parser is
+// appended to buf as it was given, before it is parsed. If buf is nil an internal buffer is used.
+func (sp *StreamingParser) SetRawBuffer(buf *bytes.Buffer) {
+	if buf == nil {
+		buf = &bytes.Buffer{}
 	}
+	sp.raw = buf
 }
 
-// GetCurrentOutput returns the current output map
-func (sp *StreamingParser) GetCurrentOutput() map[string]any {
-	return *sp.output
+// GetRaw returns the raw bytes processed so far, or nil if raw passthrough is disabled
+func (sp *StreamingParser) GetRaw() []byte {
+	if sp.raw == nil {
+		return nil
+	}
+	return sp.raw.Bytes()
+}
+
+// GetRawAndOutput returns the raw bytes processed so far alongside the current output map
+func (sp *StreamingParser) GetRawAndOutput() ([]byte, map[string]any) {
+	return sp.GetRaw(), sp.GetCurrentOutput()
 }
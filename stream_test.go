@@ -1,8 +1,13 @@
 package flexjson
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestStreamingParser_SimpleObject(t *testing.T) {
@@ -75,8 +80,8 @@ func TestStreamingParser_Array(t *testing.T) {
 
 	// Check the result
 	expected := map[string]any{
-		"numbers": &[]interface{}{int64(1), int64(2), int64(3)},
-		"names":   &[]interface{}{"John", "Jane"},
+		"numbers": []interface{}{int64(1), int64(2), int64(3)},
+		"names":   []interface{}{"John", "Jane"},
 	}
 
 	if !reflect.DeepEqual(output, expected) {
@@ -273,3 +278,322 @@ func TestStreamingParser_RequirementExample(t *testing.T) {
 		t.Errorf("Example failed. Got %v, expected %v", output, expected)
 	}
 }
+
+func TestStreamingParser_RawBuffer(t *testing.T) {
+	output := make(map[string]any)
+	sp := NewStreamingParser(&output)
+
+	if raw := sp.GetRaw(); raw != nil {
+		t.Errorf("Expected nil raw output when passthrough is disabled, got %q", raw)
+	}
+
+	var buf bytes.Buffer
+	sp.SetRawBuffer(&buf)
+
+	chunks := []string{`{"name":`, ` "John", `, `"age": 30}`}
+	for _, chunk := range chunks {
+		if err := sp.ProcessString(chunk); err != nil {
+			t.Fatalf("Error processing chunk '%s': %v", chunk, err)
+		}
+	}
+
+	raw, parsed := sp.GetRawAndOutput()
+	if string(raw) != `{"name": "John", "age": 30}` {
+		t.Errorf("Unexpected raw output. Got %q", raw)
+	}
+	if buf.String() != string(raw) {
+		t.Errorf("Expected user buffer to hold the raw output. Got %q", buf.String())
+	}
+
+	expected := map[string]any{
+		"name": "John",
+		"age":  int64(30),
+	}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("Unexpected result. Got %v, expected %v", parsed, expected)
+	}
+
+	// Reset clears the raw buffer along with the output
+	sp.Reset()
+	if len(sp.GetRaw()) != 0 {
+		t.Errorf("Expected empty raw output after reset, got %q", sp.GetRaw())
+	}
+}
+
+func TestStreamingParser_InternalRawBuffer(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	sp.SetRawBuffer(nil)
+
+	if err := sp.ProcessString(`{"ok":true}`); err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+
+	if string(sp.GetRaw()) != `{"ok":true}` {
+		t.Errorf("Unexpected raw output. Got %q", sp.GetRaw())
+	}
+}
+
+func TestStreamingParser_RawBufferBytes(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	sp.SetRawBuffer(nil)
+
+	// An invalid byte, and a rune cut off at the end of the input
+	chunks := []string{`{"a":"x`, "\xffy", "\",\"b\":\"\xe2", "\x82"}
+	for _, chunk := range chunks {
+		if err := sp.ProcessString(chunk); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", chunk, err)
+		}
+	}
+
+	input := strings.Join(chunks, "")
+	if raw := sp.GetRaw(); string(raw) != input {
+		t.Errorf("GetRaw() = %q, want %q", raw, input)
+	}
+	// The cut-off rune is held back until the rest of it arrives
+	if got, want := sp.Stats().Bytes, len(input)-2; got != want {
+		t.Errorf("Stats().Bytes = %d, want %d", got, want)
+	}
+	if got := sp.GetCurrentOutput()["a"]; got != "x\uFFFDy" {
+		t.Errorf(`output["a"] = %q`, got)
+	}
+}
+
+func TestStreamingParser_EscapesAcrossChunks(t *testing.T) {
+	inputs := []string{
+		`{"text":"line1\nline2\ttab"}`,
+		`{"text":"caf\u00e9 \u00C9"}`,
+		`{"text":"\ud83d\ude00 smile"}`,
+		`{"text":"lone \ud83d end"}`,
+		`{"text":"lone \ude00 end"}`,
+		`{"text":"pair \ud83dA"}`,
+		`{"text":"\"quoted\" \\ \/"}`,
+	}
+
+	for _, input := range inputs {
+		var expected map[string]any
+		if err := json.Unmarshal([]byte(input), &expected); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+
+		// Split the input at every possible position so escapes straddle chunk boundaries
+		for i := 1; i < len(input); i++ {
+			if !utf8.RuneStart(input[i]) {
+				continue
+			}
+
+			output := make(map[string]any)
+			sp := NewStreamingParser(&output)
+
+			for _, chunk := range []string{input[:i], input[i:]} {
+				if err := sp.ProcessString(chunk); err != nil {
+					t.Fatalf("Error processing %q split at %d: %v", input, i, err)
+				}
+			}
+
+			if !reflect.DeepEqual(output, expected) {
+				t.Errorf("Input %q split at %d. Got %q, expected %q", input, i, output, expected)
+			}
+		}
+	}
+}
+
+func TestStreamingParser_ProcessBytesSplitRunes(t *testing.T) {
+	input := []byte(`{"text":"héllo wörld 😀 日本"}`)
+	expected := map[string]any{"text": "héllo wörld 😀 日本"}
+
+	// Split the input at every byte position, including inside multi-byte runes
+	for i := 1; i < len(input); i++ {
+		output := make(map[string]any)
+		sp := NewStreamingParser(&output)
+
+		for _, chunk := range [][]byte{input[:i], input[i:]} {
+			if err := sp.ProcessBytes(chunk); err != nil {
+				t.Fatalf("Error processing input split at %d: %v", i, err)
+			}
+		}
+
+		if !reflect.DeepEqual(output, expected) {
+			t.Errorf("Input split at %d. Got %q, expected %q", i, output, expected)
+		}
+	}
+}
+
+func TestStreamingParser_ProcessBytesOneByteAtATime(t *testing.T) {
+	input := []byte(`{"emoji":"😀🌍🚀","name":"Zoë"}`)
+	expected := map[string]any{"emoji": "😀🌍🚀", "name": "Zoë"}
+
+	output := make(map[string]any)
+	sp := NewStreamingParser(&output)
+
+	for i := range input {
+		if err := sp.ProcessBytes(input[i : i+1]); err != nil {
+			t.Fatalf("Error processing byte %d: %v", i, err)
+		}
+	}
+
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("Unexpected result. Got %q, expected %q", output, expected)
+	}
+}
+
+func TestStreamingParser_IsComplete(t *testing.T) {
+	sp := NewStreamingParser(nil)
+
+	steps := []struct {
+		chunk    string
+		open     int
+		complete bool
+	}{
+		{chunk: ``, open: 0, complete: false},
+		{chunk: `{"user":`, open: 1, complete: false},
+		{chunk: `{"tags":["a",`, open: 3, complete: false},
+		{chunk: `"b"]`, open: 2, complete: false},
+		{chunk: `,"note":"}]"`, open: 2, complete: false},
+		{chunk: `}`, open: 1, complete: false},
+		{chunk: `}`, open: 0, complete: true},
+		{chunk: "\n", open: 0, complete: true},
+	}
+
+	for _, step := range steps {
+		if err := sp.ProcessString(step.chunk); err != nil {
+			t.Fatalf("Error processing chunk '%s': %v", step.chunk, err)
+		}
+		if sp.OpenContainers() != step.open {
+			t.Errorf("After %q: OpenContainers() = %d, want %d", step.chunk, sp.OpenContainers(), step.open)
+		}
+		if sp.IsComplete() != step.complete {
+			t.Errorf("After %q: IsComplete() = %v, want %v", step.chunk, sp.IsComplete(), step.complete)
+		}
+	}
+
+	sp.Reset()
+	if sp.IsComplete() || sp.OpenContainers() != 0 {
+		t.Errorf("Expected reset parser to be empty and incomplete")
+	}
+}
+
+func TestStreamingParser_IncompletePaths(t *testing.T) {
+	sp := NewStreamingParser(nil)
+
+	steps := []struct {
+		chunk    string
+		expected []string
+	}{
+		{chunk: `{"id":12`, expected: []string{"id"}},
+		{chunk: `3,"choices":[{"delta":{"content":"Hel`, expected: []string{"choices", "choices[0]", "choices[0].delta", "choices[0].delta.content"}},
+		{chunk: `lo"`, expected: []string{"choices", "choices[0]", "choices[0].delta"}},
+		{chunk: `}},{"x.y":[tr`, expected: []string{"choices", "choices[1]", `choices[1]["x.y"]`, `choices[1]["x.y"][0]`}},
+		{chunk: `ue]}],"done":`, expected: []string{}},
+		{chunk: `"ye`, expected: []string{"done"}},
+		{chunk: `s"}`, expected: []string{}},
+	}
+
+	for _, step := range steps {
+		if err := sp.ProcessString(step.chunk); err != nil {
+			t.Fatalf("Error processing chunk '%s': %v", step.chunk, err)
+		}
+		if paths := sp.IncompletePaths(); !reflect.DeepEqual(paths, step.expected) {
+			t.Errorf("After %q: IncompletePaths() = %q, want %q", step.chunk, paths, step.expected)
+		}
+	}
+}
+
+func TestStreamingParser_SiblingObjects(t *testing.T) {
+	output := make(map[string]any)
+	sp := NewStreamingParser(&output)
+
+	if err := sp.ProcessString(`{"a":{"b":1,"c":{"d":2}},"e":{"f":3},"g":4}`); err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+
+	expected := map[string]any{
+		"a": map[string]any{"b": int64(1), "c": map[string]any{"d": int64(2)}},
+		"e": map[string]any{"f": int64(3)},
+		"g": int64(4),
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("Unexpected result. Got %v, expected %v", output, expected)
+	}
+}
+
+func TestStreamingParserUseNumber(t *testing.T) {
+	output := make(map[string]any)
+	sp := NewStreamingParser(&output)
+	sp.UseNumber()
+
+	if err := sp.ProcessString(`{"id":9007199254740993,"n":[2.50`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if output["id"] != json.Number("9007199254740993") {
+		t.Errorf("id = %#v", output["id"])
+	}
+
+	// The number in progress is stored once it ends
+	if err := sp.ProcessString(`]}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if n := output["n"]; !reflect.DeepEqual(n, []interface{}{json.Number("2.50")}) {
+		t.Errorf("n = %#v", n)
+	}
+}
+
+func TestStreamingParserSnapshot(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	if err := sp.ProcessString(`{"a": [1, {"b": "x"}], "c": {"d": true`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+
+	snap := sp.Snapshot()
+	want := map[string]any{
+		"a": []interface{}{int64(1), map[string]any{"b": "x"}},
+		"c": map[string]any{"d": true},
+	}
+	if !reflect.DeepEqual(snap, want) {
+		t.Fatalf("Snapshot() = %v, want %v", snap, want)
+	}
+
+	// The snapshot doesn't change as the parser carries on
+	if err := sp.ProcessString(`, "e": 1}, "a2": 2}`); err != nil {
+		t.Fatalf("ProcessString() error = %v", err)
+	}
+	if !reflect.DeepEqual(snap, want) {
+		t.Errorf("Snapshot() changed to %v", snap)
+	}
+	snap["a"].([]interface{})[1].(map[string]any)["b"] = "changed"
+	if sp.GetCurrentOutput()["a"].([]interface{})[1].(map[string]any)["b"] != "x" {
+		t.Error("Changing the snapshot changed the output")
+	}
+}
+
+func TestStreamingParserSnapshotConcurrent(t *testing.T) {
+	sp := NewStreamingParser(nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sp.ProcessString(`{"items": [`)
+		for i := range 200 {
+			sp.ProcessString(fmt.Sprintf(`{"n": %d, "s": "%s"}, `, i, strings.Repeat("x", i%7)))
+		}
+		sp.ProcessString(`null]}`)
+	}()
+
+	for {
+		select {
+		case <-done:
+			items := sp.Snapshot()["items"].([]interface{})
+			if len(items) != 201 {
+				t.Errorf("Snapshot() has %d items, want 201", len(items))
+			}
+			return
+		default:
+			snap := sp.Snapshot()
+			if items, ok := snap["items"].([]interface{}); ok {
+				for _, item := range items {
+					if m, ok := item.(map[string]any); ok {
+						_ = m["n"]
+					}
+				}
+			}
+		}
+	}
+}
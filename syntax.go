@@ -0,0 +1,339 @@
+package flexjson
+
+import (
+	"math/big"
+	"strings"
+	"unicode/utf8"
+)
+
+// Syntax selects extensions to JSON that the lexer and parser accept. The
+// zero value accepts standard JSON only.
+type Syntax uint
+
+const (
+	// SyntaxComments skips "//" line comments and "/* */" block comments
+	// between tokens, as in JSONC files such as VS Code's settings
+	SyntaxComments Syntax = 1 << iota
+
+	// SyntaxUnquotedKeys accepts object keys that are identifiers rather than
+	// strings, as in {name: "John", age: 30}. An identifier is made of
+	// letters, digits, '_', '$', and non-ASCII characters, and doesn't start
+	// with a digit. true, false, and null are still literals.
+	SyntaxUnquotedKeys
+
+	// SyntaxPythonLiterals accepts Python's True, False, and None as the
+	// literals true, false, and null, as often found in the output of models
+	// and of Python's str() of a dict
+	SyntaxPythonLiterals
+
+	// syntaxJSON5 is the part of JSON5 without its own flag
+	syntaxJSON5
+)
+
+// SyntaxJSON5 accepts JSON5 (https://json5.org): unquoted object keys,
+// single-quoted strings, line continuations and the \v, \0, and \xHH escapes
+// in strings, trailing commas, hexadecimal numbers, numbers with a leading '+'
+// or a leading or trailing decimal point, Infinity, NaN, and comments.
+// Numbers are stored as if they had been written as JSON numbers, so 0x1F is
+// stored as 31 and .5 as 0.5.
+const SyntaxJSON5 = syntaxJSON5 | SyntaxComments | SyntaxUnquotedKeys
+
+// Comment kinds being skipped by the lexer
+const (
+	lineComment  = 1 // A "//" comment, up to the end of the line
+	blockComment = 2 // A "/* */" comment
+)
+
+// Escape states beyond the \uXXXX digits
+const (
+	escapeLineBreak = 10 // After "\" and a carriage return, which may be followed by a line feed
+	escapeHexByte   = 11 // 11-12 while reading \xHH digits
+)
+
+// WithSyntax makes a parser accept the extensions to JSON in syntax. With a
+// Parser, the tokens must come from a Lexer with the same syntax; Parse and
+// StreamingParser set up their lexers themselves.
+func WithSyntax(syntax Syntax) Option {
+	return func(d *decoder) {
+		d.syntax = syntax
+	}
+}
+
+// SetSyntax makes the lexer accept the extensions to JSON in syntax. It must
+// be called before any tokens are read.
+func (l *Lexer) SetSyntax(syntax Syntax) {
+	l.syntax = syntax
+}
+
+// trailingCommas reports whether a comma may follow the last member of an
+// object or element of an array
+func (d *decoder) trailingCommas() bool {
+	return d.syntax&syntaxJSON5 != 0
+}
+
+// skipSpace skips whitespace, transport noise, and comments between tokens,
+// and the rest of a number cut short by a size limit.
+// It returns false if more input is needed to tell whether a '/' starts a
+// comment, or to see the end of a block comment.
+func (l *Lexer) skipSpace() bool {
+	if !l.skipLongNumber() {
+		return false
+	}
+	if l.pos == 0 && !l.skipBOM() {
+		return false
+	}
+
+	comments := l.syntax&SyntaxComments != 0
+	for l.pos < l.end() {
+		c := l.at(l.pos)
+		switch {
+		case l.comment == lineComment:
+			if c == '\n' {
+				l.comment = 0
+			}
+		case l.comment == blockComment:
+			if c == '*' {
+				if l.pos+1 == l.end() {
+					return l.final
+				}
+				if l.at(l.pos+1) == '/' {
+					l.comment = 0
+					l.pos++
+				}
+			}
+		case isSpace(c) || l.isNoise(l.pos):
+		case c == '/' && comments:
+			if l.pos+1 == l.end() {
+				return l.final
+			}
+			switch l.at(l.pos + 1) {
+			case '/':
+				l.comment = lineComment
+			case '*':
+				l.comment = blockComment
+			default:
+				return true
+			}
+			l.pos++
+		default:
+			return true
+		}
+		l.pos++
+	}
+	return true
+}
+
+// startJSON5Escape handles the character after a backslash that only JSON5
+// gives a meaning to. It returns false for the escapes of standard JSON.
+func (l *Lexer) startJSON5Escape(c byte) bool {
+	switch c {
+	case 'v':
+		l.escape = 0
+		l.flushSurrogate()
+		l.str.WriteByte('\v')
+	case '0':
+		l.escape = 0
+		l.flushSurrogate()
+		l.str.WriteByte(0)
+	case 'x':
+		l.escape, l.hex = escapeHexByte, 0
+	case '\n':
+		// A line continuation, which adds nothing to the string
+		l.escape = 0
+	case '\r':
+		l.escape = escapeLineBreak
+	default:
+		return false
+	}
+	return true
+}
+
+// scanJSON5Escape handles the byte c in the parts of an escape sequence that
+// only JSON5 has
+func (l *Lexer) scanJSON5Escape(c byte) {
+	if l.escape == escapeLineBreak {
+		// Skip the line feed of a CRLF line continuation. Any other byte is
+		// scanned as part of the string.
+		l.escape = 0
+		if c == '\n' {
+			l.scanned++
+		}
+		return
+	}
+
+	d, ok := hexValue(c)
+	if !ok {
+		l.escape = 0
+		l.flushSurrogate()
+		l.str.WriteRune(utf8.RuneError)
+		return
+	}
+
+	l.scanned++
+	l.hex = l.hex<<4 | d
+	if l.escape++; l.escape == escapeHexByte+2 {
+		l.escape = 0
+		l.flushSurrogate()
+		l.str.WriteRune(l.hex)
+	}
+}
+
+// scanJSON5Number scans a JSON5 number, or Infinity or NaN with a sign, and
+// returns it as the equivalent JSON number
+func (l *Lexer) scanJSON5Number() (Token, bool) {
+	if c := l.at(l.pos); (c == '-' || c == '+') && l.pos+1 < l.end() && isAlpha(l.at(l.pos+1)) {
+		// A signed Infinity or NaN
+		return l.scanWord()
+	}
+
+	i, end := l.resumeScan()
+	for i < end {
+		c := l.at(i)
+		if (c == 'x' || c == 'X') && l.part == numberInteger && strings.TrimLeft(l.text(l.start, i), "+-") == "0" {
+			l.part = numberHex
+			i++
+			continue
+		}
+		next, ok := l.part.next(c, true)
+		if !ok {
+			break
+		}
+		l.part = next
+		i++
+	}
+
+	if l.maxNumber > 0 && i-l.start > l.maxNumber {
+		return l.longNumber(i), true
+	}
+
+	// More of the number may follow in the next chunk
+	if i == end && !l.final {
+		l.scannedTo = i
+		return Token{}, false
+	}
+
+	l.pos = i
+	return l.token(TokenNumber, normalizeNumber(l.text(l.start, l.pos))), true
+}
+
+// normalizeNumber rewrites a JSON5 number as the equivalent JSON number.
+// Text that isn't a valid number is returned unchanged, to be reported as an
+// invalid number.
+func normalizeNumber(s string) string {
+	sign, digits := "", s
+	switch s[0] {
+	case '-':
+		sign, digits = "-", s[1:]
+	case '+':
+		digits = s[1:]
+	}
+
+	if len(digits) > 2 && digits[0] == '0' && (digits[1] == 'x' || digits[1] == 'X') {
+		n, ok := new(big.Int).SetString(digits[2:], 16)
+		if !ok {
+			return s
+		}
+		return sign + n.String()
+	}
+
+	mantissa, exponent := digits, ""
+	if i := strings.IndexAny(digits, "eE"); i >= 0 {
+		mantissa, exponent = digits[:i], digits[i:]
+	}
+	if strings.Trim(mantissa, ".") == "" {
+		return s
+	}
+	if strings.HasPrefix(mantissa, ".") {
+		mantissa = "0" + mantissa
+	}
+	mantissa = strings.TrimSuffix(mantissa, ".")
+	return sign + mantissa + exponent
+}
+
+// scanWord scans an identifier, which is an unquoted object key unless it is
+// a literal or, in JSON5, Infinity or NaN, which may be signed. It is used
+// instead of scanIdentifier when any syntax that adds words is enabled.
+func (l *Lexer) scanWord() (Token, bool) {
+	i, end := l.resumeScan()
+	if c := l.at(l.pos); i == l.pos && (c == '-' || c == '+') {
+		i++
+	}
+	for i < end && (isAlphaNumeric(l.at(i)) || isIdentifierStart(l.at(i))) {
+		i++
+	}
+
+	if i == end && !l.final {
+		// More of the identifier may follow in the next chunk
+		l.scannedTo = i
+		return Token{}, false
+	}
+	word := l.text(l.pos, i)
+	if i == end {
+		if _, ok := l.literalType(word); !ok && l.isLiteralPrefix(word) {
+			// Drop a literal cut off by the end of the input
+			l.pos = i
+			return Token{}, false
+		}
+	}
+
+	l.pos = i
+	if tokenType, ok := l.literalType(word); ok {
+		return l.token(tokenType, word), true
+	}
+	if l.syntax&syntaxJSON5 != 0 {
+		switch strings.TrimLeft(word, "+-") {
+		case "Infinity":
+			return l.token(TokenNumber, word), true
+		case "NaN":
+			// strconv doesn't accept a signed NaN
+			return l.token(TokenNumber, "NaN"), true
+		}
+	}
+	if word[0] == '-' || word[0] == '+' || l.syntax&SyntaxUnquotedKeys == 0 {
+		return l.token(TokenError, word), true
+	}
+	return l.token(TokenIdentifier, word), true
+}
+
+// isIdentifierStart reports whether c can start an identifier, besides
+// the letters and '_' accepted by isAlpha. Bytes of multi-byte characters
+// are accepted, so identifiers may contain any non-ASCII character.
+func isIdentifierStart(c byte) bool {
+	return c == '$' || c >= utf8.RuneSelf
+}
+
+// literalType returns the token type of a complete literal, including
+// Python's literals when they are enabled
+func (l *Lexer) literalType(word string) (TokenType, bool) {
+	if l.syntax&SyntaxPythonLiterals != 0 {
+		switch word {
+		case "True":
+			return TokenTrue, true
+		case "False":
+			return TokenFalse, true
+		case "None":
+			return TokenNull, true
+		}
+	}
+	return literalType(word)
+}
+
+// isLiteralPrefix reports whether word is the start of a literal, of a
+// Python literal when they are enabled, or, in JSON5, of Infinity or NaN,
+// which may be signed
+func (l *Lexer) isLiteralPrefix(word string) bool {
+	unsigned := strings.TrimLeft(word, "+-")
+	json5 := l.syntax&syntaxJSON5 != 0
+	if len(unsigned) < len(word) {
+		return json5 && (strings.HasPrefix("Infinity", unsigned) || strings.HasPrefix("NaN", unsigned))
+	}
+	switch {
+	case isLiteralPrefix(word):
+		return true
+	case json5 && (strings.HasPrefix("Infinity", word) || strings.HasPrefix("NaN", word)):
+		return true
+	case l.syntax&SyntaxPythonLiterals != 0:
+		return strings.HasPrefix("True", word) || strings.HasPrefix("False", word) || strings.HasPrefix("None", word)
+	}
+	return false
+}
@@ -0,0 +1,273 @@
+package flexjson
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSON5(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+	}{
+		{
+			name:     "unquoted keys",
+			input:    `{name: "Jo", $id: 1, _x: true, naïve: null}`,
+			expected: map[string]any{"name": "Jo", "$id": int64(1), "_x": true, "naïve": nil},
+		},
+		{
+			name:     "single quotes",
+			input:    `{'a': 'it\'s "quoted"'}`,
+			expected: map[string]any{"a": `it's "quoted"`},
+		},
+		{
+			name:     "trailing commas",
+			input:    `{"a": [1, 2,], "b": {"c": 3,},}`,
+			expected: map[string]any{"a": []interface{}{int64(1), int64(2)}, "b": map[string]any{"c": int64(3)}},
+		},
+		{
+			name:     "numbers",
+			input:    `{"hex": 0x1F, "neg": -0XA, "lead": .5, "trail": 5., "plus": +1, "exp": .5e1}`,
+			expected: map[string]any{"hex": int64(31), "neg": int64(-10), "lead": 0.5, "trail": int64(5), "plus": int64(1), "exp": float64(5)},
+		},
+		{
+			name:     "comments",
+			input:    "// leading\n{\"a\": /* inline */ 1, // trailing\n\"b\": 2 /* last */}",
+			expected: map[string]any{"a": int64(1), "b": int64(2)},
+		},
+		{
+			name:     "escapes",
+			input:    "{\"a\": \"\\x41\\v\\0\", \"b\": \"one \\\ntwo \\\r\nthree\"}",
+			expected: map[string]any{"a": "A\v\x00", "b": "one two three"},
+		},
+		{
+			name:     "partial unquoted key",
+			input:    `{done: true, na`,
+			expected: map[string]any{"done": true, "na": nil},
+		},
+		{
+			name:     "partial literal",
+			input:    `{"a": 1, "b": Infin`,
+			expected: map[string]any{"a": int64(1), "b": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(tt.input, WithSyntax(SyntaxJSON5))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Parse() = %#v, want %#v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseJSON5Infinity(t *testing.T) {
+	result, err := Parse(`{a: Infinity, b: -Infinity, c: +NaN}`, WithSyntax(SyntaxJSON5))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if v := result["a"].(float64); !math.IsInf(v, 1) {
+		t.Errorf("a = %v, want +Inf", v)
+	}
+	if v := result["b"].(float64); !math.IsInf(v, -1) {
+		t.Errorf("b = %v, want -Inf", v)
+	}
+	if v := result["c"].(float64); !math.IsNaN(v) {
+		t.Errorf("c = %v, want NaN", v)
+	}
+}
+
+func TestStreamingParserJSON5(t *testing.T) {
+	input := "{\n  // settings\n  name: 'flex',\n  size: 0x10,\n  ratio: .25,\n  tags: ['a', 'b',],\n  /* nested */ inner: {ok: true,},\n}"
+	expected := map[string]any{
+		"name":  "flex",
+		"size":  int64(16),
+		"ratio": 0.25,
+		"tags":  []interface{}{"a", "b"},
+		"inner": map[string]any{"ok": true},
+	}
+
+	output := map[string]any{}
+	sp := NewStreamingParser(&output, WithSyntax(SyntaxJSON5))
+	for i := 0; i < len(input); i++ {
+		if err := sp.ProcessString(input[i : i+1]); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", input[i:i+1], err)
+		}
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("Output = %#v, want %#v", output, expected)
+	}
+}
+
+func TestJSON5RequiresSyntax(t *testing.T) {
+	for _, input := range []string{
+		`{a: 1}`,
+		`{'a': 1}`,
+		`{"a": [1,]}`,
+		`{"a": 0x1}`,
+		`{"a": 1 // comment
+}`,
+	} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error without SyntaxJSON5", input)
+		}
+	}
+}
+
+func TestParseComments(t *testing.T) {
+	input := `{
+  // Editor settings
+  "editor.fontSize": 14, /* points */
+  "files.exclude": {"**/.git": true}, // a "/" inside a string isn't a comment
+  "url": "http://example.com/*not a comment*/"
+}`
+	expected := map[string]any{
+		"editor.fontSize": int64(14),
+		"files.exclude":   map[string]any{"**/.git": true},
+		"url":             "http://example.com/*not a comment*/",
+	}
+
+	result, err := Parse(input, WithSyntax(SyntaxComments))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Parse() = %#v, want %#v", result, expected)
+	}
+
+	output := map[string]any{}
+	sp := NewStreamingParser(&output, WithSyntax(SyntaxComments))
+	for i := 0; i < len(input); i++ {
+		if err := sp.ProcessString(input[i : i+1]); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", input[i:i+1], err)
+		}
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("Output = %#v, want %#v", output, expected)
+	}
+
+	// Comments don't turn on the rest of JSON5
+	if _, err := Parse(`{a: 1}`, WithSyntax(SyntaxComments)); err == nil {
+		t.Error("Parse() of an unquoted key succeeded with SyntaxComments")
+	}
+}
+
+func TestParseUnquotedKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+	}{
+		{
+			name:     "identifiers",
+			input:    `{name: "John", age: 30, $ref: "#", _id: 1, ключ: true}`,
+			expected: map[string]any{"name": "John", "age": int64(30), "$ref": "#", "_id": int64(1), "ключ": true},
+		},
+		{
+			name:     "keys that start like literals",
+			input:    `{trueish: 1, nullable: null, f: false}`,
+			expected: map[string]any{"trueish": int64(1), "nullable": nil, "f": false},
+		},
+		{
+			name:     "mixed with quoted keys",
+			input:    `{"a": {b: [1, {c: 2}]}}`,
+			expected: map[string]any{"a": map[string]any{"b": []interface{}{int64(1), map[string]any{"c": int64(2)}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(tt.input, WithSyntax(SyntaxUnquotedKeys))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Parse() = %#v, want %#v", result, tt.expected)
+			}
+
+			output := map[string]any{}
+			sp := NewStreamingParser(&output, WithSyntax(SyntaxUnquotedKeys))
+			for i := 0; i < len(tt.input); i++ {
+				if err := sp.ProcessString(tt.input[i : i+1]); err != nil {
+					t.Fatalf("ProcessString(%q) error = %v", tt.input[i:i+1], err)
+				}
+			}
+			if !reflect.DeepEqual(output, tt.expected) {
+				t.Errorf("Output = %#v, want %#v", output, tt.expected)
+			}
+		})
+	}
+
+	// Identifiers are only keys, and the rest of JSON5 stays off
+	for _, input := range []string{`{name: John}`, `{a: Infinity}`, `{a: 1,}`} {
+		if _, err := Parse(input, WithSyntax(SyntaxUnquotedKeys)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestParsePythonLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+	}{
+		{
+			name:     "literals",
+			input:    `{"ok": True, "failed": False, "error": None}`,
+			expected: map[string]any{"ok": true, "failed": false, "error": nil},
+		},
+		{
+			name:     "in arrays",
+			input:    `{"flags": [True, false, None, null]}`,
+			expected: map[string]any{"flags": []interface{}{true, false, nil, nil}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(tt.input, WithSyntax(SyntaxPythonLiterals))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Parse() = %#v, want %#v", result, tt.expected)
+			}
+
+			output := map[string]any{}
+			sp := NewStreamingParser(&output, WithSyntax(SyntaxPythonLiterals))
+			for i := 0; i < len(tt.input); i++ {
+				if err := sp.ProcessString(tt.input[i : i+1]); err != nil {
+					t.Fatalf("ProcessString(%q) error = %v", tt.input[i:i+1], err)
+				}
+			}
+			if !reflect.DeepEqual(output, tt.expected) {
+				t.Errorf("Output = %#v, want %#v", output, tt.expected)
+			}
+		})
+	}
+
+	// A literal cut off by the end of the input is dropped
+	result, err := Parse(`{"ok": True, "done": Fal`, WithSyntax(SyntaxPythonLiterals))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if expected := (map[string]any{"ok": true, "done": nil}); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Parse() = %#v, want %#v", result, expected)
+	}
+
+	for _, input := range []string{`{"a": Nothing}`, `{"a": TRUE}`, `{None: 1}`} {
+		if _, err := Parse(input, WithSyntax(SyntaxPythonLiterals)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", input)
+		}
+	}
+	if _, err := Parse(`{"a": True}`); err == nil {
+		t.Error("Parse() of True succeeded without SyntaxPythonLiterals")
+	}
+}
@@ -0,0 +1,255 @@
+package flexjson
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// maxToMapDepth bounds recursion so cyclic values fail instead of overflowing the stack
+const maxToMapDepth = 1000
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// ToMap converts a Go struct (or map with string keys) into the map[string]any
+// model produced by Parse: integers become int64, floats become float64,
+// slices become []any, and nested structs and maps become map[string]any.
+// Struct fields are named by their `flexjson` or `json` tags and honor the
+// "-", omitempty, omitzero, and string options the same way encoding/json does.
+// Types implementing json.Marshaler or encoding.TextMarshaler are converted
+// from their marshaled form. A panic from a marshaler or from reflection is
+// returned as an *InternalError.
+func ToMap(v any) (obj map[string]any, err error) {
+	defer recoverInternal(&err, nil)
+
+	value, err := toValue(reflect.ValueOf(v), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrNotAnObject, v)
+	}
+	return obj, nil
+}
+
+// toValue converts a reflected Go value into the package's value model
+func toValue(v reflect.Value, depth int) (any, error) {
+	if depth > maxToMapDepth {
+		return nil, errors.New("value is nested too deeply (is it cyclic?)")
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	// Marshalers control their own representation
+	if m, ok := marshalerFor(v); ok {
+		return marshaledValue(m)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := v.Uint(); u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return toValue(v.Elem(), depth+1)
+	case reflect.Struct:
+		return structToMap(v, depth)
+	case reflect.Map:
+		return mapToMap(v, depth)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+		return sliceToArray(v, depth)
+	case reflect.Array:
+		return sliceToArray(v, depth)
+	default:
+		return nil, fmt.Errorf("unsupported type: %s", v.Type())
+	}
+}
+
+// marshalerFor returns the marshaler implemented by v, if any. Pointer-receiver
+// methods are only used when v is addressable, as in encoding/json.
+func marshalerFor(v reflect.Value) (any, bool) {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil, false
+	}
+
+	t := v.Type()
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return v.Interface(), true
+	}
+	if v.CanAddr() {
+		pt := reflect.PointerTo(t)
+		if pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType) {
+			return v.Addr().Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// marshaledValue converts a marshaler's output into the value model
+func marshaledValue(m any) (any, error) {
+	if jm, ok := m.(json.Marshaler); ok {
+		data, err := jm.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		return NewParser(NewLexer(string(data)).Tokenize()).Parse()
+	}
+
+	text, err := m.(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// structToMap converts a struct using its field tags
+func structToMap(v reflect.Value, depth int) (map[string]any, error) {
+	fields := structFields(v.Type())
+	obj := make(map[string]any, len(fields))
+
+	for _, f := range fields {
+		fv, err := v.FieldByIndexErr(f.index)
+		if err != nil {
+			// Field of a nil embedded pointer
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if f.omitZero && isZeroValue(fv) {
+			continue
+		}
+
+		value, err := toValue(fv, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if f.asString {
+			value = scalarString(value)
+		}
+		obj[f.name] = value
+	}
+	return obj, nil
+}
+
+// mapToMap converts a map whose keys are strings, integers, or TextMarshalers
+func mapToMap(v reflect.Value, depth int) (any, error) {
+	if v.IsNil() {
+		return nil, nil
+	}
+
+	obj := make(map[string]any, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		value, err := toValue(iter.Value(), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = value
+	}
+	return obj, nil
+}
+
+// mapKey converts a map key to an object key
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type: %s", k.Type())
+}
+
+// sliceToArray converts a slice or array
+func sliceToArray(v reflect.Value, depth int) ([]any, error) {
+	arr := make([]any, v.Len())
+	for i := range arr {
+		value, err := toValue(v.Index(i), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = value
+	}
+	return arr, nil
+}
+
+// isEmptyValue reports whether v is empty for the omitempty option
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// isZeroValue reports whether v is zero for the omitzero option, preferring
+// an IsZero method when the type has one
+func isZeroValue(v reflect.Value) bool {
+	if z, ok := v.Interface().(interface{ IsZero() bool }); ok {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return true
+		}
+		return z.IsZero()
+	}
+	return v.IsZero()
+}
+
+// scalarString converts a scalar to its string form for the string option
+func scalarString(value any) any {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return value
+}
@@ -0,0 +1,166 @@
+package flexjson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type toMapAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type toMapBase struct {
+	ID      int    `json:"id"`
+	Created string `json:"created"`
+}
+
+type toMapUser struct {
+	toMapBase
+	Name     string         `json:"name"`
+	Nick     string         `flexjson:"nickname" json:"nick"`
+	Age      uint8          `json:"age"`
+	Score    float32        `json:"score"`
+	Admin    bool           `json:"admin,string"`
+	Secret   string         `json:"-"`
+	Email    string         `json:"email,omitempty"`
+	Address  *toMapAddress  `json:"address"`
+	Previous *toMapAddress  `json:"previous,omitempty"`
+	Tags     []string       `json:"tags"`
+	Labels   map[string]int `json:"labels"`
+	Data     []byte         `json:"data"`
+	When     time.Time      `json:"when"`
+	Zero     time.Time      `json:"zero,omitzero"`
+	Extra    any            `json:"extra"`
+	Untagged int
+	ByID     map[int]string `json:"by_id"`
+	private  string
+}
+
+func TestToMap(t *testing.T) {
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	user := toMapUser{
+		toMapBase: toMapBase{ID: 7, Created: "today"},
+		Name:      "John",
+		Nick:      "JJ",
+		Age:       30,
+		Score:     1.5,
+		Admin:     true,
+		Secret:    "hidden",
+		Address:   &toMapAddress{City: "Paris"},
+		Tags:      []string{"a", "b"},
+		Labels:    map[string]int{"x": 1},
+		Data:      []byte("hi"),
+		When:      when,
+		Extra:     []any{1, "two"},
+		Untagged:  3,
+		ByID:      map[int]string{1: "one"},
+		private:   "ignored",
+	}
+
+	result, err := ToMap(user)
+	if err != nil {
+		t.Fatalf("ToMap() error = %v", err)
+	}
+
+	expected := map[string]any{
+		"id":       int64(7),
+		"created":  "today",
+		"name":     "John",
+		"nickname": "JJ",
+		"age":      int64(30),
+		"score":    1.5,
+		"admin":    "true",
+		"address":  map[string]any{"city": "Paris"},
+		"tags":     []any{"a", "b"},
+		"labels":   map[string]any{"x": int64(1)},
+		"data":     "aGk=",
+		"when":     "2024-01-02T03:04:05Z",
+		"extra":    []any{int64(1), "two"},
+		"Untagged": int64(3),
+		"by_id":    map[string]any{"1": "one"},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ToMap() =\n%v\nwant\n%v", result, expected)
+	}
+}
+
+func TestToMapPointerAndMap(t *testing.T) {
+	result, err := ToMap(&toMapAddress{City: "Oslo", Zip: "0150"})
+	if err != nil {
+		t.Fatalf("ToMap() error = %v", err)
+	}
+	if !reflect.DeepEqual(result, map[string]any{"city": "Oslo", "zip": "0150"}) {
+		t.Errorf("ToMap() = %v", result)
+	}
+
+	result, err = ToMap(map[string]any{"nested": toMapAddress{City: "Rome"}})
+	if err != nil {
+		t.Fatalf("ToMap() error = %v", err)
+	}
+	if !reflect.DeepEqual(result, map[string]any{"nested": map[string]any{"city": "Rome"}}) {
+		t.Errorf("ToMap() = %v", result)
+	}
+}
+
+func TestToMapErrors(t *testing.T) {
+	if _, err := ToMap(42); !errors.Is(err, ErrNotAnObject) {
+		t.Errorf("ToMap(42) error = %v, want ErrNotAnObject", err)
+	}
+
+	if _, err := ToMap(map[string]any{"ch": make(chan int)}); err == nil {
+		t.Errorf("Expected an error for unsupported types")
+	}
+
+	type node struct {
+		Next *node `json:"next"`
+	}
+	cyclic := &node{}
+	cyclic.Next = cyclic
+	if _, err := ToMap(cyclic); err == nil {
+		t.Errorf("Expected an error for cyclic values")
+	}
+}
+
+func TestStructFieldsConflicts(t *testing.T) {
+	type A struct {
+		Name string
+		Dup  string
+	}
+	type B struct {
+		Dup string
+	}
+	type C struct {
+		Tagged string `json:"Dup"`
+	}
+	type outer struct {
+		A
+		B
+		Name string
+	}
+	type tagged struct {
+		B
+		C
+	}
+
+	result, err := ToMap(outer{A: A{Name: "inner", Dup: "a"}, B: B{Dup: "b"}, Name: "outer"})
+	if err != nil {
+		t.Fatalf("ToMap() error = %v", err)
+	}
+	// The shallower Name wins and the ambiguous Dup is dropped
+	if !reflect.DeepEqual(result, map[string]any{"Name": "outer"}) {
+		t.Errorf("ToMap() = %v", result)
+	}
+
+	result, err = ToMap(tagged{B: B{Dup: "b"}, C: C{Tagged: "c"}})
+	if err != nil {
+		t.Fatalf("ToMap() error = %v", err)
+	}
+	// The tagged field wins at equal depth
+	if !reflect.DeepEqual(result, map[string]any{"Dup": "c"}) {
+		t.Errorf("ToMap() = %v", result)
+	}
+}
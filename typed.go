@@ -0,0 +1,103 @@
+package flexjson
+
+import "reflect"
+
+// Decode parses a possibly partial JSON document into a value of type T, as
+// Unmarshal does. Fields of T whose keys haven't arrived yet keep their zero
+// values.
+func Decode[T any](input string) (T, error) {
+	var v T
+	err := Unmarshal([]byte(input), &v)
+	return v, err
+}
+
+// TypedStreamingParser is a StreamingParser that keeps a Go value of type T up
+// to date as chunks arrive, mapping keys onto struct fields as Unmarshal does.
+// Like the output map, the value gets strings and numbers once they are complete.
+// Integers that don't fit in an int64 are read into unsigned fields only
+// with UseNumber, which keeps their text.
+// The methods of the embedded StreamingParser, such as Watch and IsComplete,
+// are available as usual.
+type TypedStreamingParser[T any] struct {
+	*StreamingParser
+	output map[string]any // Document being built by the parser
+	value  *T             // Value kept up to date with the document
+	hook   DecodeHookFunc // Converts values before they are stored in the value
+}
+
+// NewTypedStreamingParser creates a new TypedStreamingParser that will update
+// the value pointed to by target. If target is nil a new value is allocated.
+// The options configure the underlying StreamingParser.
+func NewTypedStreamingParser[T any](target *T, opts ...Option) *TypedStreamingParser[T] {
+	if target == nil {
+		target = new(T)
+	}
+
+	tp := &TypedStreamingParser[T]{
+		output: make(map[string]any),
+		value:  target,
+	}
+	tp.StreamingParser = NewStreamingParser(&tp.output, opts...)
+	tp.updated = &changeSet{seen: make(map[string]bool)}
+	return tp
+}
+
+// ProcessString processes a chunk of JSON data, then updates the value with
+// what has been parsed since the last update. A key whose value has the
+// wrong type for its field is reported as a *json.UnmarshalTypeError by the
+// call that stores it.
+func (tp *TypedStreamingParser[T]) ProcessString(chunk string) error {
+	if err := tp.StreamingParser.ProcessString(chunk); err != nil {
+		return err
+	}
+	return tp.update()
+}
+
+// ProcessBytes processes a chunk of raw bytes, then updates the value
+func (tp *TypedStreamingParser[T]) ProcessBytes(chunk []byte) error {
+	return tp.ProcessString(string(chunk))
+}
+
+// ProcessChar processes a single character, then updates the value
+func (tp *TypedStreamingParser[T]) ProcessChar(c string) error {
+	if err := tp.StreamingParser.ProcessChar(c); err != nil {
+		return err
+	}
+	return tp.update()
+}
+
+// Value returns the value being updated
+func (tp *TypedStreamingParser[T]) Value() *T {
+	return tp.value
+}
+
+// SetDecodeHook sets a hook that converts values before they are stored in
+// the Go value. Unlike StreamingParser.SetDecodeHook, it doesn't change the
+// output map. Passing nil removes it.
+func (tp *TypedStreamingParser[T]) SetDecodeHook(hook DecodeHookFunc) {
+	tp.hook = hook
+}
+
+// Reset resets the parser state and sets the value back to its zero value,
+// unless the parser was created with WithMerge
+func (tp *TypedStreamingParser[T]) Reset() {
+	tp.StreamingParser.Reset()
+	tp.updated.drain()
+	if !tp.merge {
+		var zero T
+		*tp.value = zero
+	}
+}
+
+// update stores the values stored in the document since the last update in
+// the value, leaving the rest of it as it is
+func (tp *TypedStreamingParser[T]) update() (err error) {
+	defer recoverInternal(&err, nil)
+	paths := tp.updated.drain()
+	if len(paths) == 0 {
+		return nil
+	}
+	s := valueStore{hook: tp.hook, changes: newChangeTree(paths), shared: true}
+	s.store(reflect.ValueOf(tp.value), tp.output, "")
+	return s.err
+}
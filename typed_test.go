@@ -0,0 +1,155 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type typedMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Tools   []string `json:"tools"`
+	Meta    any      `json:"meta"`
+}
+
+func TestDecode(t *testing.T) {
+	msg, err := Decode[typedMessage](`{"role":"assistant","content":"Hel`)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	expected := typedMessage{Role: "assistant", Content: "Hel"}
+	if !reflect.DeepEqual(msg, expected) {
+		t.Errorf("Decode() = %+v, want %+v", msg, expected)
+	}
+
+	n, err := Decode[[]int](`[1,2,3`)
+	if err != nil || !reflect.DeepEqual(n, []int{1, 2, 3}) {
+		t.Errorf("Decode() = %v, %v", n, err)
+	}
+}
+
+func TestTypedStreamingParser(t *testing.T) {
+	chunks := []string{`{"role":"assi`, `stant","tools":["a"`, `,"b"],"meta":{"x":[1`, `]}}`}
+	expected := []typedMessage{
+		{},
+		{Role: "assistant", Tools: []string{"a"}},
+		{Role: "assistant", Tools: []string{"a", "b"}, Meta: map[string]any{"x": []interface{}{}}},
+		{Role: "assistant", Tools: []string{"a", "b"}, Meta: map[string]any{"x": []interface{}{int64(1)}}},
+	}
+
+	// Strings and numbers are stored once they are complete
+	var msg typedMessage
+	tp := NewTypedStreamingParser(&msg)
+	for i, chunk := range chunks {
+		if err := tp.ProcessString(chunk); err != nil {
+			t.Fatalf("ProcessString(%q) error = %v", chunk, err)
+		}
+		if !reflect.DeepEqual(msg, expected[i]) {
+			t.Errorf("After %q: %+v, want %+v", chunk, msg, expected[i])
+		}
+	}
+	if !tp.IsComplete() || tp.Value() != &msg {
+		t.Errorf("IsComplete() = %v, Value() = %p", tp.IsComplete(), tp.Value())
+	}
+
+	tp.Reset()
+	if !reflect.DeepEqual(msg, typedMessage{}) {
+		t.Errorf("After Reset: %+v", msg)
+	}
+}
+
+func TestTypedStreamingParserErrors(t *testing.T) {
+	tp := NewTypedStreamingParser[typedMessage](nil)
+
+	var typeErr *json.UnmarshalTypeError
+	if err := tp.ProcessString(`{"role":1,`); !errors.As(err, &typeErr) {
+		t.Errorf("ProcessString() error = %v, want *json.UnmarshalTypeError", err)
+	}
+
+	var perr *ParseError
+	if err := tp.ProcessString(`:`); !errors.As(err, &perr) {
+		t.Errorf("ProcessString() error = %v, want *ParseError", err)
+	}
+}
+
+func TestTypedStreamingParserIncremental(t *testing.T) {
+	type item struct {
+		ID   int            `json:"id"`
+		Name string         `json:"name"`
+		Tags []string       `json:"tags"`
+		Meta map[string]any `json:"meta"`
+	}
+	type document struct {
+		Items  []item           `json:"items"`
+		ByName map[string]*item `json:"byName"`
+		Raw    any              `json:"raw"`
+		Total  int              `json:"TOTAL"`
+	}
+
+	input := `{"items": [{"id": 1, "name": "a", "tags": ["x"]}, {"id": 2, "meta": {"n": [1, 2]}}],
+		"byName": {"a": {"id": 1, "tags": ["y", "z"]}, "b": {"name": "b"}},
+		"raw": {"list": [1, {"k": "v"}, [true]], "s": "t"}, "total": 3, "items": [{"id": 9}]}`
+
+	upper := func(path string, t reflect.Type, v any) (any, error) {
+		if s, ok := v.(string); ok {
+			return strings.ToUpper(s), nil
+		}
+		return v, nil
+	}
+
+	for _, size := range []int{1, 5, 16} {
+		var doc document
+		tp := NewTypedStreamingParser(&doc)
+		hook := DecodeHookFunc(nil)
+		if size == 5 {
+			hook = upper
+			tp.SetDecodeHook(hook)
+		}
+		for chunk := range slices.Chunk([]byte(input), size) {
+			if err := tp.ProcessBytes(chunk); err != nil {
+				t.Fatalf("ProcessBytes(%q) error = %v", chunk, err)
+			}
+
+			// The value matches storing the whole output again
+			var want document
+			if err := storeValue(reflect.ValueOf(&want), snapshotValue(tp.output), hook); err != nil {
+				t.Fatalf("storeValue() error = %v", err)
+			}
+			if !reflect.DeepEqual(doc, want) {
+				t.Fatalf("Chunks of %d, after %q: %+v, want %+v", size, chunk, doc, want)
+			}
+		}
+		if doc.Total != 3 || len(doc.Items) != 1 || doc.Items[0].ID != 9 {
+			t.Errorf("Chunks of %d: %+v", size, doc)
+		}
+	}
+}
+
+func BenchmarkTypedStreamingParser(b *testing.B) {
+	type item struct {
+		ID   int            `json:"id"`
+		Name string         `json:"name"`
+		Tags []string       `json:"tags"`
+		Meta map[string]any `json:"meta"`
+	}
+	type document struct {
+		Items []item `json:"items"`
+		Total int    `json:"total"`
+	}
+
+	input := benchmarkDocument(2000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for b.Loop() {
+		tp := NewTypedStreamingParser[document](nil)
+		for chunk := range slices.Chunk([]byte(input), 256) {
+			if err := tp.ProcessBytes(chunk); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,699 @@
+package flexjson
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	numberType          = reflect.TypeFor[json.Number]()
+)
+
+// Unmarshal parses the JSON-encoded data and stores the result in the value
+// pointed to by v, following the rules of encoding/json.Unmarshal. Unlike
+// json.Unmarshal, data may be cut off part way through: the values that were
+// read are stored and the rest of v is left as it was, so `{"name":"Jo`
+// sets Name to "Jo" without an error. A cut-off string isn't passed to an
+// unmarshaler, such as time.Time's, which would likely reject it, so its
+// destination is left as it was too.
+//
+// Struct fields are matched by their `flexjson` or `json` tags, as with ToMap,
+// falling back to a key that differs only in case when there's no exact match.
+// Fields tagged "-" are never set. Interface values receive the value model
+// produced by Parse, so numbers are int64 or float64. Numbers are read
+// exactly into integer fields, which, as with encoding/json, don't accept
+// numbers written with a fraction or an exponent. A value of the wrong
+// type for its destination is skipped and reported as a
+// *json.UnmarshalTypeError once everything else has been stored. A panic from
+// an unmarshaler or from reflection is returned as an *InternalError. UTF-16
+// and UTF-32 data is converted with ToUTF8 first.
+func Unmarshal(data []byte, v any) error {
+	return UnmarshalWithHook(data, v, nil)
+}
+
+// UnmarshalWithHook is like Unmarshal, but runs hook on every value before it
+// is stored. See DecodeHookFunc.
+func UnmarshalWithHook(data []byte, v any, hook DecodeHookFunc) (err error) {
+	defer recoverInternal(&err, nil)
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+
+	input := string(ToUTF8(data))
+	tokens := NewLexer(input).Tokenize()
+	p := NewParser(tokens)
+	p.UseNumber()
+	value, err := p.Parse()
+	if err != nil {
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			perr.locate(input)
+		}
+		return err
+	}
+
+	s := valueStore{hook: hook, literals: true}
+	s.cutPath, s.cutOff = cutOffString(input, tokens)
+	s.store(rv, value, "")
+	return s.err
+}
+
+// cutOffString returns the path of the string value that tokens, the tokens
+// of input, end inside, and false if they don't end inside one
+func cutOffString(input string, tokens []Token) (string, bool) {
+	n := len(tokens)
+	if n > 0 && tokens[n-1].Type == TokenEOF {
+		n--
+	}
+	if n == 0 || tokens[n-1].Type != TokenString || closedString(input[tokens[n-1].Start:tokens[n-1].End]) {
+		return "", false
+	}
+
+	// Find where the string is by decoding the tokens before it
+	d := &decoder{skipOutput: true}
+	for _, tok := range tokens[:n-1] {
+		if d.token(tok) != nil {
+			return "", false
+		}
+	}
+	return d.valuePath(), d.expectingValue()
+}
+
+// storeValue stores a value from the value model in dst, running hook on
+// each value first if it isn't nil
+func storeValue(dst reflect.Value, value any, hook DecodeHookFunc) error {
+	s := valueStore{hook: hook}
+	s.store(dst, value, "")
+	return s.err
+}
+
+// valueStore stores values from the value model in Go values
+type valueStore struct {
+	err      error          // First error, reported once everything else is stored
+	parent   string         // Name of the struct holding the value being stored
+	hook     DecodeHookFunc // Converts values before they are stored (nil when unset)
+	changes  *changeTree    // Changes inside the value being stored, when only they are stored (nil to store all of it)
+	shared   bool           // Whether the values belong to a parser, so they are copied before being kept
+	literals bool           // Whether numbers are json.Number, which hooks and interfaces get in the value model
+	cutPath  string         // Path of a string cut off by the end of the input, when cutOff is set
+	cutOff   bool
+}
+
+// fail records err unless an earlier error has been recorded
+func (s *valueStore) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// mismatch records that value can't be stored in a value of type t
+func (s *valueStore) mismatch(value any, t reflect.Type, path string) {
+	s.fail(&json.UnmarshalTypeError{Value: typeName(value), Type: t, Struct: s.parent, Field: path})
+}
+
+// store stores value in dst. path is the path of the value in the document.
+func (s *valueStore) store(dst reflect.Value, value any, path string) {
+	if s.changes != nil && s.changes.stored {
+		// The value is new, so all of it is stored
+		s.storeAll(dst, value, path)
+		return
+	}
+
+	if s.hook != nil {
+		in := value
+		if s.literals {
+			in = numberModel(value)
+		}
+		v, err := s.hook(path, dst.Type(), in)
+		if err != nil {
+			s.fail(&HookError{Path: path, Err: err})
+			return
+		}
+		if _, literal := value.(json.Number); literal && s.literals && sameNumber(v, in) {
+			// The number is stored from its literal, as it would be without
+			// the hook
+		} else if s.changes == nil || !sameContainer(v, value) {
+			if s.changes != nil {
+				// The hook replaced the container, so the replacement is
+				// stored in full
+				defer func(c *changeTree) { s.changes = c }(s.changes)
+				s.changes = nil
+			}
+			if v != nil && dst.CanSet() && reflect.TypeOf(v).AssignableTo(dst.Type()) {
+				if s.shared {
+					v = snapshotValue(v)
+				}
+				if s.literals {
+					v = numberModel(v)
+				}
+				dst.Set(reflect.ValueOf(v))
+				return
+			}
+			value = v
+		}
+	}
+
+	if value == nil {
+		switch dst.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+			dst.SetZero()
+		}
+		return
+	}
+
+	// Unmarshalers control how they are decoded
+	if u, ok := unmarshalerFor(dst); ok {
+		s.unmarshal(u, dst.Type(), value, path)
+		return
+	}
+
+	switch dst.Kind() {
+	case reflect.Pointer:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+			s.storeAll(dst.Elem(), value, path)
+			return
+		}
+		s.store(dst.Elem(), value, path)
+	case reflect.Interface:
+		if dst.NumMethod() == 0 {
+			if s.shared {
+				value = mergeValue(dst.Interface(), value, s.changes)
+			}
+			if s.literals {
+				value = numberModel(value)
+			}
+			dst.Set(reflect.ValueOf(value))
+			return
+		}
+		// Decode into the value already held, if it can be modified
+		if e := dst.Elem(); e.Kind() == reflect.Pointer && !e.IsNil() {
+			s.store(e, value, path)
+			return
+		}
+		s.mismatch(value, dst.Type(), path)
+	case reflect.Struct:
+		s.storeStruct(dst, value, path)
+	case reflect.Map:
+		s.storeMap(dst, value, path)
+	case reflect.Slice:
+		s.storeSlice(dst, value, path)
+	case reflect.Array:
+		arr, ok := value.([]interface{})
+		if !ok {
+			s.mismatch(value, dst.Type(), path)
+			return
+		}
+		for i := 0; i < dst.Len(); i++ {
+			c, changed := s.changes.index(i)
+			switch {
+			case !changed:
+			case i < len(arr):
+				s.storeChild(dst.Index(i), arr[i], appendIndexPath(path, i), c)
+			default:
+				dst.Index(i).SetZero()
+			}
+		}
+	default:
+		s.storeScalar(dst, value, path)
+	}
+}
+
+// storeAll stores all of value in dst, when only the changes inside the
+// value that holds it are being stored
+func (s *valueStore) storeAll(dst reflect.Value, value any, path string) {
+	c := s.changes
+	s.changes = nil
+	s.store(dst, value, path)
+	s.changes = c
+}
+
+// storeChild stores value, a member or element at path whose changes are c,
+// in dst
+func (s *valueStore) storeChild(dst reflect.Value, value any, path string, c *changeTree) {
+	parent := s.changes
+	s.changes = c
+	s.store(dst, value, path)
+	s.changes = parent
+}
+
+// unmarshalerFor returns the unmarshaler implemented by a pointer to dst, if any
+func unmarshalerFor(dst reflect.Value) (any, bool) {
+	if dst.Kind() == reflect.Pointer || !dst.CanAddr() {
+		return nil, false
+	}
+	pt := reflect.PointerTo(dst.Type())
+	if pt.Implements(jsonUnmarshalerType) || pt.Implements(textUnmarshalerType) {
+		return dst.Addr().Interface(), true
+	}
+	return nil, false
+}
+
+// unmarshal passes value to an unmarshaler of type t. A string cut off by
+// the end of the input is skipped, as what it holds is likely to be invalid.
+func (s *valueStore) unmarshal(u any, t reflect.Type, value any, path string) {
+	if _, ok := value.(string); ok && s.cutOff && path == s.cutPath {
+		return
+	}
+	if ju, ok := u.(json.Unmarshaler); ok {
+		data, err := json.Marshal(value)
+		if err == nil {
+			err = ju.UnmarshalJSON(data)
+		}
+		if err != nil {
+			s.fail(err)
+		}
+		return
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		s.mismatch(value, t, path)
+		return
+	}
+	if err := u.(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+		s.fail(err)
+	}
+}
+
+// storeStruct stores an object in a struct, field by field
+func (s *valueStore) storeStruct(dst reflect.Value, value any, path string) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		s.mismatch(value, dst.Type(), path)
+		return
+	}
+
+	parent := s.parent
+	defer func() { s.parent = parent }()
+
+	fields := structFields(dst.Type())
+	var folded map[string][]string
+	for _, f := range fields {
+		key := f.name
+		v, ok := obj[key]
+		if !ok {
+			// Fall back to a key that differs only in case
+			if folded == nil {
+				folded = foldKeys(obj, fields)
+			}
+			var found bool
+			if key, found = foldedKey(folded, f.name); !found {
+				continue
+			}
+			v = obj[key]
+		}
+		c, changed := s.changes.key(key)
+		if !changed {
+			continue
+		}
+		fv, ok := fieldByIndex(dst, f.index)
+		if !ok {
+			// Field of a nil embedded pointer to an unexported type
+			continue
+		}
+
+		if f.asString {
+			unquoted, ok := unquoteScalar(v, fv)
+			if !ok {
+				s.mismatch(v, fv.Type(), appendKeyPath(path, f.name))
+				continue
+			}
+			v = unquoted
+		}
+
+		s.parent = dst.Type().Name()
+		s.storeChild(fv, v, appendKeyPath(path, f.name), c)
+	}
+}
+
+// foldKeys groups the keys of obj that don't name a field by their lower
+// case form, for matching them to fields regardless of case
+func foldKeys(obj map[string]any, fields []structField) map[string][]string {
+	names := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		names[f.name] = true
+	}
+
+	folded := make(map[string][]string)
+	for k := range obj {
+		if !names[k] {
+			lower := strings.ToLower(k)
+			folded[lower] = append(folded[lower], k)
+		}
+	}
+	return folded
+}
+
+// foldedKey returns the key matching name regardless of case. If several
+// keys match, the first in sort order is used.
+func foldedKey(folded map[string][]string, name string) (string, bool) {
+	var match string
+	found := false
+	for _, k := range folded[strings.ToLower(name)] {
+		if strings.EqualFold(k, name) && (!found || k < match) {
+			match, found = k, true
+		}
+	}
+	return match, found
+}
+
+// fieldByIndex returns the struct field at index, allocating nil embedded
+// pointers on the way
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// unquoteScalar decodes the JSON text in a string value for a field with the
+// string option. Values of other fields, and null, are returned unchanged.
+func unquoteScalar(value any, field reflect.Value) (any, bool) {
+	switch field.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+	default:
+		return value, true
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return value, value == nil
+	}
+	p := NewParser(NewLexer(text).Tokenize())
+	p.UseNumber()
+	v, err := p.Parse()
+	return v, err == nil
+}
+
+// storeMap stores an object in a map, converting the keys to the map's key type
+func (s *valueStore) storeMap(dst reflect.Value, value any, path string) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		s.mismatch(value, dst.Type(), path)
+		return
+	}
+
+	t := dst.Type()
+	changes := s.changes
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(t, len(obj)))
+		changes = nil
+	}
+
+	store := func(k string, v any, c *changeTree) {
+		key, err := mapKeyValue(k, t.Key())
+		if err != nil {
+			s.mismatch(k, t.Key(), appendKeyPath(path, k))
+			return
+		}
+
+		elem := reflect.New(t.Elem()).Elem()
+		if c != nil {
+			// Only the changes inside the element are stored in it
+			if old := dst.MapIndex(key); old.IsValid() {
+				elem.Set(old)
+			} else {
+				c = nil
+			}
+		}
+		s.storeChild(elem, v, appendKeyPath(path, k), c)
+		dst.SetMapIndex(key, elem)
+	}
+
+	if changes == nil {
+		for k, v := range obj {
+			store(k, v, nil)
+		}
+		return
+	}
+	for k, c := range changes.keys {
+		if v, ok := obj[k]; ok {
+			store(k, v, c)
+		}
+	}
+}
+
+// mapKeyValue converts an object key to a map key of type t
+func mapKeyValue(key string, t reflect.Type) (reflect.Value, error) {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		kv := reflect.New(t)
+		err := kv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key))
+		return kv.Elem(), err
+	}
+
+	kv := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		kv.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || kv.OverflowInt(n) {
+			return kv, strconv.ErrRange
+		}
+		kv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || kv.OverflowUint(n) {
+			return kv, strconv.ErrRange
+		}
+		kv.SetUint(n)
+	default:
+		return kv, errors.ErrUnsupported
+	}
+	return kv, nil
+}
+
+// storeSlice stores an array in a slice, or a base64 string in a []byte
+func (s *valueStore) storeSlice(dst reflect.Value, value any, path string) {
+	t := dst.Type()
+	if text, ok := value.(string); ok && t.Elem().Kind() == reflect.Uint8 {
+		b, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		dst.SetBytes(b)
+		return
+	}
+
+	arr, ok := value.([]interface{})
+	if !ok {
+		s.mismatch(value, t, path)
+		return
+	}
+
+	n := dst.Len()
+	if s.changes == nil || dst.IsNil() || n > len(arr) {
+		slice := reflect.MakeSlice(t, len(arr), len(arr))
+		for i, v := range arr {
+			s.storeAll(slice.Index(i), v, appendIndexPath(path, i))
+		}
+		dst.Set(slice)
+		return
+	}
+
+	// Only new elements and the changes inside the others are stored
+	if len(arr) > n {
+		dst.Grow(len(arr) - n)
+		dst.SetLen(len(arr))
+		for i := n; i < len(arr); i++ {
+			dst.Index(i).SetZero()
+			s.storeAll(dst.Index(i), arr[i], appendIndexPath(path, i))
+		}
+	}
+	for i, c := range s.changes.indexes {
+		if i < n {
+			s.storeChild(dst.Index(i), arr[i], appendIndexPath(path, i), c)
+		}
+	}
+}
+
+// storeScalar stores a string, number, or boolean
+func (s *valueStore) storeScalar(dst reflect.Value, value any, path string) {
+	switch dst.Kind() {
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			dst.SetBool(b)
+			return
+		}
+	case reflect.String:
+		if str, ok := value.(string); ok {
+			dst.SetString(str)
+			return
+		}
+		if text, ok := numberText(value); ok && dst.Type() == numberType {
+			dst.SetString(text)
+			return
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := integerValue(value); ok && !dst.OverflowInt(n) {
+			dst.SetInt(n)
+			return
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := value.(json.Number); ok {
+			if u, err := strconv.ParseUint(string(n), 10, 64); err == nil && !dst.OverflowUint(u) {
+				dst.SetUint(u)
+				return
+			}
+		} else if n, ok := integerValue(value); ok && n >= 0 && !dst.OverflowUint(uint64(n)) {
+			dst.SetUint(uint64(n))
+			return
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch n := value.(type) {
+		case int64:
+			f = float64(n)
+		case float64:
+			f = n
+		case json.Number:
+			var err error
+			if f, err = n.Float64(); err != nil {
+				s.mismatch(value, dst.Type(), path)
+				return
+			}
+		default:
+			s.mismatch(value, dst.Type(), path)
+			return
+		}
+		if !dst.OverflowFloat(f) {
+			dst.SetFloat(f)
+			return
+		}
+	}
+	s.mismatch(value, dst.Type(), path)
+}
+
+// integerValue returns an integer that fits in an int64. Like
+// encoding/json, it takes numbers written as integers only: a float64 in the
+// value model was written with a fraction or an exponent, or doesn't fit.
+func integerValue(value any) (int64, bool) {
+	switch n := value.(type) {
+	case int64:
+		return n, true
+	case json.Number:
+		i, err := strconv.ParseInt(string(n), 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// numberModel returns v with the json.Number values in it converted to the
+// int64 or float64 values Parse produces. Containers are copied.
+func numberModel(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if n, ok := parseNumber(string(v)); ok {
+			return n
+		}
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = numberModel(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			a[i] = numberModel(e)
+		}
+		return a
+	}
+	return v
+}
+
+// sameNumber reports whether v is the int64 or float64 n
+func sameNumber(v, n any) bool {
+	switch v.(type) {
+	case int64, float64:
+		return v == n
+	}
+	return false
+}
+
+// mergeValue returns the value in the value model for an interface that
+// holds old, when value belongs to a parser: old updated with the changes c
+// inside value, or a copy of value when all of it is new
+func mergeValue(old, value any, c *changeTree) any {
+	if c == nil || c.stored {
+		return snapshotValue(value)
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		m, ok := old.(map[string]any)
+		if !ok {
+			return snapshotValue(value)
+		}
+		for k, child := range c.keys {
+			if e, ok := v[k]; ok {
+				m[k] = mergeValue(m[k], e, child)
+			}
+		}
+		return m
+	case []interface{}:
+		a, ok := old.([]interface{})
+		if !ok || len(a) > len(v) {
+			return snapshotValue(value)
+		}
+		n := len(a)
+		for _, e := range v[n:] {
+			a = append(a, snapshotValue(e))
+		}
+		for i, child := range c.indexes {
+			if i < n {
+				a[i] = mergeValue(a[i], v[i], child)
+			}
+		}
+		return a
+	}
+	return snapshotValue(value)
+}
+
+// sameContainer reports whether a and b are the same map or slice
+func sameContainer(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		return ok && reflect.ValueOf(a).UnsafePointer() == reflect.ValueOf(b).UnsafePointer()
+	case []interface{}:
+		b, ok := b.([]interface{})
+		return ok && len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+	}
+	return false
+}
+
+// numberText returns the text of a number for a json.Number
+func numberText(value any) (string, bool) {
+	switch n := value.(type) {
+	case json.Number:
+		return string(n), true
+	case int64:
+		return strconv.FormatInt(n, 10), true
+	case float64:
+		return strconv.FormatFloat(n, 'g', -1, 64), true
+	}
+	return "", false
+}
@@ -0,0 +1,360 @@
+package flexjson
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type unmarshalAddress struct {
+	City string `json:"city"`
+	Zip  *int   `json:"zip,omitempty"`
+}
+
+type unmarshalPerson struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age"`
+	Admin   bool              `json:"admin"`
+	Tags    []string          `json:"tags"`
+	Address *unmarshalAddress `json:"address"`
+	Extra   map[string]any    `json:"extra"`
+	Skipped string            `json:"-"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	zip := 12345
+
+	tests := []struct {
+		name     string
+		input    string
+		expected unmarshalPerson
+	}{
+		{
+			name:  "Complete",
+			input: `{"name":"Jo","age":30,"admin":true,"tags":["a","b"],"address":{"city":"Oslo","zip":12345},"extra":{"n":1.5}}`,
+			expected: unmarshalPerson{
+				Name:    "Jo",
+				Age:     30,
+				Admin:   true,
+				Tags:    []string{"a", "b"},
+				Address: &unmarshalAddress{City: "Oslo", Zip: &zip},
+				Extra:   map[string]any{"n": 1.5},
+			},
+		},
+		{
+			name:     "Partial string",
+			input:    `{"name":"Jo`,
+			expected: unmarshalPerson{Name: "Jo"},
+		},
+		{
+			name:     "Partial nested",
+			input:    `{"name":"Jo","tags":["a","b`,
+			expected: unmarshalPerson{Name: "Jo", Tags: []string{"a", "b"}},
+		},
+		{
+			name:     "Partial pointer",
+			input:    `{"address":{"ci`,
+			expected: unmarshalPerson{Address: &unmarshalAddress{}},
+		},
+		{
+			name:     "Key without value",
+			input:    `{"age":`,
+			expected: unmarshalPerson{},
+		},
+		{
+			name:     "Unknown and ignored keys",
+			input:    `{"other":1,"-":"x","age":4}`,
+			expected: unmarshalPerson{Age: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p unmarshalPerson
+			if err := Unmarshal([]byte(tt.input), &p); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(p, tt.expected) {
+				t.Errorf("Unmarshal() = %+v, want %+v", p, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnmarshalKinds(t *testing.T) {
+	var arr [3]int
+	if err := Unmarshal([]byte(`[1,2`), &arr); err != nil || arr != [3]int{1, 2, 0} {
+		t.Errorf("Array = %v, %v", arr, err)
+	}
+
+	var counts map[int]uint8
+	if err := Unmarshal([]byte(`{"1":2,"3":4`), &counts); err != nil || !reflect.DeepEqual(counts, map[int]uint8{1: 2, 3: 4}) {
+		t.Errorf("Map = %v, %v", counts, err)
+	}
+
+	var b []byte
+	if err := Unmarshal([]byte(`"aGk="`), &b); err != nil || string(b) != "hi" {
+		t.Errorf("Bytes = %q, %v", b, err)
+	}
+
+	var f float32
+	if err := Unmarshal([]byte(`2`), &f); err != nil || f != 2 {
+		t.Errorf("Float = %v, %v", f, err)
+	}
+
+	var v any
+	if err := Unmarshal([]byte(`{"a":[1,2.5`), &v); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	expected := map[string]any{"a": []interface{}{int64(1), 2.5}}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("Interface = %#v, want %#v", v, expected)
+	}
+
+	// null clears pointers, maps, and slices and leaves other values alone
+	p := unmarshalPerson{Name: "Jo", Tags: []string{"a"}, Address: &unmarshalAddress{}}
+	if err := Unmarshal([]byte(`{"name":null,"tags":null,"address":null}`), &p); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(p, unmarshalPerson{Name: "Jo"}) {
+		t.Errorf("Null = %+v", p)
+	}
+}
+
+func TestUnmarshalOptions(t *testing.T) {
+	type options struct {
+		ID    int64     `json:"id,string"`
+		When  time.Time `json:"when"`
+		Raw   json.RawMessage
+		Inner struct {
+			unmarshalAddress
+		} `json:"inner"`
+	}
+
+	var o options
+	input := `{"id":"42","when":"2024-01-02T03:04:05Z","Raw":{"x":[1]},"inner":{"city":"Rome"}}`
+	if err := Unmarshal([]byte(input), &o); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if o.ID != 42 {
+		t.Errorf("ID = %d, want 42", o.ID)
+	}
+	if !o.When.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("When = %v", o.When)
+	}
+	if string(o.Raw) != `{"x":[1]}` {
+		t.Errorf("Raw = %s", o.Raw)
+	}
+	if o.Inner.City != "Rome" {
+		t.Errorf("Inner.City = %q, want Rome", o.Inner.City)
+	}
+}
+
+func TestUnmarshalCutOffUnmarshaler(t *testing.T) {
+	type event struct {
+		Name string    `json:"name"`
+		When time.Time `json:"when"`
+		Tags []string  `json:"tags"`
+	}
+
+	tests := []struct {
+		input string
+		want  event
+	}{
+		{`{"name":"launch","when":"2020-01`, event{Name: "launch"}},
+		{`{"when":"2020-01-02T03:04:05Z","name":"lau`, event{Name: "lau", When: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}},
+		{`{"tags":["a","b`, event{Tags: []string{"a", "b"}}},
+	}
+
+	for _, tt := range tests {
+		var e event
+		if err := Unmarshal([]byte(tt.input), &e); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.input, err)
+		}
+		if !reflect.DeepEqual(e, tt.want) {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.input, e, tt.want)
+		}
+	}
+
+	var when time.Time
+	if err := Unmarshal([]byte(`"2020-01-02T03`), &when); err != nil || !when.IsZero() {
+		t.Errorf("Unmarshal() = %v, %v, want the zero time", when, err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var p unmarshalPerson
+	err := Unmarshal([]byte(`{"name":1,"age":"x","admin":true,"address":{"city":[]}}`), &p)
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("Unmarshal() error = %v, want *json.UnmarshalTypeError", err)
+	}
+	if typeErr.Field != "name" || typeErr.Value != "number" || typeErr.Type != reflect.TypeFor[string]() {
+		t.Errorf("UnmarshalTypeError = %+v", typeErr)
+	}
+	// The values that fit are still stored
+	if !p.Admin || p.Address == nil {
+		t.Errorf("Unmarshal() = %+v", p)
+	}
+
+	var small int8
+	if err := Unmarshal([]byte(`300`), &small); !errors.As(err, &typeErr) {
+		t.Errorf("Overflow error = %v", err)
+	}
+	var u uint
+	if err := Unmarshal([]byte(`-1`), &u); !errors.As(err, &typeErr) {
+		t.Errorf("Negative error = %v", err)
+	}
+
+	var invalid *json.InvalidUnmarshalError
+	if err := Unmarshal([]byte(`{}`), p); !errors.As(err, &invalid) {
+		t.Errorf("Non-pointer error = %v", err)
+	}
+	if err := Unmarshal([]byte(`{}`), nil); !errors.As(err, &invalid) {
+		t.Errorf("Nil error = %v", err)
+	}
+
+	err = Unmarshal([]byte("{\n  \"a\" 1}"), &p)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 2 || !strings.Contains(perr.Error(), "':' after key") {
+		t.Errorf("Syntax error = %v", err)
+	}
+}
+
+func TestUnmarshalFieldNames(t *testing.T) {
+	type names struct {
+		Name     string `json:"name,omitempty"`
+		UserID   int
+		Secret   string `json:"-"`
+		Override string `json:"other" flexjson:"override"`
+		Exact    string `json:"exact"`
+		EXACT    string `json:"EXACT"`
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected names
+	}{
+		{
+			name:     "Tags",
+			input:    `{"name":"Jo","UserID":7,"override":"o","other":"x"}`,
+			expected: names{Name: "Jo", UserID: 7, Override: "o"},
+		},
+		{
+			name:     "Case-insensitive fallback",
+			input:    `{"NAME":"Jo","userid":7,"Override":"o"}`,
+			expected: names{Name: "Jo", UserID: 7, Override: "o"},
+		},
+		{
+			name:     "Exact match wins",
+			input:    `{"Name":"fold","name":"exact"}`,
+			expected: names{Name: "exact"},
+		},
+		{
+			name:     "Keys naming another field are not folded",
+			input:    `{"EXACT":"upper"}`,
+			expected: names{EXACT: "upper"},
+		},
+		{
+			name:     "Excluded fields",
+			input:    `{"Secret":"s","secret":"s","-":"s"}`,
+			expected: names{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n names
+			if err := Unmarshal([]byte(tt.input), &n); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(n, tt.expected) {
+				t.Errorf("Unmarshal() = %+v, want %+v", n, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnmarshalNumbers(t *testing.T) {
+	type numbers struct {
+		ID    uint64      `json:"id"`
+		Exact json.Number `json:"exact"`
+		Ratio float32     `json:"ratio"`
+		Any   any         `json:"any"`
+	}
+
+	// Values from a parser using UseNumber
+	value := map[string]any{
+		"id":    json.Number("18446744073709551615"),
+		"exact": json.Number("9007199254740993"),
+		"ratio": json.Number("0.5"),
+		"any":   json.Number("1e400"),
+	}
+
+	var n numbers
+	if err := storeValue(reflect.ValueOf(&n), value, nil); err != nil {
+		t.Fatalf("storeValue() error = %v", err)
+	}
+	expected := numbers{ID: 18446744073709551615, Exact: "9007199254740993", Ratio: 0.5, Any: json.Number("1e400")}
+	if !reflect.DeepEqual(n, expected) {
+		t.Errorf("storeValue() = %+v, want %+v", n, expected)
+	}
+
+	// int64 and float64 values can be stored in a json.Number too
+	if err := Unmarshal([]byte(`{"exact":12}`), &n); err != nil || n.Exact != "12" {
+		t.Errorf("Exact = %q, %v", n.Exact, err)
+	}
+}
+
+func TestUnmarshalIntegers(t *testing.T) {
+	type integers struct {
+		U   uint64 `json:"u"`
+		I   int64  `json:"i"`
+		N   int    `json:"n"`
+		S   int    `json:"s,string"`
+		Any any    `json:"any"`
+	}
+
+	tests := []struct {
+		input    string
+		expected integers
+		wantErr  bool
+	}{
+		{`{"u": 9223372036854775809}`, integers{U: 9223372036854775809}, false},
+		{`{"u": 18446744073709551615}`, integers{U: 18446744073709551615}, false},
+		{`{"u": 18446744073709551616}`, integers{}, true},
+		{`{"i": -9223372036854775808}`, integers{I: -9223372036854775808}, false},
+		{`{"s": "9007199254740993"}`, integers{S: 9007199254740993}, false},
+		{`{"n": 2.0}`, integers{}, true},
+		{`{"n": 1e2}`, integers{}, true},
+		{`{"u": 1E2}`, integers{}, true},
+		{`{"s": "2.5"}`, integers{}, true},
+		{`{"any": [1, 2.0, {"x": 9223372036854775809}]}`, integers{Any: []interface{}{int64(1), 2.0, map[string]any{"x": 9223372036854775809.0}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var v integers
+			err := Unmarshal([]byte(tt.input), &v)
+			var typeErr *json.UnmarshalTypeError
+			if tt.wantErr != errors.As(err, &typeErr) {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(v, tt.expected) {
+				t.Errorf("Unmarshal() = %+v, want %+v", v, tt.expected)
+			}
+
+			// encoding/json agrees
+			var std integers
+			if stdErr := json.Unmarshal([]byte(tt.input), &std); (stdErr != nil) != tt.wantErr || !tt.wantErr && !reflect.DeepEqual(std.U, v.U) {
+				t.Errorf("json.Unmarshal() = %+v, %v", std, stdErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package flexjson
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity is how serious an Issue is
+type Severity uint8
+
+const (
+	// SeverityWarning marks valid JSON that is likely a mistake
+	SeverityWarning Severity = iota + 1
+	// SeverityError marks input that isn't valid JSON
+	SeverityError
+)
+
+// String returns the name of the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// IssueCode is a stable, machine-readable identifier for a kind of Issue
+type IssueCode string
+
+const (
+	IssueSyntax            IssueCode = "syntax"                 // Input that can't be parsed further; the Message says why
+	IssueTruncated         IssueCode = "truncated"              // The input ended before the document was complete
+	IssueTrailingContent   IssueCode = "trailing-content"       // Text after the root value
+	IssueTrailingComma     IssueCode = "trailing-comma"         // A comma after the last member or element
+	IssueComment           IssueCode = "comment"                // A // or /* */ comment
+	IssueUnquotedKey       IssueCode = "unquoted-key"           // An object key that isn't a string
+	IssueSingleQuotes      IssueCode = "single-quotes"          // A string in single quotes
+	IssueUnescapedControl  IssueCode = "unescaped-control-char" // A control character in a string that isn't escaped
+	IssueInvalidEscape     IssueCode = "invalid-escape"         // An escape sequence that JSON doesn't have
+	IssueInvalidUTF8       IssueCode = "invalid-utf8"           // A string that isn't valid UTF-8
+	IssueNonStandardNumber IssueCode = "non-standard-number"    // A number outside JSON's grammar, such as 0x1F, .5, or NaN
+	IssuePythonLiteral     IssueCode = "python-literal"         // True, False, or None instead of true, false, or null
+	IssueDuplicateKey      IssueCode = "duplicate-key"          // A key repeated in the same object
+)
+
+// Issue is a problem found by Validate
+type Issue struct {
+	Code     IssueCode // What kind of problem it is
+	Severity Severity  // How serious it is
+	Offset   int       // Byte offset of the problem
+	Line     int       // 1-based line of the problem
+	Column   int       // 1-based column of the problem, counted in characters
+	Message  string    // Description of the problem
+}
+
+// String formats the issue as "line:column: severity: message (code)"
+func (i Issue) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", i.Line, i.Column, i.Severity, i.Message, i.Code)
+}
+
+// Validate lints near-JSON input, reporting every deviation from JSON it finds
+// rather than stopping at the first, in the order they appear. The extensions
+// flexjson can parse, such as comments, trailing commas, and the rest of
+// JSON5 and Python's literals, are reported as errors, as are truncation and
+// text after the root value; duplicate keys are reported as warnings. Input
+// that can't be parsed ends the check with an IssueSyntax issue.
+//
+// Validate returns nil for valid JSON. The root value may be of any type.
+func Validate(input string) []Issue {
+	v := &validator{input: input}
+	v.run()
+
+	// A trailing comma is only known once the container closes, after the
+	// issues between them
+	slices.SortStableFunc(v.issues, func(a, b Issue) int { return a.Offset - b.Offset })
+	return v.issues
+}
+
+// validator collects the issues in an input
+type validator struct {
+	input  string
+	issues []Issue
+	keys   []map[string]bool // Keys seen in each open container (nil for arrays)
+}
+
+// run checks the input
+func (v *validator) run() {
+	defer func() {
+		if r := recover(); r != nil {
+			v.add(IssueSyntax, SeverityError, len(v.input), fmt.Sprintf("internal error: %v", r))
+		}
+	}()
+
+	d := &decoder{skipOutput: true, syntax: SyntaxJSON5 | SyntaxPythonLiterals}
+	lexer := NewLexer(v.input)
+	lexer.SetSyntax(d.syntax)
+
+	gap, comma := 0, -1 // End of the last token, and offset of the last comma
+	for tok := range lexer.Tokens() {
+		v.checkGap(gap, tok.Start)
+		gap = tok.End
+
+		if tok.Type == TokenEOF {
+			v.checkEnd(d, tok)
+			return
+		}
+		if d.done() {
+			v.add(IssueTrailingContent, SeverityError, tok.Start, "text after the root value")
+			return
+		}
+
+		switch {
+		case tok.Type == TokenComma:
+			comma = tok.Start
+		case tok.Type == TokenRightBrace && d.state == stateKey:
+			v.add(IssueTrailingComma, SeverityError, comma, "comma after the last member")
+		case tok.Type == TokenRightBracket && d.state == stateValue && len(d.stack) > 0 && d.inArray():
+			v.add(IssueTrailingComma, SeverityError, comma, "comma after the last element")
+		}
+
+		key := d.expectingKey() && (tok.Type == TokenString || tok.Type == TokenIdentifier)
+		v.checkToken(tok, key)
+		if key {
+			v.checkKey(tok)
+		}
+
+		if err := d.token(tok); err != nil {
+			v.add(IssueSyntax, SeverityError, tok.Start, locateError(err, v.input).Error())
+			return
+		}
+		v.trackContainers(d)
+	}
+}
+
+// checkToken checks the text of a token
+func (v *validator) checkToken(tok Token, key bool) {
+	raw := v.input[tok.Start:tok.End]
+	switch tok.Type {
+	case TokenIdentifier:
+		v.add(IssueUnquotedKey, SeverityError, tok.Start, fmt.Sprintf("key %s isn't quoted", raw))
+	case TokenString:
+		v.checkString(tok.Start, raw)
+	case TokenNumber:
+		if !isJSONNumber(raw) {
+			v.add(IssueNonStandardNumber, SeverityError, tok.Start, fmt.Sprintf("%s isn't a JSON number", raw))
+		}
+	case TokenTrue, TokenFalse, TokenNull:
+		if literal, ok := pythonLiterals[raw]; ok {
+			v.add(IssuePythonLiteral, SeverityError, tok.Start, fmt.Sprintf("%s should be %s", raw, literal))
+		}
+	}
+}
+
+// pythonLiterals maps Python's literals to JSON's
+var pythonLiterals = map[string]string{"True": "true", "False": "false", "None": "null"}
+
+// checkString checks the text of a string token that starts at offset start
+func (v *validator) checkString(start int, raw string) {
+	if raw[0] == '\'' {
+		v.add(IssueSingleQuotes, SeverityError, start, "string in single quotes")
+	}
+	if !utf8.ValidString(raw) {
+		v.add(IssueInvalidUTF8, SeverityError, start, "string isn't valid UTF-8")
+	}
+	if !closedString(raw) {
+		v.add(IssueTruncated, SeverityError, start+len(raw), "input ended inside a string")
+		raw += raw[:1] // Check the text up to the end
+	}
+
+	for i := 1; i < len(raw)-1; i++ {
+		c := raw[i]
+		switch {
+		case c < 0x20:
+			v.add(IssueUnescapedControl, SeverityError, start+i, fmt.Sprintf("control character %q must be escaped", c))
+		case c == '\\' && i+1 < len(raw):
+			if n, ok := escapeLength(raw[i+1:]); ok {
+				i += n
+				continue
+			}
+			v.add(IssueInvalidEscape, SeverityError, start+i, fmt.Sprintf("escape %q isn't valid JSON", raw[i:i+2]))
+			i++
+		}
+	}
+}
+
+// escapeLength returns the length of the JSON escape that s starts after its
+// backslash, and false if it isn't one
+func escapeLength(s string) (int, bool) {
+	if s[0] != 'u' {
+		_, ok := unescapeChar(s[0])
+		return 1, ok
+	}
+	if len(s) < 5 {
+		return 0, false
+	}
+	for i := 1; i < 5; i++ {
+		if _, ok := hexValue(s[i]); !ok {
+			return 0, false
+		}
+	}
+	return 5, true
+}
+
+// checkKey reports a key that is already in the object
+func (v *validator) checkKey(tok Token) {
+	if len(v.keys) == 0 {
+		return
+	}
+	seen := v.keys[len(v.keys)-1]
+	if seen[tok.Value] {
+		v.add(IssueDuplicateKey, SeverityWarning, tok.Start, fmt.Sprintf("duplicate key %q", tok.Value))
+	}
+	seen[tok.Value] = true
+}
+
+// trackContainers keeps the sets of keys in step with the open containers
+func (v *validator) trackContainers(d *decoder) {
+	for len(v.keys) > len(d.stack) {
+		v.keys = v.keys[:len(v.keys)-1]
+	}
+	for len(v.keys) < len(d.stack) {
+		var seen map[string]bool
+		if !d.inArray() {
+			seen = make(map[string]bool)
+		}
+		v.keys = append(v.keys, seen)
+	}
+}
+
+// checkGap reports comments in the input between offsets from and to
+func (v *validator) checkGap(from, to int) {
+	gap := v.input[from:to]
+	for i := 0; i+1 < len(gap); i++ {
+		if gap[i] != '/' || (gap[i+1] != '/' && gap[i+1] != '*') {
+			continue
+		}
+		v.add(IssueComment, SeverityError, from+i, "comments aren't allowed in JSON")
+
+		// Skip to the end of the comment
+		end := "\n"
+		if gap[i+1] == '*' {
+			end = "*/"
+		}
+		n := strings.Index(gap[i+2:], end)
+		if n < 0 {
+			return
+		}
+		i += n + 1 + len(end)
+	}
+}
+
+// checkEnd reports a document cut off by the end of the input
+func (v *validator) checkEnd(d *decoder, eof Token) {
+	switch {
+	case d.done():
+	case len(d.stack) == 0:
+		v.add(IssueTruncated, SeverityError, eof.Start, "input ended before a value")
+	default:
+		v.add(IssueTruncated, SeverityError, eof.Start, fmt.Sprintf("input ended with %d open objects or arrays", len(d.stack)))
+	}
+}
+
+// add records an issue at offset
+func (v *validator) add(code IssueCode, severity Severity, offset int, msg string) {
+	line, column := position(v.input, offset)
+	v.issues = append(v.issues, Issue{
+		Code:     code,
+		Severity: severity,
+		Offset:   offset,
+		Line:     line,
+		Column:   column,
+		Message:  msg,
+	})
+}
+
+// position returns the 1-based line and column of offset in input
+func position(input string, offset int) (line, column int) {
+	before := input[:min(offset, len(input))]
+	line = strings.Count(before, "\n") + 1
+	column = utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+	return line, column
+}
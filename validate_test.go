@@ -0,0 +1,101 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Issue
+	}{
+		{
+			name:  "valid",
+			input: `{"a": [1, -2.5e3, true, null, "xé\n"], "b": {"c": "d"}}`,
+		},
+		{
+			name:  "trailing commas",
+			input: "{\"a\": [1, 2,],\n \"b\": 3,}",
+			expected: []Issue{
+				{Code: IssueTrailingComma, Severity: SeverityError, Offset: 11, Line: 1, Column: 12, Message: "comma after the last element"},
+				{Code: IssueTrailingComma, Severity: SeverityError, Offset: 22, Line: 2, Column: 8, Message: "comma after the last member"},
+			},
+		},
+		{
+			name:  "comment after trailing comma",
+			input: "{a:'b', // c\n}",
+			expected: []Issue{
+				{Code: IssueUnquotedKey, Severity: SeverityError, Offset: 1, Line: 1, Column: 2, Message: "key a isn't quoted"},
+				{Code: IssueSingleQuotes, Severity: SeverityError, Offset: 3, Line: 1, Column: 4, Message: "string in single quotes"},
+				{Code: IssueTrailingComma, Severity: SeverityError, Offset: 6, Line: 1, Column: 7, Message: "comma after the last member"},
+				{Code: IssueComment, Severity: SeverityError, Offset: 8, Line: 1, Column: 9, Message: "comments aren't allowed in JSON"},
+			},
+		},
+		{
+			name:  "duplicate key",
+			input: `{"a": 1, "b": {"a": 2}, "a": 3}`,
+			expected: []Issue{
+				{Code: IssueDuplicateKey, Severity: SeverityWarning, Offset: 24, Line: 1, Column: 25, Message: `duplicate key "a"`},
+			},
+		},
+		{
+			name:  "truncated",
+			input: `{"a": [1, "tw`,
+			expected: []Issue{
+				{Code: IssueTruncated, Severity: SeverityError, Offset: 13, Line: 1, Column: 14, Message: "input ended inside a string"},
+				{Code: IssueTruncated, Severity: SeverityError, Offset: 13, Line: 1, Column: 14, Message: "input ended with 2 open objects or arrays"},
+			},
+		},
+		{
+			name:  "empty",
+			input: "  ",
+			expected: []Issue{
+				{Code: IssueTruncated, Severity: SeverityError, Offset: 2, Line: 1, Column: 3, Message: "input ended before a value"},
+			},
+		},
+		{
+			name:  "string contents",
+			input: "[\"a\tb\", \"c\\qd\", \"\xff\"]",
+			expected: []Issue{
+				{Code: IssueUnescapedControl, Severity: SeverityError, Offset: 3, Line: 1, Column: 4, Message: `control character '\t' must be escaped`},
+				{Code: IssueInvalidEscape, Severity: SeverityError, Offset: 10, Line: 1, Column: 11, Message: `escape "\\q" isn't valid JSON`},
+				{Code: IssueInvalidUTF8, Severity: SeverityError, Offset: 16, Line: 1, Column: 17, Message: "string isn't valid UTF-8"},
+			},
+		},
+		{
+			name:  "near JSON",
+			input: "// config\n{name: 'x', n: 0x1F, ok: True} extra",
+			expected: []Issue{
+				{Code: IssueComment, Severity: SeverityError, Offset: 0, Line: 1, Column: 1, Message: "comments aren't allowed in JSON"},
+				{Code: IssueUnquotedKey, Severity: SeverityError, Offset: 11, Line: 2, Column: 2, Message: "key name isn't quoted"},
+				{Code: IssueSingleQuotes, Severity: SeverityError, Offset: 17, Line: 2, Column: 8, Message: "string in single quotes"},
+				{Code: IssueUnquotedKey, Severity: SeverityError, Offset: 22, Line: 2, Column: 13, Message: "key n isn't quoted"},
+				{Code: IssueNonStandardNumber, Severity: SeverityError, Offset: 25, Line: 2, Column: 16, Message: "0x1F isn't a JSON number"},
+				{Code: IssueUnquotedKey, Severity: SeverityError, Offset: 31, Line: 2, Column: 22, Message: "key ok isn't quoted"},
+				{Code: IssuePythonLiteral, Severity: SeverityError, Offset: 35, Line: 2, Column: 26, Message: "True should be true"},
+				{Code: IssueTrailingContent, Severity: SeverityError, Offset: 41, Line: 2, Column: 32, Message: "text after the root value"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := Validate(tt.input)
+			if !reflect.DeepEqual(issues, tt.expected) {
+				t.Errorf("Validate() = %v, want %v", issues, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	issues := Validate(`{"a": 1 "b": 2, "a": 3}`)
+	if len(issues) != 1 || issues[0].Code != IssueSyntax || issues[0].Offset != 8 {
+		t.Fatalf("Validate() = %v, want one syntax issue at offset 8", issues)
+	}
+	if want := "1:9: error: unexpected '\"b\"' at line 1, column 9: expected ',' or '}' after object value (syntax)"; issues[0].String() != want {
+		t.Errorf("String() = %q, want %q", issues[0].String(), want)
+	}
+}
@@ -0,0 +1,171 @@
+package flexjson
+
+import (
+	"slices"
+	"strings"
+)
+
+// WatchFunc is called with the current value at a watched path. done is true
+// once the value is final: a string, number, or literal has been completely
+// parsed, or an object or array has been closed.
+type WatchFunc func(v any, done bool)
+
+// watcher is a subscription registered with Watch
+type watcher struct {
+	path string
+	fn   WatchFunc
+}
+
+// Watch subscribes fn to the value at path, using the path format described in
+// path.go (e.g. "choices[0].delta.content"). fn is called when the value is
+// created, each time it is updated, and once more with done set when it is
+// finalized:
+//
+//   - A string value is reported as it grows, so partial text can be displayed
+//     before the closing quote arrives.
+//   - Numbers and literals are reported once, when they are complete.
+//   - An object or array is reported when it is opened, whenever a value
+//     anywhere inside it changes (at most once per Process call with async
+//     dispatch), and when it is closed.
+//
+// Calls follow the event order described in dispatch.go. With synchronous
+// dispatch, objects and arrays are passed as the live map[string]any or
+// []interface{} being built, so callers must not modify them and should copy
+// them if they are kept beyond the callback. Subscriptions survive Reset.
+func (sp *StreamingParser) Watch(path string, fn WatchFunc) {
+	sp.watchers = append(sp.watchers, watcher{path: path, fn: fn})
+}
+
+// notify reports a change to the value at path to its watchers and to the
+// watchers of the containers that hold it
+func (d *decoder) notify(path string, value any, done bool) {
+	for i, w := range d.watchers {
+		if w.path == path {
+			// The value passed now is newer than a merged update
+			d.merged = slices.DeleteFunc(d.merged, func(m int) bool { return m == i })
+			d.deliver(w.fn, value, done)
+			continue
+		}
+		if !isPathPrefix(w.path, path) {
+			continue
+		}
+		for j := range d.stack {
+			if d.stack[j].path != w.path {
+				continue
+			}
+			if d.dispatch != nil {
+				// Each snapshot copies the whole container, so one is taken
+				// at the end of the Process call rather than for every change
+				if !slices.Contains(d.merged, i) {
+					d.merged = append(d.merged, i)
+				}
+				break
+			}
+			d.syncArrays()
+			d.deliver(w.fn, d.stack[j].value(), false)
+			break
+		}
+	}
+}
+
+// deliverMerged reports the containers that changed inside during a Process
+// call with async dispatch, in the order their watchers were registered.
+// Watchers of the same container share one snapshot.
+func (d *decoder) deliverMerged() {
+	if len(d.merged) == 0 {
+		return
+	}
+	d.syncArrays()
+	slices.Sort(d.merged)
+	snapshots := make([]any, len(d.stack))
+	for _, i := range d.merged {
+		w := d.watchers[i]
+		for j := range d.stack {
+			if d.stack[j].path != w.path {
+				continue
+			}
+			if snapshots[j] == nil {
+				snapshots[j] = d.snapshotFrame(j)
+			}
+			v := snapshots[j]
+			d.enqueue(func() { w.fn(v, false) })
+			break
+		}
+	}
+	d.merged = d.merged[:0]
+}
+
+// notifyString reports the partial string value currently being streamed,
+// when it starts and each time more of it is decoded
+func (sp *StreamingParser) notifyString() {
+	if len(sp.watchers) == 0 || !sp.lexer.inString() || !sp.expectingValue() {
+		sp.partial = -1
+		return
+	}
+	if text := sp.lexer.pendingText(); len(text) != sp.partial {
+		sp.partial = len(text)
+		sp.notify(sp.valuePath(), text, false)
+	}
+}
+
+// notifyClose reports that the container on top of the stack has been closed
+func (d *decoder) notifyClose() {
+	if len(d.watchers) > 0 {
+		top := d.top()
+		d.notify(top.path, top.value(), true)
+	}
+}
+
+// isPathPrefix reports whether the value at path is nested inside the value at prefix
+func isPathPrefix(prefix, path string) bool {
+	if len(path) <= len(prefix) || !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return prefix == "" || path[len(prefix)] == '.' || path[len(prefix)] == '['
+}
+
+// snapshotFrame copies the container of stack[j] for async watchers. Values
+// that are complete don't change any more, so they are copied once and the
+// copies are kept in the frame, and only the child that is still open is
+// copied again for each update.
+func (d *decoder) snapshotFrame(j int) any {
+	f := &d.stack[j]
+	open := j+1 < len(d.stack) && (d.stack[j+1].kind == containerObject || d.stack[j+1].kind == containerArray)
+
+	switch f.kind {
+	case containerArray:
+		// An open child is always the last element
+		complete := len(f.arr)
+		if open {
+			complete--
+		}
+		for i := len(f.copied); i < complete; i++ {
+			f.copied = append(f.copied, snapshotValue(f.arr[i]))
+		}
+		s := make([]interface{}, len(f.arr))
+		copy(s, f.copied[:complete])
+		if open {
+			s[complete] = d.snapshotFrame(j + 1)
+		}
+		return s
+	case containerRoot, containerObject:
+		if f.copiedObj == nil {
+			f.copiedObj = make(map[string]any, len(f.obj))
+		}
+		m := make(map[string]any, len(f.obj))
+		for k, v := range f.obj {
+			if open && k == f.key {
+				m[k] = d.snapshotFrame(j + 1)
+				continue
+			}
+			c, ok := f.copiedObj[k]
+			if !ok {
+				c = snapshotValue(v)
+				f.copiedObj[k] = c
+			}
+			m[k] = c
+		}
+		return m
+	}
+	return snapshotValue(f.value())
+}
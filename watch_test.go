@@ -0,0 +1,99 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+type watchCall struct {
+	value any
+	done  bool
+}
+
+func TestWatch(t *testing.T) {
+	input := `{"choices":[{"delta":{"content":"Hi\n"},"index":0}],"usage":{"tokens":7}}`
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []watchCall
+	}{
+		{
+			name: "Streamed string",
+			path: "choices[0].delta.content",
+			expected: []watchCall{
+				{"", false},
+				{"H", false},
+				{"Hi", false},
+				{"Hi\n", false},
+				{"Hi\n", true},
+			},
+		},
+		{
+			name: "Number",
+			path: "choices[0].index",
+			expected: []watchCall{
+				{int64(0), true},
+			},
+		},
+		{
+			name: "Object",
+			path: "usage",
+			expected: []watchCall{
+				{map[string]any{"tokens": int64(7)}, false},
+				{map[string]any{"tokens": int64(7)}, false},
+				{map[string]any{"tokens": int64(7)}, true},
+			},
+		},
+		{
+			name:     "Missing path",
+			path:     "choices[1]",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := NewStreamingParser(nil)
+
+			var calls []watchCall
+			sp.Watch(tt.path, func(v any, done bool) {
+				calls = append(calls, watchCall{v, done})
+			})
+
+			if err := sp.ProcessString(input); err != nil {
+				t.Fatalf("ProcessString() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(calls, tt.expected) {
+				t.Errorf("Watch() calls = %#v, want %#v", calls, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWatchArray(t *testing.T) {
+	sp := NewStreamingParser(nil)
+
+	var lengths []int
+	var done bool
+	sp.Watch("items", func(v any, d bool) {
+		lengths = append(lengths, len(v.([]interface{})))
+		done = d
+	})
+
+	for _, chunk := range []string{`{"items": [1, "a`, `b", [true]`, `]}`} {
+		if err := sp.ProcessString(chunk); err != nil {
+			t.Fatalf("ProcessString() error = %v", err)
+		}
+	}
+
+	// Created, 1, partial string updates, string added, nested array and its element, closed
+	expected := []int{0, 1, 1, 1, 1, 2, 3, 3, 3, 3}
+	if !reflect.DeepEqual(lengths, expected) {
+		t.Errorf("Array lengths = %v, want %v", lengths, expected)
+	}
+	if !done {
+		t.Errorf("Expected final call to be done")
+	}
+}
@@ -0,0 +1,103 @@
+package flexjson
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// xmlElement is an element that is still open while converting XML
+type xmlElement struct {
+	name   string
+	fields map[string]any
+	text   strings.Builder
+}
+
+// ParseXML converts XML into the same map[string]any model produced by Parse,
+// on a best-effort basis:
+//   - the document becomes an object keyed by the root element's name
+//   - attributes become "@name" keys
+//   - text content becomes the element's value, or a "#text" key when the
+//     element also has attributes or children
+//   - repeated child elements with the same name become arrays
+//
+// Like Parse, truncated input is tolerated: open elements are closed and the
+// data seen so far is returned.
+func ParseXML(input string) (map[string]any, error) {
+	decoder := xml.NewDecoder(strings.NewReader(input))
+	decoder.Strict = false
+
+	root := &xmlElement{fields: make(map[string]any)}
+	stack := []*xmlElement{root}
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			var syntaxErr *xml.SyntaxError
+			if !errors.Is(err, io.EOF) && !(errors.As(err, &syntaxErr) && syntaxErr.Msg == "unexpected EOF") {
+				return nil, err
+			}
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			elem := &xmlElement{name: t.Name.Local, fields: make(map[string]any)}
+			for _, attr := range t.Attr {
+				elem.fields["@"+attr.Name.Local] = attr.Value
+			}
+			stack = append(stack, elem)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				closeXMLElement(stack)
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			stack[len(stack)-1].text.Write(t)
+		}
+	}
+
+	// Close any elements left open by truncated input
+	for len(stack) > 1 {
+		closeXMLElement(stack)
+		stack = stack[:len(stack)-1]
+	}
+
+	return root.fields, nil
+}
+
+// closeXMLElement converts the element at the top of the stack into a value
+// and adds it to its parent
+func closeXMLElement(stack []*xmlElement) {
+	elem := stack[len(stack)-1]
+	parent := stack[len(stack)-2]
+
+	text := strings.TrimSpace(elem.text.String())
+
+	var value any
+	if len(elem.fields) == 0 {
+		if text != "" {
+			value = text
+		}
+	} else {
+		if text != "" {
+			elem.fields["#text"] = text
+		}
+		value = elem.fields
+	}
+
+	// Repeated elements become arrays
+	switch existing := parent.fields[elem.name].(type) {
+	case nil:
+		if _, ok := parent.fields[elem.name]; ok {
+			parent.fields[elem.name] = []any{nil, value}
+		} else {
+			parent.fields[elem.name] = value
+		}
+	case []any:
+		parent.fields[elem.name] = append(existing, value)
+	default:
+		parent.fields[elem.name] = []any{existing, value}
+	}
+}
@@ -0,0 +1,82 @@
+package flexjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+	}{
+		{
+			name:     "Simple element",
+			input:    `<name>John</name>`,
+			expected: map[string]any{"name": "John"},
+		},
+		{
+			name:  "Nested elements and attributes",
+			input: `<?xml version="1.0"?><user id="7"><name>John</name><email verified="true">j@example.com</email><empty/></user>`,
+			expected: map[string]any{
+				"user": map[string]any{
+					"@id":  "7",
+					"name": "John",
+					"email": map[string]any{
+						"@verified": "true",
+						"#text":     "j@example.com",
+					},
+					"empty": nil,
+				},
+			},
+		},
+		{
+			name:  "Repeated elements become arrays",
+			input: `<list><item>a</item><item>b</item><item><v>c</v></item><other/></list>`,
+			expected: map[string]any{
+				"list": map[string]any{
+					"item":  []any{"a", "b", map[string]any{"v": "c"}},
+					"other": nil,
+				},
+			},
+		},
+		{
+			name:  "Truncated input",
+			input: `<order id="1"><item>apple</item><item>ban`,
+			expected: map[string]any{
+				"order": map[string]any{
+					"@id":  "1",
+					"item": []any{"apple", "ban"},
+				},
+			},
+		},
+		{
+			name:  "Truncated inside a tag",
+			input: `<order><status>open</status><not`,
+			expected: map[string]any{
+				"order": map[string]any{
+					"status": "open",
+				},
+			},
+		},
+		{
+			name:     "Empty input",
+			input:    ``,
+			expected: map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseXML(tt.input)
+			if err != nil {
+				t.Fatalf("ParseXML() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseXML() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}